@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// comparison is the outcome of comparing one metric of one label between
+// the baseline and the candidate runs sharing the same config
+type comparison struct {
+	Config      string        `json:"config"`
+	Label       string        `json:"label"`
+	Metric      string        `json:"metric"`
+	Baseline    sampleSummary `json:"baseline"`
+	Candidate   sampleSummary `json:"candidate"`
+	DeltaPct    float64       `json:"delta_pct"`
+	PValue      *float64      `json:"p_value,omitempty"`
+	Significant bool          `json:"significant"`
+	Regression  bool          `json:"regression"`
+}
+
+// compareOptions controls how deltas are judged
+type compareOptions struct {
+	// alpha is the significance level used when repeated runs allow a t-test
+	alpha float64
+	// threshold is the relative change (in percent) in the worse direction
+	// above which a significant change counts as a regression, 0 disables it
+	threshold float64
+	// ignoreConfig compares all runs together regardless of their runner config
+	ignoreConfig bool
+}
+
+// compareRuns aligns baseline and candidate runs by runner config, label
+// and metric and computes the delta for each aligned value. Configs present
+// on only one side are returned separately so they can be reported.
+func compareRuns(baseline, candidate []*runResult, opts compareOptions) ([]comparison, []string, error) {
+	if len(baseline) == 0 || len(candidate) == 0 {
+		return nil, nil, fmt.Errorf("need at least one baseline and one candidate results file")
+	}
+	kind := baseline[0].kind
+	for _, r := range append(append([]*runResult{}, baseline...), candidate...) {
+		if r.kind != kind {
+			return nil, nil, fmt.Errorf("cannot compare %s results (%s) with %s results", r.kind, r.file, kind)
+		}
+	}
+
+	baseByConfig := groupByConfig(baseline, opts.ignoreConfig)
+	candByConfig := groupByConfig(candidate, opts.ignoreConfig)
+
+	var unmatched []string
+	var configs []string
+	for c := range baseByConfig {
+		if _, ok := candByConfig[c]; ok {
+			configs = append(configs, c)
+		} else {
+			unmatched = append(unmatched, fmt.Sprintf("baseline only: %s", c))
+		}
+	}
+	for c := range candByConfig {
+		if _, ok := baseByConfig[c]; !ok {
+			unmatched = append(unmatched, fmt.Sprintf("candidate only: %s", c))
+		}
+	}
+	sort.Strings(configs)
+	sort.Strings(unmatched)
+
+	var res []comparison
+	for _, c := range configs {
+		baseSamples := collectSamples(baseByConfig[c])
+		candSamples := collectSamples(candByConfig[c])
+		keys := make([]seriesKey, 0, len(baseSamples))
+		for k := range baseSamples {
+			if _, ok := candSamples[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].label != keys[j].label {
+				return keys[i].label < keys[j].label
+			}
+			return keys[i].metric < keys[j].metric
+		})
+		for _, k := range keys {
+			res = append(res, compareSeries(c, k, baseSamples[k], candSamples[k], opts))
+		}
+	}
+	return res, unmatched, nil
+}
+
+func compareSeries(config string, k seriesKey, base, cand []float64, opts compareOptions) comparison {
+	cmp := comparison{
+		Config:    config,
+		Label:     k.label,
+		Metric:    k.metric,
+		Baseline:  summarize(base),
+		Candidate: summarize(cand),
+	}
+	if cmp.Baseline.Mean != 0 {
+		cmp.DeltaPct = (cmp.Candidate.Mean - cmp.Baseline.Mean) / cmp.Baseline.Mean * 100
+	}
+
+	// Without repeated runs there is nothing to test, so every change is
+	// taken at face value.
+	cmp.Significant = true
+	if p, ok := welchTTest(cmp.Baseline, cmp.Candidate); ok {
+		cmp.PValue = &p
+		cmp.Significant = p < opts.alpha
+	}
+
+	if opts.threshold > 0 && cmp.Significant {
+		worse := cmp.DeltaPct
+		if metricDirections[k.metric] == higherIsBetter {
+			worse = -worse
+		}
+		cmp.Regression = worse > opts.threshold
+	}
+	return cmp
+}
+
+func groupByConfig(runs []*runResult, ignoreConfig bool) map[string][]*runResult {
+	groups := make(map[string][]*runResult)
+	for _, r := range runs {
+		c := r.config
+		if ignoreConfig {
+			c = "*"
+		}
+		groups[c] = append(groups[c], r)
+	}
+	return groups
+}
+
+func collectSamples(runs []*runResult) map[seriesKey][]float64 {
+	samples := make(map[seriesKey][]float64)
+	for _, r := range runs {
+		for k, v := range r.values {
+			if _, ok := metricDirections[k.metric]; !ok {
+				continue
+			}
+			samples[k] = append(samples[k], v)
+		}
+	}
+	return samples
+}
+
+// hasRegression reports whether any of the comparisons is a regression
+func hasRegression(cmps []comparison) bool {
+	for _, c := range cmps {
+		if c.Regression {
+			return true
+		}
+	}
+	return false
+}
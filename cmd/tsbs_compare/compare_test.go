@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const (
+	loadResultFmt = `{
+ "ResultFormatVersion": "0.1",
+ "RunnerConfig": {"db-name": "benchmark", "batch-size": 10000, "workers": %s, "seed": 123},
+ "StartTime": 1, "EndTime": 2, "DurationMillis": 1000,
+ "Totals": {"metricRate": %s, "rowRate": 10}
+}`
+	queryResult = `{
+ "ResultFormatVersion": "0.1",
+ "RunnerConfig": {"DBName": "benchmark", "Workers": 8, "ResultsFile": "x.json"},
+ "StartTime": 1, "EndTime": 2, "DurationMillis": 1000,
+ "Totals": {
+  "overallQueryRates": {"all_queries": 100},
+  "overallQuantiles": {"all_queries": {"q0": 1, "q50": 10, "q95": 20, "q99": 30, "q999": 40, "q100": 50}},
+  "overallMetrics": {"all_queries": {"min": 1, "mean": 12, "max": 50, "stddev": 3, "sum": 1200, "count": 100}}
+ }
+}`
+)
+
+func loadResult(t *testing.T, workers, rate string) *runResult {
+	contents := strings.Replace(strings.Replace(loadResultFmt, "%s", workers, 1), "%s", rate, 1)
+	r, err := parseResult([]byte(contents))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestParseResult(t *testing.T) {
+	r, err := parseResult([]byte(queryResult))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.kind != kindQuery {
+		t.Errorf("incorrect kind: got %s want %s", r.kind, kindQuery)
+	}
+	if r.config != "Workers=8" {
+		t.Errorf("incorrect config key: got %s", r.config)
+	}
+	want := map[seriesKey]float64{
+		{"all_queries", "queryRate"}: 100,
+		{"all_queries", "mean"}:      12,
+		{"all_queries", "q50"}:       10,
+		{"all_queries", "q95"}:       20,
+		{"all_queries", "q99"}:       30,
+	}
+	if len(r.values) != len(want) {
+		t.Errorf("incorrect number of values: got %d want %d", len(r.values), len(want))
+	}
+	for k, v := range want {
+		if got := r.values[k]; got != v {
+			t.Errorf("incorrect value for %v: got %f want %f", k, got, v)
+		}
+	}
+
+	l := loadResult(t, "4", "1000")
+	if l.kind != kindLoad {
+		t.Errorf("incorrect kind: got %s want %s", l.kind, kindLoad)
+	}
+	if got := l.values[seriesKey{loadLabel, "metricRate"}]; got != 1000 {
+		t.Errorf("incorrect metric rate: got %f", got)
+	}
+
+	if _, err := parseResult([]byte(`{"Totals": {"foo": 1}}`)); err == nil {
+		t.Errorf("unexpected lack of error for unknown totals")
+	}
+}
+
+func TestWelchTTest(t *testing.T) {
+	a := summarize([]float64{1, 2, 3, 4, 5})
+	b := summarize([]float64{2, 3, 4, 5, 6})
+	p, ok := welchTTest(a, b)
+	if !ok {
+		t.Fatalf("t-test unexpectedly not computed")
+	}
+	// t = -1 with 8 degrees of freedom
+	if math.Abs(p-0.3466) > 1e-4 {
+		t.Errorf("incorrect p-value: got %f want 0.3466", p)
+	}
+
+	if _, ok := welchTTest(summarize([]float64{1}), b); ok {
+		t.Errorf("t-test unexpectedly computed for a single sample")
+	}
+}
+
+func TestCompareRuns(t *testing.T) {
+	baseline := []*runResult{loadResult(t, "4", "1000"), loadResult(t, "8", "2000")}
+	candidate := []*runResult{loadResult(t, "4", "800"), loadResult(t, "16", "3000")}
+
+	cmps, unmatched, err := compareRuns(baseline, candidate, compareOptions{alpha: 0.05, threshold: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unmatched) != 2 {
+		t.Errorf("incorrect number of unmatched configs: got %d want 2", len(unmatched))
+	}
+	if len(cmps) != 2 {
+		t.Fatalf("incorrect number of comparisons: got %d want 2", len(cmps))
+	}
+	if cmps[0].Metric != "metricRate" || cmps[0].DeltaPct != -20 || !cmps[0].Regression {
+		t.Errorf("incorrect metric rate comparison: %+v", cmps[0])
+	}
+	if cmps[1].Metric != "rowRate" || cmps[1].DeltaPct != 0 || cmps[1].Regression {
+		t.Errorf("incorrect row rate comparison: %+v", cmps[1])
+	}
+	if !hasRegression(cmps) {
+		t.Errorf("regression not detected")
+	}
+
+	cmps, unmatched, err = compareRuns(baseline, candidate, compareOptions{alpha: 0.05, ignoreConfig: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unmatched) != 0 || len(cmps) != 2 || cmps[0].Baseline.N != 2 {
+		t.Errorf("incorrect comparison when ignoring config: %+v, %v", cmps, unmatched)
+	}
+	if hasRegression(cmps) {
+		t.Errorf("regression detected with threshold disabled")
+	}
+
+	q, _ := parseResult([]byte(queryResult))
+	if _, _, err := compareRuns(baseline, []*runResult{q}, compareOptions{}); err == nil {
+		t.Errorf("unexpected lack of error comparing load and query results")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	cmps, _, err := compareRuns(
+		[]*runResult{loadResult(t, "4", "1000")},
+		[]*runResult{loadResult(t, "4", "1100")},
+		compareOptions{alpha: 0.05},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, f := range validFormats {
+		var buf bytes.Buffer
+		if err := writeReport(&buf, f, cmps, nil); err != nil {
+			t.Errorf("unexpected error for format %s: %v", f, err)
+		}
+		if !strings.Contains(buf.String(), "metricRate") {
+			t.Errorf("format %s output is missing compared metric:\n%s", f, buf.String())
+		}
+	}
+	if err := writeReport(&bytes.Buffer{}, "xml", cmps, nil); err == nil {
+		t.Errorf("unexpected lack of error for unknown format")
+	}
+}
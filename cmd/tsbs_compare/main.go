@@ -0,0 +1,92 @@
+// tsbs_compare compares the results files written by the load and query
+// benchmark runners (--results-file) and reports throughput and latency deltas.
+//
+// Baseline and candidate runs are aligned by runner config and query label.
+// When several results files are given for each side, they are treated as
+// repeated runs and Welch's t-test is used to decide whether a change is
+// significant. The program exits with a non-zero status when a significant
+// change exceeds the regression threshold, so it can be used to gate CI.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+const exitRegression = 2
+
+// Program option vars:
+var (
+	baselineFiles  []string
+	candidateFiles []string
+	format         string
+	threshold      float64
+	alpha          float64
+	ignoreConfig   bool
+)
+
+// Parse args:
+func init() {
+	pflag.StringSliceVar(&baselineFiles, "baseline", nil, "Comma separated list of baseline results files (repeated runs)")
+	pflag.StringSliceVar(&candidateFiles, "candidate", nil, "Comma separated list of candidate results files (repeated runs)")
+	pflag.StringVar(&format, "format", formatText, "Output format: text, markdown or json")
+	pflag.Float64Var(&threshold, "regression-threshold", 0, "Relative change (in percent) in the worse direction above which a significant change is a regression and the program exits non-zero, 0 = never fail")
+	pflag.Float64Var(&alpha, "alpha", 0.05, "Significance level for the t-test over repeated runs")
+	pflag.BoolVar(&ignoreConfig, "ignore-config", false, "Compare runs even if their runner configs differ")
+
+	pflag.Parse()
+
+	// Positional arguments are accepted as a shorthand: the first one is the
+	// baseline and the remaining ones are candidates.
+	if len(baselineFiles) == 0 && len(candidateFiles) == 0 && pflag.NArg() >= 2 {
+		baselineFiles = pflag.Args()[:1]
+		candidateFiles = pflag.Args()[1:]
+	}
+}
+
+func main() {
+	if len(baselineFiles) == 0 || len(candidateFiles) == 0 {
+		log.Fatal("at least one baseline and one candidate results file are required")
+	}
+	baseline, err := readResultFiles(baselineFiles)
+	if err != nil {
+		log.Fatal(err)
+	}
+	candidate, err := readResultFiles(candidateFiles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := compareOptions{alpha: alpha, threshold: threshold, ignoreConfig: ignoreConfig}
+	cmps, unmatched, err := compareRuns(baseline, candidate, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(cmps) == 0 {
+		log.Fatal("no comparable results found, try --ignore-config if the runner configs differ")
+	}
+
+	if err := writeReport(os.Stdout, format, cmps, unmatched); err != nil {
+		log.Fatal(err)
+	}
+
+	if hasRegression(cmps) {
+		fmt.Fprintf(os.Stderr, "regression above %0.2f%% detected\n", threshold)
+		os.Exit(exitRegression)
+	}
+}
+
+func readResultFiles(paths []string) ([]*runResult, error) {
+	results := make([]*runResult, 0, len(paths))
+	for _, p := range paths {
+		r, err := readResultFile(p)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+var validFormats = []string{formatText, formatMarkdown, formatJSON}
+
+var reportHeader = []string{"label", "metric", "baseline", "candidate", "delta", "p-value", "status"}
+
+type jsonReport struct {
+	Comparisons []comparison `json:"comparisons"`
+	Unmatched   []string     `json:"unmatched_configs,omitempty"`
+	Regression  bool         `json:"regression"`
+}
+
+// writeReport writes the comparisons to w in the requested format
+func writeReport(w io.Writer, format string, cmps []comparison, unmatched []string) error {
+	switch format {
+	case formatText:
+		return writeText(w, cmps, unmatched)
+	case formatMarkdown:
+		return writeMarkdown(w, cmps, unmatched)
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", " ")
+		return enc.Encode(jsonReport{Comparisons: cmps, Unmatched: unmatched, Regression: hasRegression(cmps)})
+	}
+	return fmt.Errorf("unknown format '%s', valid: %s", format, strings.Join(validFormats, ", "))
+}
+
+func writeText(w io.Writer, cmps []comparison, unmatched []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, group := range groupComparisons(cmps) {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "Config: %s\n", group[0].Config)
+		fmt.Fprintln(tw, strings.Join(reportHeader, "\t"))
+		for _, c := range group {
+			fmt.Fprintln(tw, strings.Join(reportRow(c), "\t"))
+		}
+	}
+	for _, u := range unmatched {
+		fmt.Fprintf(tw, "\nUnmatched config (%s)\n", u)
+	}
+	return tw.Flush()
+}
+
+func writeMarkdown(w io.Writer, cmps []comparison, unmatched []string) error {
+	for i, group := range groupComparisons(cmps) {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "**Config:** `%s`\n\n", group[0].Config)
+		fmt.Fprintf(w, "| %s |\n", strings.Join(reportHeader, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(reportHeader)))
+		for _, c := range group {
+			if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(reportRow(c), " | ")); err != nil {
+				return err
+			}
+		}
+	}
+	if len(unmatched) > 0 {
+		fmt.Fprintln(w, "\nUnmatched configs:")
+		for _, u := range unmatched {
+			fmt.Fprintf(w, "- `%s`\n", u)
+		}
+	}
+	return nil
+}
+
+// groupComparisons splits the (config sorted) comparisons into one slice per config
+func groupComparisons(cmps []comparison) [][]comparison {
+	var groups [][]comparison
+	for i, c := range cmps {
+		if i == 0 || c.Config != cmps[i-1].Config {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], c)
+	}
+	return groups
+}
+
+func reportRow(c comparison) []string {
+	pValue := "-"
+	if c.PValue != nil {
+		pValue = fmt.Sprintf("%0.4f", *c.PValue)
+	}
+	status := "ok"
+	if c.Regression {
+		status = "REGRESSION"
+	} else if !c.Significant {
+		status = "not significant"
+	}
+	return []string{
+		c.Label,
+		c.Metric,
+		formatSummary(c.Baseline),
+		formatSummary(c.Candidate),
+		fmt.Sprintf("%+0.2f%%", c.DeltaPct),
+		pValue,
+		status,
+	}
+}
+
+func formatSummary(s sampleSummary) string {
+	if s.N < 2 {
+		return fmt.Sprintf("%0.2f", s.Mean)
+	}
+	return fmt.Sprintf("%0.2f ± %0.2f (n=%d)", s.Mean, s.StdDev, s.N)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const (
+	kindLoad  = "load"
+	kindQuery = "query"
+
+	// label used for the totals of a load benchmark, which has no query labels
+	loadLabel = "load"
+)
+
+// direction tells whether a bigger value of a metric is an improvement or a regression
+type direction int
+
+const (
+	higherIsBetter direction = iota
+	lowerIsBetter
+)
+
+// metricDirections lists the metrics that are compared and how to interpret their change
+var metricDirections = map[string]direction{
+	"metricRate": higherIsBetter,
+	"rowRate":    higherIsBetter,
+	"queryRate":  higherIsBetter,
+	"mean":       lowerIsBetter,
+	"q50":        lowerIsBetter,
+	"q95":        lowerIsBetter,
+	"q99":        lowerIsBetter,
+}
+
+// ignoredConfigKeys are runner config entries that differ between otherwise
+// comparable runs (output locations, seeds, debug settings). Both the load
+// (dash-case) and query (field name) spellings are listed.
+var ignoredConfigKeys = map[string]bool{
+	"db-name": true, "DBName": true,
+	"file": true, "FileName": true,
+	"results-file": true, "ResultsFile": true,
+	"seed": true, "Seed": true,
+	"prometheus-listen-address": true, "MetricsAddress": true,
+	"reporting-period": true,
+	"MemProfile":       true,
+	"HDRLatenciesFile": true,
+	"ExplainFile":      true,
+	"PrintResponses":   true,
+	"PrintInterval":    true,
+	"Debug":            true,
+}
+
+// resultFile mirrors the JSON written by the load and query benchmark runners
+// with the --results-file flag
+type resultFile struct {
+	ResultFormatVersion string                 `json:"ResultFormatVersion"`
+	RunnerConfig        map[string]interface{} `json:"RunnerConfig"`
+	StartTime           int64                  `json:"StartTime"`
+	EndTime             int64                  `json:"EndTime"`
+	DurationMillis      int64                  `json:"DurationMillis"`
+	Totals              map[string]interface{} `json:"Totals"`
+}
+
+// seriesKey identifies one compared value inside a results file
+type seriesKey struct {
+	label  string
+	metric string
+}
+
+// runResult is a parsed results file reduced to the values that are compared
+type runResult struct {
+	file   string
+	kind   string
+	config string
+	values map[seriesKey]float64
+}
+
+// readResultFile reads and parses a results file from the given path
+func readResultFile(path string) (*runResult, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read results file %s: %v", path, err)
+	}
+	r, err := parseResult(contents)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse results file %s: %v", path, err)
+	}
+	r.file = path
+	return r, nil
+}
+
+func parseResult(contents []byte) (*runResult, error) {
+	var rf resultFile
+	if err := json.Unmarshal(contents, &rf); err != nil {
+		return nil, err
+	}
+	if rf.Totals == nil {
+		return nil, fmt.Errorf("no totals found")
+	}
+
+	r := &runResult{
+		config: configKey(rf.RunnerConfig),
+		values: make(map[seriesKey]float64),
+	}
+	if _, ok := rf.Totals["metricRate"]; ok {
+		r.kind = kindLoad
+		for _, m := range []string{"metricRate", "rowRate"} {
+			if v, ok := rf.Totals[m].(float64); ok {
+				r.values[seriesKey{loadLabel, m}] = v
+			}
+		}
+		return r, nil
+	}
+
+	rates, ok := rf.Totals["overallQueryRates"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("totals contain neither load nor query results")
+	}
+	r.kind = kindQuery
+	for label, v := range rates {
+		if rate, ok := v.(float64); ok {
+			r.values[seriesKey{label, "queryRate"}] = rate
+		}
+	}
+	if metrics, ok := rf.Totals["overallMetrics"].(map[string]interface{}); ok {
+		addLabeledValues(r.values, metrics, "mean")
+	}
+	if quantiles, ok := rf.Totals["overallQuantiles"].(map[string]interface{}); ok {
+		addLabeledValues(r.values, quantiles, "q50", "q95", "q99")
+	}
+	return r, nil
+}
+
+// addLabeledValues copies the requested keys of each per-label object in m to values
+func addLabeledValues(values map[seriesKey]float64, m map[string]interface{}, keys ...string) {
+	for label, v := range m {
+		perLabel, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			if f, ok := perLabel[k].(float64); ok {
+				values[seriesKey{label, k}] = f
+			}
+		}
+	}
+}
+
+// configKey builds a stable, human readable representation of the runner
+// config entries that must match for two runs to be comparable
+func configKey(conf map[string]interface{}) string {
+	keys := make([]string, 0, len(conf))
+	for k := range conf {
+		if !ignoredConfigKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, conf[k])
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	betaMaxIterations = 200
+	betaEpsilon       = 3e-14
+)
+
+// sampleSummary holds the descriptive statistics of repeated runs
+type sampleSummary struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	N      int     `json:"n"`
+}
+
+func summarize(samples []float64) sampleSummary {
+	n := len(samples)
+	if n == 0 {
+		return sampleSummary{}
+	}
+	sum := 0.0
+	for _, s := range samples {
+		sum += s
+	}
+	mean := sum / float64(n)
+	if n == 1 {
+		return sampleSummary{Mean: mean, N: 1}
+	}
+	sq := 0.0
+	for _, s := range samples {
+		sq += (s - mean) * (s - mean)
+	}
+	return sampleSummary{Mean: mean, StdDev: math.Sqrt(sq / float64(n-1)), N: n}
+}
+
+// welchTTest returns the two-sided p-value of Welch's unequal variances
+// t-test for the two summaries. ok is false when either side has fewer
+// than two samples, since no variance can be estimated then.
+func welchTTest(a, b sampleSummary) (p float64, ok bool) {
+	if a.N < 2 || b.N < 2 {
+		return 0, false
+	}
+	va := a.StdDev * a.StdDev / float64(a.N)
+	vb := b.StdDev * b.StdDev / float64(b.N)
+	if va+vb == 0 {
+		if a.Mean == b.Mean {
+			return 1, true
+		}
+		return 0, true
+	}
+	t := (a.Mean - b.Mean) / math.Sqrt(va+vb)
+	df := (va + vb) * (va + vb) / (va*va/float64(a.N-1) + vb*vb/float64(b.N-1))
+	return regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5), true
+}
+
+// regularizedIncompleteBeta computes I_x(a, b) using the continued fraction
+// representation (Numerical Recipes, 6.4).
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	qab := a + b
+	qap := a + 1
+	qam := a - 1
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= betaMaxIterations; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < betaEpsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,110 @@
+// tsbs_convert converts a data file written by tsbs_generate_data for one
+// target into the format of another target, e.g. to load a dataset that was
+// generated once in the influx format into TimescaleDB without generating it
+// again.
+//
+// The points are parsed back from the data file and serialized again, so they
+// keep their exact timestamps, tags and values. Parsing needs the config the
+// file was generated with (recorded next to it as <file>.config.yaml), which
+// gives the tags and fields of the use case and the types of their values.
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+// Program option vars:
+var (
+	fileName    string
+	format      string
+	configFile  string
+	to          string
+	output      string
+	compression string
+)
+
+// Parse args:
+func init() {
+	pflag.StringVar(&fileName, "file", "", "Data file to convert, STDIN if empty, or the directory of the parquet and arrow formats. Compressed files are detected by their extension")
+	pflag.StringVar(&format, "format", "", fmt.Sprintf("Format of the data file (choices: %s). Default: the format recorded in the config file", strings.Join(parsableFormats(), ", ")))
+	pflag.StringVar(&configFile, "config", "", "Config the data file was generated with. Default: <file>"+inputs.ConfigSidecarSuffix)
+	pflag.StringVar(&to, "to", "", fmt.Sprintf("Format to convert to (choices: %s)", strings.Join(constants.SupportedFormats(), ", ")))
+	pflag.StringVar(&output, "output", "", "File to write the converted data to, STDOUT if empty. Required for the parquet and arrow formats, which write a file per measurement to this directory")
+	pflag.StringVar(&compression, "compression", "", "Compression of the output (gzip, zstd, lz4 or none). Default: by the extension of --output")
+
+	pflag.Parse()
+}
+
+func main() {
+	config, err := readConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if format == "" {
+		format = config.Format
+	}
+	parser, err := getParser(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !utils.IsIn(to, constants.SupportedFormats()) {
+		log.Fatalf("unknown format to convert to '%s', valid: %s", to, strings.Join(constants.SupportedFormats(), ", "))
+	}
+
+	converter := &inputs.DataConverter{}
+	points, err := converter.Convert(&inputs.DataConverterConfig{
+		File:        fileName,
+		Generator:   config,
+		Output:      output,
+		Compression: compression,
+	}, parser, initializers.GetTarget(to))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("converted %d points from %s to %s", points, format, to)
+}
+
+// readConfig reads the config file given with --config, or else the config
+// sidecar of the data file
+func readConfig() (*common.DataGeneratorConfig, error) {
+	if configFile != "" {
+		return inputs.ReadConfigSidecar(configFile)
+	}
+	if fileName == "" {
+		return nil, fmt.Errorf("--config is required when reading from STDIN")
+	}
+	return inputs.ReadConfigSidecar(inputs.ConfigSidecarFile(fileName))
+}
+
+// parsableFormats returns the formats whose targets can parse their data
+// files back into points
+func parsableFormats() []string {
+	var formats []string
+	for _, f := range constants.SupportedFormats() {
+		if _, ok := initializers.GetTarget(f).(targets.PointParser); ok {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+func getParser(format string) (targets.PointParser, error) {
+	if !utils.IsIn(format, constants.SupportedFormats()) {
+		return nil, fmt.Errorf("unknown format '%s', valid: %s", format, strings.Join(parsableFormats(), ", "))
+	}
+	parser, ok := initializers.GetTarget(format).(targets.PointParser)
+	if !ok {
+		return nil, fmt.Errorf("data files of format '%s' cannot be converted, valid: %s", format, strings.Join(parsableFormats(), ", "))
+	}
+	return parser, nil
+}
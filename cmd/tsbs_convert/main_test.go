@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+func generate(t *testing.T, dir, use, format string) string {
+	file := filepath.Join(dir, use+"-"+format)
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Format:    format,
+			Use:       use,
+			Scale:     3,
+			TimeStart: "2016-01-01T00:00:00Z",
+			TimeEnd:   "2016-01-01T00:10:00Z",
+			File:      file,
+		},
+		InitialScale:         3,
+		LogInterval:          10 * time.Second,
+		InterleavedNumGroups: 1,
+	}
+	g := &inputs.DataGenerator{}
+	if err := g.Generate(c, initializers.GetTarget(format)); err != nil {
+		t.Fatalf("unexpected error generating %s %s: %v", use, format, err)
+	}
+	return file
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-convert")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The influx wire protocol does not write points whose fields are all
+	// nil, which the iot use case generates, so those are missing when
+	// converting from it. Converting back must give the original file.
+	cases := []struct {
+		from, to    string
+		dropsPoints bool
+	}{
+		{constants.FormatInflux, constants.FormatTimescaleDB, true},
+		{constants.FormatTimescaleDB, constants.FormatInflux, false},
+		{constants.FormatClickhouse, constants.FormatQuestDB, false},
+		{constants.FormatVictoriaMetrics, constants.FormatClickhouse, true},
+	}
+	for _, use := range []string{common.UseCaseDevops, common.UseCaseIoT} {
+		for _, c := range cases {
+			in := generate(t, dir, use, c.from)
+			converted := convert(t, in, c.from, c.to)
+			if !c.dropsPoints || use != common.UseCaseIoT {
+				if want := readFile(t, generate(t, dir, use, c.to)); !bytes.Equal(readFile(t, converted), want) {
+					t.Errorf("%s %s to %s: converted data differs from data generated for %s", use, c.from, c.to, c.to)
+				}
+			}
+			back := convert(t, converted, c.to, c.from)
+			if !bytes.Equal(readFile(t, back), readFile(t, in)) {
+				t.Errorf("%s %s to %s: data converted back differs from original", use, c.from, c.to)
+			}
+		}
+	}
+}
+
+// The parquet and arrow formats write a file per measurement and are read back
+// one measurement after the other, so the order of the points only matches
+// the generated order for a single measurement.
+func TestConvertColumnar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-convert")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	use := common.UseCaseCPUOnly
+	for _, format := range []string{constants.FormatParquet, constants.FormatArrow} {
+		columnar := generate(t, dir, use, format)
+		converted := convert(t, columnar, format, constants.FormatInflux)
+		if want := readFile(t, generate(t, dir, use, constants.FormatInflux)); !bytes.Equal(readFile(t, converted), want) {
+			t.Errorf("%s to influx: converted data differs from data generated for influx", format)
+		}
+
+		back := convert(t, convert(t, generate(t, dir, use, constants.FormatTimescaleDB), constants.FormatTimescaleDB, format), format, constants.FormatTimescaleDB)
+		if want := readFile(t, generate(t, dir, use, constants.FormatTimescaleDB)); !bytes.Equal(readFile(t, back), want) {
+			t.Errorf("timescaledb to %s: data converted back differs from original", format)
+		}
+	}
+}
+
+func convert(t *testing.T, in, from, to string) string {
+	config, err := inputs.ReadConfigSidecar(inputs.ConfigSidecarFile(in))
+	if err != nil {
+		t.Fatalf("could not read config of %s: %v", in, err)
+	}
+	parser, err := getParser(from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := in + "-" + to
+	converter := &inputs.DataConverter{}
+	if _, err := converter.Convert(&inputs.DataConverterConfig{File: in, Generator: config, Output: out}, parser, initializers.GetTarget(to)); err != nil {
+		t.Fatalf("%s to %s: unexpected error: %v", in, to, err)
+	}
+	return out
+}
+
+func readFile(t *testing.T, file string) []byte {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("could not read %s: %v", file, err)
+	}
+	return contents
+}
+
+func TestGetParser(t *testing.T) {
+	for _, f := range []string{constants.FormatInflux, constants.FormatTimescaleDB, constants.FormatClickhouse, constants.FormatQuestDB, constants.FormatVictoriaMetrics, constants.FormatParquet, constants.FormatArrow} {
+		if _, err := getParser(f); err != nil {
+			t.Errorf("unexpected error for %s: %v", f, err)
+		}
+	}
+	for _, f := range []string{constants.FormatMongo, "unknown"} {
+		if _, err := getParser(f); err == nil {
+			t.Errorf("expected error for %s", f)
+		}
+	}
+}
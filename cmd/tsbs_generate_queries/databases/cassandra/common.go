@@ -8,10 +8,16 @@ import (
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
 	internalutils "github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/cassandra"
 )
 
 // BaseGenerator contains settings specific for Cassandra database.
 type BaseGenerator struct {
+	// Rollups are the intervals of the rollup tables created by the loader,
+	// e.g. "1m,1h". The queries aggregating by a multiple of one read it.
+	Rollups string
+
+	rollups []time.Duration
 }
 
 // GenerateEmptyQuery returns an empty query.Cassandra.
@@ -35,6 +41,28 @@ func (g *BaseGenerator) fillInQuery(qi query.Query, humanLabel, humanDesc, aggTy
 	q.TagSets = tagSets
 }
 
+// setRollup makes the query read the coarsest rollup table its group by
+// duration is a multiple of, if any. The queries reading rollups aggregate on
+// the server, which doesn't order or limit the buckets, so queries with an
+// order or a limit keep reading the series.
+func (g *BaseGenerator) setRollup(qi query.Query) {
+	q := qi.(*query.Cassandra)
+	if q.GroupByDuration <= 0 || len(q.OrderBy) > 0 || q.Limit > 0 {
+		return
+	}
+	switch string(q.AggregationType) {
+	case "min", "max", "avg":
+	default:
+		return
+	}
+	for i := len(g.rollups) - 1; i >= 0; i-- {
+		if q.GroupByDuration%g.rollups[i] == 0 {
+			q.Rollup = g.rollups[i]
+			return
+		}
+	}
+}
+
 // NewDevops creates a new devops use case query generator.
 func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
 	core, err := devops.NewCore(start, end, scale)
@@ -43,6 +71,11 @@ func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryG
 		return nil, err
 	}
 
+	g.rollups, err = cassandra.ParseRollups(g.Rollups)
+	if err != nil {
+		return nil, err
+	}
+
 	devops := &Devops{
 		BaseGenerator: g,
 		Core:          core,
@@ -61,6 +61,7 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 	d.fillInQuery(qi, humanLabel, humanDesc, "max", metrics, interval, tagSets)
 	q := qi.(*query.Cassandra)
 	q.GroupByDuration = time.Minute
+	d.setRollup(q)
 }
 
 // GroupByOrderByLimit populates a query.Query that has a time WHERE clause, that groups by a truncated date, orders by that date, and takes a limit:
@@ -83,6 +84,7 @@ func (d *Devops) GroupByOrderByLimit(qi query.Query) {
 	q.GroupByDuration = time.Minute
 	q.OrderBy = []byte("timestamp_ns DESC")
 	q.Limit = 5
+	d.setRollup(q)
 }
 
 // GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
@@ -102,6 +104,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 	d.fillInQuery(qi, humanLabel, humanDesc, "avg", metrics, interval, nil)
 	q := qi.(*query.Cassandra)
 	q.GroupByDuration = time.Hour
+	d.setRollup(q)
 }
 
 // MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
@@ -125,6 +128,7 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 	q := qi.(*query.Cassandra)
 	q.GroupByDuration = time.Hour
 	q.TagSets = tagSets
+	d.setRollup(q)
 }
 
 // LastPointPerHost finds the last row for every host in the dataset
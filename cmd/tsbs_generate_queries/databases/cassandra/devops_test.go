@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
 	"github.com/timescale/tsbs/pkg/query"
 )
 
@@ -119,3 +120,70 @@ func TestDevopsFillInQuery(t *testing.T) {
 		}
 	}
 }
+
+func TestDevopsRollups(t *testing.T) {
+	s := time.Unix(0, 0)
+	e := s.Add(24 * time.Hour)
+
+	b := &BaseGenerator{Rollups: "7m"}
+	if _, err := b.NewDevops(s, e, 10); err == nil {
+		t.Errorf("expected error for invalid rollups")
+	}
+
+	cases := []struct {
+		desc    string
+		rollups string
+		gen     func(*Devops, query.Query)
+		want    time.Duration
+	}{
+		{
+			desc: "no rollups",
+			gen:  func(d *Devops, q query.Query) { d.MaxAllCPU(q, 1, devops.MaxAllDuration) },
+		},
+		{
+			desc:    "hourly max",
+			rollups: "1m,1h",
+			gen:     func(d *Devops, q query.Query) { d.MaxAllCPU(q, 1, devops.MaxAllDuration) },
+			want:    time.Hour,
+		},
+		{
+			desc:    "hourly avg from minutes",
+			rollups: "1m",
+			gen:     func(d *Devops, q query.Query) { d.GroupByTimeAndPrimaryTag(q, 1) },
+			want:    time.Minute,
+		},
+		{
+			desc:    "minutely max",
+			rollups: "1m,1h",
+			gen:     func(d *Devops, q query.Query) { d.GroupByTime(q, 1, 1, time.Hour) },
+			want:    time.Minute,
+		},
+		{
+			desc:    "no rollup dividing the group by",
+			rollups: "1h",
+			gen:     func(d *Devops, q query.Query) { d.GroupByOrderByLimit(q) },
+		},
+		{
+			desc:    "ordered and limited",
+			rollups: "1m,1h",
+			gen:     func(d *Devops, q query.Query) { d.GroupByOrderByLimit(q) },
+		},
+		{
+			desc:    "no aggregation",
+			rollups: "1m,1h",
+			gen:     func(d *Devops, q query.Query) { d.HighCPUForHosts(q, 1) },
+		},
+	}
+	for _, c := range cases {
+		b := &BaseGenerator{Rollups: c.rollups}
+		dq, err := b.NewDevops(s, e, 10)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		q := b.GenerateEmptyQuery()
+		c.gen(dq.(*Devops), q)
+		if got := q.(*query.Cassandra).Rollup; got != c.want {
+			t.Errorf("%s: incorrect rollup: got %s want %s", c.desc, got, c.want)
+		}
+	}
+}
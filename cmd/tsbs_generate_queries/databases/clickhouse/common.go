@@ -11,6 +11,12 @@ import (
 // BaseGenerator contains settings specific for ClickHouse.
 type BaseGenerator struct {
 	UseTags bool
+	// UseModernSchema queries the DateTime64 time column created by the
+	// loader with --modern-schema instead of created_at
+	UseModernSchema bool
+	// UseNarrowLayout queries the (metric_name, value) rows created by the
+	// loader with --schema-layout=narrow
+	UseNarrowLayout bool
 }
 
 // GenerateEmptyQuery returns an empty query.ClickHouse.
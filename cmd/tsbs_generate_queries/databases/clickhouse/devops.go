@@ -57,12 +57,36 @@ func (d *Devops) getHostWhereString(nhosts int) string {
 	return d.getHostWhereWithHostnames(hostnames)
 }
 
+// getAggExpr returns the expression aggregating the given metric.
+// With the narrow layout only the value of the metric's rows is aggregated.
+// Ex.: max(cpu_time) or maxIf(value, metric_name = 'cpu_time')
+func (d *Devops) getAggExpr(aggregateFunction, metric string) string {
+	if d.UseNarrowLayout {
+		return fmt.Sprintf("%sIf(value, metric_name = '%s')", aggregateFunction, metric)
+	}
+	return fmt.Sprintf("%s(%s)", aggregateFunction, metric)
+}
+
+// getMetricFilter returns the clause restricting the narrow layout to the rows
+// of the given metrics, ready to append to a WHERE clause. It is empty for the
+// wide layout.
+func (d *Devops) getMetricFilter(metrics []string) string {
+	if !d.UseNarrowLayout {
+		return ""
+	}
+	metricClauses := make([]string, len(metrics))
+	for i, metric := range metrics {
+		metricClauses[i] = fmt.Sprintf("'%s'", metric)
+	}
+	return fmt.Sprintf(" AND (metric_name IN (%s))", strings.Join(metricClauses, ","))
+}
+
 // getSelectClausesAggMetrics gets specified aggregate function clause for multiple memtrics
 // Ex.: max(cpu_time) AS max_cpu_time
 func (d *Devops) getSelectClausesAggMetrics(aggregateFunction string, metrics []string) []string {
 	selectAggregateClauses := make([]string, len(metrics))
 	for i, metric := range metrics {
-		selectAggregateClauses[i] = fmt.Sprintf("%[1]s(%[2]s) AS %[1]s_%[2]s", aggregateFunction, metric)
+		selectAggregateClauses[i] = fmt.Sprintf("%s AS %s_%s", d.getAggExpr(aggregateFunction, metric), aggregateFunction, metric)
 	}
 	return selectAggregateClauses
 }
@@ -70,6 +94,14 @@ func (d *Devops) getSelectClausesAggMetrics(aggregateFunction string, metrics []
 // ClickHouse understands and can compare time presented as strings of this format
 const clickhouseTimeStringFormat = "2006-01-02 15:04:05"
 
+// getTimeColumn returns the column queries filter and group the time by
+func (d *Devops) getTimeColumn() string {
+	if d.UseModernSchema {
+		return "time"
+	}
+	return "created_at"
+}
+
 // MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
 // e.g. in pseudo-SQL:
 //
@@ -92,17 +124,19 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 
 	sql := fmt.Sprintf(`
         SELECT
-            toStartOfHour(created_at) AS hour,
-            %s
+            toStartOfHour(%[1]s) AS hour,
+            %[2]s
         FROM cpu
-        WHERE %s AND (created_at >= '%s') AND (created_at < '%s')
+        WHERE %[3]s AND (%[1]s >= '%[4]s') AND (%[1]s < '%[5]s')%[6]s
         GROUP BY hour
         ORDER BY hour
         `,
+		d.getTimeColumn(),
 		strings.Join(selectClauses, ", "),
 		d.getHostWhereString(nHosts),
 		interval.Start().Format(clickhouseTimeStringFormat),
-		interval.End().Format(clickhouseTimeStringFormat))
+		interval.End().Format(clickhouseTimeStringFormat),
+		d.getMetricFilter(metrics))
 
 	humanLabel := devops.GetMaxAllLabel("ClickHouse", nHosts)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -131,7 +165,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 	meanClauses := make([]string, numMetrics)
 	for i, m := range metrics {
 		meanClauses[i] = "mean_" + m
-		selectClauses[i] = fmt.Sprintf("avg(%s) AS %s", m, meanClauses[i])
+		selectClauses[i] = fmt.Sprintf("%s AS %s", d.getAggExpr("avg", m), meanClauses[i])
 	}
 
 	hostnameField := "hostname"
@@ -143,32 +177,33 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 	sql := fmt.Sprintf(`
         SELECT
             hour,
-            %s,
-            %s
+            %[1]s,
+            %[2]s
         FROM
         (
             SELECT
-                toStartOfHour(created_at) AS hour,
+                toStartOfHour(%[4]s) AS hour,
                 tags_id AS id,
-                %s
+                %[3]s
             FROM cpu
-            WHERE (created_at >= '%s') AND (created_at < '%s')
+            WHERE (%[4]s >= '%[5]s') AND (%[4]s < '%[6]s')%[8]s
             GROUP BY
                 hour,
                 id
         ) AS cpu_avg
-        %s
+        %[7]s
         ORDER BY
             hour ASC,
-            %s
+            %[1]s
         `,
-		hostnameField,                                       // main SELECT %s,
+		hostnameField,                                       // main SELECT %s, ORDER BY %s
 		strings.Join(meanClauses, ", "),                     // main SELECT %s
 		strings.Join(selectClauses, ", "),                   // cpu_avg SELECT %s
+		d.getTimeColumn(),                                   // cpu_avg time column
 		interval.Start().Format(clickhouseTimeStringFormat), // cpu_avg time >= '%s'
 		interval.End().Format(clickhouseTimeStringFormat),   // cpu_avg time < '%s'
-		joinClause,    // JOIN clause
-		hostnameField) // ORDER BY %s
+		joinClause,                 // JOIN clause
+		d.getMetricFilter(metrics)) // cpu_avg metric_name IN (%s)
 
 	humanLabel := devops.GetDoubleGroupByLabel("ClickHouse", numMetrics)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -190,15 +225,18 @@ func (d *Devops) GroupByOrderByLimit(qi query.Query) {
 
 	sql := fmt.Sprintf(`
         SELECT
-            toStartOfMinute(created_at) AS minute,
-            max(usage_user)
+            toStartOfMinute(%[1]s) AS minute,
+            %[3]s
         FROM cpu
-        WHERE created_at < '%s'
+        WHERE %[1]s < '%[2]s'%[4]s
         GROUP BY minute
         ORDER BY minute DESC
         LIMIT 5
         `,
-		interval.End().Format(clickhouseTimeStringFormat))
+		d.getTimeColumn(),
+		interval.End().Format(clickhouseTimeStringFormat),
+		d.getAggExpr("max", "usage_user"),
+		d.getMetricFilter([]string{"usage_user"}))
 
 	humanLabel := "ClickHouse max cpu over last 5 min-intervals (random end)"
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
@@ -226,14 +264,31 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	}
 	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
 
+	highCPUClause := "(usage_user > 90.0)"
+	if d.UseNarrowLayout {
+		// Select all metrics of the series at the times usage_user was high
+		highCPUClause = fmt.Sprintf(`((tags_id, %[1]s) IN
+        (
+            SELECT tags_id, %[1]s
+            FROM cpu
+            PREWHERE (metric_name = 'usage_user') AND (value > 90.0) AND (%[1]s >= '%[2]s') AND (%[1]s <  '%[3]s') %[4]s
+        ))`,
+			d.getTimeColumn(),
+			interval.Start().Format(clickhouseTimeStringFormat),
+			interval.End().Format(clickhouseTimeStringFormat),
+			hostWhereClause)
+	}
+
 	sql := fmt.Sprintf(`
         SELECT *
         FROM cpu
-        PREWHERE (usage_user > 90.0) AND (created_at >= '%s') AND (created_at <  '%s') %s
+        PREWHERE %[5]s AND (%[1]s >= '%[2]s') AND (%[1]s <  '%[3]s') %[4]s
         `,
+		d.getTimeColumn(),
 		interval.Start().Format(clickhouseTimeStringFormat),
 		interval.End().Format(clickhouseTimeStringFormat),
-		hostWhereClause)
+		hostWhereClause,
+		highCPUClause)
 
 	humanLabel, err := devops.GetHighCPULabel("ClickHouse", nHosts)
 	panicIfErr(err)
@@ -241,24 +296,57 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
 }
 
-// LastPointPerHost finds the last row for every host in the dataset
+// LastPointPerHost finds the last row for every host in the dataset. With the
+// narrow layout that is the last row of every metric of every host.
 //
 // Resultsets:
 // lastpoint
 func (d *Devops) LastPointPerHost(qi query.Query) {
 	var sql string
-	if d.UseTags {
+	if d.UseNarrowLayout && d.UseTags {
 		sql = fmt.Sprintf(`
             SELECT *
             FROM
             (
                 SELECT *
                 FROM cpu
-                WHERE (tags_id, created_at) IN
+                WHERE (tags_id, metric_name, %[1]s) IN
                 (
                     SELECT
                         tags_id,
-                        max(created_at)
+                        metric_name,
+                        max(%[1]s)
+                    FROM cpu
+                    GROUP BY tags_id, metric_name
+                )
+            ) AS c
+            ANY INNER JOIN tags AS t ON c.tags_id = t.id
+            ORDER BY
+                t.hostname ASC,
+                c.metric_name ASC
+            `, d.getTimeColumn())
+	} else if d.UseNarrowLayout {
+		sql = fmt.Sprintf(`
+            SELECT *
+            FROM cpu
+            ORDER BY
+                hostname ASC,
+                metric_name ASC,
+                %s DESC
+            LIMIT 1 BY hostname, metric_name
+            `, d.getTimeColumn())
+	} else if d.UseTags {
+		sql = fmt.Sprintf(`
+            SELECT *
+            FROM
+            (
+                SELECT *
+                FROM cpu
+                WHERE (tags_id, %[1]s) IN
+                (
+                    SELECT
+                        tags_id,
+                        max(%[1]s)
                     FROM cpu
                     GROUP BY tags_id
                 )
@@ -267,15 +355,15 @@ func (d *Devops) LastPointPerHost(qi query.Query) {
             ORDER BY
                 t.hostname ASC,
                 c.time DESC
-            `)
+            `, d.getTimeColumn())
 	} else {
 		sql = fmt.Sprintf(`
             SELECT DISTINCT(hostname), *
             FROM cpu
             ORDER BY
                 hostname ASC,
-                created_at DESC
-            `)
+                %s DESC
+            `, d.getTimeColumn())
 	}
 
 	humanLabel := "ClickHouse last row per host"
@@ -311,17 +399,19 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 
 	sql := fmt.Sprintf(`
         SELECT
-            toStartOfMinute(created_at) AS minute,
-            %s
+            toStartOfMinute(%[1]s) AS minute,
+            %[2]s
         FROM cpu
-        WHERE %s AND (created_at >= '%s') AND (created_at < '%s')
+        WHERE %[3]s AND (%[1]s >= '%[4]s') AND (%[1]s < '%[5]s')%[6]s
         GROUP BY minute
         ORDER BY minute ASC
         `,
+		d.getTimeColumn(),
 		strings.Join(selectClauses, ", "),
 		d.getHostWhereString(nHosts),
 		interval.Start().Format(clickhouseTimeStringFormat),
-		interval.End().Format(clickhouseTimeStringFormat))
+		interval.End().Format(clickhouseTimeStringFormat),
+		d.getMetricFilter(metrics))
 
 	humanLabel := fmt.Sprintf("ClickHouse %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
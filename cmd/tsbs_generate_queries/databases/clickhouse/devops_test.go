@@ -459,10 +459,162 @@ func TestGroupByTime(t *testing.T) {
 	runTestCases(t, testFunc, start, end, cases)
 }
 
+func TestDevopsModernSchema(t *testing.T) {
+	cases := []testCase{
+		{
+			desc:               "group by time",
+			input:              1,
+			useModernSchema:    true,
+			expectedHumanLabel: "ClickHouse 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "ClickHouse 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T01:09:26Z",
+			expectedQuery: `
+        SELECT
+            toStartOfMinute(time) AS minute,
+            max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE (hostname = 'host_9') AND (time >= '1970-01-01 01:09:26') AND (time < '1970-01-01 01:09:27')
+        GROUP BY minute
+        ORDER BY minute ASC
+        `,
+		},
+		{
+			desc:               "last point with tags",
+			input:              -1,
+			devopsUseTags:      true,
+			useModernSchema:    true,
+			expectedHumanLabel: "ClickHouse last row per host",
+			expectedHumanDesc:  "ClickHouse last row per host",
+			expectedQuery: `
+            SELECT *
+            FROM
+            (
+                SELECT *
+                FROM cpu
+                WHERE (tags_id, time) IN
+                (
+                    SELECT
+                        tags_id,
+                        max(time)
+                    FROM cpu
+                    GROUP BY tags_id
+                )
+            ) AS c
+            ANY INNER JOIN tags AS t ON c.tags_id = t.id
+            ORDER BY
+                t.hostname ASC,
+                c.time DESC
+            `,
+		},
+	}
+
+	testFunc := func(d *Devops, c testCase) query.Query {
+		q := d.GenerateEmptyQuery()
+		if c.input < 0 {
+			d.LastPointPerHost(q)
+		} else {
+			d.GroupByTime(q, c.input, 1, time.Second)
+		}
+		return q
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(2 * time.Hour)
+
+	runTestCases(t, testFunc, start, end, cases)
+}
+
+func TestDevopsNarrowLayout(t *testing.T) {
+	cases := []testCase{
+		{
+			desc:               "group by time",
+			input:              1,
+			useNarrowLayout:    true,
+			expectedHumanLabel: "ClickHouse 2 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "ClickHouse 2 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T10:05:50Z",
+			expectedQuery: `
+        SELECT
+            toStartOfMinute(created_at) AS minute,
+            maxIf(value, metric_name = 'usage_user') AS max_usage_user, maxIf(value, metric_name = 'usage_system') AS max_usage_system
+        FROM cpu
+        WHERE (hostname = 'host_9') AND (created_at >= '1970-01-01 10:05:50') AND (created_at < '1970-01-01 10:05:51') AND (metric_name IN ('usage_user','usage_system'))
+        GROUP BY minute
+        ORDER BY minute ASC
+        `,
+		},
+		{
+			desc:               "high cpu",
+			input:              0,
+			useNarrowLayout:    true,
+			expectedHumanLabel: "ClickHouse CPU over threshold, 1 host(s)",
+			expectedHumanDesc:  "ClickHouse CPU over threshold, 1 host(s): 1970-01-01T00:37:12Z",
+			expectedQuery: `
+        SELECT *
+        FROM cpu
+        PREWHERE ((tags_id, created_at) IN
+        (
+            SELECT tags_id, created_at
+            FROM cpu
+            PREWHERE (metric_name = 'usage_user') AND (value > 90.0) AND (created_at >= '1970-01-01 00:37:12') AND (created_at <  '1970-01-01 12:37:12') AND ((hostname = 'host_3'))
+        )) AND (created_at >= '1970-01-01 00:37:12') AND (created_at <  '1970-01-01 12:37:12') AND ((hostname = 'host_3'))
+        `,
+		},
+		{
+			desc:               "last point with tags",
+			input:              -1,
+			devopsUseTags:      true,
+			useModernSchema:    true,
+			useNarrowLayout:    true,
+			expectedHumanLabel: "ClickHouse last row per host",
+			expectedHumanDesc:  "ClickHouse last row per host",
+			expectedQuery: `
+            SELECT *
+            FROM
+            (
+                SELECT *
+                FROM cpu
+                WHERE (tags_id, metric_name, time) IN
+                (
+                    SELECT
+                        tags_id,
+                        metric_name,
+                        max(time)
+                    FROM cpu
+                    GROUP BY tags_id, metric_name
+                )
+            ) AS c
+            ANY INNER JOIN tags AS t ON c.tags_id = t.id
+            ORDER BY
+                t.hostname ASC,
+                c.metric_name ASC
+            `,
+		},
+	}
+
+	testFunc := func(d *Devops, c testCase) query.Query {
+		q := d.GenerateEmptyQuery()
+		switch {
+		case c.input < 0:
+			d.LastPointPerHost(q)
+		case c.input == 0:
+			d.HighCPUForHosts(q, 1)
+		default:
+			d.GroupByTime(q, c.input, 2, time.Second)
+		}
+		return q
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(devops.HighCPUDuration).Add(time.Hour)
+
+	runTestCases(t, testFunc, start, end, cases)
+}
+
 type testCase struct {
 	desc               string
 	input              int
 	devopsUseTags      bool
+	useModernSchema    bool
+	useNarrowLayout    bool
 	fail               bool
 	failMsg            string
 	expectedHumanLabel string
@@ -482,6 +634,8 @@ func runTestCases(t *testing.T, testFunc func(*Devops, testCase) query.Query, s
 			}
 			d := dg.(*Devops)
 			d.UseTags = c.devopsUseTags
+			d.UseModernSchema = c.useModernSchema
+			d.UseNarrowLayout = c.useNarrowLayout
 
 			if c.fail {
 				func() {
@@ -10,6 +10,9 @@ import (
 
 // BaseGenerator contains settings specific for CrateDB
 type BaseGenerator struct {
+	// UseNarrowLayout queries the (tags, ts, metric_name, value) rows created
+	// by the loader with --schema-layout=narrow
+	UseNarrowLayout bool
 }
 
 // GenerateEmptyQuery returns an empty query.CrateDB.
@@ -33,11 +33,34 @@ func (d *Devops) getSelectAggClauses(aggFunc string, idents []string) []string {
 	selectAggClauses := make([]string, len(idents))
 	for i, ident := range idents {
 		selectAggClauses[i] =
-			fmt.Sprintf("%[1]s(%[2]s) AS %[1]s_%[2]s", aggFunc, ident)
+			fmt.Sprintf("%s AS %s_%s", d.getAggExpr(aggFunc, ident), aggFunc, ident)
 	}
 	return selectAggClauses
 }
 
+// getAggExpr returns the expression aggregating the given column ident.
+// The narrow layout stores every metric in the value column, so only the
+// rows of the metric are aggregated.
+//
+// For instance:
+//      max(value) FILTER (WHERE metric_name = 'cpu_time')
+func (d *Devops) getAggExpr(aggFunc, ident string) string {
+	if d.UseNarrowLayout {
+		return fmt.Sprintf("%s(value) FILTER (WHERE metric_name = '%s')", aggFunc, ident)
+	}
+	return fmt.Sprintf("%s(%s)", aggFunc, ident)
+}
+
+// getMetricFilter returns the clause restricting the narrow layout to the rows
+// of the given metrics, ready to append to a WHERE clause. It is empty for the
+// wide layout.
+func (d *Devops) getMetricFilter(metrics []string) string {
+	if !d.UseNarrowLayout {
+		return ""
+	}
+	return fmt.Sprintf("\n\t\t  AND metric_name IN ('%s')", strings.Join(metrics, "', '"))
+}
+
 // MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for N random
 // hosts
 //
@@ -46,7 +69,8 @@ func (d *Devops) getSelectAggClauses(aggFunc string, idents []string) []string {
 // cpu-max-all-8
 func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 	interval := d.Interval.MustRandWindow(devops.MaxAllDuration)
-	selectClauses := d.getSelectAggClauses("max", devops.GetAllCPUMetrics())
+	metrics := devops.GetAllCPUMetrics()
+	selectClauses := d.getSelectAggClauses("max", metrics)
 	hosts, err := d.GetRandomHosts(nHosts)
 	panicIfErr(err)
 
@@ -57,14 +81,15 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int) {
 		FROM cpu
 		WHERE %s IN ('%s')
 		  AND ts >= %d
-		  AND ts < %d
+		  AND ts < %d%s
 		GROUP BY hour
 		ORDER BY hour`,
 		strings.Join(selectClauses, ", "),
 		hostnameField,
 		strings.Join(hosts, "', '"),
 		interval.StartUnixMillis(),
-		interval.EndUnixMillis())
+		interval.EndUnixMillis(),
+		d.getMetricFilter(metrics))
 
 	humanLabel := devops.GetMaxAllLabel("CrateDB", nHosts)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -90,12 +115,13 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 			%s
 		FROM cpu
 		WHERE ts >= %d
-		  AND ts < %d
+		  AND ts < %d%s
 		GROUP BY hour, %s
 		ORDER BY hour`,
 		strings.Join(selectClauses, ", "),
 		interval.StartUnixMillis(),
 		interval.EndUnixMillis(),
+		d.getMetricFilter(metrics),
 		hostnameField)
 
 	humanLabel := devops.GetDoubleGroupByLabel("CrateDB", numMetrics)
@@ -113,21 +139,41 @@ func (d *Devops) GroupByOrderByLimit(qi query.Query) {
 	sql := fmt.Sprintf(`
 		SELECT
 			date_trunc('minute', ts) as minute,
-			max(usage_user)
+			%s
 		FROM cpu
-		WHERE ts < %d
+		WHERE ts < %d%s
 		GROUP BY minute
 		ORDER BY minute DESC
 		LIMIT 5`,
-		interval.EndUnixMillis())
+		d.getAggExpr("max", "usage_user"),
+		interval.EndUnixMillis(),
+		d.getMetricFilter([]string{"usage_user"}))
 
 	humanLabel := "CrateDB max cpu over last 5 min-intervals (random end)"
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
 	d.fillInQuery(qi, humanLabel, humanDesc, sql)
 }
 
-// LastPointPerHost finds the last row for every host in the dataset
+// LastPointPerHost finds the last row for every host in the dataset. With the
+// narrow layout that is the last row of every metric of every host.
 func (d *Devops) LastPointPerHost(qi query.Query) {
+	if d.UseNarrowLayout {
+		sql := fmt.Sprintf(`
+		SELECT *
+		FROM
+		  (
+			SELECT %[1]s AS host, metric_name, max(ts) AS max_ts
+			FROM cpu
+			GROUP BY %[1]s, metric_name
+		  ) t, cpu c
+		WHERE t.max_ts = c.ts
+		  AND t.host = c.%[1]s
+		  AND t.metric_name = c.metric_name`, hostnameField)
+
+		humanLabel := "CrateDB last row per host"
+		d.fillInQuery(qi, humanLabel, humanLabel, sql)
+		return
+	}
 	sql := fmt.Sprintf(`
 		SELECT *
 		FROM
@@ -167,6 +213,29 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 		interval.EndUnixMillis(),
 		hostnameField,
 		strings.Join(hosts, "', '"))
+	if d.UseNarrowLayout {
+		// select all metrics of the hosts at the times usage_user was high
+		sql = fmt.Sprintf(`
+		SELECT c.*
+		FROM
+		  (
+			SELECT %[3]s AS host, ts
+			FROM cpu
+			WHERE metric_name = 'usage_user'
+			  AND value > 90.0
+			  AND ts >= %[1]d
+			  AND ts < %[2]d
+			  AND %[3]s IN ('%[4]s')
+		  ) h, cpu c
+		WHERE h.ts = c.ts
+		  AND h.host = c.%[3]s
+		  AND c.ts >= %[1]d
+		  AND c.ts < %[2]d`,
+			interval.StartUnixMillis(),
+			interval.EndUnixMillis(),
+			hostnameField,
+			strings.Join(hosts, "', '"))
+	}
 
 	humanLabel, err := devops.GetHighCPULabel("CrateDB", nHosts)
 	panicIfErr(err)
@@ -199,14 +268,15 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 		FROM cpu
 		WHERE %s IN ('%s')
 		  AND ts >= %d
-		  AND ts < %d
+		  AND ts < %d%s
 		GROUP BY minute
 		ORDER BY minute ASC`,
 		strings.Join(selectClauses, ", "),
 		hostnameField,
 		strings.Join(hosts, "', '"),
 		interval.StartUnixMillis(),
-		interval.EndUnixMillis())
+		interval.EndUnixMillis(),
+		d.getMetricFilter(metrics))
 
 	humanLabel := fmt.Sprintf(
 		"CrateDB %d cpu metric(s), random %4d hosts, random %s by 1m",
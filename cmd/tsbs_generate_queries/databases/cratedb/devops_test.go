@@ -259,3 +259,86 @@ func TestDevopsGroupByTimeQuery(t *testing.T) {
 			got.SqlQuery, want.SqlQuery)
 	}
 }
+
+func TestDevopsNarrowLayout(t *testing.T) {
+	start := time.Date(2006, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2006, 1, 2, 10, 0, 0, 0, time.UTC)
+	b := BaseGenerator{UseNarrowLayout: true}
+	dq, err := b.NewDevops(start, end, testScale)
+	if err != nil {
+		t.Fatalf("error while creating devops generator")
+	}
+	d := dq.(*Devops)
+
+	cases := []struct {
+		desc string
+		fn   func(query.Query)
+		want string
+	}{
+		{
+			desc: "group by time",
+			fn: func(q query.Query) {
+				d.GroupByTime(q, 2, 2, devops.MaxAllDuration)
+			},
+			want: `
+		SELECT
+			date_trunc('minute', ts) as minute,
+			max(value) FILTER (WHERE metric_name = 'usage_user') AS max_usage_user, max(value) FILTER (WHERE metric_name = 'usage_system') AS max_usage_system
+		FROM cpu
+		WHERE tags['hostname'] IN ('host_2', 'host_5')
+		  AND ts >= 1136136902666
+		  AND ts < 1136165702666
+		  AND metric_name IN ('usage_user', 'usage_system')
+		GROUP BY minute
+		ORDER BY minute ASC`,
+		},
+		{
+			desc: "last point",
+			fn:   d.LastPointPerHost,
+			want: `
+		SELECT *
+		FROM
+		  (
+			SELECT tags['hostname'] AS host, metric_name, max(ts) AS max_ts
+			FROM cpu
+			GROUP BY tags['hostname'], metric_name
+		  ) t, cpu c
+		WHERE t.max_ts = c.ts
+		  AND t.host = c.tags['hostname']
+		  AND t.metric_name = c.metric_name`,
+		},
+		{
+			desc: "high cpu",
+			fn: func(q query.Query) {
+				d.HighCPUForHosts(q, 2)
+			},
+			want: `
+		SELECT c.*
+		FROM
+		  (
+			SELECT tags['hostname'] AS host, ts
+			FROM cpu
+			WHERE metric_name = 'usage_user'
+			  AND value > 90.0
+			  AND ts >= 1136111400201
+			  AND ts < 1136154600201
+			  AND tags['hostname'] IN ('host_3', 'host_7')
+		  ) h, cpu c
+		WHERE h.ts = c.ts
+		  AND h.host = c.tags['hostname']
+		  AND c.ts >= 1136111400201
+		  AND c.ts < 1136154600201`,
+		},
+	}
+
+	// return the same set of random hosts deterministic
+	rand.Seed(101)
+	for _, c := range cases {
+		got := &query.CrateDB{}
+		c.fn(got)
+		if string(got.SqlQuery) != c.want {
+			t.Errorf("%s: incorrect sql query:\ngot: %s\n want:\n %s",
+				c.desc, got.SqlQuery, c.want)
+		}
+	}
+}
@@ -10,7 +10,8 @@ import (
 
 // BaseGenerator contains settings specific for Mongo database.
 type BaseGenerator struct {
-	UseNaive bool
+	UseNaive      bool
+	UseTimeSeries bool
 }
 
 // GenerateEmptyQuery returns an empty query.Mongo.
@@ -31,7 +32,12 @@ func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryG
 		Core:          core,
 	}
 
-	if g.UseNaive {
+	if g.UseTimeSeries {
+		devops = &TimeSeriesDevops{
+			BaseGenerator: g,
+			Core:          core,
+		}
+	} else if g.UseNaive {
 		devops = &NaiveDevops{
 			BaseGenerator: g,
 			Core:          core,
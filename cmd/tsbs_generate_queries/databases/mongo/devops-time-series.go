@@ -0,0 +1,217 @@
+package mongo
+
+import (
+	"encoding/gob"
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func init() {
+	// needed for serializing the mongo query to gob
+	gob.Register(bson.D{})
+	gob.Register(time.Time{})
+}
+
+const timeSeriesLabel = "Mongo [TIME-SERIES]"
+
+// TimeSeriesDevops produces Mongo-specific queries for the devops use case,
+// for the native time-series collection created by tsbs_load_mongo with
+// --time-series. Its documents have the time in 'time', the measurement and
+// the tags in 'meta' and the fields at the top level. The buckets of time
+// are computed with $dateTrunc, so the queries need MongoDB 5.0+.
+type TimeSeriesDevops struct {
+	*BaseGenerator
+	*devops.Core
+}
+
+// timeSeriesMatch returns the $match stage of the cpu documents in the
+// interval, of the given hosts if any
+func timeSeriesMatch(interval *utils.TimeInterval, hostnames []string) bson.M {
+	match := bson.M{
+		"meta.measurement": "cpu",
+		"time": bson.M{
+			"$gte": interval.Start(),
+			"$lt":  interval.End(),
+		},
+	}
+	if len(hostnames) > 0 {
+		match["meta.tags.hostname"] = bson.M{"$in": hostnames}
+	}
+	return bson.M{"$match": match}
+}
+
+// dateTrunc returns the expression truncating the time to the unit
+func dateTrunc(unit string) bson.M {
+	return bson.M{"$dateTrunc": bson.M{"date": "$time", "unit": unit}}
+}
+
+func (d *TimeSeriesDevops) fillInQuery(qi query.Query, humanLabel, humanDesc string, pipelineQuery []bson.M) {
+	q := qi.(*query.Mongo)
+	q.HumanLabel = []byte(humanLabel)
+	q.BsonDoc = pipelineQuery
+	q.CollectionName = []byte("point_data")
+	q.HumanDescription = []byte(humanDesc)
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g. in pseudo-SQL:
+//
+// SELECT minute, max(metric1), ..., max(metricN)
+// FROM cpu
+// WHERE (hostname = '$HOSTNAME_1' OR ... OR hostname = '$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY minute ORDER BY minute ASC
+func (d *TimeSeriesDevops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.MustRandWindow(timeRange)
+	hostnames, err := d.GetRandomHosts(nHosts)
+	panicIfErr(err)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+
+	group := bson.M{"_id": dateTrunc("minute")}
+	for _, metric := range metrics {
+		group["max_"+metric] = bson.M{"$max": "$" + metric}
+	}
+	pipelineQuery := []bson.M{
+		timeSeriesMatch(interval, hostnames),
+		{"$group": group},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	humanLabel := fmt.Sprintf("%s %d cpu metric(s), random %4d hosts, random %s by 1m", timeSeriesLabel, numMetrics, nHosts, timeRange)
+	d.fillInQuery(qi, humanLabel, fmt.Sprintf("%s: %s (point_data)", humanLabel, interval.StartString()), pipelineQuery)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in pseudo-SQL:
+//
+// SELECT MAX(metric1), ..., MAX(metricN)
+// FROM cpu WHERE (hostname = '$HOSTNAME_1' OR ... OR hostname = '$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour ORDER BY hour
+func (d *TimeSeriesDevops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
+	interval := d.Interval.MustRandWindow(duration)
+	hostnames, err := d.GetRandomHosts(nHosts)
+	panicIfErr(err)
+
+	group := bson.M{"_id": dateTrunc("hour")}
+	for _, metric := range devops.GetAllCPUMetrics() {
+		group["max_"+metric] = bson.M{"$max": "$" + metric}
+	}
+	pipelineQuery := []bson.M{
+		timeSeriesMatch(interval, hostnames),
+		{"$group": group},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	humanLabel := devops.GetMaxAllLabel(timeSeriesLabel, nHosts)
+	d.fillInQuery(qi, humanLabel, fmt.Sprintf("%s: %s", humanLabel, interval.StartString()), pipelineQuery)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. in pseudo-SQL:
+//
+// SELECT AVG(metric1), ..., AVG(metricN)
+// FROM cpu
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, hostname ORDER BY hour, hostname
+func (d *TimeSeriesDevops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	interval := d.Interval.MustRandWindow(devops.DoubleGroupByDuration)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+
+	group := bson.M{
+		"_id": bson.D{
+			{Name: "time", Value: dateTrunc("hour")},
+			{Name: "hostname", Value: "$meta.tags.hostname"},
+		},
+	}
+	for _, metric := range metrics {
+		group["avg_"+metric] = bson.M{"$avg": "$" + metric}
+	}
+	pipelineQuery := []bson.M{
+		timeSeriesMatch(interval, nil),
+		{"$group": group},
+		{"$sort": bson.D{{Name: "_id.time", Value: 1}, {Name: "_id.hostname", Value: 1}}},
+	}
+
+	humanLabel := devops.GetDoubleGroupByLabel(timeSeriesLabel, numMetrics)
+	d.fillInQuery(qi, humanLabel, fmt.Sprintf("%s: %s (point_data)", humanLabel, interval.StartString()), pipelineQuery)
+}
+
+// HighCPUForHosts populates a query that gets CPU metrics when the CPU has high
+// usage between a time period for a number of hosts (if 0, it will search all hosts),
+// e.g. in pseudo-SQL:
+//
+// SELECT * FROM cpu
+// WHERE usage_user > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (hostname = '$HOST' OR hostname = '$HOST2'...)
+func (d *TimeSeriesDevops) HighCPUForHosts(qi query.Query, nHosts int) {
+	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
+	var hostnames []string
+	if nHosts > 0 {
+		var err error
+		hostnames, err = d.GetRandomHosts(nHosts)
+		panicIfErr(err)
+	}
+
+	match := timeSeriesMatch(interval, hostnames)
+	match["$match"].(bson.M)["usage_user"] = bson.M{"$gt": 90.0}
+	pipelineQuery := []bson.M{match}
+
+	humanLabel, err := devops.GetHighCPULabel(timeSeriesLabel, nHosts)
+	panicIfErr(err)
+	d.fillInQuery(qi, humanLabel, fmt.Sprintf("%s: %s (point_data)", humanLabel, interval.StartString()), pipelineQuery)
+}
+
+// LastPointPerHost finds the last row for every host in the dataset
+func (d *TimeSeriesDevops) LastPointPerHost(qi query.Query) {
+	pipelineQuery := []bson.M{
+		{"$match": bson.M{"meta.measurement": "cpu"}},
+		{"$sort": bson.D{{Name: "meta.tags.hostname", Value: 1}, {Name: "time", Value: -1}}},
+		{
+			"$group": bson.M{
+				"_id":    "$meta.tags.hostname",
+				"result": bson.M{"$first": "$$ROOT"},
+			},
+		},
+	}
+
+	humanLabel := timeSeriesLabel + " last row per host"
+	d.fillInQuery(qi, humanLabel, humanLabel, pipelineQuery)
+}
+
+// GroupByOrderByLimit populates a query.Query that has a time WHERE clause, that groups by a truncated date, orders by that date, and takes a limit:
+// SELECT date_trunc('minute', time) AS t, MAX(cpu) FROM cpu
+// WHERE time < '$TIME'
+// GROUP BY t ORDER BY t DESC
+// LIMIT $LIMIT
+func (d *TimeSeriesDevops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.MustRandWindow(time.Hour)
+	pipelineQuery := []bson.M{
+		{
+			"$match": bson.M{
+				"meta.measurement": "cpu",
+				"time":             bson.M{"$lt": interval.End()},
+			},
+		},
+		{
+			"$group": bson.M{
+				"_id":       dateTrunc("minute"),
+				"max_value": bson.M{"$max": "$usage_user"},
+			},
+		},
+		{"$sort": bson.M{"_id": -1}},
+		{"$limit": 5},
+	}
+
+	humanLabel := timeSeriesLabel + " max cpu over last 5 min-intervals (random end)"
+	d.fillInQuery(qi, humanLabel, fmt.Sprintf("%s: %s", humanLabel, interval.EndString()), pipelineQuery)
+}
@@ -0,0 +1,134 @@
+package mongo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func newTestTimeSeriesDevops(t *testing.T) *TimeSeriesDevops {
+	rand.Seed(123) // Setting seed for testing purposes.
+	s := time.Unix(0, 0).UTC()
+	e := s.Add(24 * time.Hour)
+	b := BaseGenerator{UseNaive: true, UseTimeSeries: true}
+	dq, err := b.NewDevops(s, e, 10)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	d, ok := dq.(*TimeSeriesDevops)
+	if !ok {
+		t.Fatalf("incorrect generator type: got %T", dq)
+	}
+	return d
+}
+
+func TestTimeSeriesDevopsGroupByTime(t *testing.T) {
+	d := newTestTimeSeriesDevops(t)
+	q := d.GenerateEmptyQuery()
+	d.GroupByTime(q, 1, 2, time.Hour)
+	mq := q.(*query.Mongo)
+
+	wantLabel := "Mongo [TIME-SERIES] 2 cpu metric(s), random    1 hosts, random 1h0m0s by 1m"
+	if got := string(mq.HumanLabel); got != wantLabel {
+		t.Errorf("incorrect label: got %s want %s", got, wantLabel)
+	}
+	if got := string(mq.CollectionName); got != "point_data" {
+		t.Errorf("incorrect collection: %s", got)
+	}
+	if len(mq.BsonDoc) != 3 {
+		t.Fatalf("incorrect number of stages: %d", len(mq.BsonDoc))
+	}
+
+	match := mq.BsonDoc[0]["$match"].(bson.M)
+	if match["meta.measurement"] != "cpu" {
+		t.Errorf("incorrect measurement: %v", match["meta.measurement"])
+	}
+	if hosts := match["meta.tags.hostname"].(bson.M)["$in"].([]string); len(hosts) != 1 {
+		t.Errorf("incorrect hosts: %v", hosts)
+	}
+	timeRange := match["time"].(bson.M)
+	start, end := timeRange["$gte"].(time.Time), timeRange["$lt"].(time.Time)
+	if end.Sub(start) != time.Hour {
+		t.Errorf("incorrect time range: %v to %v", start, end)
+	}
+
+	wantGroup := bson.M{
+		"_id":              bson.M{"$dateTrunc": bson.M{"date": "$time", "unit": "minute"}},
+		"max_usage_user":   bson.M{"$max": "$usage_user"},
+		"max_usage_system": bson.M{"$max": "$usage_system"},
+	}
+	if got := mq.BsonDoc[1]["$group"]; !reflect.DeepEqual(got, wantGroup) {
+		t.Errorf("incorrect group:\ngot\n%v\nwant\n%v", got, wantGroup)
+	}
+}
+
+func TestTimeSeriesDevopsGroupByTimeAndPrimaryTag(t *testing.T) {
+	d := newTestTimeSeriesDevops(t)
+	q := d.GenerateEmptyQuery()
+	d.GroupByTimeAndPrimaryTag(q, 1)
+	mq := q.(*query.Mongo)
+
+	match := mq.BsonDoc[0]["$match"].(bson.M)
+	if _, ok := match["meta.tags.hostname"]; ok {
+		t.Errorf("unexpected host filter: %v", match)
+	}
+	wantID := bson.D{
+		{Name: "time", Value: bson.M{"$dateTrunc": bson.M{"date": "$time", "unit": "hour"}}},
+		{Name: "hostname", Value: "$meta.tags.hostname"},
+	}
+	if got := mq.BsonDoc[1]["$group"].(bson.M)["_id"]; !reflect.DeepEqual(got, wantID) {
+		t.Errorf("incorrect group id: got %v want %v", got, wantID)
+	}
+	wantSort := bson.D{{Name: "_id.time", Value: 1}, {Name: "_id.hostname", Value: 1}}
+	if got := mq.BsonDoc[2]["$sort"]; !reflect.DeepEqual(got, wantSort) {
+		t.Errorf("incorrect sort: got %v want %v", got, wantSort)
+	}
+}
+
+func TestTimeSeriesDevopsHighCPUForHosts(t *testing.T) {
+	cases := []struct {
+		nHosts    int
+		wantLabel string
+	}{
+		{nHosts: 0, wantLabel: "Mongo [TIME-SERIES] CPU over threshold, all hosts"},
+		{nHosts: 2, wantLabel: "Mongo [TIME-SERIES] CPU over threshold, 2 host(s)"},
+	}
+	for _, c := range cases {
+		d := newTestTimeSeriesDevops(t)
+		q := d.GenerateEmptyQuery()
+		d.HighCPUForHosts(q, c.nHosts)
+		mq := q.(*query.Mongo)
+
+		if got := string(mq.HumanLabel); got != c.wantLabel {
+			t.Errorf("incorrect label: got %s want %s", got, c.wantLabel)
+		}
+		match := mq.BsonDoc[0]["$match"].(bson.M)
+		if !reflect.DeepEqual(match["usage_user"], bson.M{"$gt": 90.0}) {
+			t.Errorf("incorrect threshold: %v", match["usage_user"])
+		}
+		_, hasHosts := match["meta.tags.hostname"]
+		if hasHosts != (c.nHosts > 0) {
+			t.Errorf("%d hosts: incorrect host filter: %v", c.nHosts, match)
+		}
+	}
+}
+
+func TestTimeSeriesDevopsLastPointPerHost(t *testing.T) {
+	d := newTestTimeSeriesDevops(t)
+	q := d.GenerateEmptyQuery()
+	d.LastPointPerHost(q)
+	mq := q.(*query.Mongo)
+
+	wantSort := bson.D{{Name: "meta.tags.hostname", Value: 1}, {Name: "time", Value: -1}}
+	if got := mq.BsonDoc[1]["$sort"]; !reflect.DeepEqual(got, wantSort) {
+		t.Errorf("incorrect sort: got %v want %v", got, wantSort)
+	}
+	wantGroup := bson.M{"_id": "$meta.tags.hostname", "result": bson.M{"$first": "$$ROOT"}}
+	if got := mq.BsonDoc[2]["$group"]; !reflect.DeepEqual(got, wantGroup) {
+		t.Errorf("incorrect group: got %v want %v", got, wantGroup)
+	}
+}
@@ -12,6 +12,9 @@ import (
 
 // BaseGenerator contains settings specific for QuestDB
 type BaseGenerator struct {
+	// UseNarrowLayout queries the (metric_name, value) rows created by the
+	// loader with --schema-layout=narrow
+	UseNarrowLayout bool
 }
 
 // GenerateEmptyQuery returns an empty query.QuestDB.
@@ -32,11 +32,35 @@ func (d *Devops) getSelectAggClauses(aggFunc string, idents []string) []string {
 	selectAggClauses := make([]string, len(idents))
 	for i, ident := range idents {
 		selectAggClauses[i] =
-			fmt.Sprintf("%[1]s(%[2]s) AS %[1]s_%[2]s", aggFunc, ident)
+			fmt.Sprintf("%s AS %s_%s", d.getAggExpr(aggFunc, ident), aggFunc, ident)
 	}
 	return selectAggClauses
 }
 
+// getAggExpr returns the expression aggregating the given column ident.
+// The narrow layout stores every metric in the value column, so only the
+// rows of the metric are aggregated.
+//
+// For instance:
+//
+//	max(CASE WHEN metric_name = 'cpu_time' THEN value END)
+func (d *Devops) getAggExpr(aggFunc, ident string) string {
+	if d.UseNarrowLayout {
+		return fmt.Sprintf("%s(CASE WHEN metric_name = '%s' THEN value END)", aggFunc, ident)
+	}
+	return fmt.Sprintf("%s(%s)", aggFunc, ident)
+}
+
+// getMetricFilter returns the clause restricting the narrow layout to the rows
+// of the given metrics, ready to append to a WHERE clause. It is empty for the
+// wide layout.
+func (d *Devops) getMetricFilter(metrics []string) string {
+	if !d.UseNarrowLayout {
+		return ""
+	}
+	return fmt.Sprintf("\n\t\t  AND metric_name IN ('%s')", strings.Join(metrics, "', '"))
+}
+
 // MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for N random
 // hosts
 //
@@ -45,7 +69,8 @@ func (d *Devops) getSelectAggClauses(aggFunc string, idents []string) []string {
 // cpu-max-all-8
 func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 	interval := d.Interval.MustRandWindow(duration)
-	selectClauses := d.getSelectAggClauses("max", devops.GetAllCPUMetrics())
+	metrics := devops.GetAllCPUMetrics()
+	selectClauses := d.getSelectAggClauses("max", metrics)
 	hosts, err := d.GetRandomHosts(nHosts)
 	panicIfErr(err)
 
@@ -56,13 +81,14 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 		FROM cpu
 		WHERE hostname IN ('%s')
 		  AND timestamp >= '%s'
-		  AND timestamp < '%s'
+		  AND timestamp < '%s'%s
 		GROUP BY hour
 		ORDER BY hour`,
 		strings.Join(selectClauses, ", "),
 		strings.Join(hosts, "', '"),
 		interval.StartString(),
-		interval.EndString())
+		interval.EndString(),
+		d.getMetricFilter(metrics))
 
 	humanLabel := devops.GetMaxAllLabel("QuestDB", nHosts)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -87,12 +113,13 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 			%s
 		FROM cpu
 		WHERE timestamp >= '%s'
-		  AND timestamp < '%s'
+		  AND timestamp < '%s'%s
 		GROUP BY timestamp, hostname
 		ORDER BY timestamp, hostname`,
 		strings.Join(selectClauses, ", "),
 		interval.StartString(),
-		interval.EndString())
+		interval.EndString(),
+		d.getMetricFilter(metrics))
 
 	humanLabel := devops.GetDoubleGroupByLabel("QuestDB", numMetrics)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -108,25 +135,31 @@ func (d *Devops) GroupByOrderByLimit(qi query.Query) {
 	interval := d.Interval.MustRandWindow(time.Hour)
 	sql := fmt.Sprintf(`
 		SELECT date_trunc('minute', timestamp) AS minute,
-			max(usage_user)
+			%s
 		FROM cpu
-		WHERE timestamp < '%s'
+		WHERE timestamp < '%s'%s
 		GROUP BY minute
 		ORDER BY minute DESC
 		LIMIT 5`,
-		interval.EndString())
+		d.getAggExpr("max", "usage_user"),
+		interval.EndString(),
+		d.getMetricFilter([]string{"usage_user"}))
 
 	humanLabel := "QuestDB max cpu over last 5 min-intervals (random end)"
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
 	d.fillInQuery(qi, humanLabel, humanDesc, sql)
 }
 
-// LastPointPerHost finds the last row for every host in the dataset
+// LastPointPerHost finds the last row for every host in the dataset. With the
+// narrow layout that is the last row of every metric of every host.
 //
 // Queries:
 // lastpoint
 func (d *Devops) LastPointPerHost(qi query.Query) {
 	sql := fmt.Sprintf(`SELECT * FROM cpu latest by hostname`)
+	if d.UseNarrowLayout {
+		sql = `SELECT * FROM cpu latest by hostname, metric_name`
+	}
 
 	humanLabel := "QuestDB last row per host"
 	humanDesc := humanLabel
@@ -143,7 +176,32 @@ func (d *Devops) LastPointPerHost(qi query.Query) {
 func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
 	sql := ""
-	if nHosts > 0 {
+	if d.UseNarrowLayout {
+		hostFilter := ""
+		if nHosts > 0 {
+			hosts, err := d.GetRandomHosts(nHosts)
+			panicIfErr(err)
+			hostFilter = fmt.Sprintf("\n\t\t         AND hostname IN ('%s')", strings.Join(hosts, "', '"))
+		}
+
+		// select all metrics of the hosts at the times usage_user was high
+		sql = fmt.Sprintf(`
+		      SELECT c.*
+		      FROM cpu c
+		      JOIN (
+		        SELECT hostname, timestamp
+		        FROM cpu
+		        WHERE metric_name = 'usage_user'
+		         AND value > 90.0%[3]s
+		         AND timestamp >= '%[1]s'
+		         AND timestamp < '%[2]s'
+		      ) h ON c.hostname = h.hostname AND c.timestamp = h.timestamp
+		      WHERE c.timestamp >= '%[1]s'
+		       AND c.timestamp < '%[2]s'`,
+			interval.StartString(),
+			interval.EndString(),
+			hostFilter)
+	} else if nHosts > 0 {
 		hosts, err := d.GetRandomHosts(nHosts)
 		panicIfErr(err)
 
@@ -198,13 +256,14 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 		FROM cpu
 		WHERE hostname IN ('%s')
 		  AND timestamp >= '%s'
-		  AND timestamp < '%s'
+		  AND timestamp < '%s'%s
 		GROUP BY minute
 		ORDER BY minute`,
 		strings.Join(selectClauses, ", "),
 		strings.Join(hosts, "', '"),
 		interval.StartString(),
-		interval.EndString())
+		interval.EndString(),
+		d.getMetricFilter(metrics))
 
 	humanLabel := fmt.Sprintf(
 		"QuestDB %d cpu metric(s), random %4d hosts, random %s by 1m",
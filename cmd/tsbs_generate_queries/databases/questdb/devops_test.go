@@ -208,6 +208,81 @@ func TestHighCPUForHosts(t *testing.T) {
 	runTestCases(t, testFunc, start, end, cases)
 }
 
+func TestDevopsNarrowLayout(t *testing.T) {
+	cases := []struct {
+		desc               string
+		fn                 func(d *Devops, q query.Query)
+		expectedHumanLabel string
+		expectedHumanDesc  string
+		expectedQuery      string
+	}{
+		{
+			desc: "group by time",
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 2, time.Hour)
+			},
+			expectedHumanLabel: "QuestDB 2 cpu metric(s), random    1 hosts, random 1h0m0s by 1m",
+			expectedHumanDesc:  "QuestDB 2 cpu metric(s), random    1 hosts, random 1h0m0s by 1m: 1970-01-01T06:16:22Z",
+			expectedQuery: "SELECT date_trunc('minute', timestamp) as minute, " +
+				"max(CASE WHEN metric_name = 'usage_user' THEN value END) AS max_usage_user, " +
+				"max(CASE WHEN metric_name = 'usage_system' THEN value END) AS max_usage_system FROM cpu " +
+				"WHERE hostname IN ('host_9') AND timestamp >= '1970-01-01T06:16:22Z' AND timestamp < '1970-01-01T07:16:22Z' " +
+				"AND metric_name IN ('usage_user', 'usage_system') GROUP BY minute ORDER BY minute",
+		},
+		{
+			desc:               "last point",
+			fn:                 (*Devops).LastPointPerHost,
+			expectedHumanLabel: "QuestDB last row per host",
+			expectedHumanDesc:  "QuestDB last row per host",
+			expectedQuery:      "SELECT * FROM cpu latest by hostname, metric_name",
+		},
+		{
+			desc: "high cpu 1 host",
+			fn: func(d *Devops, q query.Query) {
+				d.HighCPUForHosts(q, 1)
+			},
+			expectedHumanLabel: "QuestDB CPU over threshold, 1 host(s)",
+			expectedHumanDesc:  "QuestDB CPU over threshold, 1 host(s): 1970-01-01T00:16:22Z",
+			expectedQuery: "SELECT c.* FROM cpu c JOIN ( SELECT hostname, timestamp FROM cpu " +
+				"WHERE metric_name = 'usage_user' AND value > 90.0 AND hostname IN ('host_9') AND " +
+				"timestamp >= '1970-01-01T00:16:22Z' AND timestamp < '1970-01-01T12:16:22Z' ) h " +
+				"ON c.hostname = h.hostname AND c.timestamp = h.timestamp " +
+				"WHERE c.timestamp >= '1970-01-01T00:16:22Z' AND c.timestamp < '1970-01-01T12:16:22Z'",
+		},
+		{
+			desc: "high cpu all hosts",
+			fn: func(d *Devops, q query.Query) {
+				d.HighCPUForHosts(q, 0)
+			},
+			expectedHumanLabel: "QuestDB CPU over threshold, all hosts",
+			expectedHumanDesc:  "QuestDB CPU over threshold, all hosts: 1970-01-01T00:16:22Z",
+			expectedQuery: "SELECT c.* FROM cpu c JOIN ( SELECT hostname, timestamp FROM cpu " +
+				"WHERE metric_name = 'usage_user' AND value > 90.0 AND " +
+				"timestamp >= '1970-01-01T00:16:22Z' AND timestamp < '1970-01-01T12:16:22Z' ) h " +
+				"ON c.hostname = h.hostname AND c.timestamp = h.timestamp " +
+				"WHERE c.timestamp >= '1970-01-01T00:16:22Z' AND c.timestamp < '1970-01-01T12:16:22Z'",
+		},
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(devops.HighCPUDuration).Add(time.Hour)
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			b := BaseGenerator{UseNarrowLayout: true}
+			dq, err := b.NewDevops(start, end, 10)
+			if err != nil {
+				t.Fatalf("Error while creating devops generator")
+			}
+			d := dq.(*Devops)
+
+			q := d.GenerateEmptyQuery()
+			c.fn(d, q)
+			verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanDesc, c.expectedQuery)
+		})
+	}
+}
+
 type testCase struct {
 	desc               string
 	input              int
@@ -1,6 +1,7 @@
 package timescaledb
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
@@ -16,6 +17,12 @@ type BaseGenerator struct {
 	UseJSON       bool
 	UseTags       bool
 	UseTimeBucket bool
+	// UseContinuousAggregates reads eligible queries from the continuous
+	// aggregates on cpu instead of the raw rows
+	UseContinuousAggregates bool
+	// UseNarrowLayout queries the (time, tags_id, metric_name, value) rows
+	// created by the loader with --schema-layout=narrow
+	UseNarrowLayout bool
 }
 
 // GenerateEmptyQuery returns an empty query.TimescaleDB.
@@ -34,6 +41,9 @@ func (g *BaseGenerator) fillInQuery(qi query.Query, humanLabel, humanDesc, table
 
 // NewDevops creates a new devops use case query generator.
 func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	if g.UseNarrowLayout && g.UseContinuousAggregates {
+		return nil, fmt.Errorf("continuous aggregates are not supported with the narrow schema layout")
+	}
 	core, err := devops.NewCore(start, end, scale)
 
 	if err != nil {
@@ -50,6 +60,9 @@ func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryG
 
 // NewIoT creates a new iot use case query generator.
 func (g *BaseGenerator) NewIoT(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	if g.UseNarrowLayout {
+		return nil, fmt.Errorf("iot queries are not supported with the narrow schema layout")
+	}
 	core, err := iot.NewCore(start, end, scale)
 
 	if err != nil {
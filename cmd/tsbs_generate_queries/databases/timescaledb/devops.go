@@ -22,8 +22,19 @@ const (
 
 	timeBucketFmt    = "time_bucket('%d seconds', time)"
 	nonTimeBucketFmt = "to_timestamp(((extract(epoch from time)::int)/%d)*%d)"
+
+	// aggregateBucketColumn is the time column of the continuous aggregates
+	aggregateBucketColumn = "bucket"
 )
 
+// cpuAggregates are the continuous aggregates on cpu created by the loader
+// with --continuous-aggregates, by their bucket width in seconds. They hold
+// the max_<metric> and avg_<metric> of every cpu metric per bucket and host.
+var cpuAggregates = map[int]string{
+	oneMinute: "cpu_1m",
+	oneHour:   "cpu_1h",
+}
+
 // Devops produces TimescaleDB-specific queries for all the devops query types.
 type Devops struct {
 	*BaseGenerator
@@ -68,10 +79,53 @@ func (d *Devops) getTimeBucket(seconds int) string {
 	return fmt.Sprintf(nonTimeBucketFmt, seconds, seconds)
 }
 
+// getSource returns the table, time column and time bucket to aggregate cpu
+// rows by the given number of seconds. With continuous aggregates the
+// matching aggregate is read instead of the raw rows.
+func (d *Devops) getSource(seconds int) (table, timeColumn, bucket string) {
+	if aggregate, ok := cpuAggregates[seconds]; ok && d.UseContinuousAggregates {
+		return aggregate, aggregateBucketColumn, aggregateBucketColumn
+	}
+	return devops.TableName, "time", d.getTimeBucket(seconds)
+}
+
+// getAggColumn returns the column to aggregate with agg for the given metric.
+// Continuous aggregates store it pre-aggregated as <agg>_<metric>.
+func (d *Devops) getAggColumn(agg, metric string) string {
+	if d.UseContinuousAggregates {
+		return agg + "_" + metric
+	}
+	return metric
+}
+
+// getAggExpr returns the expression aggregating the given metric with agg.
+// The narrow layout stores every metric in the value column, so only the
+// rows of the metric are aggregated.
+func (d *Devops) getAggExpr(agg, metric string) string {
+	if d.UseNarrowLayout {
+		return fmt.Sprintf("%s(value) FILTER (WHERE metric_name = '%s')", agg, metric)
+	}
+	return fmt.Sprintf("%s(%s)", agg, d.getAggColumn(agg, metric))
+}
+
+// getMetricFilter returns the clause restricting the narrow layout to the rows
+// of the given metrics, ready to append to a WHERE clause. It is empty for the
+// wide layout.
+func (d *Devops) getMetricFilter(metrics []string) string {
+	if !d.UseNarrowLayout {
+		return ""
+	}
+	metricClauses := make([]string, len(metrics))
+	for i, m := range metrics {
+		metricClauses[i] = fmt.Sprintf("'%s'", m)
+	}
+	return fmt.Sprintf(" AND metric_name IN (%s)", strings.Join(metricClauses, ","))
+}
+
 func (d *Devops) getSelectClausesAggMetrics(agg string, metrics []string) []string {
 	selectClauses := make([]string, len(metrics))
 	for i, m := range metrics {
-		selectClauses[i] = fmt.Sprintf("%[1]s(%[2]s) as %[1]s_%[2]s", agg, m)
+		selectClauses[i] = fmt.Sprintf("%s as %s_%s", d.getAggExpr(agg, m), agg, m)
 	}
 
 	return selectClauses
@@ -95,16 +149,19 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 		panic(fmt.Sprintf("invalid number of select clauses: got %d", len(selectClauses)))
 	}
 
+	table, timeColumn, bucket := d.getSource(oneMinute)
 	sql := fmt.Sprintf(`SELECT %s AS minute,
         %s
-        FROM cpu
-        WHERE %s AND time >= '%s' AND time < '%s'
+        FROM %s
+        WHERE %s AND %s >= '%s' AND %s < '%s'%s
         GROUP BY minute ORDER BY minute ASC`,
-		d.getTimeBucket(oneMinute),
+		bucket,
 		strings.Join(selectClauses, ", "),
+		table,
 		d.getHostWhereString(nHosts),
-		interval.Start().Format(goTimeFmt),
-		interval.End().Format(goTimeFmt))
+		timeColumn, interval.Start().Format(goTimeFmt),
+		timeColumn, interval.End().Format(goTimeFmt),
+		d.getMetricFilter(metrics))
 
 	humanLabel := fmt.Sprintf("TimescaleDB %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
@@ -118,14 +175,18 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 // LIMIT $LIMIT
 func (d *Devops) GroupByOrderByLimit(qi query.Query) {
 	interval := d.Interval.MustRandWindow(time.Hour)
-	sql := fmt.Sprintf(`SELECT %s AS minute, max(usage_user)
-        FROM cpu
-        WHERE time < '%s'
+	table, timeColumn, bucket := d.getSource(oneMinute)
+	sql := fmt.Sprintf(`SELECT %s AS minute, %s
+        FROM %s
+        WHERE %s < '%s'%s
         GROUP BY minute
         ORDER BY minute DESC
         LIMIT 5`,
-		d.getTimeBucket(oneMinute),
-		interval.End().Format(goTimeFmt))
+		bucket,
+		d.getAggExpr("max", "usage_user"),
+		table,
+		timeColumn, interval.End().Format(goTimeFmt),
+		d.getMetricFilter([]string{"usage_user"}))
 
 	humanLabel := "TimescaleDB max cpu over last 5 min-intervals (random end)"
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
@@ -148,7 +209,7 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 	meanClauses := make([]string, numMetrics)
 	for i, m := range metrics {
 		meanClauses[i] = "mean_" + m
-		selectClauses[i] = fmt.Sprintf("avg(%s) as %s", m, meanClauses[i])
+		selectClauses[i] = fmt.Sprintf("%s as %s", d.getAggExpr("avg", m), meanClauses[i])
 	}
 
 	hostnameField := "hostname"
@@ -164,23 +225,26 @@ func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
 		partitionGrouping = "tags_id"
 	}
 
+	table, timeColumn, bucket := d.getSource(oneHour)
 	sql := fmt.Sprintf(`
         WITH cpu_avg AS (
           SELECT %s as hour, %s,
           %s
-          FROM cpu
-          WHERE time >= '%s' AND time < '%s'
+          FROM %s
+          WHERE %s >= '%s' AND %s < '%s'%s
           GROUP BY 1, 2
         )
         SELECT hour, %s, %s
         FROM cpu_avg
         %s
         ORDER BY hour, %s`,
-		d.getTimeBucket(oneHour),
+		bucket,
 		partitionGrouping,
 		strings.Join(selectClauses, ", "),
-		interval.Start().Format(goTimeFmt),
-		interval.End().Format(goTimeFmt),
+		table,
+		timeColumn, interval.Start().Format(goTimeFmt),
+		timeColumn, interval.End().Format(goTimeFmt),
+		d.getMetricFilter(metrics),
 		hostnameField, strings.Join(meanClauses, ", "),
 		joinStr, hostnameField)
 	humanLabel := devops.GetDoubleGroupByLabel("TimescaleDB", numMetrics)
@@ -201,26 +265,38 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 	metrics := devops.GetAllCPUMetrics()
 	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
 
+	table, timeColumn, bucket := d.getSource(oneHour)
 	sql := fmt.Sprintf(`SELECT %s AS hour,
         %s
-        FROM cpu
-        WHERE %s AND time >= '%s' AND time < '%s'
+        FROM %s
+        WHERE %s AND %s >= '%s' AND %s < '%s'%s
         GROUP BY hour ORDER BY hour`,
-		d.getTimeBucket(oneHour),
+		bucket,
 		strings.Join(selectClauses, ", "),
+		table,
 		d.getHostWhereString(nHosts),
-		interval.Start().Format(goTimeFmt),
-		interval.End().Format(goTimeFmt))
+		timeColumn, interval.Start().Format(goTimeFmt),
+		timeColumn, interval.End().Format(goTimeFmt),
+		d.getMetricFilter(metrics))
 
 	humanLabel := devops.GetMaxAllLabel("TimescaleDB", nHosts)
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
 	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
 }
 
-// LastPointPerHost finds the last row for every host in the dataset
+// LastPointPerHost finds the last row for every host in the dataset. With the
+// narrow layout that is the last row of every metric of every host.
 func (d *Devops) LastPointPerHost(qi query.Query) {
 	var sql string
-	if d.UseTags {
+	if d.UseNarrowLayout {
+		if d.UseTags {
+			sql = "SELECT DISTINCT ON (t.hostname, c.metric_name) * FROM tags t INNER JOIN cpu c ON c.tags_id = t.id ORDER BY t.hostname, c.metric_name, c.time DESC"
+		} else if d.UseJSON {
+			sql = "SELECT DISTINCT ON (t.tagset->>'hostname', c.metric_name) * FROM tags t INNER JOIN cpu c ON c.tags_id = t.id ORDER BY t.tagset->>'hostname', c.metric_name, c.time DESC"
+		} else {
+			sql = "SELECT DISTINCT ON (hostname, metric_name) * FROM cpu ORDER BY hostname, metric_name, time DESC"
+		}
+	} else if d.UseTags {
 		sql = fmt.Sprintf("SELECT DISTINCT ON (t.hostname) * FROM tags t INNER JOIN LATERAL(SELECT * FROM cpu c WHERE c.tags_id = t.id ORDER BY time DESC LIMIT 1) AS b ON true ORDER BY t.hostname, b.time DESC")
 	} else if d.UseJSON {
 		sql = fmt.Sprintf("SELECT DISTINCT ON (t.tagset->>'hostname') * FROM tags t INNER JOIN LATERAL(SELECT * FROM cpu c WHERE c.tags_id = t.id ORDER BY time DESC LIMIT 1) AS b ON true ORDER BY t.tagset->>'hostname', b.time DESC")
@@ -250,8 +326,15 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	}
 	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
 
-	sql := fmt.Sprintf(`SELECT * FROM cpu WHERE usage_user > 90.0 and time >= '%s' AND time < '%s' %s`,
-		interval.Start().Format(goTimeFmt), interval.End().Format(goTimeFmt), hostWhereClause)
+	var sql string
+	if d.UseNarrowLayout {
+		// Return all metrics of the series at the times usage_user was high
+		sql = fmt.Sprintf(`SELECT * FROM cpu WHERE (tags_id, time) IN (SELECT tags_id, time FROM cpu WHERE metric_name = 'usage_user' AND value > 90.0 and time >= '%[1]s' AND time < '%[2]s' %[3]s) and time >= '%[1]s' AND time < '%[2]s'`,
+			interval.Start().Format(goTimeFmt), interval.End().Format(goTimeFmt), hostWhereClause)
+	} else {
+		sql = fmt.Sprintf(`SELECT * FROM cpu WHERE usage_user > 90.0 and time >= '%s' AND time < '%s' %s`,
+			interval.Start().Format(goTimeFmt), interval.End().Format(goTimeFmt), hostWhereClause)
+	}
 
 	humanLabel, err := devops.GetHighCPULabel("TimescaleDB", nHosts)
 	panicIfErr(err)
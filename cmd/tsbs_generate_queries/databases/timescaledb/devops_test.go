@@ -533,3 +533,186 @@ func verifyQuery(t *testing.T, q query.Query, humanLabel, humanDesc, hypertable,
 		t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, sqlQuery), got, sqlQuery)
 	}
 }
+
+func TestDevopsContinuousAggregates(t *testing.T) {
+	cases := []struct {
+		desc               string
+		fill               func(d *Devops, q query.Query)
+		expectedHumanLabel string
+		expectedSQLQuery   string
+	}{
+		{
+			desc:               "group by time",
+			fill:               func(d *Devops, q query.Query) { d.GroupByTime(q, 1, 1, time.Hour) },
+			expectedHumanLabel: "TimescaleDB 1 cpu metric(s), random    1 hosts, random 1h0m0s by 1m",
+			expectedSQLQuery: `SELECT bucket AS minute,
+        max(max_usage_user) as max_usage_user
+        FROM cpu_1m
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9')) AND bucket >= '1970-01-01 04:16:22.646325 +0000' AND bucket < '1970-01-01 05:16:22.646325 +0000'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:               "group by order by limit",
+			fill:               func(d *Devops, q query.Query) { d.GroupByOrderByLimit(q) },
+			expectedHumanLabel: "TimescaleDB max cpu over last 5 min-intervals (random end)",
+			expectedSQLQuery: `SELECT bucket AS minute, max(max_usage_user)
+        FROM cpu_1m
+        WHERE bucket < '1970-01-01 05:16:22.646325 +0000'
+        GROUP BY minute
+        ORDER BY minute DESC
+        LIMIT 5`,
+		},
+		{
+			desc:               "group by time and primary tag",
+			fill:               func(d *Devops, q query.Query) { d.GroupByTimeAndPrimaryTag(q, 1) },
+			expectedHumanLabel: "TimescaleDB mean of 1 metrics, all hosts, random 12h0m0s by 1h",
+			expectedSQLQuery: `
+        WITH cpu_avg AS (
+          SELECT bucket as hour, tags_id,
+          avg(avg_usage_user) as mean_usage_user
+          FROM cpu_1h
+          WHERE bucket >= '1970-01-01 00:16:22.646325 +0000' AND bucket < '1970-01-01 12:16:22.646325 +0000'
+          GROUP BY 1, 2
+        )
+        SELECT hour, tags.hostname, mean_usage_user
+        FROM cpu_avg
+        JOIN tags ON cpu_avg.tags_id = tags.id
+        ORDER BY hour, tags.hostname`,
+		},
+		{
+			desc:               "max all cpu",
+			fill:               func(d *Devops, q query.Query) { d.MaxAllCPU(q, 1, devops.MaxAllDuration) },
+			expectedHumanLabel: "TimescaleDB max of all CPU metrics, random    1 hosts, random 8h0m0s by 1h",
+			expectedSQLQuery: `SELECT bucket AS hour,
+        max(max_usage_user) as max_usage_user, max(max_usage_system) as max_usage_system, max(max_usage_idle) as max_usage_idle, ` +
+				"max(max_usage_nice) as max_usage_nice, max(max_usage_iowait) as max_usage_iowait, max(max_usage_irq) as max_usage_irq, " +
+				"max(max_usage_softirq) as max_usage_softirq, max(max_usage_steal) as max_usage_steal, max(max_usage_guest) as max_usage_guest, " +
+				`max(max_usage_guest_nice) as max_usage_guest_nice
+        FROM cpu_1h
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9')) AND bucket >= '1970-01-01 00:16:22.646325 +0000' AND bucket < '1970-01-01 08:16:22.646325 +0000'
+        GROUP BY hour ORDER BY hour`,
+		},
+	}
+
+	s := time.Unix(0, 0)
+	e := s.Add(devops.DoubleGroupByDuration).Add(2 * time.Hour)
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			b := BaseGenerator{
+				UseTags:                 true,
+				UseTimeBucket:           true,
+				UseContinuousAggregates: true,
+			}
+			dq, err := b.NewDevops(s, e, 10)
+			if err != nil {
+				t.Fatalf("Error while creating devops generator")
+			}
+			d := dq.(*Devops)
+
+			q := d.GenerateEmptyQuery()
+			c.fill(d, q)
+			tsq := q.(*query.TimescaleDB)
+			if got := string(tsq.HumanLabel); got != c.expectedHumanLabel {
+				t.Errorf("incorrect human label:\ngot\n%s\nwant\n%s", got, c.expectedHumanLabel)
+			}
+			if got := string(tsq.SqlQuery); got != c.expectedSQLQuery {
+				t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, c.expectedSQLQuery), got, c.expectedSQLQuery)
+			}
+		})
+	}
+}
+
+func TestDevopsNarrowLayout(t *testing.T) {
+	cases := []struct {
+		desc               string
+		fill               func(d *Devops, q query.Query)
+		expectedHumanLabel string
+		expectedSQLQuery   string
+	}{
+		{
+			desc:               "group by time",
+			fill:               func(d *Devops, q query.Query) { d.GroupByTime(q, 1, 2, time.Hour) },
+			expectedHumanLabel: "TimescaleDB 2 cpu metric(s), random    1 hosts, random 1h0m0s by 1m",
+			expectedSQLQuery: `SELECT time_bucket('60 seconds', time) AS minute,
+        max(value) FILTER (WHERE metric_name = 'usage_user') as max_usage_user, max(value) FILTER (WHERE metric_name = 'usage_system') as max_usage_system
+        FROM cpu
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9')) AND time >= '1970-01-01 04:16:22.646325 +0000' AND time < '1970-01-01 05:16:22.646325 +0000' AND metric_name IN ('usage_user','usage_system')
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:               "group by order by limit",
+			fill:               func(d *Devops, q query.Query) { d.GroupByOrderByLimit(q) },
+			expectedHumanLabel: "TimescaleDB max cpu over last 5 min-intervals (random end)",
+			expectedSQLQuery: `SELECT time_bucket('60 seconds', time) AS minute, max(value) FILTER (WHERE metric_name = 'usage_user')
+        FROM cpu
+        WHERE time < '1970-01-01 05:16:22.646325 +0000' AND metric_name IN ('usage_user')
+        GROUP BY minute
+        ORDER BY minute DESC
+        LIMIT 5`,
+		},
+		{
+			desc:               "group by time and primary tag",
+			fill:               func(d *Devops, q query.Query) { d.GroupByTimeAndPrimaryTag(q, 1) },
+			expectedHumanLabel: "TimescaleDB mean of 1 metrics, all hosts, random 12h0m0s by 1h",
+			expectedSQLQuery: `
+        WITH cpu_avg AS (
+          SELECT time_bucket('3600 seconds', time) as hour, tags_id,
+          avg(value) FILTER (WHERE metric_name = 'usage_user') as mean_usage_user
+          FROM cpu
+          WHERE time >= '1970-01-01 00:16:22.646325 +0000' AND time < '1970-01-01 12:16:22.646325 +0000' AND metric_name IN ('usage_user')
+          GROUP BY 1, 2
+        )
+        SELECT hour, tags.hostname, mean_usage_user
+        FROM cpu_avg
+        JOIN tags ON cpu_avg.tags_id = tags.id
+        ORDER BY hour, tags.hostname`,
+		},
+		{
+			desc:               "last point per host",
+			fill:               func(d *Devops, q query.Query) { d.LastPointPerHost(q) },
+			expectedHumanLabel: "TimescaleDB last row per host",
+			expectedSQLQuery:   "SELECT DISTINCT ON (t.hostname, c.metric_name) * FROM tags t INNER JOIN cpu c ON c.tags_id = t.id ORDER BY t.hostname, c.metric_name, c.time DESC",
+		},
+		{
+			desc:               "high cpu for hosts",
+			fill:               func(d *Devops, q query.Query) { d.HighCPUForHosts(q, 1) },
+			expectedHumanLabel: "TimescaleDB CPU over threshold, 1 host(s)",
+			expectedSQLQuery: `SELECT * FROM cpu WHERE (tags_id, time) IN (SELECT tags_id, time FROM cpu WHERE metric_name = 'usage_user' AND value > 90.0 and time >= '1970-01-01 01:54:10.138978 +0000' AND time < '1970-01-01 13:54:10.138978 +0000' ` +
+				"AND tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_5'))) and time >= '1970-01-01 01:54:10.138978 +0000' AND time < '1970-01-01 13:54:10.138978 +0000'",
+		},
+	}
+
+	s := time.Unix(0, 0)
+	e := s.Add(devops.DoubleGroupByDuration).Add(2 * time.Hour)
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			b := BaseGenerator{
+				UseTags:         true,
+				UseTimeBucket:   true,
+				UseNarrowLayout: true,
+			}
+			dq, err := b.NewDevops(s, e, 10)
+			if err != nil {
+				t.Fatalf("Error while creating devops generator")
+			}
+			d := dq.(*Devops)
+
+			q := d.GenerateEmptyQuery()
+			c.fill(d, q)
+			tsq := q.(*query.TimescaleDB)
+			if got := string(tsq.HumanLabel); got != c.expectedHumanLabel {
+				t.Errorf("incorrect human label:\ngot\n%s\nwant\n%s", got, c.expectedHumanLabel)
+			}
+			if got := string(tsq.SqlQuery); got != c.expectedSQLQuery {
+				t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, c.expectedSQLQuery), got, c.expectedSQLQuery)
+			}
+		})
+	}
+
+	b := BaseGenerator{UseNarrowLayout: true, UseContinuousAggregates: true}
+	if _, err := b.NewDevops(s, e, 10); err == nil {
+		t.Errorf("expected error for continuous aggregates with the narrow layout")
+	}
+}
@@ -6,58 +6,23 @@ import (
 	"fmt"
 	"github.com/timescale/tsbs/pkg/query/config"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
-	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
-	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/iot"
-	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses"
 	"github.com/timescale/tsbs/internal/inputs"
 	internalUtils "github.com/timescale/tsbs/internal/utils"
 )
 
-var useCaseMatrix = map[string]map[string]utils.QueryFillerMaker{
-	"devops": {
-		devops.LabelSingleGroupby + "-1-1-1":  devops.NewSingleGroupby(1, 1, 1),
-		devops.LabelSingleGroupby + "-1-1-12": devops.NewSingleGroupby(1, 1, 12),
-		devops.LabelSingleGroupby + "-1-8-1":  devops.NewSingleGroupby(1, 8, 1),
-		devops.LabelSingleGroupby + "-5-1-1":  devops.NewSingleGroupby(5, 1, 1),
-		devops.LabelSingleGroupby + "-5-1-12": devops.NewSingleGroupby(5, 1, 12),
-		devops.LabelSingleGroupby + "-5-8-1":  devops.NewSingleGroupby(5, 8, 1),
-		devops.LabelMaxAll + "-1":             devops.NewMaxAllCPU(1, devops.MaxAllDuration),
-		devops.LabelMaxAll + "-8":             devops.NewMaxAllCPU(8, devops.MaxAllDuration),
-		devops.LabelMaxAll + "-32-24":         devops.NewMaxAllCPU(32, 24*time.Hour),
-		devops.LabelDoubleGroupby + "-1":      devops.NewGroupBy(1),
-		devops.LabelDoubleGroupby + "-5":      devops.NewGroupBy(5),
-		devops.LabelDoubleGroupby + "-all":    devops.NewGroupBy(devops.GetCPUMetricsLen()),
-		devops.LabelGroupbyOrderbyLimit:       devops.NewGroupByOrderByLimit,
-		devops.LabelHighCPU + "-all":          devops.NewHighCPU(0),
-		devops.LabelHighCPU + "-1":            devops.NewHighCPU(1),
-		devops.LabelLastpoint:                 devops.NewLastPointPerHost,
-	},
-	"iot": {
-		iot.LabelLastLoc:                       iot.NewLastLocPerTruck,
-		iot.LabelLastLocSingleTruck:            iot.NewLastLocSingleTruck,
-		iot.LabelLowFuel:                       iot.NewTruckWithLowFuel,
-		iot.LabelHighLoad:                      iot.NewTruckWithHighLoad,
-		iot.LabelStationaryTrucks:              iot.NewStationaryTrucks,
-		iot.LabelLongDrivingSessions:           iot.NewTrucksWithLongDrivingSession,
-		iot.LabelLongDailySessions:             iot.NewTruckWithLongDailySession,
-		iot.LabelAvgVsProjectedFuelConsumption: iot.NewAvgVsProjectedFuelConsumption,
-		iot.LabelAvgDailyDrivingDuration:       iot.NewAvgDailyDrivingDuration,
-		iot.LabelAvgDailyDrivingSession:        iot.NewAvgDailyDrivingSession,
-		iot.LabelAvgLoad:                       iot.NewAvgLoad,
-		iot.LabelDailyActivity:                 iot.NewDailyTruckActivity,
-		iot.LabelBreakdownFrequency:            iot.NewTruckBreakdownFrequency,
-	},
-}
+var useCaseMatrix = uses.UseCaseMatrix()
+
+var dashboardMatrix = uses.DashboardMatrix()
 
 var conf = &config.QueryGeneratorConfig{}
 
 // Parse args:
 func init() {
-	useCaseMatrix["cpu-only"] = useCaseMatrix["devops"]
 	// Change the Usage function to print the use case matrix of choices:
 	oldUsage := pflag.Usage
 	pflag.Usage = func() {
@@ -70,6 +35,13 @@ func init() {
 				fmt.Fprintf(os.Stderr, "  use case: %s, query type: %s\n", uc, qt)
 			}
 		}
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "The predefined dashboards are:\n")
+		for uc, dashboards := range dashboardMatrix {
+			for name, queryTypes := range dashboards {
+				fmt.Fprintf(os.Stderr, "  use case: %s, dashboard: %s, query types: %s\n", uc, name, strings.Join(queryTypes, ","))
+			}
+		}
 	}
 
 	conf.AddToFlagSet(pflag.CommandLine)
@@ -89,10 +61,28 @@ func init() {
 	if err := viper.Unmarshal(&conf); err != nil {
 		panic(fmt.Errorf("unable to decode config: %s", err))
 	}
+
+	// The use case of the data config is only overridden by an explicit
+	// --use-case, not by the default of the flag
+	if conf.DataConfig != "" && !viper.IsSet("use-case") {
+		conf.Use = ""
+	}
 }
 
 func main() {
+	if conf.ProbeDB != "" {
+		// reject an unsupported format before connecting to the database
+		if err := conf.Validate(); err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+		if err := probeDataRange(conf); err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+	}
 	qg := inputs.NewQueryGenerator(useCaseMatrix)
+	qg.Dashboards = dashboardMatrix
 	err := qg.Generate(conf)
 	if err != nil {
 		fmt.Printf("error: %v\n", err)
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query/config"
+)
+
+// probeDataRange sets the time range and scale of the queries to the ones of
+// the data loaded in the database. Only the first and last time of the data
+// table and the number of rows of the tags table are read, which the indexes
+// the TimescaleDB loader creates answer without scanning the data. The config
+// was validated, so the format is TimescaleDB.
+func probeDataRange(c *config.QueryGeneratorConfig) error {
+	db, err := sql.Open("pgx", c.ProbeDB)
+	if err != nil {
+		return fmt.Errorf("cannot connect to the probed database: %v", err)
+	}
+	defer db.Close()
+
+	table := "cpu"
+	if c.Use == common.UseCaseIoT {
+		table = "readings"
+	}
+	var start, end time.Time
+	err = db.QueryRow(fmt.Sprintf("SELECT min(time), max(time) FROM %s", table)).Scan(&start, &end)
+	if err != nil {
+		return fmt.Errorf("cannot probe the time range of %s: %v", table, err)
+	}
+	var scale uint64
+	if err := db.QueryRow("SELECT count(*) FROM tags").Scan(&scale); err != nil {
+		return fmt.Errorf("cannot probe the scale from tags: %v", err)
+	}
+
+	// the end of the range is exclusive, so it is past the last row
+	c.TimeStart = start.UTC().Truncate(time.Second).Format(time.RFC3339)
+	c.TimeEnd = end.UTC().Truncate(time.Second).Add(time.Second).Format(time.RFC3339)
+	c.Scale = scale
+	return nil
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ItemPicker picks the index of a host or truck of a query out of the total
+type ItemPicker interface {
+	Pick(total int) int
+}
+
+// UniformPicker picks the items uniformly at random
+type UniformPicker struct{}
+
+// Pick returns a uniformly random item
+func (UniformPicker) Pick(total int) int {
+	return rand.Intn(total)
+}
+
+// zipfPicker picks item 0 most often, following a Zipf distribution of
+// exponent s
+type zipfPicker struct {
+	s     float64
+	total int
+	zipf  *rand.Zipf
+}
+
+// NewZipfPicker returns an ItemPicker following a Zipf distribution of
+// exponent s, which must be greater than 1. The greater s, the more the
+// first items are picked.
+func NewZipfPicker(s float64) (ItemPicker, error) {
+	if s <= 1 {
+		return nil, fmt.Errorf("zipf exponent must be greater than 1, got %v", s)
+	}
+	return &zipfPicker{s: s}, nil
+}
+
+func (p *zipfPicker) Pick(total int) int {
+	// the source is seeded from the global one on first use, so the picks
+	// follow the seed of the generator
+	if p.zipf == nil || p.total != total {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		p.zipf = rand.NewZipf(r, p.s, 1, uint64(total-1))
+		p.total = total
+	}
+	return int(p.zipf.Uint64())
+}
+
+// hotSetPicker picks the first items, the hot set, with a given share of the
+// picks, and the other items uniformly otherwise
+type hotSetPicker struct {
+	size  float64
+	share float64
+}
+
+// NewHotSetPicker returns an ItemPicker picking the items of the hot set, the
+// first size fraction of the items, for a share of the picks.
+func NewHotSetPicker(size, share float64) (ItemPicker, error) {
+	if size <= 0 || size > 1 {
+		return nil, fmt.Errorf("hot set size must be in (0, 1], got %v", size)
+	}
+	if share < 0 || share > 1 {
+		return nil, fmt.Errorf("hot set share must be in [0, 1], got %v", share)
+	}
+	return &hotSetPicker{size: size, share: share}, nil
+}
+
+func (p *hotSetPicker) Pick(total int) int {
+	hot := int(p.size * float64(total))
+	if hot < 1 {
+		hot = 1
+	}
+	if hot == total || rand.Float64() < p.share {
+		return rand.Intn(hot)
+	}
+	return hot + rand.Intn(total-hot)
+}
+
+// noRepeatPicker deals the items from a shuffled deck, so no item is picked
+// twice before every item was
+type noRepeatPicker struct {
+	deck []int
+	next int
+}
+
+// NewNoRepeatPicker returns an ItemPicker that picks every item once, in a
+// random order, before picking any of them again.
+func NewNoRepeatPicker() ItemPicker {
+	return &noRepeatPicker{}
+}
+
+func (p *noRepeatPicker) Pick(total int) int {
+	if len(p.deck) != total || p.next == total {
+		p.deck = rand.Perm(total)
+		p.next = 0
+	}
+	p.next++
+	return p.deck[p.next-1]
+}
+
+// dashboardPicker picks the items picked by the previous queries of a
+// dashboard first, in the same order, and new items with the inner picker
+// after them
+type dashboardPicker struct {
+	inner ItemPicker
+	items []int
+	// next is the index of the next item of the query in items
+	next int
+}
+
+func (p *dashboardPicker) Pick(total int) int {
+	if p.next < len(p.items) {
+		p.next++
+		return p.items[p.next-1]
+	}
+	n := p.inner.Pick(total)
+	for len(p.items) < total && p.picked(n) {
+		n = rand.Intn(total)
+	}
+	p.items = append(p.items, n)
+	p.next++
+	return n
+}
+
+func (p *dashboardPicker) picked(n int) bool {
+	for _, item := range p.items {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package common
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func countPicks(p ItemPicker, total, picks int) []int {
+	counts := make([]int, total)
+	for i := 0; i < picks; i++ {
+		counts[p.Pick(total)]++
+	}
+	return counts
+}
+
+func TestZipfPicker(t *testing.T) {
+	if _, err := NewZipfPicker(1); err == nil {
+		t.Errorf("unexpected lack of error for exponent 1")
+	}
+	rand.Seed(123)
+	p, err := NewZipfPicker(1.2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts := countPicks(p, 100, 10000)
+	if counts[0] <= counts[1] || counts[1] <= counts[10] || counts[10] <= counts[99] {
+		t.Errorf("picks not skewed toward the first items: %v", counts)
+	}
+}
+
+func TestHotSetPicker(t *testing.T) {
+	for _, c := range []struct{ size, share float64 }{{0, 0.9}, {1.5, 0.9}, {0.1, -1}, {0.1, 2}} {
+		if _, err := NewHotSetPicker(c.size, c.share); err == nil {
+			t.Errorf("unexpected lack of error for size %v share %v", c.size, c.share)
+		}
+	}
+	rand.Seed(123)
+	p, err := NewHotSetPicker(0.1, 0.9)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counts := countPicks(p, 100, 10000)
+	hot := 0
+	for _, n := range counts[:10] {
+		hot += n
+	}
+	if hot < 8800 || hot > 9200 {
+		t.Errorf("incorrect share of the hot set: got %d of 10000", hot)
+	}
+
+	// a hot set of every item picks uniformly
+	p, _ = NewHotSetPicker(1, 0)
+	for _, n := range countPicks(p, 3, 300) {
+		if n == 0 {
+			t.Errorf("item never picked with a hot set of every item")
+		}
+	}
+}
+
+func TestNoRepeatPicker(t *testing.T) {
+	rand.Seed(123)
+	p := NewNoRepeatPicker()
+	for round := 0; round < 3; round++ {
+		for i, n := range countPicks(p, 50, 50) {
+			if n != 1 {
+				t.Errorf("round %d: item %d picked %d times", round, i, n)
+			}
+		}
+	}
+}
+
+func TestGetPickedSubset(t *testing.T) {
+	// a picker always picking the same item still returns distinct items
+	p, _ := NewHotSetPicker(0.01, 1)
+	ret, err := GetPickedSubset(p, 10, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := map[int]bool{}
+	for _, n := range ret {
+		if seen[n] {
+			t.Errorf("duplicate item %d in %v", n, ret)
+		}
+		seen[n] = true
+	}
+	if ret[0] != 0 {
+		t.Errorf("first item not picked by the picker: %v", ret)
+	}
+	if _, err := GetPickedSubset(p, 11, 10); err == nil {
+		t.Errorf("unexpected lack of error for a subset larger than the scale")
+	}
+}
+
+func TestCoreDashboard(t *testing.T) {
+	rand.Seed(123)
+	c, err := NewCore(time.Unix(0, 0), time.Unix(0, 0).Add(24*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.StartDashboard()
+	first, _ := GetPickedSubset(c.Items, 2, c.Scale)
+	window := c.Interval.MustRandWindow(time.Hour)
+	c.NextDashboardQuery()
+	second, _ := GetPickedSubset(c.Items, 5, c.Scale)
+	if !reflect.DeepEqual(second[:2], first) {
+		t.Errorf("hosts not shared by the queries of the dashboard: %v then %v", first, second)
+	}
+	if x := c.Interval.MustRandWindow(12 * time.Hour); x.End() != window.End() {
+		t.Errorf("time range not shared by the queries of the dashboard: %v then %v", window.End(), x.End())
+	}
+	c.NextDashboardQuery()
+	third, _ := GetPickedSubset(c.Items, 5, c.Scale)
+	if !reflect.DeepEqual(third, second) {
+		t.Errorf("hosts not shared by the queries of the dashboard: %v then %v", second, third)
+	}
+
+	c.StartDashboard()
+	next, _ := GetPickedSubset(c.Items, 5, c.Scale)
+	if reflect.DeepEqual(next, second) {
+		t.Errorf("hosts of the previous dashboard picked again: %v", next)
+	}
+}
@@ -21,6 +21,13 @@ type Core struct {
 
 	// Scale is the cardinality of the dataset in terms of devices/hosts
 	Scale int
+
+	// Items picks the devices/hosts of the queries
+	Items ItemPicker
+
+	// dashboard replays the devices/hosts of the first query of a dashboard
+	// for the next ones, when the queries are grouped in dashboards
+	dashboard *dashboardPicker
 }
 
 // NewCore returns a new Core for the given time range and cardinality
@@ -30,7 +37,46 @@ func NewCore(start, end time.Time, scale int) (*Core, error) {
 		return nil, err
 	}
 
-	return &Core{Interval: ti, Scale: scale}, nil
+	return &Core{Interval: ti, Scale: scale, Items: UniformPicker{}}, nil
+}
+
+// UseRecentWindows biases the random time windows of the queries toward the
+// end of the dataset, see TimeInterval.WithRecency
+func (c *Core) UseRecentWindows(halfLife time.Duration) {
+	c.Interval = c.Interval.WithRecency(halfLife)
+}
+
+// UseItemPicker sets how the devices/hosts of the queries are picked
+func (c *Core) UseItemPicker(p ItemPicker) {
+	c.Items = p
+}
+
+// UseNoRepeatWindows draws the random time windows of the queries so no
+// window is read twice, see TimeInterval.WithoutRepeats
+func (c *Core) UseNoRepeatWindows() {
+	c.Interval = c.Interval.WithoutRepeats()
+}
+
+// StartDashboard starts the queries of a new dashboard: until the next call,
+// the queries share the devices/hosts and the end of the time windows of the
+// first query of the dashboard.
+func (c *Core) StartDashboard() {
+	if c.dashboard == nil {
+		c.dashboard = &dashboardPicker{inner: c.Items}
+		c.Items = c.dashboard
+		c.Interval = c.Interval.WithSharedEnd()
+	}
+	c.dashboard.items = c.dashboard.items[:0]
+	c.dashboard.next = 0
+	c.Interval.ResetSharedEnd()
+}
+
+// NextDashboardQuery starts the next query of the current dashboard, which
+// picks the devices/hosts of the previous queries first
+func (c *Core) NextDashboardQuery() {
+	if c.dashboard != nil {
+		c.dashboard.next = 0
+	}
 }
 
 // PanicUnimplementedQuery generates a panic for the provided query generator.
@@ -44,6 +90,13 @@ func PanicUnimplementedQuery(dg utils.QueryGenerator) {
 // The subset of the permutation should have no duplicates and thus, can not be longer that original set
 // Ex.: 12, 7, 25 for numItems=3 and totalItems=30 (3 out of 30)
 func GetRandomSubsetPerm(numItems int, totalItems int) ([]int, error) {
+	return GetPickedSubset(UniformPicker{}, numItems, totalItems)
+}
+
+// GetPickedSubset returns numItems distinct numbers from 0 to totalItems,
+// picked by the picker. A number the picker repeats is replaced by a uniformly
+// random one, so a skewed picker does not loop on a large subset.
+func GetPickedSubset(picker ItemPicker, numItems int, totalItems int) ([]int, error) {
 	if numItems > totalItems {
 		// Cannot make a subset longer than the original set
 		return nil, fmt.Errorf(errMoreItemsThanScale)
@@ -52,15 +105,13 @@ func GetRandomSubsetPerm(numItems int, totalItems int) ([]int, error) {
 	seen := map[int]bool{}
 	res := make([]int, numItems)
 	for i := 0; i < numItems; i++ {
-		for {
-			n := rand.Intn(totalItems)
+		n := picker.Pick(totalItems)
+		for seen[n] {
 			// Keep iterating until a previously unseen int is found
-			if !seen[n] {
-				seen[n] = true
-				res[i] = n
-				break
-			}
+			n = rand.Intn(totalItems)
 		}
+		seen[n] = true
+		res[i] = n
 	}
 	return res, nil
 }
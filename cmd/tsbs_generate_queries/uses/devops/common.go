@@ -52,7 +52,7 @@ func NewCore(start, end time.Time, scale int) (*Core, error) {
 
 // GetRandomHosts returns a random set of nHosts from a given Core
 func (d *Core) GetRandomHosts(nHosts int) ([]string, error) {
-	return getRandomHosts(nHosts, d.Scale)
+	return getRandomHosts(d.Core.Items, nHosts, d.Scale)
 }
 
 // cpuMetrics is the list of metric names for CPU
@@ -146,7 +146,7 @@ func GetMaxAllLabel(dbName string, nHosts int) string {
 // getRandomHosts returns a subset of numHosts hostnames of a permutation of hostnames,
 // numbered from 0 to totalHosts.
 // Ex.: host_12, host_7, host_25 for numHosts=3 and totalHosts=30 (3 out of 30)
-func getRandomHosts(numHosts int, totalHosts int) ([]string, error) {
+func getRandomHosts(picker common.ItemPicker, numHosts int, totalHosts int) ([]string, error) {
 	if numHosts < 1 {
 		return nil, fmt.Errorf("number of hosts cannot be < 1; got %d", numHosts)
 	}
@@ -154,7 +154,7 @@ func getRandomHosts(numHosts int, totalHosts int) ([]string, error) {
 		return nil, fmt.Errorf("number of hosts (%d) larger than total hosts. See --scale (%d)", numHosts, totalHosts)
 	}
 
-	randomNumbers, err := common.GetRandomSubsetPerm(numHosts, totalHosts)
+	randomNumbers, err := common.GetPickedSubset(picker, numHosts, totalHosts)
 	if err != nil {
 		return nil, err
 	}
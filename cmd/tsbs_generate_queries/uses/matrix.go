@@ -0,0 +1,90 @@
+// Package uses defines the query types that can be generated for each use case.
+package uses
+
+import (
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+)
+
+// UseCaseMatrix returns, for every use case, the query types that can be
+// generated for it and the QueryFillerMaker creating them.
+func UseCaseMatrix() map[string]map[string]utils.QueryFillerMaker {
+	matrix := map[string]map[string]utils.QueryFillerMaker{
+		"devops": {
+			devops.LabelSingleGroupby + "-1-1-1":  devops.NewSingleGroupby(1, 1, 1),
+			devops.LabelSingleGroupby + "-1-1-12": devops.NewSingleGroupby(1, 1, 12),
+			devops.LabelSingleGroupby + "-1-8-1":  devops.NewSingleGroupby(1, 8, 1),
+			devops.LabelSingleGroupby + "-5-1-1":  devops.NewSingleGroupby(5, 1, 1),
+			devops.LabelSingleGroupby + "-5-1-12": devops.NewSingleGroupby(5, 1, 12),
+			devops.LabelSingleGroupby + "-5-8-1":  devops.NewSingleGroupby(5, 8, 1),
+			devops.LabelMaxAll + "-1":             devops.NewMaxAllCPU(1, devops.MaxAllDuration),
+			devops.LabelMaxAll + "-8":             devops.NewMaxAllCPU(8, devops.MaxAllDuration),
+			devops.LabelMaxAll + "-32-24":         devops.NewMaxAllCPU(32, 24*time.Hour),
+			devops.LabelDoubleGroupby + "-1":      devops.NewGroupBy(1),
+			devops.LabelDoubleGroupby + "-5":      devops.NewGroupBy(5),
+			devops.LabelDoubleGroupby + "-all":    devops.NewGroupBy(devops.GetCPUMetricsLen()),
+			devops.LabelGroupbyOrderbyLimit:       devops.NewGroupByOrderByLimit,
+			devops.LabelHighCPU + "-all":          devops.NewHighCPU(0),
+			devops.LabelHighCPU + "-1":            devops.NewHighCPU(1),
+			devops.LabelLastpoint:                 devops.NewLastPointPerHost,
+		},
+		"iot": {
+			iot.LabelLastLoc:                       iot.NewLastLocPerTruck,
+			iot.LabelLastLocSingleTruck:            iot.NewLastLocSingleTruck,
+			iot.LabelLowFuel:                       iot.NewTruckWithLowFuel,
+			iot.LabelHighLoad:                      iot.NewTruckWithHighLoad,
+			iot.LabelStationaryTrucks:              iot.NewStationaryTrucks,
+			iot.LabelLongDrivingSessions:           iot.NewTrucksWithLongDrivingSession,
+			iot.LabelLongDailySessions:             iot.NewTruckWithLongDailySession,
+			iot.LabelAvgVsProjectedFuelConsumption: iot.NewAvgVsProjectedFuelConsumption,
+			iot.LabelAvgDailyDrivingDuration:       iot.NewAvgDailyDrivingDuration,
+			iot.LabelAvgDailyDrivingSession:        iot.NewAvgDailyDrivingSession,
+			iot.LabelAvgLoad:                       iot.NewAvgLoad,
+			iot.LabelDailyActivity:                 iot.NewDailyTruckActivity,
+			iot.LabelBreakdownFrequency:            iot.NewTruckBreakdownFrequency,
+		},
+	}
+	matrix["cpu-only"] = matrix["devops"]
+	return matrix
+}
+
+// DashboardMatrix returns, for every use case, the predefined dashboards and
+// their query types. The queries of a dashboard share their hosts and time
+// range, like the panels of a dashboard.
+func DashboardMatrix() map[string]map[string][]string {
+	matrix := map[string]map[string][]string{
+		"devops": {
+			"host-overview": {
+				devops.LabelSingleGroupby + "-1-1-1",
+				devops.LabelSingleGroupby + "-5-1-1",
+				devops.LabelSingleGroupby + "-5-1-12",
+				devops.LabelMaxAll + "-1",
+				devops.LabelHighCPU + "-1",
+			},
+			"fleet-overview": {
+				devops.LabelSingleGroupby + "-5-8-1",
+				devops.LabelMaxAll + "-8",
+				devops.LabelDoubleGroupby + "-5",
+				devops.LabelGroupbyOrderbyLimit,
+				devops.LabelHighCPU + "-all",
+				devops.LabelLastpoint,
+			},
+		},
+		"iot": {
+			"fleet-status": {
+				iot.LabelLastLoc,
+				iot.LabelLowFuel,
+				iot.LabelHighLoad,
+				iot.LabelStationaryTrucks,
+				iot.LabelAvgLoad,
+			},
+		},
+	}
+	matrix["cpu-only"] = map[string][]string{
+		"host-overview": matrix["devops"]["host-overview"],
+	}
+	return matrix
+}
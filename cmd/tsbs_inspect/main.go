@@ -0,0 +1,172 @@
+// tsbs_inspect streams a data file written by tsbs_generate_data and prints
+// summary stats: measurements, series cardinality, point count, time range
+// and malformed points.
+//
+// The file is read with the file data source of the target it was generated
+// for, so it does not need to be loaded into a database. If the config the
+// file was generated with was recorded next to it (<file>.config.yaml), the
+// stats are verified against it and the program exits with a non-zero status
+// when a check fails, e.g. because the file was truncated.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+const exitVerifyFailed = 2
+
+// Program option vars:
+var (
+	fileName   string
+	format     string
+	configFile string
+	noVerify   bool
+)
+
+// Parse args:
+func init() {
+	pflag.StringVar(&fileName, "file", "", "Data file to inspect, STDIN if empty. Compressed files are detected by their extension")
+	pflag.StringVar(&format, "format", "", fmt.Sprintf("Format of the data file (choices: %s). Default: the format recorded in the config file", strings.Join(inspectableFormats(), ", ")))
+	pflag.StringVar(&configFile, "config", "", "Config the data file was generated with. Default: <file>"+inputs.ConfigSidecarSuffix+" if it exists")
+	pflag.BoolVar(&noVerify, "no-verify", false, "Only print the stats, without verifying them against the config")
+
+	pflag.Parse()
+}
+
+func main() {
+	var config *common.DataGeneratorConfig
+	if !noVerify {
+		var err error
+		if config, err = readConfig(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if format == "" && config != nil {
+		format = config.Format
+	}
+	if format == "" {
+		log.Fatal("--format is required when there is no config file")
+	}
+	inspector, err := getInspector(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stats := inspect(inspector)
+	writeStats(os.Stdout, stats)
+
+	if config == nil {
+		return
+	}
+	checks, err := verify(stats, format, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !writeChecks(os.Stdout, checks) {
+		os.Exit(exitVerifyFailed)
+	}
+}
+
+// readConfig reads the config file given with --config, or else the config
+// sidecar of the data file if there is one
+func readConfig() (*common.DataGeneratorConfig, error) {
+	if configFile != "" {
+		return inputs.ReadConfigSidecar(configFile)
+	}
+	if fileName == "" {
+		return nil, nil
+	}
+	sidecar := inputs.ConfigSidecarFile(fileName)
+	if _, err := os.Stat(sidecar); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return inputs.ReadConfigSidecar(sidecar)
+}
+
+// inspectableFormats returns the formats whose targets can read their data
+// files outside of a load
+func inspectableFormats() []string {
+	var formats []string
+	for _, f := range constants.SupportedFormats() {
+		if _, ok := initializers.GetTarget(f).(targets.FileInspector); ok {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+func getInspector(format string) (targets.FileInspector, error) {
+	if !utils.IsIn(format, constants.SupportedFormats()) {
+		return nil, fmt.Errorf("unknown format '%s', valid: %s", format, strings.Join(inspectableFormats(), ", "))
+	}
+	inspector, ok := initializers.GetTarget(format).(targets.FileInspector)
+	if !ok {
+		return nil, fmt.Errorf("data files of format '%s' cannot be inspected, valid: %s", format, strings.Join(inspectableFormats(), ", "))
+	}
+	return inspector, nil
+}
+
+// inspect streams the data file and describes each of its points
+func inspect(inspector targets.FileInspector) *fileStats {
+	ds := inspector.FileDataSource(fileName)
+	stats := newFileStats(ds.Headers())
+	for {
+		item := ds.NextItem()
+		if item.Data == nil {
+			break
+		}
+		stats.addPoint(inspector.DescribePoint(item))
+	}
+	return stats
+}
+
+func writeStats(w io.Writer, s *fileStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Points:\t%d\n", s.points)
+	fmt.Fprintf(tw, "Values:\t%d\n", s.values)
+	fmt.Fprintf(tw, "Malformed points:\t%d\n", s.malformed)
+	fmt.Fprintf(tw, "Series:\t%d\n", s.series())
+	fmt.Fprintf(tw, "Primary tag values:\t%d\n", len(s.primaryTags))
+	fmt.Fprintf(tw, "First timestamp:\t%s\n", formatTime(s.min))
+	fmt.Fprintf(tw, "Last timestamp:\t%s\n", formatTime(s.max))
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "measurement\tpoints\tvalues\tseries\tfirst\tlast")
+	for _, m := range s.sortedMeasurements() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\n", m.name, m.points, m.values, len(m.series), formatTime(m.min), formatTime(m.max))
+	}
+	tw.Flush()
+	for _, sample := range s.malformedSamples {
+		fmt.Fprintf(w, "malformed %s\n", sample)
+	}
+}
+
+// writeChecks writes the verification checks and returns whether all passed
+func writeChecks(w io.Writer, checks []check) bool {
+	passed := true
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "check\tstatus\tdetail")
+	for _, c := range checks {
+		status := "ok"
+		if !c.ok {
+			status = "FAILED"
+			passed = false
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.name, status, c.detail)
+	}
+	tw.Flush()
+	return passed
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// maxMalformedSamples is the number of malformed point errors kept to report
+const maxMalformedSamples = 5
+
+// timeRange is the first and last timestamp of a set of points
+type timeRange struct {
+	min, max time.Time
+}
+
+func (r *timeRange) add(t time.Time) {
+	if r.min.IsZero() || t.Before(r.min) {
+		r.min = t
+	}
+	if r.max.IsZero() || t.After(r.max) {
+		r.max = t
+	}
+}
+
+// measurementStats summarizes the points of a measurement
+type measurementStats struct {
+	name   string
+	points uint64
+	values uint64
+	series map[string]struct{}
+	timeRange
+}
+
+// fileStats summarizes the points of a data file
+type fileStats struct {
+	headers      *common.GeneratedDataHeaders
+	measurements map[string]*measurementStats
+	primaryTags  map[string]struct{}
+	points       uint64
+	values       uint64
+	malformed    uint64
+	// malformedSamples are the errors of the first malformed points
+	malformedSamples []string
+	timeRange
+}
+
+func newFileStats(headers *common.GeneratedDataHeaders) *fileStats {
+	return &fileStats{
+		headers:      headers,
+		measurements: make(map[string]*measurementStats),
+		primaryTags:  make(map[string]struct{}),
+	}
+}
+
+// addPoint adds a described point to the stats. Points that could not be
+// described, or that do not match the headers of the file, are counted as
+// malformed.
+func (s *fileStats) addPoint(desc *targets.PointDescription, err error) {
+	if err == nil {
+		err = s.checkHeaders(desc)
+	}
+	if err != nil {
+		s.malformed++
+		if len(s.malformedSamples) < maxMalformedSamples {
+			s.malformedSamples = append(s.malformedSamples, fmt.Sprintf("point %d: %v", s.points+s.malformed, err))
+		}
+		return
+	}
+
+	m, ok := s.measurements[desc.Measurement]
+	if !ok {
+		m = &measurementStats{name: desc.Measurement, series: make(map[string]struct{})}
+		s.measurements[desc.Measurement] = m
+	}
+	m.points++
+	m.values += uint64(desc.Values)
+	m.series[desc.Series] = struct{}{}
+	m.add(desc.Timestamp)
+
+	s.points++
+	s.values += uint64(desc.Values)
+	// Simulators may drop the primary tag of some points (e.g. IoT trucks
+	// without a name), those points do not belong to a host
+	if desc.PrimaryTag != "" {
+		s.primaryTags[desc.PrimaryTag] = struct{}{}
+	}
+	s.add(desc.Timestamp)
+}
+
+// checkHeaders checks a point against the headers of the file, if it has any
+func (s *fileStats) checkHeaders(desc *targets.PointDescription) error {
+	if s.headers == nil {
+		return nil
+	}
+	fields, ok := s.headers.FieldKeys[desc.Measurement]
+	if !ok {
+		return fmt.Errorf("measurement '%s' not in headers", desc.Measurement)
+	}
+	if desc.Fields != len(fields) {
+		return fmt.Errorf("%s point has %d fields, headers have %d", desc.Measurement, desc.Fields, len(fields))
+	}
+	return nil
+}
+
+// series returns the number of distinct series over all measurements
+func (s *fileStats) series() int {
+	series := 0
+	for _, m := range s.measurements {
+		series += len(m.series)
+	}
+	return series
+}
+
+// sortedMeasurements returns the measurement stats ordered by name
+func (s *fileStats) sortedMeasurements() []*measurementStats {
+	ms := make([]*measurementStats, 0, len(s.measurements))
+	for _, m := range s.measurements {
+		ms = append(ms, m)
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].name < ms[j].name })
+	return ms
+}
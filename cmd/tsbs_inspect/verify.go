@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+// check is the outcome of verifying one property of a data file against the
+// config it was generated with
+type check struct {
+	name   string
+	ok     bool
+	detail string
+}
+
+// verify checks the stats of a data file against the config it was generated
+// with. Data files generated with a point limit or interleaved groups hold a
+// subset of the simulation, so only upper bounds are checked for them.
+func verify(s *fileStats, format string, config *common.DataGeneratorConfig) ([]check, error) {
+	start, err := time.Parse(time.RFC3339, config.TimeStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp-start in config: %v", err)
+	}
+	end, err := time.Parse(time.RFC3339, config.TimeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp-end in config: %v", err)
+	}
+	complete := config.Limit == 0 && config.InterleavedNumGroups <= 1
+
+	checks := []check{{
+		name:   "format",
+		ok:     config.Format == format,
+		detail: fmt.Sprintf("config %s, file read as %s", config.Format, format),
+	}, {
+		name:   "malformed points",
+		ok:     s.malformed == 0,
+		detail: fmt.Sprintf("%d", s.malformed),
+	}, {
+		name:   "time range",
+		ok:     s.points > 0 && !s.min.Before(start) && s.max.Before(end),
+		detail: fmt.Sprintf("%s - %s within [%s, %s)", formatTime(s.min), formatTime(s.max), config.TimeStart, config.TimeEnd),
+	}}
+
+	hosts := uint64(len(s.primaryTags))
+	if complete {
+		checks = append(checks, check{
+			name:   "series",
+			ok:     hosts == config.Scale,
+			detail: fmt.Sprintf("%d distinct primary tag values, scale %d", hosts, config.Scale),
+		})
+		// The last reading is at most one log interval before the end
+		lastExpected := end.Add(-config.LogInterval)
+		checks = append(checks, check{
+			name:   "not truncated",
+			ok:     s.points > 0 && !s.max.Before(lastExpected),
+			detail: fmt.Sprintf("last point at %s, expected at or after %s", formatTime(s.max), formatTime(lastExpected)),
+		})
+		if s.headers != nil {
+			missing := 0
+			for m := range s.headers.FieldKeys {
+				if _, ok := s.measurements[m]; !ok {
+					missing++
+				}
+			}
+			checks = append(checks, check{
+				name:   "measurements",
+				ok:     missing == 0,
+				detail: fmt.Sprintf("%d of %d measurements in headers have points", len(s.headers.FieldKeys)-missing, len(s.headers.FieldKeys)),
+			})
+		}
+	} else {
+		checks = append(checks, check{
+			name:   "series",
+			ok:     hosts <= config.Scale,
+			detail: fmt.Sprintf("%d distinct primary tag values, at most scale %d", hosts, config.Scale),
+		})
+		if config.Limit > 0 {
+			checks = append(checks, check{
+				name:   "point limit",
+				ok:     s.points+s.malformed <= config.Limit,
+				detail: fmt.Sprintf("%d points, max-data-points %d", s.points+s.malformed, config.Limit),
+			})
+		}
+	}
+	return checks, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
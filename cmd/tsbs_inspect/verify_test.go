@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+var testStart = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testConfig() *common.DataGeneratorConfig {
+	return &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Format:    "timescaledb",
+			Use:       "cpu-only",
+			Scale:     2,
+			TimeStart: "2016-01-01T00:00:00Z",
+			TimeEnd:   "2016-01-01T00:01:00Z",
+		},
+		LogInterval: 10 * time.Second,
+	}
+}
+
+// testStats returns the stats of a complete simulation of the test config
+func testStats(hosts int) *fileStats {
+	s := newFileStats(&common.GeneratedDataHeaders{
+		FieldKeys: map[string][]string{"cpu": {"usage_user", "usage_system"}},
+	})
+	for ts := testStart; ts.Before(testStart.Add(time.Minute)); ts = ts.Add(10 * time.Second) {
+		for h := 0; h < hosts; h++ {
+			host := fmt.Sprintf("host_%d", h)
+			s.addPoint(&targets.PointDescription{
+				Measurement: "cpu",
+				Series:      "hostname=" + host,
+				PrimaryTag:  host,
+				Timestamp:   ts,
+				Fields:      2,
+				Values:      2,
+			}, nil)
+		}
+	}
+	return s
+}
+
+func failedChecks(t *testing.T, s *fileStats, format string, config *common.DataGeneratorConfig) []string {
+	checks, err := verify(s, format, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var failed []string
+	for _, c := range checks {
+		if !c.ok {
+			failed = append(failed, c.name)
+		}
+	}
+	return failed
+}
+
+func TestFileStatsAddPoint(t *testing.T) {
+	s := testStats(2)
+	if s.points != 12 || s.values != 24 {
+		t.Errorf("incorrect counts: got %d points %d values", s.points, s.values)
+	}
+	if got := s.series(); got != 2 {
+		t.Errorf("incorrect series: got %d want 2", got)
+	}
+	if !s.min.Equal(testStart) || !s.max.Equal(testStart.Add(50*time.Second)) {
+		t.Errorf("incorrect time range: got %v - %v", s.min, s.max)
+	}
+
+	// points without a primary tag do not add a host
+	s.addPoint(&targets.PointDescription{Measurement: "cpu", Timestamp: testStart, Fields: 2}, nil)
+	if got := len(s.primaryTags); got != 2 {
+		t.Errorf("incorrect primary tags: got %d want 2", got)
+	}
+
+	s.addPoint(nil, fmt.Errorf("bad line"))
+	s.addPoint(&targets.PointDescription{Measurement: "mem", Timestamp: testStart}, nil)
+	s.addPoint(&targets.PointDescription{Measurement: "cpu", Timestamp: testStart, Fields: 3}, nil)
+	if s.malformed != 3 {
+		t.Errorf("incorrect malformed: got %d want 3", s.malformed)
+	}
+	if len(s.malformedSamples) != 3 {
+		t.Errorf("incorrect malformed samples: got %v", s.malformedSamples)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	cases := []struct {
+		desc   string
+		stats  func() *fileStats
+		format string
+		config func() *common.DataGeneratorConfig
+		failed []string
+	}{
+		{
+			desc:   "complete file",
+			stats:  func() *fileStats { return testStats(2) },
+			format: "timescaledb",
+			config: testConfig,
+		},
+		{
+			desc:   "wrong format",
+			stats:  func() *fileStats { return testStats(2) },
+			format: "influx",
+			config: testConfig,
+			failed: []string{"format"},
+		},
+		{
+			desc:   "missing host",
+			stats:  func() *fileStats { return testStats(1) },
+			format: "timescaledb",
+			config: testConfig,
+			failed: []string{"series"},
+		},
+		{
+			desc: "truncated",
+			stats: func() *fileStats {
+				s := testStats(2)
+				s.max = testStart.Add(20 * time.Second)
+				return s
+			},
+			format: "timescaledb",
+			config: testConfig,
+			failed: []string{"not truncated"},
+		},
+		{
+			desc: "out of range",
+			stats: func() *fileStats {
+				s := testStats(2)
+				s.max = testStart.Add(time.Hour)
+				return s
+			},
+			format: "timescaledb",
+			config: testConfig,
+			failed: []string{"time range"},
+		},
+		{
+			desc: "missing measurement",
+			stats: func() *fileStats {
+				s := testStats(2)
+				s.headers.FieldKeys["mem"] = []string{"used"}
+				return s
+			},
+			format: "timescaledb",
+			config: testConfig,
+			failed: []string{"measurements"},
+		},
+		{
+			desc:   "limited subset",
+			stats:  func() *fileStats { return testStats(1) },
+			format: "timescaledb",
+			config: func() *common.DataGeneratorConfig {
+				c := testConfig()
+				c.Limit = 6
+				return c
+			},
+		},
+		{
+			desc:   "limit exceeded",
+			stats:  func() *fileStats { return testStats(1) },
+			format: "timescaledb",
+			config: func() *common.DataGeneratorConfig {
+				c := testConfig()
+				c.Limit = 5
+				return c
+			},
+			failed: []string{"point limit"},
+		},
+		{
+			desc:   "empty file",
+			stats:  func() *fileStats { return newFileStats(nil) },
+			format: "timescaledb",
+			config: testConfig,
+			failed: []string{"time range", "series", "not truncated"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			failed := failedChecks(t, c.stats(), c.format, c.config())
+			if fmt.Sprint(failed) != fmt.Sprint(c.failed) {
+				t.Errorf("incorrect failed checks: got %v want %v", failed, c.failed)
+			}
+		})
+	}
+}
+
+func TestVerifyInvalidConfig(t *testing.T) {
+	c := testConfig()
+	c.TimeEnd = "tomorrow"
+	if _, err := verify(testStats(2), "timescaledb", c); err == nil {
+		t.Errorf("expected error for invalid timestamp-end")
+	}
+}
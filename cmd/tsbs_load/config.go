@@ -25,10 +25,14 @@ type RunnerConfig struct {
 	DoAbortOnExist  bool          `yaml:"do-abort-on-exist" mapstructure:"do-abort-on-exist"`
 	ReportingPeriod time.Duration `yaml:"reporting-period" mapstructure:"reporting-period"`
 	Seed            int64
-	HashWorkers     bool   `yaml:"hash-workers" mapstructure:"hash-workers"`
-	InsertIntervals string `yaml:"insert-intervals" mapstructure:"insert-intervals"`
-	FlowControl     bool   `yaml:"flow-control" mapstructure:"flow-control"`
-	ChannelCapacity uint   `yaml:"channel-capacity" mapstructure:"channel-capacity"`
+	HashWorkers     bool    `yaml:"hash-workers" mapstructure:"hash-workers"`
+	InsertIntervals string  `yaml:"insert-intervals" mapstructure:"insert-intervals"`
+	InsertRate      string  `yaml:"insert-rate-profile" mapstructure:"insert-rate-profile"`
+	FlowControl     bool    `yaml:"flow-control" mapstructure:"flow-control"`
+	ChannelCapacity uint    `yaml:"channel-capacity" mapstructure:"channel-capacity"`
+	MetricsAddress  string  `yaml:"prometheus-listen-address" mapstructure:"prometheus-listen-address"`
+	Replay          bool    `yaml:"replay" mapstructure:"replay"`
+	ReplaySpeed     float64 `yaml:"replay-speed" mapstructure:"replay-speed"`
 }
 
 type DataSourceConfig struct {
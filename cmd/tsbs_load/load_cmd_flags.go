@@ -23,6 +23,25 @@ func addLoaderRunnerFlags(fs *pflag.FlagSet) {
 		"Time to wait between each insert, default '' => all workers insert ASAP. '1,2' = worker 1 waits 1s "+
 			"between inserts, worker 2 and others wait 2s",
 	)
+	fs.String(
+		"loader.runner.insert-rate-profile",
+		"",
+		"Target insert rate in points/sec shared by all workers, default '' => no limit. 'constant:R', "+
+			"'ramp:FROM-TO/DURATION' (e.g. 'ramp:1000-100000/10m'), 'step:R1,R2,...,Rn/DURATION' or "+
+			"'sine:MEAN,AMPLITUDE/PERIOD'",
+	)
+	fs.Bool(
+		"loader.runner.replay",
+		false,
+		"Whether to replay the data file in real time, processing each batch when the timestamp of its "+
+			"earliest point is reached, offset to the start of the run. Requires data-source.type=FILE",
+	)
+	fs.Float64(
+		"loader.runner.replay-speed",
+		1,
+		"(Used only when replay=true) Factor by which the replay is sped up, e.g. 60 replays an hour of "+
+			"data in a minute",
+	)
 	fs.Bool(
 		"loader.runner.hash-workers",
 		false,
@@ -34,6 +53,12 @@ func addLoaderRunnerFlags(fs *pflag.FlagSet) {
 		"Whether to abort if a database with the given name already exists.",
 	)
 	fs.Duration("loader.runner.reporting-period", 10*time.Second, "Period to report write stats")
+	fs.String(
+		"loader.runner.prometheus-listen-address",
+		"",
+		"Address (e.g. ':9100') on which to expose live load metrics in Prometheus format under /metrics, "+
+			"default '' => disabled",
+	)
 	fs.Int64("loader.runner.seed", 0, "PRNG seed (default: 0, which uses the current timestamp)")
 	fs.Bool(
 		"loader.runner.do-load",
@@ -47,6 +47,12 @@ func parseConfig(target targets.ImplementedTarget, v *viper.Viper) (targets.Benc
 	if err != nil {
 		return nil, nil, err
 	}
+	if loaderConfigInternal.Replay {
+		benchmark, err = load.NewReplayBenchmark(benchmark, loaderConfigInternal.ReplaySpeed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot replay target '%s': %v", target.TargetName(), err)
+		}
+	}
 
 	return benchmark, load.GetBenchmarkRunner(*loaderConfigInternal), nil
 }
@@ -72,8 +78,12 @@ func convertRunnerConfigToInternalRep(r *RunnerConfig) *load.BenchmarkRunnerConf
 		Seed:            r.Seed,
 		HashWorkers:     r.HashWorkers,
 		InsertIntervals: r.InsertIntervals,
+		InsertRate:      r.InsertRate,
 		NoFlowControl:   !r.FlowControl,
 		ChannelCapacity: r.ChannelCapacity,
+		MetricsAddress:  r.MetricsAddress,
+		Replay:          r.Replay,
+		ReplaySpeed:     r.ReplaySpeed,
 	}
 }
 
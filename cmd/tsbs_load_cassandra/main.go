@@ -35,10 +35,14 @@ func initProgramOptions() (*cassandra.SpecificConfig, *load.BenchmarkRunnerConfi
 	}
 
 	dbConfig := &cassandra.SpecificConfig{
-		Hosts:             viper.GetString("hosts"),
-		ReplicationFactor: viper.GetInt("replication-factor"),
-		ConsistencyLevel:  viper.GetString("consistency"),
-		WriteTimeout:      viper.GetDuration("write-timeout"),
+		Hosts:               viper.GetString("hosts"),
+		ReplicationStrategy: viper.GetString("replication-strategy"),
+		ReplicationFactor:   viper.GetInt("replication-factor"),
+		ConsistencyLevel:    viper.GetString("consistency"),
+		CompactionWindow:    viper.GetDuration("compaction-window"),
+		DefaultTTL:          viper.GetDuration("default-ttl"),
+		Rollups:             viper.GetString("rollups"),
+		WriteTimeout:        viper.GetDuration("write-timeout"),
 	}
 
 	config.HashWorkers = false
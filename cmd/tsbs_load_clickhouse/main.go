@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
@@ -12,6 +13,7 @@ import (
 	"github.com/timescale/tsbs/load"
 	"github.com/timescale/tsbs/pkg/targets"
 	"github.com/timescale/tsbs/pkg/targets/clickhouse"
+	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
 // Global vars
@@ -47,11 +49,26 @@ func init() {
 		LogBatches: viper.GetBool("log-batches"),
 		Debug:      viper.GetInt("debug"),
 		DbName:     loaderConf.DBName,
+
+		ModernSchema: viper.GetBool("modern-schema"),
+		SchemaLayout: viper.GetString("schema-layout"),
+	}
+	if !utils.IsIn(conf.SchemaLayout, constants.SupportedSchemaLayouts()) {
+		panic(fmt.Errorf("invalid schema layout '%s', valid: %s",
+			conf.SchemaLayout, strings.Join(constants.SupportedSchemaLayouts(), ", ")))
 	}
 
 	loader = load.GetBenchmarkRunner(loaderConf)
 }
 
 func main() {
-	loader.RunBenchmark(clickhouse.NewBenchmark(loaderConf.FileName, loaderConf.HashWorkers, conf))
+	benchmark := clickhouse.NewBenchmark(loaderConf.FileName, loaderConf.HashWorkers, conf)
+	if loaderConf.Replay {
+		var err error
+		benchmark, err = load.NewReplayBenchmark(benchmark, loaderConf.ReplaySpeed)
+		if err != nil {
+			panic(err)
+		}
+	}
+	loader.RunBenchmark(benchmark)
 }
@@ -32,6 +32,9 @@ type dbCreator struct {
 	// common parameters for all metrics table
 	numShards   int
 	numReplicas int
+	// narrow creates (tags, ts, metric_name, value) tables instead of a
+	// column per field, see constants.SchemaLayoutNarrow
+	narrow bool
 }
 
 // loader.DBCreator interface implementation
@@ -100,10 +103,25 @@ func (d *dbCreator) CreateDB(dbName string) error {
 }
 
 func (d *dbCreator) createMetricsTable(table *tableDef) error {
+	sql, err := d.metricsTableSQL(table)
+	if err != nil {
+		return err
+	}
+	_, err = d.conn.Exec(context.Background(), sql)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// metricsTableSQL returns the CREATE TABLE statement of a metrics table. The
+// narrow layout stores a row per field value in the metric_name and value
+// columns instead of a column per field.
+func (d *dbCreator) metricsTableSQL(table *tableDef) (string, error) {
 	var tagsObjectChildCols []string
 	for i, column := range table.tags {
 		if table.tagTypes[i] != "string" {
-			return fmt.Errorf("cratedb db creator does not support non-string tags")
+			return "", fmt.Errorf("cratedb db creator does not support non-string tags")
 		}
 		tagsObjectChildCols = append(
 			tagsObjectChildCols,
@@ -111,14 +129,18 @@ func (d *dbCreator) createMetricsTable(table *tableDef) error {
 	}
 
 	var metricCols []string
-	for _, column := range table.cols {
-		metricCols = append(
-			metricCols,
-			fmt.Sprintf("%s %s", column, "double"))
+	if d.narrow {
+		metricCols = []string{"metric_name string", "value double"}
+	} else {
+		for _, column := range table.cols {
+			metricCols = append(
+				metricCols,
+				fmt.Sprintf("%s %s", column, "double"))
+		}
 	}
 
 	// TODO partition table by configurable time interval
-	sql := fmt.Sprintf(`
+	return fmt.Sprintf(`
 		CREATE TABLE %s (
 			tags object as (%s),
 			ts timestamp,
@@ -129,12 +151,7 @@ func (d *dbCreator) createMetricsTable(table *tableDef) error {
 		strings.Join(tagsObjectChildCols, ", "),
 		strings.Join(metricCols, ", "),
 		d.numShards,
-		d.numReplicas)
-	_, err := d.conn.Exec(context.Background(), sql)
-	if err != nil {
-		return err
-	}
-	return nil
+		d.numReplicas), nil
 }
 
 // loader.DBCreator interface implementation
@@ -107,3 +107,56 @@ func arrEq(a []string, b []string) bool {
 	}
 	return true
 }
+
+func TestDBCreatorMetricsTableSQL(t *testing.T) {
+	table := &tableDef{
+		schema:   "doc",
+		name:     "cpu",
+		tags:     []string{"hostname"},
+		tagTypes: []string{"string"},
+		cols:     []string{"usage_user", "usage_system"},
+	}
+	cases := []struct {
+		desc   string
+		narrow bool
+		want   string
+	}{
+		{
+			desc: "wide",
+			want: `
+		CREATE TABLE "doc"."cpu" (
+			tags object as (hostname string),
+			ts timestamp,
+			usage_user double, usage_system double
+		) CLUSTERED INTO 5 SHARDS
+		WITH (number_of_replicas = 1)`,
+		},
+		{
+			desc:   "narrow",
+			narrow: true,
+			want: `
+		CREATE TABLE "doc"."cpu" (
+			tags object as (hostname string),
+			ts timestamp,
+			metric_name string, value double
+		) CLUSTERED INTO 5 SHARDS
+		WITH (number_of_replicas = 1)`,
+		},
+	}
+
+	for _, c := range cases {
+		dbc := &dbCreator{numShards: 5, numReplicas: 1, narrow: c.narrow}
+		got, err := dbc.metricsTableSQL(table)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: incorrect sql: got\n%s\nwant\n%s", c.desc, got, c.want)
+		}
+	}
+
+	dbc := &dbCreator{}
+	if _, err := dbc.metricsTableSQL(&tableDef{tags: []string{"n"}, tagTypes: []string{"int64"}}); err == nil {
+		t.Errorf("non-string tags: expected an error")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/blagojts/viper"
 	"github.com/jackc/pgx/v4"
@@ -49,6 +50,7 @@ func (b *benchmark) GetProcessor() targets.Processor {
 	return &processor{
 		tableDefs: tableDefs,
 		connCfg:   b.dbc.cfg,
+		narrow:    b.dbc.narrow,
 	}
 }
 
@@ -77,6 +79,11 @@ func main() {
 	port := viper.GetUint("port")
 	user := viper.GetString("user")
 	pass := viper.GetString("pass")
+	schemaLayout := viper.GetString("schema-layout")
+	if !utils.IsIn(schemaLayout, constants.SupportedSchemaLayouts()) {
+		panic(fmt.Errorf("invalid schema layout '%s', valid: %s",
+			schemaLayout, strings.Join(constants.SupportedSchemaLayouts(), ", ")))
+	}
 
 	numReplicas := flag.Int("replicas", 0, "Number of replicas per a metric table")
 	numShards := flag.Int("shards", 5, "Number of shards per a metric table")
@@ -96,6 +103,7 @@ func main() {
 			cfg:         connConfig,
 			numReplicas: *numReplicas,
 			numShards:   *numShards,
+			narrow:      schemaLayout == constants.SchemaLayoutNarrow,
 			ds:          ds,
 		},
 		ds: ds,
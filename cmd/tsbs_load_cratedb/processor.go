@@ -12,6 +12,8 @@ type processor struct {
 	tableDefs map[string]*tableDef
 	connCfg   *pgx.ConnConfig
 	conn      *pgx.Conn
+	// narrow inserts a (metric_name, value) row per field value
+	narrow bool
 }
 
 // load.Processor interface implementation
@@ -33,8 +35,12 @@ func (p *processor) createInsertStmt(table *tableDef) (string, error) {
 	var cols []string
 	cols = append(cols, "tags", "ts")
 
-	for _, col := range table.cols {
-		cols = append(cols, col)
+	if p.narrow {
+		cols = append(cols, "metric_name", "value")
+	} else {
+		for _, col := range table.cols {
+			cols = append(cols, col)
+		}
 	}
 
 	stmt := fmt.Sprintf(
@@ -64,6 +70,9 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 
 // load.Processor interface implementation
 func (p *processor) InsertBatch(table string, rows []*row) uint64 {
+	if p.narrow {
+		return p.insertNarrowBatch(table, rows)
+	}
 	metricCnt := uint64(0)
 	b := pgx.Batch{}
 	for _, row := range rows {
@@ -84,6 +93,33 @@ func (p *processor) InsertBatch(table string, rows []*row) uint64 {
 	return metricCnt
 }
 
+// insertNarrowBatch inserts a row per field value of the rows, each one is a
+// single metric value
+func (p *processor) insertNarrowBatch(table string, rows []*row) uint64 {
+	tableDef := p.tableDefs[table]
+	insertStmt, err := p.createInsertStmt(tableDef)
+	if err != nil {
+		fatal("could not create insert statement for table %s", table)
+	}
+	dataRows := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		dataRows[i] = *row
+	}
+	narrowRows := targets.ToNarrowRows(dataRows, 2, tableDef.cols)
+	if len(narrowRows) == 0 {
+		return 0
+	}
+	b := pgx.Batch{}
+	for _, narrowRow := range narrowRows {
+		b.Queue(insertStmt, narrowRow...)
+	}
+	batchResults := p.conn.SendBatch(context.Background(), &b)
+	if err := batchResults.Close(); err != nil {
+		fatal("failed to close a batch operation %v", err)
+	}
+	return uint64(len(narrowRows))
+}
+
 // load.ProcessorCloser interface implementation
 func (p *processor) Close(doLoad bool) {
 	if doLoad {
@@ -17,8 +17,10 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/targets"
 	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/influx"
 	"github.com/timescale/tsbs/pkg/targets/initializers"
 )
 
@@ -123,6 +125,11 @@ func (b *benchmark) GetDBCreator() targets.DBCreator {
 	return &dbCreator{}
 }
 
+// PointTimestamp implements targets.PointTimestamper
+func (b *benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	return influx.LineTimestamp(item.Data.([]byte))
+}
+
 func main() {
 	bufPool = sync.Pool{
 		New: func() interface{} {
@@ -130,5 +137,12 @@ func main() {
 		},
 	}
 
-	loader.RunBenchmark(&benchmark{})
+	var b targets.Benchmark = &benchmark{}
+	if config.Replay {
+		var err error
+		if b, err = load.NewReplayBenchmark(b, config.ReplaySpeed); err != nil {
+			log.Fatal(err)
+		}
+	}
+	loader.RunBenchmark(b)
 }
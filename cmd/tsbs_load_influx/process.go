@@ -19,6 +19,7 @@ type processor struct {
 	backingOffChan chan bool
 	backingOffDone chan struct{}
 	httpWriter     *HTTPWriter
+	errors         uint64
 }
 
 func (p *processor) Init(numWorker int, _, _ bool) {
@@ -63,6 +64,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 			}
 
 			if err == errBackoff {
+				p.errors++
 				p.backingOffChan <- true
 				time.Sleep(backoff)
 			} else {
@@ -83,6 +85,11 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 	return metricCnt, uint64(rowCnt)
 }
 
+// Errors returns the number of writes that had to be retried because of backoff
+func (p *processor) Errors() uint64 {
+	return p.errors
+}
+
 func (p *processor) processBackoffMessages(workerID int) {
 	var totalBackoffSecs float64
 	var start time.Time
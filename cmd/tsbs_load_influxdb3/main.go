@@ -1,132 +1,64 @@
-// bulk_load_influx loads an InfluxDB daemon with data from stdin.
+// tsbs_load_influxdb3 loads an InfluxDB 3.x daemon with data from stdin or file.
 //
 // The caller is responsible for assuring that the database is empty before
 // bulk load.
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
-	"log"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/load"
-	"github.com/timescale/tsbs/pkg/targets"
-	"github.com/timescale/tsbs/pkg/targets/constants"
-	"github.com/timescale/tsbs/pkg/targets/initializers"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets/influxdb3"
 )
 
-// Program option vars:
-var (
-	daemonURLs        []string
-	replicationFactor int
-	backoff           time.Duration
-	useGzip           bool
-	doAbortOnExist    bool
-	consistency       string
-	token             string
-	notCreateDB       bool
-	org               string
-	bearer            string
-)
-
-// Global vars
-var (
-	loader  load.BenchmarkRunner
-	config  load.BenchmarkRunnerConfig
-	bufPool sync.Pool
-	target  targets.ImplementedTarget
-)
-
-var consistencyChoices = map[string]struct{}{
-	"any":    {},
-	"one":    {},
-	"quorum": {},
-	"all":    {},
-}
-
-// allows for testing
-var fatal = log.Fatalf
-
 // Parse args:
-func init() {
-	target = initializers.GetTarget(constants.FormatInflux)
-	config = load.BenchmarkRunnerConfig{}
-	config.AddToFlagSet(pflag.CommandLine)
-	target.TargetSpecificFlags("", pflag.CommandLine)
-	var csvDaemonURLs string
+func initProgramOptions() (*influxdb3.SpecificConfig, load.BenchmarkRunner, *load.BenchmarkRunnerConfig) {
+	target := influxdb3.NewTarget()
 
+	loaderConf := load.BenchmarkRunnerConfig{}
+	loaderConf.AddToFlagSet(pflag.CommandLine)
+	target.TargetSpecificFlags("", pflag.CommandLine)
 	pflag.Parse()
 
-	err := utils.SetupConfigFile()
-
-	if err != nil {
+	if err := utils.SetupConfigFile(); err != nil {
 		panic(fmt.Errorf("fatal error config file: %s", err))
 	}
-
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&loaderConf); err != nil {
 		panic(fmt.Errorf("unable to decode config: %s", err))
 	}
 
-	csvDaemonURLs = viper.GetString("urls")
-	replicationFactor = viper.GetInt("replication-factor")
-	consistency = viper.GetString("consistency")
-	backoff = viper.GetDuration("backoff")
-	useGzip = viper.GetBool("gzip")
-	token = viper.GetString("token")
-	notCreateDB = viper.GetBool("not-create-db")
-	org = viper.GetString("org")
-	bearer = viper.GetString("bearer")
-
-	if _, ok := consistencyChoices[consistency]; !ok {
-		log.Fatalf("invalid consistency settings")
+	influxDB3Conf := influxdb3.SpecificConfig{
+		URLs:              strings.Split(viper.GetString("urls"), ","),
+		ReplicationFactor: viper.GetInt("replication-factor"),
+		Backoff:           viper.GetDuration("backoff"),
+		Gzip:              viper.GetBool("gzip"),
+		Token:             viper.GetString("token"),
+		Org:               viper.GetString("org"),
+		Bearer:            viper.GetString("bearer"),
+		NoSync:            viper.GetBool("no-sync"),
+		WriteEndpoint:     viper.GetString("write-endpoint"),
+		Ingest:            viper.GetString("ingest"),
 	}
 
-	daemonURLs = strings.Split(csvDaemonURLs, ",")
-	if len(daemonURLs) == 0 {
-		log.Fatal("missing 'urls' flag")
-	}
-	config.HashWorkers = false
-	loader = load.GetBenchmarkRunner(config)
-}
-
-type benchmark struct{}
-
-func (b *benchmark) GetDataSource() targets.DataSource {
-	return &fileDataSource{scanner: bufio.NewScanner(load.GetBufferedReader(config.FileName))}
-}
-
-func (b *benchmark) GetBatchFactory() targets.BatchFactory {
-	return &factory{}
-}
-
-func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
-	return &targets.ConstantIndexer{}
-}
-
-func (b *benchmark) GetProcessor() targets.Processor {
-	return &processor{}
-}
-
-func (b *benchmark) GetDBCreator() targets.DBCreator {
-	if notCreateDB {
-		return nil
-	}
-	return &dbCreator{}
+	loaderConf.HashWorkers = false
+	loader := load.GetBenchmarkRunner(loaderConf)
+	return &influxDB3Conf, loader, &loaderConf
 }
 
 func main() {
-	bufPool = sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
-		},
-	}
+	influxDB3Conf, loader, loaderConf := initProgramOptions()
 
-	loader.RunBenchmark(&benchmark{})
+	benchmark, err := influxdb3.NewBenchmark(loaderConf.DBName, influxDB3Conf, &source.DataSourceConfig{
+		Type: source.FileDataSourceType,
+		File: &source.FileDataSourceConfig{Location: loaderConf.FileName},
+	})
+	if err != nil {
+		panic(err)
+	}
+	loader.RunBenchmark(benchmark)
 }
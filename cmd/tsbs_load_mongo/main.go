@@ -5,6 +5,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/blagojts/viper"
@@ -27,9 +28,11 @@ const (
 
 // Program option vars:
 var (
-	daemonURL    string
-	documentPer  bool
-	writeTimeout time.Duration
+	daemonURL             string
+	documentPer           bool
+	timeSeries            bool
+	timeSeriesGranularity string
+	writeTimeout          time.Duration
 )
 
 // Global vars
@@ -61,7 +64,12 @@ func init() {
 	daemonURL = viper.GetString("url")
 	writeTimeout = viper.GetDuration("write-timeout")
 	documentPer = viper.GetBool("document-per-event")
-	if documentPer {
+	timeSeries = viper.GetBool("time-series")
+	timeSeriesGranularity = viper.GetString("time-series-granularity")
+	if documentPer && timeSeries {
+		log.Fatal("only one of --document-per-event and --time-series can be set")
+	}
+	if documentPer || timeSeries {
 		config.HashWorkers = false
 	} else {
 		config.HashWorkers = true
@@ -72,7 +80,9 @@ func init() {
 
 func main() {
 	var benchmark targets.Benchmark
-	if documentPer {
+	if timeSeries {
+		benchmark = newTimeSeriesBenchmark(loader, &config)
+	} else if documentPer {
 		benchmark = newNaiveBenchmark(loader, &config)
 	} else {
 		benchmark = newAggBenchmark(loader, &config)
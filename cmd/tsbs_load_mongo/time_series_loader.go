@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// tsTimeField and tsMetaField are the timeField and metaField of the
+	// time-series collection. The meta document has the measurement and the
+	// tags of the point, the fields are top-level values of the document.
+	tsTimeField = "time"
+	tsMetaField = "meta"
+)
+
+// tsBenchmark allows you to run a benchmark using a native time-series
+// collection (MongoDB 5.0+) with the official driver
+type tsBenchmark struct {
+	mongoBenchmark
+	tsc *tsDBCreator
+}
+
+func newTimeSeriesBenchmark(l load.BenchmarkRunner, loaderConf *load.BenchmarkRunnerConfig) *tsBenchmark {
+	return &tsBenchmark{mongoBenchmark: mongoBenchmark{loaderConf.FileName, l, nil}, tsc: &tsDBCreator{}}
+}
+
+func (b *tsBenchmark) GetProcessor() targets.Processor {
+	return &tsProcessor{dbc: b.tsc}
+}
+
+func (b *tsBenchmark) GetPointIndexer(_ uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *tsBenchmark) GetDBCreator() targets.DBCreator {
+	return b.tsc
+}
+
+type tsDBCreator struct {
+	client *mongodrv.Client
+}
+
+func (d *tsDBCreator) Init() {
+	opts := options.Client().ApplyURI(mongo.ClientURI(daemonURL)).SetTimeout(writeTimeout)
+	var err error
+	d.client, err = mongodrv.Connect(context.Background(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (d *tsDBCreator) DBExists(dbName string) bool {
+	dbs, err := d.client.ListDatabaseNames(context.Background(), bson.D{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range dbs {
+		if name == dbName {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *tsDBCreator) RemoveOldDB(dbName string) error {
+	return d.client.Database(dbName).Drop(context.Background())
+}
+
+func (d *tsDBCreator) CreateDB(dbName string) error {
+	ctx := context.Background()
+	tsOpts := options.TimeSeries().SetTimeField(tsTimeField).SetMetaField(tsMetaField)
+	if timeSeriesGranularity != "" {
+		tsOpts.SetGranularity(timeSeriesGranularity)
+	}
+	db := d.client.Database(dbName)
+	err := db.CreateCollection(ctx, collectionName, options.CreateCollection().SetTimeSeriesOptions(tsOpts))
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return nil
+		}
+		return fmt.Errorf("create time-series collection err: %v", err)
+	}
+
+	// The queries select a measurement and hosts over a time range
+	_, err = db.Collection(collectionName).Indexes().CreateOne(ctx, mongodrv.IndexModel{
+		Keys: bson.D{
+			{Key: tsMetaField + ".measurement", Value: 1},
+			{Key: tsMetaField + ".tags.hostname", Value: 1},
+			{Key: tsTimeField, Value: 1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create time-series index err: %v", err)
+	}
+	return nil
+}
+
+func (d *tsDBCreator) Close() {
+	d.client.Disconnect(context.Background())
+}
+
+type tsProcessor struct {
+	dbc        *tsDBCreator
+	collection *mongodrv.Collection
+
+	docs []interface{}
+}
+
+func (p *tsProcessor) Init(_ int, doLoad, _ bool) {
+	if doLoad {
+		p.collection = p.dbc.client.Database(loader.DatabaseName()).Collection(collectionName)
+	}
+	p.docs = []interface{}{}
+}
+
+// timeSeriesDoc converts an event to a document of the time-series
+// collection. BSON dates have a millisecond precision, so the timestamp is
+// truncated.
+func timeSeriesDoc(event *mongo.MongoPoint) (bson.D, uint64) {
+	tags := make(bson.D, 0, event.TagsLength())
+	t := &mongo.MongoTag{}
+	for j := 0; j < event.TagsLength(); j++ {
+		event.Tags(t, j)
+		tags = append(tags, bson.E{Key: string(t.Key()), Value: string(t.Value())})
+	}
+
+	doc := make(bson.D, 0, event.FieldsLength()+2)
+	doc = append(doc,
+		bson.E{Key: tsTimeField, Value: time.Unix(0, event.Timestamp()).UTC()},
+		bson.E{Key: tsMetaField, Value: bson.D{
+			{Key: "measurement", Value: string(event.MeasurementName())},
+			{Key: "tags", Value: tags},
+		}},
+	)
+	f := &mongo.MongoReading{}
+	for j := 0; j < event.FieldsLength(); j++ {
+		event.Fields(f, j)
+		doc = append(doc, bson.E{Key: string(f.Key()), Value: f.Value()})
+	}
+	return doc, uint64(event.FieldsLength())
+}
+
+// ProcessBatch inserts a document per event into the time-series collection,
+// which groups them in buckets of the same meta document on its own
+func (p *tsProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*batch).arr
+	p.docs = p.docs[:0]
+	var metricCnt uint64
+	for _, event := range batch {
+		doc, fields := timeSeriesDoc(event)
+		p.docs = append(p.docs, doc)
+		metricCnt += fields
+	}
+
+	if doLoad && len(p.docs) > 0 {
+		_, err := p.collection.InsertMany(context.Background(), p.docs, options.InsertMany().SetOrdered(false))
+		if err != nil {
+			log.Fatalf("Bulk insert docs err: %s\n", err.Error())
+		}
+	}
+
+	return metricCnt, 0
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/targets/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTimeSeriesDoc(t *testing.T) {
+	var buf bytes.Buffer
+	s := &mongo.Serializer{}
+	if err := s.Serialize(serialize.TestPointMultiField(), &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ds := &fileDataSource{lenBuf: make([]byte, 8), r: bufio.NewReader(&buf)}
+	b := (&factory{}).New().(*batch)
+	b.Append(ds.NextItem())
+
+	doc, fields := timeSeriesDoc(b.arr[0])
+	if fields != 3 {
+		t.Errorf("incorrect number of fields: got %d want 3", fields)
+	}
+	want := bson.D{
+		{Key: "time", Value: serialize.TestNow.UTC()},
+		{Key: "meta", Value: bson.D{
+			{Key: "measurement", Value: "cpu"},
+			{Key: "tags", Value: bson.D{
+				{Key: "hostname", Value: "host_0"},
+				{Key: "region", Value: "eu-west-1"},
+				{Key: "datacenter", Value: "eu-west-1b"},
+			}},
+		}},
+		{Key: "big_usage_guest", Value: float64(serialize.TestInt64)},
+		{Key: "usage_guest", Value: float64(serialize.TestInt)},
+		{Key: "usage_guest_nice", Value: serialize.TestFloat},
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("incorrect document:\ngot\n%v\nwant\n%v", doc, want)
+	}
+
+	p := &tsProcessor{}
+	p.Init(0, false, false)
+	if metrics, rows := p.ProcessBatch(b, false); metrics != 3 || rows != 0 {
+		t.Errorf("incorrect counts: got %d metrics %d rows", metrics, rows)
+	}
+}
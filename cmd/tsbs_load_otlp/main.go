@@ -0,0 +1,55 @@
+// tsbs_load_otlp loads an OpenTelemetry (OTLP) metrics receiver with data from stdin or file.
+package main
+
+import (
+	"fmt"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets/otlp"
+)
+
+// Parse args:
+func initProgramOptions() (*otlp.SpecificConfig, load.BenchmarkRunner, *load.BenchmarkRunnerConfig) {
+	target := otlp.NewTarget()
+
+	loaderConf := load.BenchmarkRunnerConfig{}
+	loaderConf.AddToFlagSet(pflag.CommandLine)
+	target.TargetSpecificFlags("", pflag.CommandLine)
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&loaderConf); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	otlpConf := otlp.SpecificConfig{
+		Protocol:     viper.GetString("protocol"),
+		HTTPURL:      viper.GetString("http-url"),
+		GRPCEndpoint: viper.GetString("grpc-endpoint"),
+		Gzip:         viper.GetBool("gzip"),
+		Timeout:      viper.GetDuration("timeout"),
+		Backoff:      viper.GetDuration("backoff"),
+	}
+
+	loader := load.GetBenchmarkRunner(loaderConf)
+	return &otlpConf, loader, &loaderConf
+}
+
+func main() {
+	otlpConf, loader, loaderConf := initProgramOptions()
+
+	benchmark, err := otlp.NewBenchmark(otlpConf, &source.DataSourceConfig{
+		Type: source.FileDataSourceType,
+		File: &source.FileDataSourceConfig{Location: loaderConf.FileName},
+	})
+	if err != nil {
+		panic(err)
+	}
+	loader.RunBenchmark(benchmark)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/snappy"
@@ -28,8 +29,13 @@ func (adapter *Adapter) Start() error {
 	return http.ListenAndServe(fmt.Sprintf(":%d", adapter.port), nil)
 }
 
-// Handler counts number of requests and samples
+// Handler counts number of requests and samples. Like any remote-write 1.0
+// receiver it rejects remote-write 2.0 requests as an unsupported media type.
 func (adapter *Adapter) Handler(rw http.ResponseWriter, req *http.Request) {
+	if strings.Contains(req.Header.Get("Content-Type"), "proto=io.prometheus.write.v2.Request") {
+		http.Error(rw, "remote-write 2.0 is not supported", http.StatusUnsupportedMediaType)
+		return
+	}
 	compressed, err := ioutil.ReadAll(req.Body)
 	if err != nil {
 		log.Error("msg", "error while reading request", "error", err)
@@ -18,7 +18,7 @@ var (
 	loader load.BenchmarkRunner
 	config load.BenchmarkRunnerConfig
 )
-var adapterWriteUrl string
+var promConfig prometheus.SpecificConfig
 
 func init() {
 	target = prometheus.NewTarget()
@@ -34,13 +34,15 @@ func init() {
 	if err := viper.Unmarshal(&config); err != nil {
 		panic(fmt.Errorf("unable to decode config: %s", err))
 	}
-	adapterWriteUrl = viper.GetString("adapter-write-url")
+	if err := viper.Unmarshal(&promConfig); err != nil {
+		panic(fmt.Errorf("unable to decode prometheus config: %s", err))
+	}
 	loader = load.GetBenchmarkRunner(config)
 }
 
 func main() {
 	benchmark, err := prometheus.NewBenchmark(
-		&prometheus.SpecificConfig{AdapterWriteURL: adapterWriteUrl},
+		&promConfig,
 		&source.DataSourceConfig{
 			Type: source.FileDataSourceType,
 			File: &source.FileDataSourceConfig{Location: config.FileName},
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +28,7 @@ var (
 	useTLS           bool
 	authTokenId      string
 	authToken        string
+	schemaLayout     string
 )
 
 // Global vars
@@ -76,6 +78,11 @@ func init() {
 	useTLS = viper.GetBool("tls")
 	authTokenId = viper.GetString("auth-id")
 	authToken = viper.GetString("auth-token")
+	schemaLayout = viper.GetString("schema-layout")
+	if !utils.IsIn(schemaLayout, constants.SupportedSchemaLayouts()) {
+		panic(fmt.Errorf("invalid schema layout '%s', valid: %s",
+			schemaLayout, strings.Join(constants.SupportedSchemaLayouts(), ", ")))
+	}
 	config.HashWorkers = false
 	config.NoFlowControl = true
 	config.ResultsFile = viper.GetString("results-file")
@@ -89,7 +96,7 @@ func (b *benchmark) GetDataSource() targets.DataSource {
 }
 
 func (b *benchmark) GetBatchFactory() targets.BatchFactory {
-	return &factory{}
+	return &factory{narrow: schemaLayout == constants.SchemaLayoutNarrow}
 }
 
 func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
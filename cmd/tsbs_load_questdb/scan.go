@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"strconv"
+	"strings"
 
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
@@ -35,6 +37,8 @@ type batch struct {
 	rows          uint
 	metrics       uint64
 	metricsPerRow uint64
+	// narrow writes a line per field value, see appendNarrow
+	narrow bool
 }
 
 func (b *batch) Len() uint {
@@ -44,6 +48,10 @@ func (b *batch) Len() uint {
 func (b *batch) Append(item data.LoadedPoint) {
 	that := item.Data.([]byte)
 	b.rows++
+	if b.narrow {
+		b.appendNarrow(string(that))
+		return
+	}
 
 	// We only validate the very first row per batch since it's an expensive operation.
 	// As a part of the validation we also calculate the number of metrics per row.
@@ -71,8 +79,98 @@ func (b *batch) Append(item data.LoadedPoint) {
 	b.buf.Write(newLine)
 }
 
-type factory struct{}
+// appendNarrow writes a line per numeric field value of the line, with the
+// field name in the metric_name tag and the value in the value field, as
+// stored by the narrow schema layout. Integers are written as floats so that
+// all the values fit the same column, string and boolean fields are skipped.
+func (b *batch) appendNarrow(line string) {
+	tagsEnd := indexUnescaped(line, ' ')
+	timestampStart := strings.LastIndexByte(line, ' ')
+	if tagsEnd < 0 || timestampStart <= tagsEnd {
+		fatal(errNotThreeTuplesFmt, strings.Count(line, " ")+1)
+		return
+	}
+
+	fieldSet := splitFields(line[tagsEnd+1 : timestampStart])
+	fieldNames := make([]string, len(fieldSet))
+	row := make([]interface{}, 2, 2+len(fieldSet))
+	row[0], row[1] = line[:tagsEnd], line[timestampStart+1:]
+	for i, field := range fieldSet {
+		sep := indexUnescaped(field, '=')
+		if sep < 0 {
+			fatal("parse error: field %q has no value", field)
+			return
+		}
+		fieldNames[i] = field[:sep]
+		row = append(row, numericValue(field[sep+1:]))
+	}
+
+	narrowRows := targets.ToNarrowRows([][]interface{}{row}, 2, fieldNames)
+	for _, r := range narrowRows {
+		b.buf.WriteString(r[0].(string))
+		b.buf.WriteString(",metric_name=")
+		b.buf.WriteString(r[2].(string))
+		b.buf.WriteString(" value=")
+		b.buf.WriteString(r[3].(string))
+		b.buf.WriteByte(' ')
+		b.buf.WriteString(r[1].(string))
+		b.buf.Write(newLine)
+	}
+	b.metrics += uint64(len(narrowRows))
+}
+
+// indexUnescaped returns the index of the first c in s not escaped with a
+// backslash, or -1
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitFields splits the field set of a line on the commas that are neither
+// escaped nor in a string value
+func splitFields(fieldSet string) []string {
+	var fields []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(fieldSet); i++ {
+		switch fieldSet[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				fields = append(fields, fieldSet[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, fieldSet[start:])
+}
+
+// numericValue returns the field value as a float, or nil for the string and
+// boolean values
+func numericValue(v string) interface{} {
+	if strings.HasSuffix(v, "i") || strings.HasSuffix(v, "u") {
+		v = v[:len(v)-1]
+	}
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return nil
+	}
+	return v
+}
+
+type factory struct {
+	narrow bool
+}
 
 func (f *factory) New() targets.Batch {
-	return &batch{buf: bufPool.Get().(*bytes.Buffer)}
+	return &batch{buf: bufPool.Get().(*bytes.Buffer), narrow: f.narrow}
 }
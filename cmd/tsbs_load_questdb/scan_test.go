@@ -122,3 +122,37 @@ func TestDecodeEOF(t *testing.T) {
 		t.Errorf("expected p to be nil, got %v", p)
 	}
 }
+
+func TestBatchNarrow(t *testing.T) {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+	f := &factory{narrow: true}
+	b := f.New().(*batch)
+	p := data.LoadedPoint{
+		Data: []byte(`cpu,hostname=host\ 0,region=eu col1=1.5,col2=2i,col3="a, b",col4=t 140`),
+	}
+	b.Append(p)
+	if b.rows != 1 {
+		t.Errorf("batch row count is not 1 after first append: got %d", b.rows)
+	}
+	if b.metrics != 2 {
+		t.Errorf("batch metric count is not 2 after first append: got %d", b.metrics)
+	}
+	want := "cpu,hostname=host\\ 0,region=eu,metric_name=col1 value=1.5 140\n" +
+		"cpu,hostname=host\\ 0,region=eu,metric_name=col2 value=2 140\n"
+	if got := b.buf.String(); got != want {
+		t.Errorf("incorrect narrow lines: got\n%s\nwant\n%s", got, want)
+	}
+
+	errMsg := ""
+	fatal = func(f string, args ...interface{}) {
+		errMsg = fmt.Sprintf(f, args...)
+	}
+	b.Append(data.LoadedPoint{Data: []byte("bad_point")})
+	if errMsg == "" {
+		t.Errorf("batch append did not error with ill-formed point")
+	}
+}
@@ -45,12 +45,21 @@ func initProgramOptions() (*timescaledb.LoadingOptions, load.BenchmarkRunner, *l
 	opts.UseHypertable = viper.GetBool("use-hypertable")
 	opts.ChunkTime = viper.GetDuration("chunk-time")
 
+	opts.UseCompression = viper.GetBool("use-compression")
+	opts.CompressSegmentBy = viper.GetString("compress-segmentby")
+	opts.CompressOrderBy = viper.GetString("compress-orderby")
+	opts.CompressAfter = viper.GetDuration("compress-after")
+	opts.CompressAfterLoad = viper.GetBool("compress-after-load")
+	opts.ContinuousAggregates = viper.GetBool("continuous-aggregates")
+	opts.RefreshContinuousAggregates = viper.GetBool("refresh-continuous-aggregates")
+
 	opts.UseJSON = viper.GetBool("use-jsonb-tags")
 
 	// This must be set to 'true' if you are going to test
 	// distributed hypertable queries and insert. Replication
 	// factor must also be set to true for distributed hypertables
 	opts.InTableTag = viper.GetBool("in-table-partition-tag")
+	opts.SchemaLayout = viper.GetString("schema-layout")
 
 	// 	We currently use `create_hypertable` for all variations. When
 	//   `replication-factor`>=1, we automatically create a distributed
@@ -99,6 +108,12 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if loaderConf.Replay {
+		benchmark, err = load.NewReplayBenchmark(benchmark, loaderConf.ReplaySpeed)
+		if err != nil {
+			panic(err)
+		}
+	}
 	loader.RunBenchmark(benchmark)
 
 	if len(opts.ReplicationStatsFile) > 0 {
@@ -50,5 +50,11 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if loaderConf.Replay {
+		benchmark, err = load.NewReplayBenchmark(benchmark, loaderConf.ReplaySpeed)
+		if err != nil {
+			panic(err)
+		}
+	}
 	loader.RunBenchmark(benchmark)
 }
@@ -10,6 +10,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"sync/atomic"
 	"time"
 
 	"github.com/blagojts/viper"
@@ -39,6 +40,7 @@ var (
 var (
 	daemonURL      string
 	aggrPlanLabel  string
+	subQueryPar    int
 	requestTimeout time.Duration
 	csiTimeout     time.Duration
 )
@@ -57,6 +59,11 @@ var (
 	aggrPlan int
 	csi      *ClientSideIndex
 	session  *gocql.Session
+
+	// CQL queries run and the queries they were run for, reported apart
+	// from the latency stats as they are counts
+	subQueryCount atomic.Int64
+	queryCount    atomic.Int64
 )
 
 // Parse args:
@@ -66,6 +73,7 @@ func init() {
 
 	pflag.String("host", "localhost:9042", "Cassandra hostname and port combination.")
 	pflag.String("aggregation-plan", "", "Aggregation plan (choices: server, client)")
+	pflag.Int("sub-query-parallelism", 1, "Number of CQL sub-queries of a query plan to run concurrently (1 runs them one after the other).")
 	pflag.Duration("read-timeout", 1*time.Second, "Maximum request timeout.")
 	pflag.Duration("client-side-index-timeout", 10*time.Second, "Maximum client-side index timeout (only used at initialization).")
 
@@ -83,18 +91,22 @@ func init() {
 
 	daemonURL = viper.GetString("host")
 	aggrPlanLabel = viper.GetString("aggregation-plan")
+	subQueryPar = viper.GetInt("sub-query-parallelism")
 	requestTimeout = viper.GetDuration("read-timeout")
 	csiTimeout = viper.GetDuration("client-side-index-timeout")
 
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
 	if _, ok := aggrPlanChoices[aggrPlanLabel]; !ok {
 		log.Fatal("invalid aggregation plan")
 	}
 	aggrPlan = aggrPlanChoices[aggrPlanLabel]
+	if subQueryPar < 1 {
+		log.Fatal("sub-query parallelism must be at least 1")
+	}
 
-	runner = query.NewBenchmarkRunner(config)
-}
-
-func main() {
 	// Make client-side index:
 	session = NewCassandraSession(daemonURL, runner.DatabaseName(), csiTimeout)
 	csi = NewClientSideIndex(FetchSeriesCollection(session))
@@ -105,6 +117,17 @@ func main() {
 	defer session.Close()
 
 	runner.Run(&query.CassandraPool, newProcessor)
+	printSubQueries()
+}
+
+// printSubQueries prints how many CQL queries the queries were fulfilled by
+func printSubQueries() {
+	n := queryCount.Load()
+	if n == 0 {
+		return
+	}
+	sq := subQueryCount.Load()
+	fmt.Printf("sub-queries: %d total, %.2f per query\n", sq, float64(sq)/float64(n))
 }
 
 type processor struct {
@@ -117,6 +140,7 @@ func newProcessor() query.Processor { return &processor{} }
 func (p *processor) Init(workerNumber int) {
 	p.opts = &HLQueryExecutorDoOptions{
 		AggregationPlan:      aggrPlan,
+		SubQueryParallelism:  subQueryPar,
 		Debug:                runner.DebugLevel(),
 		PrettyPrintResponses: runner.DoPrintResponses(),
 	}
@@ -131,21 +155,25 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 		q.HumanLabelName(),
 		append(q.HumanLabelName(), "-qp"...),
 		append(q.HumanLabelName(), "-req"...),
+		append(q.HumanLabelName(), "-fanout"...),
 	}
 	if isWarm {
 		for i, l := range labels {
 			labels[i] = append(l, " (warm)"...)
 		}
 	}
-	qpLagMs, reqLagMs, err := p.qe.Do(hlq, *p.opts)
+	qpLagMs, reqLagMs, fanOutLagMs, subQueries, err := p.qe.Do(hlq, *p.opts)
 	if err != nil {
 		return nil, err
 	}
+	subQueryCount.Add(int64(subQueries))
+	queryCount.Add(1)
 	// total stat
 	totalMs := qpLagMs + reqLagMs
 	stats := []*query.Stat{
 		query.GetPartialStat().Init(labels[1], qpLagMs),
 		query.GetPartialStat().Init(labels[2], reqLagMs),
+		query.GetPartialStat().Init(labels[3], fanOutLagMs),
 		query.GetStat().Init(labels[0], totalMs),
 	}
 	return stats, nil
@@ -7,6 +7,7 @@ import (
 
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/cassandra"
 )
 
 // HLQuery is a high-level query, usually read from stdin after being
@@ -31,6 +32,9 @@ func (q *HLQuery) ForceUTC() {
 
 // ToQueryPlanWithServerAggregation combines an HLQuery with a
 // ClientSideIndex to make a QueryPlanWithServerAggregation.
+//
+// When the HLQuery has a rollup, its CQLQueries read the buckets of the
+// rollup table starting within each time bucket instead of the series.
 func (q *HLQuery) ToQueryPlanWithServerAggregation(csi *ClientSideIndex) (qp *QueryPlanWithServerAggregation, err error) {
 	seriesChoices := csi.SeriesForMeasurementAndField(string(q.MeasurementName), string(q.FieldName))
 
@@ -84,7 +88,11 @@ func (q *HLQuery) ToQueryPlanWithServerAggregation(csi *ClientSideIndex) (qp *Qu
 				end = q.TimeEnd
 			}
 
-			cqlQueries[i] = NewCQLQuery(string(q.AggregationType), ser.Table, ser.Id, string(q.OrderBy), start.UnixNano(), end.UnixNano())
+			if q.Rollup > 0 {
+				cqlQueries[i] = NewRollupCQLQuery(string(q.AggregationType), cassandra.RollupTableName(q.Rollup), ser.Id, start.UnixNano(), end.UnixNano())
+			} else {
+				cqlQueries[i] = NewCQLQuery(string(q.AggregationType), ser.Table, ser.Id, string(q.OrderBy), start.UnixNano(), end.UnixNano())
+			}
 		}
 		cqlBuckets[ti] = cqlQueries
 	}
@@ -263,6 +271,9 @@ type CQLQuery struct {
 	PreparableQueryString string
 	Args                  []interface{}
 	Field                 string
+	// Weighted is set when the query returns a sum and a count to average,
+	// rather than an aggregated value.
+	Weighted bool
 }
 
 // NewCQLQuery builds a CQLQuery, using prepared CQL statements.
@@ -281,7 +292,23 @@ func NewCQLQuery(aggrLabel, tableName, rowName, orderBy string, timeStartNanos,
 	}
 	args := []interface{}{rowName, timeStartNanos, timeEndNanos}
 	rowParts := strings.Split(rowName, "#")
-	return CQLQuery{preparableQueryString, args, rowParts[len(rowParts)-2]}
+	return CQLQuery{preparableQueryString, args, rowParts[len(rowParts)-2], false}
+}
+
+// NewRollupCQLQuery builds a CQLQuery aggregating the partial rows of a
+// rollup table, using prepared CQL statements. An average is returned as the
+// sum and the count of the values, so that it is weighted when merged.
+func NewRollupCQLQuery(aggrLabel, tableName, rowName string, timeStartNanos, timeEndNanos int64) CQLQuery {
+	var preparableQueryString string
+	weighted := aggrLabel == "avg"
+	if weighted {
+		preparableQueryString = fmt.Sprintf("SELECT sum(sum), sum(count) FROM %s WHERE series_id = ? AND bucket_ns >= ? AND bucket_ns < ?", tableName)
+	} else {
+		preparableQueryString = fmt.Sprintf("SELECT %s(%s) FROM %s WHERE series_id = ? AND bucket_ns >= ? AND bucket_ns < ?", aggrLabel, aggrLabel, tableName)
+	}
+	args := []interface{}{rowName, timeStartNanos, timeEndNanos}
+	rowParts := strings.Split(rowName, "#")
+	return CQLQuery{preparableQueryString, args, rowParts[len(rowParts)-2], weighted}
 }
 
 // CQLResult holds a result from a set of CQL aggregation queries.
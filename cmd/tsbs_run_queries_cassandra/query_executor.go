@@ -34,7 +34,7 @@ func NewHLQueryExecutor(session *gocql.Session, csi *ClientSideIndex, debug int)
 // HLQueryExecutorDoOptions contains options used by HLQueryExecutor.
 type HLQueryExecutorDoOptions struct {
 	AggregationPlan      int
-	SubQueryParallelism  int // unused
+	SubQueryParallelism  int
 	Debug                int
 	PrettyPrintResponses bool
 }
@@ -42,7 +42,12 @@ type HLQueryExecutorDoOptions struct {
 // Do takes a high-level query, constructs a query plan using the client-side
 // index contained within the query executor, executes that query plan, then
 // aggregates the results.
-func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagMs, requestLagMs float64, err error) {
+//
+// The CQLQueries of the plan run with up to opts.SubQueryParallelism of them
+// at a time. Along with the lags of the planning and of the whole request, Do
+// returns the number of CQLQueries that were run and the time spent waiting
+// for them, i.e. the request lag without the client-side merging.
+func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagMs, requestLagMs, fanOutLagMs float64, subQueries int, err error) {
 	if opts.Debug >= 1 {
 		fmt.Printf("[hlqe] Do: %s\n", q)
 	}
@@ -54,6 +59,9 @@ func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagM
 		qp, err = q.ToQueryPlanNoAggregation(qe.csi)
 	} else if len(string(q.AggregationType)) == 0 {
 		qp, err = q.ToQueryPlanForEvery(qe.csi)
+	} else if q.Rollup > 0 {
+		// the rollup tables are only read with server aggregation
+		qp, err = q.ToQueryPlanWithServerAggregation(qe.csi)
 	} else {
 		switch opts.AggregationPlan {
 		case AggrPlanTypeWithServerAggregation:
@@ -81,8 +89,11 @@ func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagM
 	// execute the query plan:
 	var results []CQLResult
 	execStart := time.Now()
-	results, err = qp.Execute(qe.session)
+	fo := newFanOut(qe.session, opts.SubQueryParallelism)
+	results, err = qp.Execute(fo)
 	requestLagMs = float64(time.Now().Sub(execStart).Nanoseconds()) / 1e6
+	fanOutLagMs = float64(fo.lag.Nanoseconds()) / 1e6
+	subQueries = fo.subQueries
 	if err != nil {
 		return
 	}
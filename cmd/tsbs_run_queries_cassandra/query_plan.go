@@ -8,13 +8,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/gocql/gocql"
 	"github.com/timescale/tsbs/internal/utils"
 )
 
-// A QueryPlan is a strategy used to fulfill an HLQuery.
+// A QueryPlan is a strategy used to fulfill an HLQuery. It runs its
+// CQLQueries with the fanOut given to Execute.
 type QueryPlan interface {
-	Execute(*gocql.Session) ([]CQLResult, error)
+	Execute(*fanOut) ([]CQLResult, error)
 	DebugQueries(int)
 }
 
@@ -42,8 +42,10 @@ func NewQueryPlanWithServerAggregation(aggrLabel string, bucketedCQLQueries map[
 
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
-// TODO(rw): support parallel execution.
-func (qp *QueryPlanWithServerAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+// The queries of all the buckets are fanned out together, and the results of
+// each bucket are merged in its aggregator as they arrive, which does not
+// depend on their order as the aggregators are commutative.
+func (qp *QueryPlanWithServerAggregation) Execute(fo *fanOut) ([]CQLResult, error) {
 	// sort the time interval buckets we'll use:
 	sortedKeys := make([]*utils.TimeInterval, 0, len(qp.BucketedCQLQueries))
 	for k := range qp.BucketedCQLQueries {
@@ -51,36 +53,79 @@ func (qp *QueryPlanWithServerAggregation) Execute(session *gocql.Session) ([]CQL
 	}
 	sort.Sort(TimeIntervals(sortedKeys))
 
-	// for each bucket, execute its queries while aggregating its results
-	// in constant space, then append them to the result set:
-	results := make([]CQLResult, 0, len(qp.BucketedCQLQueries))
-	for _, k := range sortedKeys {
+	// make an aggregator per bucket, aggregating its results in constant
+	// space, and flatten the queries of the buckets:
+	aggs := make([]Aggregator, len(sortedKeys))
+	var queries []CQLQuery
+	var queryAggs []Aggregator
+	for i, k := range sortedKeys {
 		agg, err := GetAggregator(qp.AggregatorLabel)
 		if err != nil {
 			return nil, err
 		}
+		aggs[i] = agg
 
 		for _, q := range qp.BucketedCQLQueries[k] {
-			// Execute one CQLQuery and collect its result
-			//
-			// For server-side aggregation, this will return only
-			// one row; for exclusive client-side aggregation this
-			// will return a sequence.
-			iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
-			var x float64
-			for iter.Scan(&x) {
-				agg.Put(x)
-			}
-			if err := iter.Close(); err != nil {
-				return nil, err
-			}
+			queries = append(queries, q)
+			queryAggs = append(queryAggs, agg)
+		}
+	}
+
+	err := fo.execute(queries, func(i int, iter rowScanner) error {
+		if queries[i].Weighted {
+			return putWeighted(fo, queryAggs[i], iter)
+		}
+
+		// For server-side aggregation, this will return only
+		// one row; for exclusive client-side aggregation this
+		// will return a sequence.
+		var values []float64
+		var x float64
+		for iter.Scan(&x) {
+			values = append(values, x)
 		}
-		results = append(results, CQLResult{TimeInterval: k, Values: []float64{agg.Get()}})
+		fo.merge(func() {
+			for _, v := range values {
+				queryAggs[i].Put(v)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]CQLResult, 0, len(sortedKeys))
+	for i, k := range sortedKeys {
+		results = append(results, CQLResult{TimeInterval: k, Values: []float64{aggs[i].Get()}})
 	}
 
 	return results, nil
 }
 
+// putWeighted merges the sums and counts returned by a weighted CQLQuery
+// into the aggregator.
+func putWeighted(fo *fanOut, agg Aggregator, iter rowScanner) error {
+	wagg, ok := agg.(WeightedAggregator)
+	if !ok {
+		return fmt.Errorf("aggregator %T cannot merge sums and counts", agg)
+	}
+	var sums []float64
+	var counts []int64
+	var sum float64
+	var count int64
+	for iter.Scan(&sum, &count) {
+		sums = append(sums, sum)
+		counts = append(counts, count)
+	}
+	fo.merge(func() {
+		for j := range sums {
+			wagg.PutWeighted(sums[j], counts[j])
+		}
+	})
+	return nil
+}
+
 // DebugQueries prints debugging information.
 func (qp *QueryPlanWithServerAggregation) DebugQueries(level int) {
 	if level >= 1 {
@@ -94,7 +139,7 @@ func (qp *QueryPlanWithServerAggregation) DebugQueries(level int) {
 	if level >= 2 {
 		for k, qq := range qp.BucketedCQLQueries {
 			for i, q := range qq {
-				fmt.Printf("[qpsa] CQL: %v, %d, %s\n", k, i, q)
+				fmt.Printf("[qpsa] CQL: %v, %d, %v\n", k, i, q)
 			}
 		}
 	}
@@ -155,19 +200,19 @@ func csiDebugQueries(cqlQueries []CQLQuery, label string, level int) {
 
 	if level >= 2 {
 		for i, q := range cqlQueries {
-			fmt.Printf("[%s] CQL: %d, %s\n", label, i, q)
+			fmt.Printf("[%s] CQL: %d, %v\n", label, i, q)
 		}
 	}
 }
 
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
-//
-// TODO(rw): support parallel execution.
-func (qp *QueryPlanWithoutServerAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanWithoutServerAggregation) Execute(fo *fanOut) ([]CQLResult, error) {
 	// for each query, execute it, then put each result row into the
 	// client-side aggregator that matches its time bucket:
-	for _, q := range qp.CQLQueries {
-		iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+	err := fo.execute(qp.CQLQueries, func(i int, iter rowScanner) error {
+		q := qp.CQLQueries[i]
+		var buckets []*utils.TimeInterval
+		var values []float64
 
 		var timestampNs int64
 		var value float64
@@ -177,7 +222,7 @@ func (qp *QueryPlanWithoutServerAggregation) Execute(session *gocql.Session) ([]
 			tsTruncated := ts.Truncate(qp.GroupByDuration)
 			bucketKey, err := utils.NewTimeInterval(tsTruncated, tsTruncated.Add(qp.GroupByDuration))
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			// Due to limits, bucket is not needed, skip
@@ -185,11 +230,18 @@ func (qp *QueryPlanWithoutServerAggregation) Execute(session *gocql.Session) ([]
 				break
 			}
 
-			qp.Aggregators[bucketKey][q.Field].Put(value)
-		}
-		if err := iter.Close(); err != nil {
-			return nil, err
+			buckets = append(buckets, bucketKey)
+			values = append(values, value)
 		}
+		fo.merge(func() {
+			for j, bucketKey := range buckets {
+				qp.Aggregators[bucketKey][q.Field].Put(values[j])
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// perform client-side aggregation across all buckets:
@@ -239,9 +291,7 @@ func (a int64arr) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a int64arr) Less(i, j int) bool { return a[i] < a[j] }
 
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
-//
-// TODO(rw): support parallel execution.
-func (qp *QueryPlanNoAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanNoAggregation) Execute(fo *fanOut) ([]CQLResult, error) {
 	res := make(map[int64]map[string][]float64)
 	// Useful index for placing values in a row correctly
 	fieldPos := make(map[string]int)
@@ -263,58 +313,82 @@ func (qp *QueryPlanNoAggregation) Execute(session *gocql.Session) ([]CQLResult,
 	if len(whereParts) == 3 {
 		whereFn := getWhereFn(whereParts[1], whereParts[2])
 
-		// First pass of all queries
+		var whereQueries, otherQueries []CQLQuery
 		for _, q := range qp.cqlQueries {
-			if q.Field == whereParts[0] { // only handle queries for where clause field
-				iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+			if q.Field == whereParts[0] {
+				whereQueries = append(whereQueries, q)
+			} else {
+				otherQueries = append(otherQueries, q)
+			}
+		}
 
-				var timestampNs int64
-				var value float64
+		// First pass of all queries, only for where clause field
+		err := fo.execute(whereQueries, func(i int, iter rowScanner) error {
+			q := whereQueries[i]
+			var timestamps []int64
+			var values []float64
 
-				key := strings.Replace(q.Args[0].(string), q.Field, "", 1)
-				for iter.Scan(&timestampNs, &value) {
-					// Skip rows that do not match where clause
-					if !whereFn(value) {
-						continue
-					}
+			var timestampNs int64
+			var value float64
 
-					if _, ok := res[timestampNs]; !ok {
-						res[timestampNs] = make(map[string][]float64)
+			for iter.Scan(&timestampNs, &value) {
+				// Skip rows that do not match where clause
+				if !whereFn(value) {
+					continue
+				}
+				timestamps = append(timestamps, timestampNs)
+				values = append(values, value)
+			}
+
+			key := strings.Replace(q.Args[0].(string), q.Field, "", 1)
+			fo.merge(func() {
+				for j, ts := range timestamps {
+					if _, ok := res[ts]; !ok {
+						res[ts] = make(map[string][]float64)
 					}
-					if _, ok := res[timestampNs][key]; !ok {
-						res[timestampNs][key] = make([]float64, len(qp.fields))
+					if _, ok := res[ts][key]; !ok {
+						res[ts][key] = make([]float64, len(qp.fields))
 					}
-					res[timestampNs][key][fieldPos[q.Field]] = value
-				}
-				if err := iter.Close(); err != nil {
-					return nil, err
+					res[ts][key][fieldPos[q.Field]] = values[j]
 				}
-			}
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 
 		// Second pass for non-where clause fields
-		for _, q := range qp.cqlQueries {
-			if q.Field != whereParts[0] {
-				iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+		err = fo.execute(otherQueries, func(i int, iter rowScanner) error {
+			q := otherQueries[i]
+			var timestamps []int64
+			var values []float64
+
+			var timestampNs int64
+			var value float64
 
-				var timestampNs int64
-				var value float64
+			for iter.Scan(&timestampNs, &value) {
+				timestamps = append(timestamps, timestampNs)
+				values = append(values, value)
+			}
 
-				key := strings.Replace(q.Args[0].(string), q.Field, "", 1)
-				for iter.Scan(&timestampNs, &value) {
+			key := strings.Replace(q.Args[0].(string), q.Field, "", 1)
+			fo.merge(func() {
+				for j, ts := range timestamps {
 					// First pass added the only timestamps or series we accept
-					if _, ok := res[timestampNs]; !ok {
+					if _, ok := res[ts]; !ok {
 						continue
 					}
-					if _, ok := res[timestampNs][key]; !ok {
+					if _, ok := res[ts][key]; !ok {
 						continue
 					}
-					res[timestampNs][key][fieldPos[q.Field]] = value
-				}
-				if err := iter.Close(); err != nil {
-					return nil, err
+					res[ts][key][fieldPos[q.Field]] = values[j]
 				}
-			}
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	} else {
 		// TODO support no where clause?
@@ -395,8 +469,10 @@ func NewQueryPlanForEvery(fields []string, forEveryTag string, forEveryNum int64
 
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
-// TODO(rw): support parallel execution.
-func (qp *QueryPlanForEvery) Execute(session *gocql.Session) ([]CQLResult, error) {
+// The queries run one after the other regardless of the parallelism of the
+// fanOut, as whether a query is needed depends on the results of the previous
+// ones.
+func (qp *QueryPlanForEvery) Execute(fo *fanOut) ([]CQLResult, error) {
 	res := make(map[string]map[int64][]float64)
 	seriesTracker := make(map[string]int)
 
@@ -407,8 +483,6 @@ func (qp *QueryPlanForEvery) Execute(session *gocql.Session) ([]CQLResult, error
 	}
 
 	for _, q := range qp.cqlQueries {
-		iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
-
 		rm := r.FindSubmatch([]byte(q.Args[0].(string)))
 		key := string(rm[1])
 
@@ -425,29 +499,32 @@ func (qp *QueryPlanForEvery) Execute(session *gocql.Session) ([]CQLResult, error
 			continue
 		}
 
-		var timestampNs int64
-		var value float64
-		for iter.Scan(&timestampNs, &value) {
-			// Haven't encountered this host yet
-			// TODO - for N, need to keep making timestamp secondary keys until N
-			if len(res[key]) == 0 {
-				res[key][timestampNs] = make([]float64, 0)
-			}
+		err := fo.execute([]CQLQuery{q}, func(_ int, iter rowScanner) error {
+			var timestampNs int64
+			var value float64
+			for iter.Scan(&timestampNs, &value) {
+				// Haven't encountered this host yet
+				// TODO - for N, need to keep making timestamp secondary keys until N
+				if len(res[key]) == 0 {
+					res[key][timestampNs] = make([]float64, 0)
+				}
 
-			if _, ok := res[key][timestampNs]; !ok {
-				// Sorted by descending, so once we encounter one not in our
-				// map, we can skip. It will be added to the map in previous step.
-				break
-			}
+				if _, ok := res[key][timestampNs]; !ok {
+					// Sorted by descending, so once we encounter one not in our
+					// map, we can skip. It will be added to the map in previous step.
+					break
+				}
 
-			// TODO put in proper position according to field
-			res[key][timestampNs] = append(res[key][timestampNs], value)
-			seriesTracker[key]++
-			if seriesTracker[key] == len(qp.fields) {
-				break
+				// TODO put in proper position according to field
+				res[key][timestampNs] = append(res[key][timestampNs], value)
+				seriesTracker[key]++
+				if seriesTracker[key] == len(qp.fields) {
+					break
+				}
 			}
-		}
-		if err := iter.Close(); err != nil {
+			return nil
+		})
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -12,6 +12,13 @@ type Aggregator interface {
 	Get() float64
 }
 
+// WeightedAggregator is an Aggregator that also merges pre-aggregated sums
+// of values, e.g. the rows of a rollup table.
+type WeightedAggregator interface {
+	Aggregator
+	PutWeighted(sum float64, count int64)
+}
+
 // AggregatorMax aggregates the maximum of a stream of values.
 type AggregatorMax struct {
 	value float64
@@ -65,6 +72,12 @@ func (a *AggregatorAvg) Put(n float64) {
 	a.count++
 }
 
+// PutWeighted puts the sum of count values for averaging.
+func (a *AggregatorAvg) PutWeighted(sum float64, count int64) {
+	a.value += sum
+	a.count += count
+}
+
 // Get computes the aggregated average.
 func (a *AggregatorAvg) Get() float64 {
 	if a.count == 0 {
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"golang.org/x/sync/errgroup"
+)
+
+// rowScanner iterates over the rows of a CQLQuery, like *gocql.Iter.
+type rowScanner interface {
+	Scan(...interface{}) bool
+	Close() error
+}
+
+// A fanOut executes the CQLQueries of a QueryPlan, running up to parallelism
+// of them concurrently, and records how many were run and the time spent
+// waiting for them.
+//
+// The scan functions given to execute are called concurrently, so they read
+// their rows into local state and merge it into the plan's results with
+// merge, which serializes the merges of a fanOut.
+type fanOut struct {
+	parallelism int
+	query       func(context.Context, CQLQuery) rowScanner
+
+	mu         sync.Mutex
+	subQueries int
+	lag        time.Duration
+}
+
+// newFanOut creates a fanOut running the CQLQueries over the session.
+func newFanOut(session *gocql.Session, parallelism int) *fanOut {
+	return &fanOut{
+		parallelism: parallelism,
+		query: func(ctx context.Context, q CQLQuery) rowScanner {
+			return session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
+		},
+	}
+}
+
+// execute runs the queries and calls scan with the index and rows of each of
+// them. It returns the first error of a query or of scan, after which the
+// queries that did not start yet are skipped and the running ones canceled.
+func (f *fanOut) execute(queries []CQLQuery, scan func(int, rowScanner) error) error {
+	start := time.Now()
+	defer func() {
+		f.lag += time.Since(start)
+	}()
+
+	g, ctx := errgroup.WithContext(context.Background())
+	if f.parallelism > 0 {
+		g.SetLimit(f.parallelism)
+	} else {
+		g.SetLimit(1)
+	}
+	for i := range queries {
+		i := i
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			f.mu.Lock()
+			f.subQueries++
+			f.mu.Unlock()
+
+			iter := f.query(ctx, queries[i])
+			err := scan(i, iter)
+			if closeErr := iter.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// merge runs fn while no other merge of the fanOut runs.
+func (f *fanOut) merge(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+}
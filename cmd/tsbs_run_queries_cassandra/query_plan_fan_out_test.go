@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/internal/utils"
+)
+
+// testScanner returns rows of (timestamp, value) or (value) for the columns
+// scanned.
+type testScanner struct {
+	rows [][]float64
+	err  error
+}
+
+func (s *testScanner) Scan(dest ...interface{}) bool {
+	if len(s.rows) == 0 {
+		return false
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	for i, d := range dest {
+		switch d := d.(type) {
+		case *int64:
+			*d = int64(row[i])
+		case *float64:
+			*d = row[i]
+		}
+	}
+	return true
+}
+
+func (s *testScanner) Close() error {
+	return s.err
+}
+
+// newTestFanOut returns a fanOut answering each query with the rows of its
+// table, and tracking the maximum number of concurrent queries.
+func newTestFanOut(parallelism int, rows map[string][][]float64, errs map[string]error) (*fanOut, *int) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	fo := &fanOut{parallelism: parallelism}
+	fo.query = func(_ context.Context, q CQLQuery) rowScanner {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return &testScanner{rows: rows[q.Args[0].(string)], err: errs[q.Args[0].(string)]}
+	}
+	return fo, &maxInFlight
+}
+
+func TestFanOutExecute(t *testing.T) {
+	queries := make([]CQLQuery, 8)
+	rows := map[string][][]float64{}
+	for i := range queries {
+		table := fmt.Sprintf("series_%d", i)
+		queries[i] = CQLQuery{Args: []interface{}{table}}
+		rows[table] = [][]float64{{float64(i)}}
+	}
+
+	for _, parallelism := range []int{1, 3, 8} {
+		fo, maxInFlight := newTestFanOut(parallelism, rows, nil)
+		got := make([]float64, len(queries))
+		err := fo.execute(queries, func(i int, iter rowScanner) error {
+			var x float64
+			for iter.Scan(&x) {
+				fo.merge(func() { got[i] = x })
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("parallelism %d: unexpected error: %v", parallelism, err)
+		}
+		for i, x := range got {
+			if x != float64(i) {
+				t.Errorf("parallelism %d: incorrect result for query %d: %v", parallelism, i, x)
+			}
+		}
+		if fo.subQueries != len(queries) {
+			t.Errorf("parallelism %d: incorrect sub-queries: got %d want %d", parallelism, fo.subQueries, len(queries))
+		}
+		if *maxInFlight > parallelism {
+			t.Errorf("parallelism %d: too many concurrent queries: %d", parallelism, *maxInFlight)
+		}
+		if parallelism > 1 && *maxInFlight < 2 {
+			t.Errorf("parallelism %d: queries did not run concurrently", parallelism)
+		}
+		if fo.lag <= 0 {
+			t.Errorf("parallelism %d: fan-out lag not recorded", parallelism)
+		}
+	}
+}
+
+func TestFanOutExecuteError(t *testing.T) {
+	queries := make([]CQLQuery, 6)
+	for i := range queries {
+		queries[i] = CQLQuery{Args: []interface{}{fmt.Sprintf("series_%d", i)}}
+	}
+	wantErr := fmt.Errorf("read timeout")
+	fo, _ := newTestFanOut(1, nil, map[string]error{"series_1": wantErr})
+	err := fo.execute(queries, func(int, rowScanner) error { return nil })
+	if err != wantErr {
+		t.Errorf("incorrect error: got %v want %v", err, wantErr)
+	}
+	if fo.subQueries != 2 {
+		t.Errorf("queries after the error were not skipped: %d sub-queries", fo.subQueries)
+	}
+}
+
+func TestQueryPlanWithServerAggregationExecute(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	ti1, _ := utils.NewTimeInterval(start, start.Add(time.Hour))
+	ti2, _ := utils.NewTimeInterval(start.Add(time.Hour), start.Add(2*time.Hour))
+	buckets := map[*utils.TimeInterval][]CQLQuery{
+		ti2: {{Args: []interface{}{"a2"}}, {Args: []interface{}{"b2"}}, {Args: []interface{}{"c2"}}},
+		ti1: {{Args: []interface{}{"a1"}}, {Args: []interface{}{"b1"}}},
+	}
+	rows := map[string][][]float64{
+		"a1": {{1}}, "b1": {{5}},
+		"a2": {{7}}, "b2": {{3}}, "c2": {{2}},
+	}
+	cases := []struct {
+		label string
+		want  []float64
+	}{
+		{label: "max", want: []float64{5, 7}},
+		{label: "min", want: []float64{1, 2}},
+		{label: "avg", want: []float64{3, 4}},
+	}
+	for _, c := range cases {
+		for _, parallelism := range []int{1, 4} {
+			qp, _ := NewQueryPlanWithServerAggregation(c.label, buckets)
+			fo, _ := newTestFanOut(parallelism, rows, nil)
+			results, err := qp.Execute(fo)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", c.label, err)
+			}
+			got := make([]float64, len(results))
+			for i, r := range results {
+				got[i] = r.Values[0]
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("%s parallelism %d: incorrect results: got %v want %v", c.label, parallelism, got, c.want)
+			}
+			if results[0].TimeInterval != ti1 || results[1].TimeInterval != ti2 {
+				t.Errorf("%s parallelism %d: results not sorted by time", c.label, parallelism)
+			}
+			if fo.subQueries != 5 {
+				t.Errorf("%s parallelism %d: incorrect sub-queries: %d", c.label, parallelism, fo.subQueries)
+			}
+		}
+	}
+}
+
+func TestQueryPlanNoAggregationExecute(t *testing.T) {
+	queries := []CQLQuery{
+		{Field: "usage_user", Args: []interface{}{"cpu,hostname=host_0#usage_user"}},
+		{Field: "usage_system", Args: []interface{}{"cpu,hostname=host_0#usage_system"}},
+		{Field: "usage_user", Args: []interface{}{"cpu,hostname=host_1#usage_user"}},
+		{Field: "usage_system", Args: []interface{}{"cpu,hostname=host_1#usage_system"}},
+	}
+	rows := map[string][][]float64{
+		"cpu,hostname=host_0#usage_user":   {{1, 95}, {2, 10}},
+		"cpu,hostname=host_0#usage_system": {{1, 3}, {2, 4}},
+		"cpu,hostname=host_1#usage_user":   {{2, 99}},
+		"cpu,hostname=host_1#usage_system": {{1, 5}, {2, 6}},
+	}
+	for _, parallelism := range []int{1, 4} {
+		qp, _ := NewQueryPlanNoAggregation([]string{"usage_user", "usage_system"}, "usage_user,>,90", queries)
+		fo, _ := newTestFanOut(parallelism, rows, nil)
+		results, err := qp.Execute(fo)
+		if err != nil {
+			t.Fatalf("parallelism %d: unexpected error: %v", parallelism, err)
+		}
+		got := map[int64][]float64{}
+		for _, r := range results {
+			got[r.TimeInterval.Start().UnixNano()] = r.Values
+		}
+		want := map[int64][]float64{1: {95, 3}, 2: {99, 6}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parallelism %d: incorrect results: got %v want %v", parallelism, got, want)
+		}
+	}
+}
+
+func TestQueryPlanWithServerAggregationRollup(t *testing.T) {
+	id := "cpu,hostname=host_0#usage_user#2016-01-01"
+	q := NewRollupCQLQuery("max", "rollup_1h", id, 1, 2)
+	wantQuery := "SELECT max(max) FROM rollup_1h WHERE series_id = ? AND bucket_ns >= ? AND bucket_ns < ?"
+	if q.PreparableQueryString != wantQuery || q.Weighted || q.Field != "usage_user" {
+		t.Errorf("incorrect max query: %+v", q)
+	}
+	q = NewRollupCQLQuery("avg", "rollup_1h", id, 1, 2)
+	wantQuery = "SELECT sum(sum), sum(count) FROM rollup_1h WHERE series_id = ? AND bucket_ns >= ? AND bucket_ns < ?"
+	if q.PreparableQueryString != wantQuery || !q.Weighted {
+		t.Errorf("incorrect avg query: %+v", q)
+	}
+
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	ti, _ := utils.NewTimeInterval(start, start.Add(time.Hour))
+	buckets := map[*utils.TimeInterval][]CQLQuery{
+		ti: {
+			NewRollupCQLQuery("avg", "rollup_1h", "cpu,hostname=host_0#usage_user#2016-01-01", 0, 1),
+			NewRollupCQLQuery("avg", "rollup_1h", "cpu,hostname=host_1#usage_user#2016-01-01", 0, 1),
+		},
+	}
+	// the average is weighted by the counts of the series
+	rows := map[string][][]float64{
+		"cpu,hostname=host_0#usage_user#2016-01-01": {{30, 3}},
+		"cpu,hostname=host_1#usage_user#2016-01-01": {{10, 1}},
+	}
+	qp, _ := NewQueryPlanWithServerAggregation("avg", buckets)
+	fo, _ := newTestFanOut(2, rows, nil)
+	results, err := qp.Execute(fo)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Values[0] != 10 {
+		t.Errorf("incorrect results: %v", results)
+	}
+
+	qp, _ = NewQueryPlanWithServerAggregation("max", buckets)
+	fo, _ = newTestFanOut(2, rows, nil)
+	if _, err := qp.Execute(fo); err == nil {
+		t.Errorf("expected error merging sums and counts into a max")
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"strings"
 	"time"
 
+	_ "github.com/ClickHouse/clickhouse-go"
 	"github.com/blagojts/viper"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/kshvakov/clickhouse"
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/query"
@@ -178,3 +178,22 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 
 	return []*query.Stat{stat}, err
 }
+
+// Explain implements query.Explainer. ClickHouse has no EXPLAIN ANALYZE, so
+// the plan is the one of EXPLAIN with the indexes and parts the query reads.
+func (p *processor) Explain(q query.Query) (string, error) {
+	rows, err := p.db.Queryx("EXPLAIN indexes = 1 " + string(q.(*query.ClickHouse).SqlQuery))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	text := ""
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return "", err
+		}
+		text += s + "\n"
+	}
+	return text, rows.Err()
+}
@@ -144,6 +144,35 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 	return []*query.Stat{stat}, err
 }
 
+// Explain implements query.Explainer, it runs the query with EXPLAIN ANALYZE.
+// CrateDB returns the plan as an object, which is written as indented JSON.
+func (p *processor) Explain(q query.Query) (string, error) {
+	rows, err := p.conn.Query(context.Background(), "EXPLAIN ANALYZE "+string(q.(*query.CrateDB).SqlQuery))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	text := ""
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return "", err
+		}
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				text += s + "\n"
+				continue
+			}
+			line, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				return "", err
+			}
+			text += string(line) + "\n"
+		}
+	}
+	return text, rows.Err()
+}
+
 // prettyPrintResponse prints a Query and its response in JSON format with two
 // keys: 'query' which has a value of the SQL used to generate the second key
 // 'results' which is an array of each row in the return set.
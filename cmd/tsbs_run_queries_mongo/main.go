@@ -1,7 +1,8 @@
 // tsbs_run_queries_mongo speed tests Mongo using requests from stdin.
 //
 // It reads encoded Query objects from stdin, and makes concurrent requests
-// to the provided Mongo endpoint using mgo.
+// to the provided Mongo endpoint using mgo, or the official Go driver for the
+// queries of time-series collections.
 package main
 
 import (
@@ -20,8 +21,9 @@ import (
 
 // Program option vars:
 var (
-	daemonURL string
-	timeout   time.Duration
+	daemonURL  string
+	timeout    time.Duration
+	timeSeries bool
 )
 
 // Global vars:
@@ -38,12 +40,15 @@ func init() {
 	gob.Register([]map[string]interface{}{})
 	gob.Register(bson.M{})
 	gob.Register([]bson.M{})
+	gob.Register(bson.D{})
+	gob.Register(time.Time{})
 
 	var config query.BenchmarkRunnerConfig
 	config.AddToFlagSet(pflag.CommandLine)
 
 	pflag.String("url", "mongodb://localhost:27017", "Daemon URL.")
 	pflag.Duration("read-timeout", 30*time.Second, "Timeout value for individual queries")
+	pflag.Bool("time-series", false, "Whether the queries are for the time-series collection of tsbs_load_mongo --time-series, run with the official driver (MongoDB 5.0+)")
 
 	pflag.Parse()
 
@@ -59,11 +64,16 @@ func init() {
 
 	daemonURL = viper.GetString("url")
 	timeout = viper.GetDuration("read-timeout")
+	timeSeries = viper.GetBool("time-series")
 
 	runner = query.NewBenchmarkRunner(config)
 }
 
 func main() {
+	if timeSeries {
+		runTimeSeries()
+		return
+	}
 	var err error
 	session, err = mgo.DialWithTimeout(daemonURL, timeout)
 	if err != nil {
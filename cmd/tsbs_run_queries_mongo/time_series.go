@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/mongo"
+	mongobson "go.mongodb.org/mongo-driver/bson"
+	mongodrv "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// client runs the queries of time-series collections, which need a newer
+// server than mgo supports
+var client *mongodrv.Client
+
+func runTimeSeries() {
+	var err error
+	client, err = mongodrv.Connect(context.Background(), options.Client().ApplyURI(mongo.ClientURI(daemonURL)).SetTimeout(timeout))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	runner.Run(&query.MongoPool, newTimeSeriesProcessor)
+}
+
+// driverPipeline converts the generated pipeline to the types of the driver.
+// The generators build the stages with the mgo types, whose ordered bson.D
+// the driver would encode as an array of name/value documents.
+func driverPipeline(pipeline []bson.M) []interface{} {
+	stages := make([]interface{}, len(pipeline))
+	for i, stage := range pipeline {
+		stages[i] = driverValue(stage)
+	}
+	return stages
+}
+
+func driverValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.M:
+		m := make(mongobson.M, len(v))
+		for k, e := range v {
+			m[k] = driverValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		return driverValue(bson.M(v))
+	case bson.D:
+		d := make(mongobson.D, len(v))
+		for i, e := range v {
+			d[i] = mongobson.E{Key: e.Name, Value: driverValue(e.Value)}
+		}
+		return d
+	case []bson.M:
+		a := make(mongobson.A, len(v))
+		for i, e := range v {
+			a[i] = driverValue(e)
+		}
+		return a
+	case []interface{}:
+		a := make(mongobson.A, len(v))
+		for i, e := range v {
+			a[i] = driverValue(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// timeSeriesProcessor runs the queries with the official driver
+type timeSeriesProcessor struct {
+	db *mongodrv.Database
+}
+
+func newTimeSeriesProcessor() query.Processor { return &timeSeriesProcessor{} }
+
+func (p *timeSeriesProcessor) Init(workerNumber int) {
+	p.db = client.Database(runner.DatabaseName())
+}
+
+func (p *timeSeriesProcessor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	mq := q.(*query.Mongo)
+	collection := "point_data"
+	if len(mq.CollectionName) > 0 {
+		collection = string(mq.CollectionName)
+	}
+	start := time.Now().UnixNano()
+	if runner.DebugLevel() > 0 {
+		fmt.Println(mq.BsonDoc)
+	}
+	ctx := context.Background()
+	cursor, err := p.db.Collection(collection).Aggregate(ctx, driverPipeline(mq.BsonDoc), options.Aggregate().SetAllowDiskUse(true))
+	cnt := 0
+	if err == nil {
+		for cursor.Next(ctx) {
+			if runner.DoPrintResponses() {
+				var result mongobson.M
+				if err := cursor.Decode(&result); err == nil {
+					fmt.Printf("ID %d: %v\n", q.GetID(), result)
+				}
+			}
+			cnt++
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+	}
+	if runner.DebugLevel() > 0 {
+		fmt.Println(cnt)
+	}
+
+	took := time.Now().UnixNano() - start
+	lag := float64(took) / 1e6 // milliseconds
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	mongobson "go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDriverPipeline(t *testing.T) {
+	start := time.Unix(1451606400, 0).UTC()
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"time":  bson.M{"$gte": start},
+			"hosts": bson.M{"$in": []interface{}{"host_0", "host_1"}},
+		}},
+		{"$sort": bson.D{{Name: "_id.time", Value: 1}, {Name: "_id.hostname", Value: -1}}},
+		{"$project": map[string]interface{}{"a": []bson.M{{"b": 1}}}},
+	}
+	want := []interface{}{
+		mongobson.M{"$match": mongobson.M{
+			"time":  mongobson.M{"$gte": start},
+			"hosts": mongobson.M{"$in": mongobson.A{"host_0", "host_1"}},
+		}},
+		mongobson.M{"$sort": mongobson.D{{Key: "_id.time", Value: 1}, {Key: "_id.hostname", Value: -1}}},
+		mongobson.M{"$project": mongobson.M{"a": mongobson.A{mongobson.M{"b": 1}}}},
+	}
+	got := driverPipeline(pipeline)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect pipeline:\ngot\n%#v\nwant\n%#v", got, want)
+	}
+
+	// the ordered stage must keep its order once marshalled
+	raw, err := mongobson.Marshal(got[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var sort mongobson.D
+	if err := mongobson.Unmarshal(raw, &sort); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := sort[0].Value.(mongobson.D)
+	if keys[0].Key != "_id.time" || keys[1].Key != "_id.hostname" {
+		t.Errorf("incorrect sort order: %v", keys)
+	}
+}
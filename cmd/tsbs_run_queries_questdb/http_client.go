@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -139,3 +140,43 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 
 	return lag, err
 }
+
+// Explain runs the SQL query of the given Query with EXPLAIN on the /exec
+// endpoint, and returns the lines of the plan. QuestDB has no EXPLAIN ANALYZE.
+func (w *HTTPClient) Explain(q *query.HTTP, opts *HTTPClientDoOptions) (string, error) {
+	v := url.Values{}
+	v.Set("query", "EXPLAIN "+string(q.RawQuery))
+	req, err := http.NewRequest(http.MethodGet, w.HostString+"/exec?"+v.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if opts.Username != "" {
+		req.SetBasicAuth(opts.Username, opts.Password)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("explain returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Dataset [][]interface{} `json:"dataset"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	text := ""
+	for _, row := range result.Dataset {
+		for _, value := range row {
+			text += fmt.Sprint(value) + "\n"
+		}
+	}
+	return text, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestHTTPClientExplain(t *testing.T) {
+	var gotQuery, gotUser string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/exec" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		gotQuery = r.URL.Query().Get("query")
+		gotUser, _, _ = r.BasicAuth()
+		w.Write([]byte(`{"query":"EXPLAIN","columns":[{"name":"QUERY PLAN","type":"STRING"}],"dataset":[["GroupBy vectorized: true"],["    DataFrame"]],"count":2}`))
+	}))
+	defer server.Close()
+
+	q := &query.HTTP{RawQuery: []byte("SELECT max(usage_user) FROM cpu")}
+	w := NewHTTPClient(server.URL + "/")
+	plan, err := w.Explain(q, &HTTPClientDoOptions{Username: "admin", Password: "quest"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != "EXPLAIN SELECT max(usage_user) FROM cpu" {
+		t.Errorf("incorrect query: %s", gotQuery)
+	}
+	if gotUser != "admin" {
+		t.Errorf("basic auth not set: %s", gotUser)
+	}
+	if want := "GroupBy vectorized: true\n    DataFrame\n"; plan != want {
+		t.Errorf("incorrect plan: got %q want %q", plan, want)
+	}
+
+	q.RawQuery = []byte("SELECT nope")
+	w = NewHTTPClient(server.URL + "/missing")
+	if _, err := w.Explain(q, &HTTPClientDoOptions{}); err == nil {
+		t.Errorf("expected error for a failed explain")
+	}
+}
@@ -85,3 +85,8 @@ func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
 	stat.Init(q.HumanLabelName(), lag)
 	return []*query.Stat{stat}, nil
 }
+
+// Explain implements query.Explainer
+func (p *processor) Explain(q query.Query) (string, error) {
+	return p.w.Explain(q.(*query.HTTP), p.opts)
+}
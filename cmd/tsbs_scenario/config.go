@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/compress"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query/config"
+	"github.com/timescale/tsbs/pkg/query/factories"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// Phases of a scenario, run in this order
+const (
+	phaseGenerateData    = "generate-data"
+	phaseLoad            = "load"
+	phaseMaintenance     = "maintenance"
+	phaseGenerateQueries = "generate-queries"
+	phaseRunQueries      = "run-queries"
+)
+
+var allPhases = []string{phaseGenerateData, phaseLoad, phaseMaintenance, phaseGenerateQueries, phaseRunQueries}
+
+// defaultPhases are run when the scenario lists none. The maintenance phase
+// needs its operations to be configured, so it is left out.
+var defaultPhases = []string{phaseGenerateData, phaseLoad, phaseGenerateQueries, phaseRunQueries}
+
+// inProcessLoadFormats are the targets that can be loaded in-process, the
+// others are only loaded by their own tsbs_load_* program
+var inProcessLoadFormats = []string{
+	constants.FormatTimescaleDB,
+	constants.FormatVictoriaMetrics,
+	constants.FormatPrometheus,
+	constants.FormatInfluxDB3,
+	constants.FormatTimestream,
+	constants.FormatOTLP,
+}
+
+// Operations of the maintenance phase
+const (
+	maintenanceDrop       = "drop"
+	maintenanceDownsample = "downsample"
+)
+
+var maintenanceOperations = []string{maintenanceDrop, maintenanceDownsample}
+
+// maintenanceDuringPhases are the phases the maintenance can run alongside
+var maintenanceDuringPhases = []string{phaseLoad, phaseRunQueries}
+
+// ScenarioConfig describes a full benchmark cycle: the dataset to generate,
+// the target to load it into and the queries to run against it.
+type ScenarioConfig struct {
+	Name        string
+	OutputDir   string   `yaml:"output-dir" mapstructure:"output-dir"`
+	Phases      []string `yaml:"phases"`
+	Dataset     DatasetConfig
+	Target      TargetConfig
+	Load        LoadConfig
+	Maintenance MaintenanceConfig
+	Queries     QueriesConfig
+}
+
+// DatasetConfig holds the simulator settings shared by data and query generation
+type DatasetConfig struct {
+	Use            string        `yaml:"use-case" mapstructure:"use-case"`
+	Scale          uint64        `yaml:"scale"`
+	TimeStart      string        `yaml:"timestamp-start" mapstructure:"timestamp-start"`
+	TimeEnd        string        `yaml:"timestamp-end" mapstructure:"timestamp-end"`
+	LogInterval    time.Duration `yaml:"log-interval" mapstructure:"log-interval"`
+	Seed           int64         `yaml:"seed"`
+	Limit          uint64        `yaml:"max-data-points" mapstructure:"max-data-points"`
+	MaxMetricCount uint64        `yaml:"max-metric-count" mapstructure:"max-metric-count"`
+	// Compression of the generated data and query files
+	Compression string `yaml:"compression"`
+}
+
+// TargetConfig selects the target database. Its db-specific settings are the
+// same as the ones of tsbs_load (loader.db-specific)
+type TargetConfig struct {
+	Format string `yaml:"format"`
+}
+
+// LoadConfig mirrors the loader.runner settings of tsbs_load
+type LoadConfig struct {
+	DBName          string        `yaml:"db-name" mapstructure:"db-name"`
+	BatchSize       uint          `yaml:"batch-size" mapstructure:"batch-size"`
+	Workers         uint          `yaml:"workers"`
+	Limit           uint64        `yaml:"limit"`
+	DoCreateDB      bool          `yaml:"do-create-db" mapstructure:"do-create-db"`
+	DoAbortOnExist  bool          `yaml:"do-abort-on-exist" mapstructure:"do-abort-on-exist"`
+	ReportingPeriod time.Duration `yaml:"reporting-period" mapstructure:"reporting-period"`
+	HashWorkers     bool          `yaml:"hash-workers" mapstructure:"hash-workers"`
+	InsertIntervals string        `yaml:"insert-intervals" mapstructure:"insert-intervals"`
+	InsertRate      string        `yaml:"insert-rate-profile" mapstructure:"insert-rate-profile"`
+	FlowControl     bool          `yaml:"flow-control" mapstructure:"flow-control"`
+	ChannelCapacity uint          `yaml:"channel-capacity" mapstructure:"channel-capacity"`
+}
+
+// MaintenanceConfig lists the operations of the maintenance phase. They run
+// once after the load, or, with during set, repeatedly while the load or the
+// queries run, to see how ingest and query latency react to them.
+type MaintenanceConfig struct {
+	Operations []string `yaml:"operations"`
+	// Retention is how much of the end of the dataset the drop operation
+	// keeps, the data before it is dropped
+	Retention time.Duration `yaml:"retention"`
+	// During is the phase the operations run alongside, every Interval
+	During   string        `yaml:"during"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// QueriesConfig lists the query types to generate and run. The generator
+// settings are the flags of tsbs_generate_queries (queries.generator) and the
+// connection settings of the query runner are under queries.db-specific
+type QueriesConfig struct {
+	Types          []string `yaml:"types"`
+	Count          uint64   `yaml:"count"`
+	Workers        uint     `yaml:"workers"`
+	BurnIn         uint64   `yaml:"burn-in" mapstructure:"burn-in"`
+	MaxRPS         uint64   `yaml:"max-rps" mapstructure:"max-rps"`
+	PrewarmQueries bool     `yaml:"prewarm-queries" mapstructure:"prewarm-queries"`
+}
+
+// scenario is a parsed ScenarioConfig together with the target and the
+// db-specific settings of each phase
+type scenario struct {
+	ScenarioConfig
+	target    targets.ImplementedTarget
+	targetV   *viper.Viper
+	generator *viper.Viper
+	queryV    *viper.Viper
+}
+
+func setScenarioDefaults(v *viper.Viper) {
+	v.SetDefault("name", "scenario")
+	v.SetDefault("output-dir", ".")
+	v.SetDefault("phases", defaultPhases)
+	v.SetDefault("dataset.use-case", common.UseCaseCPUOnly)
+	v.SetDefault("dataset.scale", 1)
+	v.SetDefault("dataset.timestamp-start", "2016-01-01T00:00:00Z")
+	v.SetDefault("dataset.timestamp-end", "2016-01-02T00:00:00Z")
+	v.SetDefault("dataset.log-interval", 10*time.Second)
+	v.SetDefault("dataset.max-metric-count", 100)
+	v.SetDefault("load.db-name", "benchmark")
+	v.SetDefault("load.batch-size", 10000)
+	v.SetDefault("load.workers", 1)
+	v.SetDefault("load.do-create-db", true)
+	v.SetDefault("load.reporting-period", 10*time.Second)
+	v.SetDefault("maintenance.interval", time.Minute)
+	v.SetDefault("queries.count", 1000)
+	v.SetDefault("queries.workers", 1)
+}
+
+// parseScenario validates the scenario read into v and prepares the
+// settings of every phase, with defaults for everything left unspecified
+func parseScenario(v *viper.Viper, getTarget func(string) targets.ImplementedTarget) (*scenario, error) {
+	setScenarioDefaults(v)
+	var conf ScenarioConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, fmt.Errorf("could not parse scenario: %v", err)
+	}
+	if conf.Target.Format == "" {
+		return nil, fmt.Errorf("scenario is missing target.format")
+	}
+	if !utils.IsIn(conf.Target.Format, constants.SupportedFormats()) {
+		return nil, fmt.Errorf("unknown target format '%s', supported: %v", conf.Target.Format, constants.SupportedFormats())
+	}
+	if _, err := compress.ParseCodec(conf.Dataset.Compression); err != nil {
+		return nil, err
+	}
+	for _, p := range conf.Phases {
+		if !utils.IsIn(p, allPhases) {
+			return nil, fmt.Errorf("unknown phase '%s', valid: %v", p, allPhases)
+		}
+	}
+	if conf.hasPhase(phaseLoad) && !utils.IsIn(conf.Target.Format, inProcessLoadFormats) {
+		return nil, fmt.Errorf("phase %s is not supported for target %s, supported: %v", phaseLoad, conf.Target.Format, inProcessLoadFormats)
+	}
+	if _, ok := factories.InitQueryFactories(&config.QueryGeneratorConfig{})[conf.Target.Format]; conf.hasPhase(phaseGenerateQueries) && !ok {
+		return nil, fmt.Errorf("phase %s is not supported for target %s", phaseGenerateQueries, conf.Target.Format)
+	}
+	if conf.hasPhase(phaseGenerateQueries) || conf.hasPhase(phaseRunQueries) {
+		if len(conf.Queries.Types) == 0 {
+			return nil, fmt.Errorf("scenario has query phases but no queries.types")
+		}
+	}
+	if _, ok := queryProcessors[conf.Target.Format]; conf.hasPhase(phaseRunQueries) && !ok {
+		return nil, fmt.Errorf("phase %s is not supported for target %s", phaseRunQueries, conf.Target.Format)
+	}
+
+	s := &scenario{ScenarioConfig: conf, target: getTarget(conf.Target.Format)}
+	if conf.hasPhase(phaseMaintenance) {
+		if err := conf.validateMaintenance(s.target); err != nil {
+			return nil, err
+		}
+	}
+
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	s.target.TargetSpecificFlags("", fs)
+	var err error
+	if s.targetV, err = subWithDefaults(v, "target.db-specific", fs); err != nil {
+		return nil, err
+	}
+	if s.generator, err = subWithDefaults(v, "queries.generator", queryGeneratorFlags()); err != nil {
+		return nil, err
+	}
+	if s.queryV, err = subWithDefaults(v, "queries.db-specific", nil); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *ScenarioConfig) hasPhase(phase string) bool {
+	return utils.IsIn(phase, c.Phases)
+}
+
+// validateMaintenance checks the maintenance operations can run on target
+func (c *ScenarioConfig) validateMaintenance(target targets.ImplementedTarget) error {
+	if _, ok := target.(targets.Maintainer); !ok {
+		return fmt.Errorf("phase %s is not supported for target %s", phaseMaintenance, c.Target.Format)
+	}
+	m := c.Maintenance
+	if len(m.Operations) == 0 {
+		return fmt.Errorf("scenario has a %s phase but no maintenance.operations", phaseMaintenance)
+	}
+	for _, op := range m.Operations {
+		if !utils.IsIn(op, maintenanceOperations) {
+			return fmt.Errorf("unknown maintenance operation '%s', valid: %v", op, maintenanceOperations)
+		}
+	}
+	if utils.IsIn(maintenanceDrop, m.Operations) && m.Retention <= 0 {
+		return fmt.Errorf("maintenance operation %s needs a positive maintenance.retention", maintenanceDrop)
+	}
+	if m.During == "" {
+		return nil
+	}
+	if !utils.IsIn(m.During, maintenanceDuringPhases) {
+		return fmt.Errorf("maintenance cannot run during '%s', valid: %v", m.During, maintenanceDuringPhases)
+	}
+	if !c.hasPhase(m.During) {
+		return fmt.Errorf("maintenance runs during phase %s, which the scenario does not run", m.During)
+	}
+	if m.Interval <= 0 {
+		return fmt.Errorf("maintenance.interval must be positive")
+	}
+	return nil
+}
+
+// subWithDefaults returns a viper holding the settings under key, using the
+// defaults of the given flags for anything that is not set
+func subWithDefaults(v *viper.Viper, key string, defaults *pflag.FlagSet) (*viper.Viper, error) {
+	sub := viper.New()
+	if defaults != nil {
+		if err := sub.BindPFlags(defaults); err != nil {
+			return nil, fmt.Errorf("could not bind defaults for %s: %v", key, err)
+		}
+	}
+	if err := sub.MergeConfigMap(v.GetStringMap(key)); err != nil {
+		return nil, fmt.Errorf("could not read %s: %v", key, err)
+	}
+	return sub, nil
+}
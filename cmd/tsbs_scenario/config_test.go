@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+func readScenario(t *testing.T, yaml string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(yaml)); err != nil {
+		t.Fatalf("could not read scenario: %v", err)
+	}
+	return v
+}
+
+func TestParseScenario(t *testing.T) {
+	v := readScenario(t, `
+name: nightly
+output-dir: /tmp/nightly
+dataset:
+  scale: 10
+target:
+  format: timescaledb
+  db-specific:
+    host: db.example.com
+    chunk-time: 1h
+load:
+  workers: 4
+queries:
+  types: [single-groupby-1-1-1, lastpoint]
+  count: 50
+  generator:
+    timescale-use-time-bucket: false
+  db-specific:
+    pass: secret
+`)
+	s, err := parseScenario(v, initializers.GetTarget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.Name; got != "nightly" {
+		t.Errorf("incorrect name: got %s want %s", got, "nightly")
+	}
+	if got := len(s.Phases); got != len(defaultPhases) {
+		t.Errorf("incorrect number of default phases: got %d want %d", got, len(defaultPhases))
+	}
+	if got := s.Dataset.Scale; got != 10 {
+		t.Errorf("incorrect scale: got %d want %d", got, 10)
+	}
+	if got := s.Dataset.Use; got != "cpu-only" {
+		t.Errorf("incorrect default use case: got %s want %s", got, "cpu-only")
+	}
+	if got := s.Load.Workers; got != 4 {
+		t.Errorf("incorrect load workers: got %d want %d", got, 4)
+	}
+	if got := s.Load.BatchSize; got != 10000 {
+		t.Errorf("incorrect default batch size: got %d want %d", got, 10000)
+	}
+	if got := s.Queries.Count; got != 50 {
+		t.Errorf("incorrect query count: got %d want %d", got, 50)
+	}
+	if got := s.targetV.GetString("host"); got != "db.example.com" {
+		t.Errorf("incorrect target host: got %s want %s", got, "db.example.com")
+	}
+	if got := s.targetV.GetString("port"); got != "5432" {
+		t.Errorf("incorrect default target port: got %s want %s", got, "5432")
+	}
+	if got := s.generator.GetBool("timescale-use-time-bucket"); got {
+		t.Errorf("incorrect timescale-use-time-bucket: got %v want %v", got, false)
+	}
+	if got := s.generator.GetBool("timescale-use-tags"); !got {
+		t.Errorf("incorrect default timescale-use-tags: got %v want %v", got, true)
+	}
+	if got := s.queryV.GetString("pass"); got != "secret" {
+		t.Errorf("incorrect query pass: got %s want %s", got, "secret")
+	}
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		yaml string
+	}{
+		{
+			desc: "missing target",
+			yaml: "name: x\n",
+		},
+		{
+			desc: "unknown target",
+			yaml: "target:\n  format: foo\n",
+		},
+		{
+			desc: "unknown phase",
+			yaml: "phases: [load, compact]\ntarget:\n  format: timescaledb\n",
+		},
+		{
+			desc: "query phases without types",
+			yaml: "target:\n  format: timescaledb\n",
+		},
+		{
+			desc: "query runner not supported",
+			yaml: "phases: [run-queries]\ntarget:\n  format: " + constants.FormatCassandra + "\nqueries:\n  types: [lastpoint]\n",
+		},
+		{
+			desc: "query runner not supported for a loadable target",
+			yaml: "phases: [load, run-queries]\ntarget:\n  format: " + constants.FormatPrometheus + "\nqueries:\n  types: [lastpoint]\n",
+		},
+		{
+			desc: "load not supported",
+			yaml: "phases: [load]\ntarget:\n  format: " + constants.FormatClickhouse + "\n",
+		},
+		{
+			desc: "query generation not supported",
+			yaml: "phases: [generate-queries]\ntarget:\n  format: " + constants.FormatOTLP + "\nqueries:\n  types: [lastpoint]\n",
+		},
+		{
+			desc: "maintenance not supported",
+			yaml: "phases: [maintenance]\ntarget:\n  format: " + constants.FormatCassandra + "\nmaintenance:\n  operations: [drop]\n  retention: 1h\n",
+		},
+		{
+			desc: "maintenance not supported by victoriametrics",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: " + constants.FormatVictoriaMetrics + "\nmaintenance:\n  operations: [drop]\n  retention: 1h\n",
+		},
+		{
+			desc: "maintenance without operations",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: timescaledb\n",
+		},
+		{
+			desc: "unknown maintenance operation",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: timescaledb\nmaintenance:\n  operations: [vacuum]\n",
+		},
+		{
+			desc: "drop without retention",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: timescaledb\nmaintenance:\n  operations: [drop]\n",
+		},
+		{
+			desc: "maintenance during unknown phase",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: timescaledb\nmaintenance:\n  operations: [downsample]\n  during: generate-data\n",
+		},
+		{
+			desc: "maintenance during phase not run",
+			yaml: "phases: [load, maintenance]\ntarget:\n  format: timescaledb\nmaintenance:\n  operations: [downsample]\n  during: run-queries\n",
+		},
+	}
+	for _, c := range cases {
+		if _, err := parseScenario(readScenario(t, c.yaml), initializers.GetTarget); err == nil {
+			t.Errorf("%s: unexpected lack of error", c.desc)
+		}
+	}
+
+	// generating only the data does not need queries
+	v := readScenario(t, "phases: [generate-data]\ntarget:\n  format: "+constants.FormatCassandra+"\n")
+	if _, err := parseScenario(v, initializers.GetTarget); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// all phases of a target loaded and queried in-process
+	v = readScenario(t, "target:\n  format: "+constants.FormatInfluxDB3+"\nqueries:\n  types: [lastpoint]\n")
+	if _, err := parseScenario(v, initializers.GetTarget); err != nil {
+		t.Errorf("unexpected error for %s: %v", constants.FormatInfluxDB3, err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs_scenario")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	results := filepath.Join(dir, "run-queries-lastpoint-results.json")
+	contents := `{"Totals":{"overallQueryRates":{"all_queries":125.5},"overallQuantiles":{"all_queries":{"q50":3.5,"q99":9.25}}}}`
+	if err := ioutil.WriteFile(results, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &scenario{}
+	r := &Report{Name: "nightly", Target: "timescaledb", UseCase: "cpu-only", Scale: 10}
+	if err := s.timePhase(r, phaseRunQueries, "lastpoint", results, func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(r.Phases); got != 1 {
+		t.Fatalf("incorrect number of phases: got %d want %d", got, 1)
+	}
+	if r.Phases[0].Totals == nil {
+		t.Errorf("totals of results file were not read")
+	}
+
+	var buf bytes.Buffer
+	if err := r.writeSummary(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "125.50 queries/sec, p50 3.50ms, p99 9.25ms"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("summary does not contain %q:\n%s", want, buf.String())
+	}
+
+	reportFile := filepath.Join(dir, reportFileName)
+	if err := r.writeJSON(reportFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	saved, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(saved, &got); err != nil {
+		t.Fatalf("could not parse report: %v", err)
+	}
+	if got.Name != r.Name || len(got.Phases) != 1 || got.Phases[0].QueryType != "lastpoint" {
+		t.Errorf("incorrect saved report: got %+v want %+v", got, *r)
+	}
+}
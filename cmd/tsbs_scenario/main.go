@@ -0,0 +1,59 @@
+// tsbs_scenario runs a full benchmark cycle described in one YAML file:
+// data generation, loading, query generation and query execution.
+//
+// All phases run in-process with the same building blocks as tsbs_generate_data,
+// tsbs_load, tsbs_generate_queries and the tsbs_run_queries_* programs. The
+// generated files and the results of every phase are written to the output
+// directory, together with a consolidated report.json.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+const reportFileName = "report.json"
+
+// Program option vars:
+var (
+	scenarioFile string
+)
+
+// Parse args:
+func init() {
+	pflag.StringVar(&scenarioFile, "scenario", "scenario.yaml", "YAML file describing the scenario to run")
+	pflag.Parse()
+}
+
+func main() {
+	v := viper.New()
+	v.SetConfigFile(scenarioFile)
+	if err := v.ReadInConfig(); err != nil {
+		log.Fatalf("could not read scenario file %s: %v", scenarioFile, err)
+	}
+	s, err := parseScenario(v, initializers.GetTarget)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, runErr := s.run()
+	if report != nil {
+		reportFile := filepath.Join(s.OutputDir, reportFileName)
+		if err := report.writeJSON(reportFile); err != nil {
+			log.Fatalf("could not write report: %v", err)
+		}
+		if err := report.writeSummary(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Saved scenario report to %s\n", reportFile)
+	}
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// maintenanceRuns records the runs of one maintenance operation
+type maintenanceRuns struct {
+	operation string
+	// starts are the offsets of the runs from the start of the maintenance,
+	// to line them up with the periodic reports of the load or the queries
+	starts    []time.Duration
+	durations []time.Duration
+}
+
+// report returns the PhaseReport of the runs
+func (r *maintenanceRuns) report(during, queryType string) PhaseReport {
+	var total, max time.Duration
+	starts := make([]int64, len(r.starts))
+	for i, d := range r.durations {
+		total += d
+		if d > max {
+			max = d
+		}
+		starts[i] = r.starts[i].Milliseconds()
+	}
+	var mean int64
+	if len(r.durations) > 0 {
+		mean = total.Milliseconds() / int64(len(r.durations))
+	}
+	return PhaseReport{
+		Phase:          phaseMaintenance,
+		QueryType:      queryType,
+		Operation:      r.operation,
+		During:         during,
+		DurationMillis: total.Milliseconds(),
+		Totals: map[string]interface{}{
+			"runs":               len(r.durations),
+			"meanMillis":         mean,
+			"maxMillis":          max.Milliseconds(),
+			"startOffsetsMillis": starts,
+		},
+	}
+}
+
+// openMaintenance returns the Maintenance of the loaded database
+func (s *scenario) openMaintenance() (targets.Maintenance, error) {
+	return s.target.(targets.Maintainer).Maintenance(s.Load.DBName, s.targetV)
+}
+
+// runMaintenance runs every maintenance operation once and adds how long
+// each took to the report
+func (s *scenario) runMaintenance(report *Report) error {
+	fmt.Printf("==> %s\n", phaseMaintenance)
+	m, err := s.openMaintenance()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	start := time.Now()
+	for _, op := range s.Maintenance.Operations {
+		runs := &maintenanceRuns{operation: op}
+		err := s.runOperation(m, runs, start)
+		report.Phases = append(report.Phases, runs.report("", ""))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPhase runs fn as timePhase does, with the maintenance operations
+// repeated alongside it if the maintenance runs during the phase
+func (s *scenario) runPhase(report *Report, phase, queryType, output string, fn func() error) error {
+	if !s.hasPhase(phaseMaintenance) || s.Maintenance.During != phase {
+		return s.timePhase(report, phase, queryType, output, fn)
+	}
+	var maintenance []PhaseReport
+	err := s.timePhase(report, phase, queryType, output, func() error {
+		var err error
+		maintenance, err = s.maintainDuring(phase, queryType, fn)
+		return err
+	})
+	report.Phases = append(report.Phases, maintenance...)
+	return err
+}
+
+// maintainDuring runs the maintenance operations every Maintenance.Interval
+// until fn returns, and returns the reports of the operations
+func (s *scenario) maintainDuring(phase, queryType string, fn func() error) ([]PhaseReport, error) {
+	m, err := s.openMaintenance()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	allRuns := make([]*maintenanceRuns, len(s.Maintenance.Operations))
+	for i, op := range s.Maintenance.Operations {
+		allRuns[i] = &maintenanceRuns{operation: op}
+	}
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- s.repeatMaintenance(m, allRuns, stop)
+	}()
+	err = fn()
+	close(stop)
+	maintenanceErr := <-done
+
+	reports := make([]PhaseReport, len(allRuns))
+	for i, runs := range allRuns {
+		reports[i] = runs.report(phase, queryType)
+	}
+	if err != nil {
+		return reports, err
+	}
+	return reports, maintenanceErr
+}
+
+// repeatMaintenance runs the maintenance operations every
+// Maintenance.Interval until stop is closed
+func (s *scenario) repeatMaintenance(m targets.Maintenance, allRuns []*maintenanceRuns, stop chan struct{}) error {
+	start := time.Now()
+	ticker := time.NewTicker(s.Maintenance.Interval)
+	defer ticker.Stop()
+	for {
+		for _, runs := range allRuns {
+			if err := s.runOperation(m, runs, start); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOperation runs a maintenance operation once, recording the run. Data is
+// dropped up to maintenance.retention before the end of the dataset, and the
+// whole dataset is downsampled.
+func (s *scenario) runOperation(m targets.Maintenance, runs *maintenanceRuns, start time.Time) error {
+	timeStart, err := time.Parse(time.RFC3339, s.Dataset.TimeStart)
+	if err != nil {
+		return fmt.Errorf("invalid dataset.timestamp-start: %v", err)
+	}
+	timeEnd, err := time.Parse(time.RFC3339, s.Dataset.TimeEnd)
+	if err != nil {
+		return fmt.Errorf("invalid dataset.timestamp-end: %v", err)
+	}
+
+	runStart := time.Now()
+	switch runs.operation {
+	case maintenanceDrop:
+		err = m.DropBefore(timeEnd.Add(-s.Maintenance.Retention))
+	case maintenanceDownsample:
+		err = m.Downsample(timeStart, timeEnd)
+	}
+	if errors.Is(err, targets.ErrMaintenanceNotSupported) {
+		return fmt.Errorf("maintenance operation %s is not supported by target %s", runs.operation, s.Target.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("maintenance operation %s failed: %v", runs.operation, err)
+	}
+	runs.starts = append(runs.starts, runStart.Sub(start))
+	runs.durations = append(runs.durations, time.Since(runStart))
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+// testMaintenance records the maintenance operations it runs
+type testMaintenance struct {
+	mu          sync.Mutex
+	dropped     []time.Time
+	downsampled [][2]time.Time
+	closed      bool
+	err         error
+}
+
+func (m *testMaintenance) DropBefore(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped = append(m.dropped, t)
+	return m.err
+}
+
+func (m *testMaintenance) Downsample(start, end time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.downsampled = append(m.downsampled, [2]time.Time{start, end})
+	return m.err
+}
+
+func (m *testMaintenance) Close() error {
+	m.closed = true
+	return nil
+}
+
+// testMaintainer is a target returning a testMaintenance
+type testMaintainer struct {
+	targets.ImplementedTarget
+	m *testMaintenance
+}
+
+func (t *testMaintainer) Maintenance(string, *viper.Viper) (targets.Maintenance, error) {
+	return t.m, nil
+}
+
+func parseMaintenanceScenario(t *testing.T, yaml string, m *testMaintenance) *scenario {
+	getTarget := func(format string) targets.ImplementedTarget {
+		return &testMaintainer{ImplementedTarget: initializers.GetTarget(format), m: m}
+	}
+	s, err := parseScenario(readScenario(t, yaml), getTarget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestRunMaintenance(t *testing.T) {
+	m := &testMaintenance{}
+	s := parseMaintenanceScenario(t, `
+phases: [maintenance]
+target:
+  format: timescaledb
+maintenance:
+  operations: [drop, downsample]
+  retention: 6h
+`, m)
+	r := &Report{}
+	if err := s.runMaintenance(r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := time.Date(2016, 1, 1, 18, 0, 0, 0, time.UTC)
+	if len(m.dropped) != 1 || !m.dropped[0].Equal(want) {
+		t.Errorf("incorrect drops: got %v want [%v]", m.dropped, want)
+	}
+	if len(m.downsampled) != 1 || m.downsampled[0][0].Hour() != 0 || m.downsampled[0][1].Day() != 2 {
+		t.Errorf("incorrect downsampling: got %v", m.downsampled)
+	}
+	if !m.closed {
+		t.Errorf("maintenance was not closed")
+	}
+	if got := len(r.Phases); got != 2 {
+		t.Fatalf("incorrect number of reports: got %d want %d", got, 2)
+	}
+	for i, op := range []string{maintenanceDrop, maintenanceDownsample} {
+		p := r.Phases[i]
+		if p.Phase != phaseMaintenance || p.Operation != op || p.Totals["runs"] != 1 {
+			t.Errorf("incorrect report of %s: got %+v", op, p)
+		}
+	}
+
+	m.err = targets.ErrMaintenanceNotSupported
+	if err := s.runMaintenance(&Report{}); err == nil {
+		t.Errorf("unexpected lack of error for an unsupported operation")
+	}
+}
+
+func TestRunPhaseWithMaintenance(t *testing.T) {
+	m := &testMaintenance{}
+	s := parseMaintenanceScenario(t, `
+phases: [load, maintenance]
+target:
+  format: timescaledb
+maintenance:
+  operations: [drop]
+  retention: 1h
+  during: load
+  interval: 1ms
+`, m)
+	r := &Report{}
+	load := func() error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	}
+	// the load does not write a results file
+	if err := s.runPhase(r, phaseLoad, "", "", load); err == nil {
+		t.Fatalf("unexpected lack of error for a missing results file")
+	}
+	if got := len(r.Phases); got != 2 {
+		t.Fatalf("incorrect number of reports: got %d want %d", got, 2)
+	}
+	p := r.Phases[1]
+	if p.Phase != phaseMaintenance || p.During != phaseLoad {
+		t.Errorf("incorrect maintenance report: got %+v", p)
+	}
+	if runs := p.Totals["runs"].(int); runs < 2 || runs != len(m.dropped) {
+		t.Errorf("incorrect number of runs: got %d, %d drops", runs, len(m.dropped))
+	}
+
+	// the operations do not run alongside the other phases
+	m.dropped = nil
+	r = &Report{}
+	if err := s.runPhase(r, phaseGenerateData, "", "", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.Phases) != 1 || len(m.dropped) != 0 {
+		t.Errorf("maintenance ran during %s", phaseGenerateData)
+	}
+
+	m.err = errors.New("drop failed")
+	err := s.runPhase(&Report{}, phaseLoad, "", "", func() error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "drop failed") {
+		t.Errorf("incorrect error for a failed operation: got %v", err)
+	}
+}
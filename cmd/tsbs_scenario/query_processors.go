@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/query/processors/influxdb3"
+	"github.com/timescale/tsbs/pkg/query/processors/timescaledb"
+	"github.com/timescale/tsbs/pkg/query/processors/victoriametrics"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// queryProcessorFactory creates the query.Processor of a target from the
+// target's db-specific settings and the queries.db-specific settings, which
+// are the flags of the target's tsbs_run_queries_* program
+type queryProcessorFactory struct {
+	pool         *sync.Pool
+	newProcessor func(dbName string, target, queries *viper.Viper) query.ProcessorCreate
+}
+
+// queryProcessors lists the targets whose queries can be run in-process
+var queryProcessors = map[string]queryProcessorFactory{
+	constants.FormatTimescaleDB:     {pool: &query.TimescaleDBPool, newProcessor: newTimescaleProcessor},
+	constants.FormatVictoriaMetrics: {pool: &query.HTTPPool, newProcessor: newVictoriaMetricsProcessor},
+	constants.FormatInfluxDB3:       {pool: &query.InfluxDB3Pool, newProcessor: newInfluxDB3Processor},
+}
+
+// newTimescaleProcessor connects with the same settings that were used for
+// loading, queries.db-specific can override them
+func newTimescaleProcessor(dbName string, target, queries *viper.Viper) query.ProcessorCreate {
+	get := func(queryKey, targetKey string) string {
+		if queries.IsSet(queryKey) {
+			return queries.GetString(queryKey)
+		}
+		return target.GetString(targetKey)
+	}
+	forceTextFormat := target.GetBool("force-text-format")
+	if queries.IsSet("force-text-format") {
+		forceTextFormat = queries.GetBool("force-text-format")
+	}
+	return timescaledb.NewProcessorCreate(dbName, &timescaledb.Options{
+		Postgres:        get("postgres", "postgres"),
+		Hosts:           strings.Split(get("hosts", "host"), ","),
+		User:            get("user", "user"),
+		Pass:            get("pass", "pass"),
+		Port:            get("port", "port"),
+		ShowExplain:     queries.GetBool("show-explain"),
+		ForceTextFormat: forceTextFormat,
+		Debug:           queries.GetInt("debug") > 0,
+		PrintResponses:  queries.GetBool("print-responses"),
+	})
+}
+
+const defaultQueryURLs = "http://localhost:8428"
+
+// newVictoriaMetricsProcessor queries queries.db-specific.urls round robin
+func newVictoriaMetricsProcessor(_ string, _, queries *viper.Viper) query.ProcessorCreate {
+	urls := queries.GetString("urls")
+	if urls == "" {
+		urls = defaultQueryURLs
+	}
+	return victoriametrics.NewProcessorCreate(&victoriametrics.Options{
+		URLs:           strings.Split(urls, ","),
+		PrintResponses: queries.GetBool("print-responses"),
+	})
+}
+
+// newInfluxDB3Processor queries the first URL and the database the data was
+// loaded to with the loading credentials, queries.db-specific can override
+// them with the flags of tsbs_run_queries_influxdb3
+func newInfluxDB3Processor(dbName string, target, queries *viper.Viper) query.ProcessorCreate {
+	get := func(key string) string {
+		if queries.IsSet(key) {
+			return queries.GetString(key)
+		}
+		return target.GetString(key)
+	}
+	host := strings.Split(target.GetString("urls"), ",")[0]
+	if queries.IsSet("host") {
+		host = queries.GetString("host") + ":" + queries.GetString("port")
+	}
+	database := dbName
+	if queries.IsSet("database") {
+		database = queries.GetString("database")
+	}
+	return influxdb3.NewProcessorCreate(&influxdb3.Options{
+		Host:           host,
+		Token:          get("token"),
+		Bucket:         queries.GetString("bucket"),
+		Database:       database,
+		Bearer:         get("bearer"),
+		Secure:         queries.GetBool("secure"),
+		FlightSQL:      queries.GetBool("flightsql"),
+		PrintResponses: queries.GetBool("print-responses"),
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+)
+
+// Report is the consolidated outcome of all phases of a scenario
+type Report struct {
+	Name    string        `json:"name"`
+	Target  string        `json:"target"`
+	UseCase string        `json:"use-case"`
+	Scale   uint64        `json:"scale"`
+	Phases  []PhaseReport `json:"phases"`
+}
+
+// PhaseReport holds the outcome of one phase. Load and query phases include
+// the totals of the results file written by the benchmark runner. The
+// maintenance phase has a report per operation, with the totals of its runs.
+type PhaseReport struct {
+	Phase          string                 `json:"phase"`
+	QueryType      string                 `json:"query-type,omitempty"`
+	Operation      string                 `json:"operation,omitempty"`
+	During         string                 `json:"during,omitempty"`
+	DurationMillis int64                  `json:"duration-millis"`
+	Output         string                 `json:"output"`
+	Totals         map[string]interface{} `json:"totals,omitempty"`
+}
+
+// writeJSON saves the report to the given file
+func (r *Report) writeJSON(fileName string) error {
+	contents, err := json.MarshalIndent(r, "", " ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, contents, 0644)
+}
+
+// writeSummary writes a human readable summary of the report to w
+func (r *Report) writeSummary(w io.Writer) error {
+	fmt.Fprintf(w, "\nScenario %s (target %s, use case %s, scale %d):\n", r.Name, r.Target, r.UseCase, r.Scale)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "phase\tquery type\ttook\tresult")
+	for _, p := range r.Phases {
+		took := (time.Duration(p.DurationMillis) * time.Millisecond).String()
+		queryType := p.QueryType
+		if queryType == "" {
+			queryType = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Phase, queryType, took, p.summary())
+	}
+	return tw.Flush()
+}
+
+// summary returns the headline numbers of a phase
+func (p *PhaseReport) summary() string {
+	switch p.Phase {
+	case phaseLoad:
+		if rate, ok := p.Totals["metricRate"].(float64); ok {
+			return fmt.Sprintf("%0.2f metrics/sec", rate)
+		}
+	case phaseRunQueries:
+		rates, _ := p.Totals["overallQueryRates"].(map[string]interface{})
+		quantiles, _ := p.Totals["overallQuantiles"].(map[string]interface{})
+		rate, _ := rates["all_queries"].(float64)
+		all, _ := quantiles["all_queries"].(map[string]interface{})
+		q50, _ := all["q50"].(float64)
+		q99, _ := all["q99"].(float64)
+		return fmt.Sprintf("%0.2f queries/sec, p50 %0.2fms, p99 %0.2fms", rate, q50, q99)
+	case phaseMaintenance:
+		summary := fmt.Sprintf("%s: %v runs, mean %vms, max %vms",
+			p.Operation, p.Totals["runs"], p.Totals["meanMillis"], p.Totals["maxMillis"])
+		if p.During != "" {
+			summary += " during " + p.During
+		}
+		return summary
+	}
+	return p.Output
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses"
+	"github.com/timescale/tsbs/internal/compress"
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/query/config"
+)
+
+// run executes the configured phases in order and returns the consolidated report
+func (s *scenario) run() (*Report, error) {
+	if err := os.MkdirAll(s.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create output dir %s: %v", s.OutputDir, err)
+	}
+	report := &Report{
+		Name:    s.Name,
+		Target:  s.Target.Format,
+		UseCase: s.Dataset.Use,
+		Scale:   s.Dataset.Scale,
+	}
+
+	for _, phase := range allPhases {
+		if !s.hasPhase(phase) {
+			continue
+		}
+		var err error
+		switch phase {
+		case phaseGenerateData:
+			err = s.timePhase(report, phase, "", s.dataFile(), s.generateData)
+		case phaseLoad:
+			err = s.runPhase(report, phase, "", s.resultsFile(phase, ""), s.load)
+		case phaseMaintenance:
+			// otherwise the maintenance runs alongside another phase
+			if s.Maintenance.During == "" {
+				err = s.runMaintenance(report)
+			}
+		case phaseGenerateQueries:
+			for _, qt := range s.Queries.Types {
+				qt := qt
+				generate := func() error { return s.generateQueries(qt) }
+				if err = s.timePhase(report, phase, qt, s.queriesFile(qt), generate); err != nil {
+					break
+				}
+			}
+		case phaseRunQueries:
+			for _, qt := range s.Queries.Types {
+				qt := qt
+				run := func() error { return s.runQueries(qt) }
+				if err = s.runPhase(report, phase, qt, s.resultsFile(phase, qt), run); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return report, fmt.Errorf("phase %s failed: %v", phase, err)
+		}
+	}
+	return report, nil
+}
+
+// timePhase runs fn and adds how long it took to the report. If output is
+// a results file of a benchmark runner, its totals are added as well.
+func (s *scenario) timePhase(report *Report, phase, queryType, output string, fn func() error) error {
+	fmt.Printf("==> %s %s\n", phase, queryType)
+	start := time.Now()
+	err := fn()
+	r := PhaseReport{
+		Phase:          phase,
+		QueryType:      queryType,
+		DurationMillis: time.Since(start).Milliseconds(),
+		Output:         output,
+	}
+	if err == nil && (phase == phaseLoad || phase == phaseRunQueries) {
+		r.Totals, err = readTotals(output)
+	}
+	report.Phases = append(report.Phases, r)
+	return err
+}
+
+func (s *scenario) dataFile() string {
+	name := fmt.Sprintf("%s-%s-data.dat", s.Target.Format, s.Dataset.Use)
+	return filepath.Join(s.OutputDir, name+s.fileExtension())
+}
+
+func (s *scenario) queriesFile(queryType string) string {
+	name := fmt.Sprintf("%s-%s-queries.dat", s.Target.Format, queryType)
+	return filepath.Join(s.OutputDir, name+s.fileExtension())
+}
+
+// fileExtension returns the extension of the compressed generated files
+func (s *scenario) fileExtension() string {
+	c, _ := compress.ParseCodec(s.Dataset.Compression)
+	return c.Extension()
+}
+
+func (s *scenario) resultsFile(phase, queryType string) string {
+	name := phase
+	if queryType != "" {
+		name += "-" + queryType
+	}
+	return filepath.Join(s.OutputDir, name+"-results.json")
+}
+
+func (s *scenario) baseConfig(file string) common.BaseConfig {
+	return common.BaseConfig{
+		Format:      s.Target.Format,
+		Use:         s.Dataset.Use,
+		Scale:       s.Dataset.Scale,
+		TimeStart:   s.Dataset.TimeStart,
+		TimeEnd:     s.Dataset.TimeEnd,
+		Seed:        s.Dataset.Seed,
+		File:        file,
+		Compression: s.Dataset.Compression,
+	}
+}
+
+func (s *scenario) generateData() error {
+	conf := &common.DataGeneratorConfig{
+		BaseConfig:            s.baseConfig(s.dataFile()),
+		Limit:                 s.Dataset.Limit,
+		LogInterval:           s.Dataset.LogInterval,
+		InterleavedNumGroups:  1,
+		MaxMetricCountPerHost: s.Dataset.MaxMetricCount,
+	}
+	dg := &inputs.DataGenerator{}
+	return dg.Generate(conf, s.target)
+}
+
+func (s *scenario) load() error {
+	bench, err := s.target.Benchmark(s.Load.DBName, &source.DataSourceConfig{
+		Type: source.FileDataSourceType,
+		File: &source.FileDataSourceConfig{Location: s.dataFile()},
+	}, s.targetV)
+	if err != nil {
+		return err
+	}
+	runner := load.GetBenchmarkRunner(load.BenchmarkRunnerConfig{
+		DBName:          s.Load.DBName,
+		BatchSize:       s.Load.BatchSize,
+		Workers:         s.Load.Workers,
+		Limit:           s.Load.Limit,
+		DoLoad:          true,
+		DoCreateDB:      s.Load.DoCreateDB,
+		DoAbortOnExist:  s.Load.DoAbortOnExist,
+		ReportingPeriod: s.Load.ReportingPeriod,
+		Seed:            s.Dataset.Seed,
+		HashWorkers:     s.Load.HashWorkers,
+		InsertIntervals: s.Load.InsertIntervals,
+		InsertRate:      s.Load.InsertRate,
+		NoFlowControl:   !s.Load.FlowControl,
+		ChannelCapacity: s.Load.ChannelCapacity,
+		ResultsFile:     s.resultsFile(phaseLoad, ""),
+	})
+	runner.RunBenchmark(bench)
+	return nil
+}
+
+func queryGeneratorFlags() *pflag.FlagSet {
+	fs := pflag.NewFlagSet("", pflag.ContinueOnError)
+	(&config.QueryGeneratorConfig{}).AddToFlagSet(fs)
+	return fs
+}
+
+func (s *scenario) generateQueries(queryType string) error {
+	conf := &config.QueryGeneratorConfig{}
+	if err := s.generator.Unmarshal(conf); err != nil {
+		return fmt.Errorf("could not parse queries.generator: %v", err)
+	}
+	conf.BaseConfig = s.baseConfig(s.queriesFile(queryType))
+	conf.Limit = s.Queries.Count
+	conf.QueryType = queryType
+	conf.InterleavedGroupID = 0
+	conf.InterleavedNumGroups = 1
+	conf.DbName = s.Load.DBName
+
+	qg := inputs.NewQueryGenerator(uses.UseCaseMatrix())
+	return qg.Generate(conf)
+}
+
+func (s *scenario) runQueries(queryType string) error {
+	qp := queryProcessors[s.Target.Format]
+	var limit uint64
+	if s.queryV.GetBool("show-explain") {
+		// as tsbs_run_queries_timescaledb, print the plan of a single query
+		limit = 1
+	}
+	runner := query.NewBenchmarkRunner(query.BenchmarkRunnerConfig{
+		DBName:         s.Load.DBName,
+		Limit:          limit,
+		Workers:        s.Queries.Workers,
+		BurnIn:         s.Queries.BurnIn,
+		LimitRPS:       s.Queries.MaxRPS,
+		PrewarmQueries: s.Queries.PrewarmQueries,
+		FileName:       s.queriesFile(queryType),
+		ResultsFile:    s.resultsFile(phaseRunQueries, queryType),
+	})
+	runner.Run(qp.pool, qp.newProcessor(s.Load.DBName, s.targetV, s.queryV))
+	return nil
+}
+
+// readTotals reads the totals from a results file written by a benchmark runner
+func readTotals(resultsFile string) (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(resultsFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read results file %s: %v", resultsFile, err)
+	}
+	var result struct {
+		Totals map[string]interface{} `json:"Totals"`
+	}
+	if err := json.Unmarshal(contents, &result); err != nil {
+		return nil, fmt.Errorf("could not parse results file %s: %v", resultsFile, err)
+	}
+	return result.Totals, nil
+}
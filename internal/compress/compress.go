@@ -0,0 +1,179 @@
+// Package compress wraps the readers and writers of generated data and query
+// files with gzip, zstd or lz4 compression.
+//
+// The codec of an input is detected by the file extension, falling back to
+// the magic bytes at the start of the stream, so compressed files can also be
+// piped through STDIN. Decompression runs in its own goroutines so it does not
+// become the bottleneck when the loaders are measuring high ingestion rates.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Codec is a compression format of a data or query file
+type Codec string
+
+// Supported codecs
+const (
+	// Auto selects the codec by the file extension, or the magic bytes of the
+	// stream when reading
+	Auto Codec = ""
+	None Codec = "none"
+	Gzip Codec = "gzip"
+	Zstd Codec = "zstd"
+	LZ4  Codec = "lz4"
+)
+
+const errUnknownCodecFmt = "unknown compression '%s', valid: %s"
+
+// Codecs lists the valid values of a compression flag
+var Codecs = []string{string(None), string(Gzip), string(Zstd), string(LZ4)}
+
+var extensions = map[string]Codec{
+	".gz":   Gzip,
+	".gzip": Gzip,
+	".zst":  Zstd,
+	".zstd": Zstd,
+	".lz4":  LZ4,
+}
+
+var magicBytes = map[Codec][]byte{
+	Gzip: {0x1f, 0x8b},
+	Zstd: {0x28, 0xb5, 0x2f, 0xfd},
+	LZ4:  {0x04, 0x22, 0x4d, 0x18},
+}
+
+// ParseCodec returns the codec with the given name. An empty name or "auto"
+// returns Auto.
+func ParseCodec(name string) (Codec, error) {
+	switch c := Codec(strings.ToLower(name)); c {
+	case Auto, "auto":
+		return Auto, nil
+	case None, Gzip, Zstd, LZ4:
+		return c, nil
+	}
+	return None, fmt.Errorf(errUnknownCodecFmt, name, strings.Join(Codecs, ", "))
+}
+
+// FromFileName returns the codec matching the extension of fileName, or None
+func FromFileName(fileName string) Codec {
+	if c, ok := extensions[strings.ToLower(filepath.Ext(fileName))]; ok {
+		return c
+	}
+	return None
+}
+
+// Extension returns the file extension of codec c, empty for None
+func (c Codec) Extension() string {
+	switch c {
+	case Gzip:
+		return ".gz"
+	case Zstd:
+		return ".zst"
+	case LZ4:
+		return ".lz4"
+	}
+	return ""
+}
+
+// Detect returns the codec whose magic bytes start the buffered stream,
+// or None. The stream is not advanced.
+func Detect(br *bufio.Reader) Codec {
+	for c, magic := range magicBytes {
+		head, err := br.Peek(len(magic))
+		if err == nil && bytes.Equal(head, magic) {
+			return c
+		}
+	}
+	return None
+}
+
+// NewWriter returns a writer compressing to w with codec c. Closing it
+// flushes the compressed stream, but does not close w.
+func NewWriter(w io.Writer, c Codec) (io.WriteCloser, error) {
+	switch c {
+	case Auto, None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	case LZ4:
+		lw := lz4.NewWriter(w)
+		if err := lw.Apply(lz4.ConcurrencyOption(0)); err != nil {
+			return nil, err
+		}
+		return lw, nil
+	}
+	return nil, fmt.Errorf(errUnknownCodecFmt, c, strings.Join(Codecs, ", "))
+}
+
+// NewReader returns a reader decompressing r with codec c. zstd and lz4
+// decode blocks concurrently, gzip streams are inherently sequential so they
+// are decompressed ahead of the reader in a separate goroutine.
+func NewReader(r io.Reader, c Codec) (io.ReadCloser, error) {
+	switch c {
+	case Auto, None:
+		return io.NopCloser(r), nil
+	case Gzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return newReadAhead(gr), nil
+	case Zstd:
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(0))
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case LZ4:
+		lr := lz4.NewReader(r)
+		if err := lr.Apply(lz4.ConcurrencyOption(0)); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(lr), nil
+	}
+	return nil, fmt.Errorf(errUnknownCodecFmt, c, strings.Join(Codecs, ", "))
+}
+
+// NewAutoReader returns a reader decompressing r with the codec matching
+// fileName or, when the extension is not a known one, the magic bytes of r.
+// fileName may be empty when reading from STDIN.
+func NewAutoReader(r io.Reader, fileName string) (io.ReadCloser, error) {
+	c := FromFileName(fileName)
+	if c == None {
+		br := bufio.NewReader(r)
+		c, r = Detect(br), br
+	}
+	return NewReader(r, c)
+}
+
+// WriterCodec returns the codec to use when writing to fileName with the
+// given compression flag value
+func WriterCodec(compression, fileName string) (Codec, error) {
+	c, err := ParseCodec(compression)
+	if err != nil {
+		return None, err
+	}
+	if c == Auto {
+		return FromFileName(fileName), nil
+	}
+	return c, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
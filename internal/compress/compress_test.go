@@ -0,0 +1,131 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestParseCodec(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Codec
+	}{
+		{in: "", want: Auto},
+		{in: "auto", want: Auto},
+		{in: "none", want: None},
+		{in: "GZIP", want: Gzip},
+		{in: "zstd", want: Zstd},
+		{in: "lz4", want: LZ4},
+	}
+	for _, c := range cases {
+		got, err := ParseCodec(c.in)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.in, err)
+		} else if got != c.want {
+			t.Errorf("%s: incorrect codec: got %s want %s", c.in, got, c.want)
+		}
+	}
+	if _, err := ParseCodec("bzip2"); err == nil {
+		t.Errorf("unexpected lack of error")
+	}
+}
+
+func TestFromFileName(t *testing.T) {
+	cases := map[string]Codec{
+		"data.gz":        Gzip,
+		"data.GZ":        Gzip,
+		"/tmp/data.zst":  Zstd,
+		"queries.lz4":    LZ4,
+		"data.influx":    None,
+		"":               None,
+		"data.zst.dummy": None,
+	}
+	for name, want := range cases {
+		if got := FromFileName(name); got != want {
+			t.Errorf("%s: incorrect codec: got %s want %s", name, got, want)
+		}
+	}
+}
+
+func TestWriterCodec(t *testing.T) {
+	if got, _ := WriterCodec("", "data.zst"); got != Zstd {
+		t.Errorf("incorrect codec by extension: got %s want %s", got, Zstd)
+	}
+	if got, _ := WriterCodec("gzip", "data.zst"); got != Gzip {
+		t.Errorf("incorrect codec by flag: got %s want %s", got, Gzip)
+	}
+	if got, _ := WriterCodec("", ""); got != None {
+		t.Errorf("incorrect codec for STDOUT: got %s want %s", got, None)
+	}
+	if _, err := WriterCodec("rar", "data"); err == nil {
+		t.Errorf("unexpected lack of error")
+	}
+}
+
+// testData is large enough to span several read-ahead chunks
+func testData() []byte {
+	line := "cpu,hostname=host_0,region=eu-west-1 usage_user=58i,usage_system=2i 1451606400000000000\n"
+	return []byte(strings.Repeat(line, (3*readAheadChunkSize)/len(line)+1))
+}
+
+func compressed(t *testing.T, c Codec, data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, c)
+	if err != nil {
+		t.Fatalf("%s: could not create writer: %v", c, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("%s: could not write: %v", c, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("%s: could not close writer: %v", c, err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	data := testData()
+	for _, c := range []Codec{None, Gzip, Zstd, LZ4} {
+		enc := compressed(t, c, data)
+		if c != None && len(enc) >= len(data) {
+			t.Errorf("%s: output is not compressed: %d bytes for %d bytes input", c, len(enc), len(data))
+		}
+
+		if got := Detect(bufio.NewReader(bytes.NewReader(enc))); got != c {
+			t.Errorf("%s: incorrect detected codec: got %s want %s", c, got, c)
+		}
+
+		// no extension, the codec is detected by the magic bytes
+		r, err := NewAutoReader(bytes.NewReader(enc), "")
+		if err != nil {
+			t.Fatalf("%s: could not create reader: %v", c, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: could not read: %v", c, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: incorrect decompressed data: got %d bytes want %d bytes", c, len(got), len(data))
+		}
+		if err := r.Close(); err != nil {
+			t.Errorf("%s: unexpected error on close: %v", c, err)
+		}
+	}
+}
+
+func TestReadAheadClosedEarly(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(compressed(t, Gzip, testData())), Gzip)
+	if err != nil {
+		t.Fatalf("could not create reader: %v", err)
+	}
+	buf := make([]byte, 10)
+	if _, err := r.Read(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected error on close: %v", err)
+	}
+}
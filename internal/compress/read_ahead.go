@@ -0,0 +1,91 @@
+package compress
+
+import (
+	"io"
+)
+
+const (
+	readAheadChunkSize = 1 << 20 // 1 MB
+	readAheadChunks    = 8
+)
+
+type chunk struct {
+	buf []byte
+	err error
+}
+
+// readAhead reads src into a fixed set of buffers in its own goroutine, so
+// the work done by src (e.g. decompression) overlaps with the consumer
+type readAhead struct {
+	src  io.ReadCloser
+	full chan chunk
+	free chan []byte
+	stop chan struct{}
+	cur  chunk
+	off  int
+}
+
+func newReadAhead(src io.ReadCloser) *readAhead {
+	r := &readAhead{
+		src:  src,
+		full: make(chan chunk, readAheadChunks),
+		free: make(chan []byte, readAheadChunks),
+		stop: make(chan struct{}),
+	}
+	for i := 0; i < readAheadChunks; i++ {
+		r.free <- make([]byte, readAheadChunkSize)
+	}
+	go r.fill()
+	return r
+}
+
+// fill owns src, it is closed once fill is done
+func (r *readAhead) fill() {
+	defer r.src.Close()
+	defer close(r.full)
+	for {
+		var buf []byte
+		select {
+		case buf = <-r.free:
+		case <-r.stop:
+			return
+		}
+		n, err := io.ReadFull(r.src, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		select {
+		case r.full <- chunk{buf: buf[:n], err: err}:
+		case <-r.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *readAhead) Read(p []byte) (int, error) {
+	for r.off == len(r.cur.buf) {
+		if r.cur.err != nil {
+			return 0, r.cur.err
+		}
+		if r.cur.buf != nil {
+			r.free <- r.cur.buf[:cap(r.cur.buf)]
+		}
+		c, ok := <-r.full
+		if !ok {
+			return 0, io.EOF
+		}
+		r.cur, r.off = c, 0
+	}
+	n := copy(p, r.cur.buf[r.off:])
+	r.off += n
+	return n, nil
+}
+
+// Close stops reading ahead, src is closed in the background
+func (r *readAhead) Close() error {
+	close(r.stop)
+	return nil
+}
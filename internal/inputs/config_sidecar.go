@@ -0,0 +1,44 @@
+package inputs
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigSidecarSuffix is appended to the name of a generated data file to get
+// the name of the file recording the config it was generated with
+const ConfigSidecarSuffix = ".config.yaml"
+
+// ConfigSidecarFile returns the name of the config file of a data file
+func ConfigSidecarFile(dataFile string) string {
+	return dataFile + ConfigSidecarSuffix
+}
+
+// WriteConfigSidecar records the config a data file was generated with next to
+// the data file, so the file can be verified later without the original flags
+func WriteConfigSidecar(dataFile string, config *common.DataGeneratorConfig) error {
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("cannot encode data generator config: %v", err)
+	}
+	if err := ioutil.WriteFile(ConfigSidecarFile(dataFile), out, 0644); err != nil {
+		return fmt.Errorf("cannot write data generator config: %v", err)
+	}
+	return nil
+}
+
+// ReadConfigSidecar reads the config file written by WriteConfigSidecar
+func ReadConfigSidecar(fileName string) (*common.DataGeneratorConfig, error) {
+	in, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read data generator config: %v", err)
+	}
+	config := &common.DataGeneratorConfig{}
+	if err := yaml.Unmarshal(in, config); err != nil {
+		return nil, fmt.Errorf("cannot decode data generator config %s: %v", fileName, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,92 @@
+package inputs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func TestConfigSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-sidecar")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dataFile := filepath.Join(dir, "data.gz")
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:        123,
+			Format:      constants.FormatTimescaleDB,
+			Use:         common.UseCaseCPUOnly,
+			Scale:       10,
+			TimeStart:   defaultTimeStart,
+			TimeEnd:     defaultTimeEnd,
+			File:        dataFile,
+			Compression: "gzip",
+		},
+		InitialScale:         10,
+		LogInterval:          defaultLogInterval,
+		InterleavedNumGroups: 1,
+	}
+	if err := WriteConfigSidecar(dataFile, c); err != nil {
+		t.Fatalf("unexpected error writing sidecar: %v", err)
+	}
+	if _, err := os.Stat(dataFile + ConfigSidecarSuffix); err != nil {
+		t.Fatalf("sidecar not written next to the data file: %v", err)
+	}
+
+	got, err := ReadConfigSidecar(ConfigSidecarFile(dataFile))
+	if err != nil {
+		t.Fatalf("unexpected error reading sidecar: %v", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("incorrect config read back: got %+v want %+v", got, c)
+	}
+
+	if _, err := ReadConfigSidecar(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected error reading a missing sidecar")
+	}
+}
+
+func TestDataGeneratorGenerateWritesSidecar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-sidecar")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Format:    constants.FormatTimescaleDB,
+			Use:       common.UseCaseCPUOnly,
+			Scale:     1,
+			TimeStart: defaultTimeStart,
+			TimeEnd:   defaultTimeEnd,
+			File:      filepath.Join(dir, "data"),
+		},
+		Limit:                3,
+		InitialScale:         1,
+		LogInterval:          time.Second,
+		InterleavedNumGroups: 1,
+	}
+	dg := &DataGenerator{}
+	target := &mockTarget{name: c.Format, serializer: &mockSerializer{}}
+	if err := dg.Generate(c, target); err != nil {
+		t.Fatalf("unexpected error when generating: %v", err)
+	}
+	got, err := ReadConfigSidecar(ConfigSidecarFile(c.File))
+	if err != nil {
+		t.Fatalf("unexpected error reading sidecar: %v", err)
+	}
+	if got.Seed != c.Seed || got.Limit != c.Limit || got.TimeEnd != c.TimeEnd {
+		t.Errorf("incorrect config recorded: got %+v want %+v", got, c)
+	}
+}
@@ -0,0 +1,181 @@
+package inputs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// maxSchemaSamples is the number of simulated points inspected to find the
+// types of the fields of a use case
+const maxSchemaSamples = 1000000
+
+// DataConverterConfig is the config of a conversion of a data file between
+// the formats of two targets.
+type DataConverterConfig struct {
+	// File is the data file to convert, stdin if empty, in the format of
+	// the target it is parsed with
+	File string
+	// Generator is the config the data file was generated with
+	Generator *common.DataGeneratorConfig
+	// Output is the file to write the converted data to, stdout if empty.
+	// Compression is its codec, by default the one of its extension.
+	Output      string
+	Compression string
+}
+
+// DataConverter converts a data file serialized for one target into the
+// format of another target, without simulating the data again. The points
+// keep their exact timestamps, tags and values.
+type DataConverter struct {
+	// Out is the writer where data should be written. If nil, it will be
+	// os.Stdout unless Output is specified in the DataConverterConfig.
+	Out io.Writer
+
+	bufOut   *bufio.Writer
+	closeOut io.Closer
+}
+
+// Convert parses the points of the data file with from and serializes them
+// for to. It returns the number of converted points. When writing to a file
+// or directory, the generator config is recorded next to it with the format
+// of to.
+func (c *DataConverter) Convert(config *DataConverterConfig, from targets.PointParser, to targets.ImplementedTarget) (uint64, error) {
+	if config.Generator == nil {
+		return 0, fmt.Errorf(ErrNoConfig)
+	}
+	schema, err := NewDataSchema(config.Generator)
+	if err != nil {
+		return 0, err
+	}
+
+	var points uint64
+	if _, ok := to.(targets.DirectoryWriter); ok {
+		points, err = c.convertToDirectory(config, from, schema, to)
+	} else {
+		points, err = c.convertToStream(config, from, schema, to)
+	}
+	if err != nil {
+		return points, err
+	}
+	if len(config.Output) > 0 {
+		converted := *config.Generator
+		converted.Format = to.TargetName()
+		converted.File = config.Output
+		converted.Compression = config.Compression
+		return points, WriteConfigSidecar(config.Output, &converted)
+	}
+	return points, nil
+}
+
+// convertToStream serializes the points to the output file or writer
+func (c *DataConverter) convertToStream(config *DataConverterConfig, from targets.PointParser, schema *targets.DataSchema, to targets.ImplementedTarget) (points uint64, err error) {
+	if c.Out == nil {
+		c.Out = os.Stdout
+	}
+	c.bufOut, c.closeOut, err = getBufferedWriter(config.Output, config.Compression, c.Out)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if closeErr := flushAndClose(c.bufOut, c.closeOut); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if writesHeaders(to.TargetName()) {
+		writeHeader(c.bufOut, schema.Headers)
+	}
+	serializer := to.Serializer()
+	return c.convert(from.FileDataSource(config.File), from, schema, func(p *data.Point) error {
+		return serializer.Serialize(p, c.bufOut)
+	})
+}
+
+// convertToDirectory writes the points to the files of a target that writes
+// a file per measurement in the output directory
+func (c *DataConverter) convertToDirectory(config *DataConverterConfig, from targets.PointParser, schema *targets.DataSchema, to targets.ImplementedTarget) (uint64, error) {
+	if len(config.Output) == 0 {
+		return 0, fmt.Errorf(ErrNoOutputDirFmt, to.TargetName())
+	}
+	w, err := to.(targets.DirectoryWriter).NewPointWriter(config.Output, schema, config.Compression)
+	if err != nil {
+		return 0, err
+	}
+	points, err := c.convert(from.FileDataSource(config.File), from, schema, w.Write)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	return points, err
+}
+
+// convert parses the points of a data source and passes them to out
+func (c *DataConverter) convert(ds targets.DataSource, from targets.PointParser, schema *targets.DataSchema, out func(*data.Point) error) (uint64, error) {
+	// formats with headers are read past them before the first point
+	ds.Headers()
+	points := uint64(0)
+	point := data.NewPoint()
+	for {
+		item := ds.NextItem()
+		if item.Data == nil {
+			return points, nil
+		}
+		if err := from.ParsePoint(item, schema, point); err != nil {
+			return points, fmt.Errorf("can not parse point %d: %v", points+1, err)
+		}
+		if err := out(point); err != nil {
+			return points, fmt.Errorf("can not write point: %s", err)
+		}
+		point.Reset()
+		points++
+	}
+}
+
+// NewDataSchema returns the schema of the points simulated with a config.
+// The headers of the simulator have no value types, so the types of the
+// fields are taken from the first simulated points.
+func NewDataSchema(config *common.DataGeneratorConfig) (*targets.DataSchema, error) {
+	rand.Seed(config.Seed)
+	scfg, err := usecases.GetSimulatorConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	sim := scfg.NewSimulator(config.LogInterval, 0)
+	headers := sim.Headers()
+
+	fieldTypes := make(map[string][]string, len(headers.FieldKeys))
+	missing := 0
+	for m, keys := range headers.FieldKeys {
+		fieldTypes[m] = make([]string, len(keys))
+		missing += len(keys)
+	}
+	point := data.NewPoint()
+	for i := 0; missing > 0 && i < maxSchemaSamples && !sim.Finished(); i++ {
+		sim.Next(point)
+		types := fieldTypes[string(point.MeasurementName())]
+		for j, v := range point.FieldValues() {
+			if j < len(types) && types[j] == "" && v != nil {
+				types[j] = reflect.TypeOf(v).String()
+				missing--
+			}
+		}
+		point.Reset()
+	}
+	// fields that were nil in every sample are floats, like most fields
+	for _, types := range fieldTypes {
+		for i := range types {
+			if types[i] == "" {
+				types[i] = reflect.TypeOf(float64(0)).String()
+			}
+		}
+	}
+	return targets.NewDataSchema(headers, fieldTypes), nil
+}
@@ -0,0 +1,63 @@
+package inputs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+func TestNewDataSchema(t *testing.T) {
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Use:       common.UseCaseDevops,
+			Scale:     2,
+			TimeStart: defaultTimeStart,
+			TimeEnd:   defaultTimeEnd,
+		},
+		InitialScale: 2,
+		LogInterval:  10 * time.Second,
+	}
+	schema, err := NewDataSchema(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schema.FieldTypes) != len(schema.Headers.FieldKeys) {
+		t.Fatalf("incorrect number of measurements: got %d want %d", len(schema.FieldTypes), len(schema.Headers.FieldKeys))
+	}
+	for m, keys := range schema.Headers.FieldKeys {
+		if len(schema.FieldTypes[m]) != len(keys) {
+			t.Errorf("incorrect number of field types for %s: got %d want %d", m, len(schema.FieldTypes[m]), len(keys))
+		}
+	}
+	want := []struct {
+		measurement string
+		field       int
+		typ         string
+	}{
+		{"cpu", 0, "int64"},
+		{"mem", 0, "int64"},
+		{"mem", 6, "float64"},
+	}
+	for _, w := range want {
+		if got := schema.FieldTypes[w.measurement][w.field]; got != w.typ {
+			t.Errorf("incorrect type of %s field %d: got %s want %s", w.measurement, w.field, got, w.typ)
+		}
+	}
+	if !schema.IsTag("hostname") || schema.IsTag("usage_user") {
+		t.Errorf("incorrect tags in schema: %v", schema.Headers.TagKeys)
+	}
+
+	c.Use = "unknown"
+	if _, err := NewDataSchema(c); err == nil {
+		t.Errorf("expected error for unknown use case")
+	}
+}
+
+func TestDataConverterConvertNoConfig(t *testing.T) {
+	c := &DataConverter{}
+	if _, err := c.Convert(&DataConverterConfig{}, nil, nil); err == nil || err.Error() != ErrNoConfig {
+		t.Errorf("incorrect error: got %v want %s", err, ErrNoConfig)
+	}
+}
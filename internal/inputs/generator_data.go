@@ -20,6 +20,7 @@ import (
 const (
 	ErrNoConfig          = "no GeneratorConfig provided"
 	ErrInvalidDataConfig = "invalid config: DataGenerator needs a DataGeneratorConfig"
+	ErrNoOutputDirFmt    = "format %s writes a file per measurement to a directory, the output directory is required"
 )
 
 // DataGenerator is a type of Generator for creating data that will be consumed
@@ -36,9 +37,29 @@ type DataGenerator struct {
 	// bufOut represents the buffered writer that should actually be passed to
 	// any operations that write out data.
 	bufOut *bufio.Writer
+	// closeOut finishes the output behind bufOut once it is flushed
+	closeOut io.Closer
 }
 
 func (g *DataGenerator) init(config common.GeneratorConfig) error {
+	err := g.setConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if g.Out == nil {
+		g.Out = os.Stdout
+	}
+	g.bufOut, g.closeOut, err = getBufferedWriter(g.config.File, g.config.Compression, g.Out)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setConfig validates and sets the config of the generator
+func (g *DataGenerator) setConfig(config common.GeneratorConfig) error {
 	if config == nil {
 		return fmt.Errorf(ErrNoConfig)
 	}
@@ -49,42 +70,73 @@ func (g *DataGenerator) init(config common.GeneratorConfig) error {
 	}
 	g.config = config.(*common.DataGeneratorConfig)
 
-	err := g.config.Validate()
+	return g.config.Validate()
+}
+
+func (g *DataGenerator) Generate(config common.GeneratorConfig, target targets.ImplementedTarget) error {
+	if dw, ok := target.(targets.DirectoryWriter); ok {
+		return g.generateDirectory(config, dw)
+	}
+	err := g.init(config)
 	if err != nil {
 		return err
 	}
 
-	if g.Out == nil {
-		g.Out = os.Stdout
+	rand.Seed(g.config.Seed)
+
+	scfg, err := usecases.GetSimulatorConfig(g.config)
+	if err != nil {
+		return err
 	}
-	g.bufOut, err = getBufferedWriter(g.config.File, g.Out)
+
+	sim := scfg.NewSimulator(g.config.LogInterval, g.config.Limit)
+	serializer, err := g.getSerializer(sim, target)
 	if err != nil {
 		return err
 	}
 
+	if err := g.runSimulator(sim, serializer, g.config); err != nil {
+		return err
+	}
+	if len(g.config.File) > 0 {
+		return WriteConfigSidecar(g.config.File, g.config)
+	}
 	return nil
 }
 
-func (g *DataGenerator) Generate(config common.GeneratorConfig, target targets.ImplementedTarget) error {
-	err := g.init(config)
+// generateDirectory generates the data of a target that writes a directory
+// with a file per measurement, given by the File of the config
+func (g *DataGenerator) generateDirectory(config common.GeneratorConfig, target targets.DirectoryWriter) error {
+	if err := g.setConfig(config); err != nil {
+		return err
+	}
+	if len(g.config.File) == 0 {
+		return fmt.Errorf(ErrNoOutputDirFmt, g.config.Format)
+	}
+	// the schema is simulated first, the data is simulated again from the seed
+	schema, err := NewDataSchema(g.config)
 	if err != nil {
 		return err
 	}
-
 	rand.Seed(g.config.Seed)
-
 	scfg, err := usecases.GetSimulatorConfig(g.config)
 	if err != nil {
 		return err
 	}
-
 	sim := scfg.NewSimulator(g.config.LogInterval, g.config.Limit)
-	serializer, err := g.getSerializer(sim, target)
+
+	w, err := target.NewPointWriter(g.config.File, schema, g.config.Compression)
 	if err != nil {
 		return err
 	}
-
-	return g.runSimulator(sim, serializer, g.config)
+	err = g.simulate(sim, g.config, w.Write)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return WriteConfigSidecar(g.config.File, g.config)
 }
 
 func (g *DataGenerator) CreateSimulator(config *common.DataGeneratorConfig) (common.Simulator, error) {
@@ -101,9 +153,20 @@ func (g *DataGenerator) CreateSimulator(config *common.DataGeneratorConfig) (com
 	return scfg.NewSimulator(g.config.LogInterval, g.config.Limit), nil
 }
 
-func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.PointSerializer, dgc *common.DataGeneratorConfig) error {
-	defer g.bufOut.Flush()
+func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.PointSerializer, dgc *common.DataGeneratorConfig) (err error) {
+	defer func() {
+		if closeErr := flushAndClose(g.bufOut, g.closeOut); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return g.simulate(sim, dgc, func(p *data.Point) error {
+		return serializer.Serialize(p, g.bufOut)
+	})
+}
 
+// simulate passes the simulated points of the generation group to out
+func (g *DataGenerator) simulate(sim common.Simulator, dgc *common.DataGeneratorConfig, out func(*data.Point) error) error {
 	currGroupID := uint(0)
 	point := data.NewPoint()
 	for !sim.Finished() {
@@ -115,7 +178,7 @@ func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.
 
 		// in the default case this is always true
 		if currGroupID == dgc.InterleavedGroupID {
-			err := serializer.Serialize(point, g.bufOut)
+			err := out(point)
 			if err != nil {
 				return fmt.Errorf("can not serialize point: %s", err)
 			}
@@ -128,29 +191,34 @@ func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.
 }
 
 func (g *DataGenerator) getSerializer(sim common.Simulator, target targets.ImplementedTarget) (serialize.PointSerializer, error) {
-	switch target.TargetName() {
-	case constants.FormatCrateDB:
-		fallthrough
-	case constants.FormatClickhouse:
-		fallthrough
-	case constants.FormatTimescaleDB:
-		g.writeHeader(sim.Headers())
+	if writesHeaders(target.TargetName()) {
+		writeHeader(g.bufOut, sim.Headers())
 	}
 	return target.Serializer(), nil
 }
 
-//TODO should be implemented in targets package
-func (g *DataGenerator) writeHeader(headers *common.GeneratedDataHeaders) {
-	g.bufOut.WriteString("tags")
+// writesHeaders returns whether the data files of a format start with the
+// headers of the simulated data
+func writesHeaders(format string) bool {
+	switch format {
+	case constants.FormatCrateDB, constants.FormatClickhouse, constants.FormatTimescaleDB:
+		return true
+	}
+	return false
+}
+
+// TODO should be implemented in targets package
+func writeHeader(w *bufio.Writer, headers *common.GeneratedDataHeaders) {
+	w.WriteString("tags")
 
 	types := headers.TagTypes
 	for i, key := range headers.TagKeys {
-		g.bufOut.WriteString(",")
-		g.bufOut.Write([]byte(key))
-		g.bufOut.WriteString(" ")
-		g.bufOut.WriteString(types[i])
+		w.WriteString(",")
+		w.Write([]byte(key))
+		w.WriteString(" ")
+		w.WriteString(types[i])
 	}
-	g.bufOut.WriteString("\n")
+	w.WriteString("\n")
 	// sort the keys so the header is deterministic
 	keys := make([]string, 0)
 	fields := headers.FieldKeys
@@ -159,12 +227,12 @@ func (g *DataGenerator) writeHeader(headers *common.GeneratedDataHeaders) {
 	}
 	sort.Strings(keys)
 	for _, measurementName := range keys {
-		g.bufOut.WriteString(measurementName)
+		w.WriteString(measurementName)
 		for _, field := range fields[measurementName] {
-			g.bufOut.WriteString(",")
-			g.bufOut.Write([]byte(field))
+			w.WriteString(",")
+			w.Write([]byte(field))
 		}
-		g.bufOut.WriteString("\n")
+		w.WriteString("\n")
 	}
-	g.bufOut.WriteString("\n")
+	w.WriteString("\n")
 }
@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -374,3 +376,67 @@ func (m *mockTarget) TargetSpecificFlags(string, *pflag.FlagSet) {
 func (m *mockTarget) TargetName() string {
 	return m.name
 }
+
+type mockDirectoryTarget struct {
+	mockTarget
+	dir     string
+	written int
+	closed  bool
+}
+
+func (m *mockDirectoryTarget) NewPointWriter(dir string, schema *targets.DataSchema, compression string) (targets.PointWriter, error) {
+	m.dir = dir
+	return m, nil
+}
+
+func (m *mockDirectoryTarget) Write(*data.Point) error {
+	m.written++
+	return nil
+}
+
+func (m *mockDirectoryTarget) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestDataGeneratorGenerateDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-directory")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Format:    constants.FormatParquet,
+			Use:       common.UseCaseCPUOnly,
+			Scale:     1,
+			TimeStart: defaultTimeStart,
+			TimeEnd:   defaultTimeEnd,
+		},
+		Limit:                3,
+		InitialScale:         1,
+		LogInterval:          time.Second,
+		InterleavedNumGroups: 1,
+	}
+	target := &mockDirectoryTarget{mockTarget: mockTarget{name: constants.FormatParquet, serializer: &mockSerializer{}}}
+	g := &DataGenerator{}
+	if err := g.Generate(c, target); err == nil || err.Error() != fmt.Sprintf(ErrNoOutputDirFmt, constants.FormatParquet) {
+		t.Errorf("incorrect error without output directory: %v", err)
+	}
+
+	c.File = filepath.Join(dir, "data")
+	if err := g.Generate(c, target); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target.dir != c.File || target.written != 3 || !target.closed {
+		t.Errorf("incorrect points written: dir %s, %d points, closed %v", target.dir, target.written, target.closed)
+	}
+	if target.serializer.(*mockSerializer).numCalledSerialize != 0 {
+		t.Errorf("serializer called for directory target")
+	}
+	if _, err := os.Stat(ConfigSidecarFile(c.File)); err != nil {
+		t.Errorf("sidecar not written next to the directory: %v", err)
+	}
+}
@@ -8,11 +8,14 @@ import (
 	"math/rand"
 	"os"
 	"sort"
+	"strings"
 	"time"
 
+	queryCommon "github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/common"
 	queryUtils "github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
 	internalUtils "github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query"
 	"github.com/timescale/tsbs/pkg/query/config"
 	"github.com/timescale/tsbs/pkg/query/factories"
 )
@@ -28,6 +31,9 @@ const (
 	errUnknownUseCaseFmt        = "use case '%s' is undefined"
 	errCannotParseTimeFmt       = "cannot parse time from string '%s': %v"
 	errBadUseFmt                = "invalid use case specified: '%v'"
+	errNoAccessPatternFmt       = "query generator for format '%s' cannot change its access pattern"
+	errUnknownDashboardFmt      = "dashboard '%s' is undefined for use case '%s', set its query types"
+	errNoDashboardsFmt          = "query generator for format '%s' cannot generate dashboards"
 )
 
 // DevopsGeneratorMaker creates a query generator for devops use case
@@ -40,6 +46,21 @@ type IoTGeneratorMaker interface {
 	NewIoT(start, end time.Time, scale int) (queryUtils.QueryGenerator, error)
 }
 
+// accessPatternUser is implemented by the query generators that can change how
+// they pick the hosts and time windows of the queries
+type accessPatternUser interface {
+	UseRecentWindows(halfLife time.Duration)
+	UseNoRepeatWindows()
+	UseItemPicker(p queryCommon.ItemPicker)
+}
+
+// dashboardUser is implemented by the query generators that can share the hosts
+// and time range of the queries of a dashboard
+type dashboardUser interface {
+	StartDashboard()
+	NextDashboardQuery()
+}
+
 // QueryGenerator is a type of Generator for creating queries to test against a
 // database. The output is specific to the type of database (due to each using
 // different querying techniques, e.g. SQL or REST), but is consumed by TSBS
@@ -52,6 +73,9 @@ type QueryGenerator struct {
 	// DebugOut is where non-generated messages should be written. If nil, it
 	// will be os.Stderr.
 	DebugOut io.Writer
+	// Dashboards are the query types of the predefined dashboards, by use
+	// case and name.
+	Dashboards map[string]map[string][]string
 
 	conf          *config.QueryGeneratorConfig
 	useCaseMatrix map[string]map[string]queryUtils.QueryFillerMaker
@@ -60,10 +84,15 @@ type QueryGenerator struct {
 	factories map[string]interface{}
 	tsStart   time.Time
 	tsEnd     time.Time
+	// queryTypes are the query types generated: the one of the config, or
+	// those of the dashboard
+	queryTypes []string
 
 	// bufOut represents the buffered writer that should actually be passed to
 	// any operations that write out data.
 	bufOut *bufio.Writer
+	// closeOut finishes the output behind bufOut once it is flushed
+	closeOut io.Closer
 }
 
 // NewQueryGenerator returns a QueryGenerator that is set up to work with a given
@@ -76,8 +105,8 @@ func NewQueryGenerator(useCaseMatrix map[string]map[string]queryUtils.QueryFille
 	}
 }
 
-func (g *QueryGenerator) Generate(config common.GeneratorConfig) error {
-	err := g.init(config)
+func (g *QueryGenerator) Generate(conf common.GeneratorConfig) error {
+	err := g.init(conf)
 	if err != nil {
 		return err
 	}
@@ -87,9 +116,21 @@ func (g *QueryGenerator) Generate(config common.GeneratorConfig) error {
 		return err
 	}
 
-	filler := g.useCaseMatrix[g.conf.Use][g.conf.QueryType](useGen)
+	if err := g.setAccessPattern(useGen); err != nil {
+		return err
+	}
+
+	fillers := make([]queryUtils.QueryFiller, len(g.queryTypes))
+	for i, queryType := range g.queryTypes {
+		fillers[i] = g.useCaseMatrix[g.conf.Use][queryType](useGen)
+	}
+	if g.conf.Dashboard != "" {
+		if _, ok := useGen.(dashboardUser); !ok {
+			return fmt.Errorf(errNoDashboardsFmt, g.conf.Format)
+		}
+	}
 
-	return g.runQueryGeneration(useGen, filler, g.conf)
+	return g.runQueryGeneration(useGen, fillers, g.conf)
 }
 
 func (g *QueryGenerator) init(conf common.GeneratorConfig) error {
@@ -103,6 +144,12 @@ func (g *QueryGenerator) init(conf common.GeneratorConfig) error {
 	}
 	g.conf = conf.(*config.QueryGeneratorConfig)
 
+	if g.conf.DataConfig != "" {
+		if err := applyDataConfig(g.conf); err != nil {
+			return err
+		}
+	}
+
 	err := g.conf.Validate()
 	if err != nil {
 		return err
@@ -116,8 +163,20 @@ func (g *QueryGenerator) init(conf common.GeneratorConfig) error {
 		return fmt.Errorf(errBadUseFmt, g.conf.Use)
 	}
 
-	if _, ok := g.useCaseMatrix[g.conf.Use][g.conf.QueryType]; !ok {
-		return fmt.Errorf(errBadQueryTypeFmt, g.conf.Use, g.conf.QueryType)
+	g.queryTypes = []string{g.conf.QueryType}
+	if g.conf.Dashboard != "" {
+		g.queryTypes = g.conf.DashboardQueryTypes
+		if len(g.queryTypes) == 0 {
+			g.queryTypes = g.Dashboards[g.conf.Use][g.conf.Dashboard]
+		}
+		if len(g.queryTypes) == 0 {
+			return fmt.Errorf(errUnknownDashboardFmt, g.conf.Dashboard, g.conf.Use)
+		}
+	}
+	for _, queryType := range g.queryTypes {
+		if _, ok := g.useCaseMatrix[g.conf.Use][queryType]; !ok {
+			return fmt.Errorf(errBadQueryTypeFmt, g.conf.Use, queryType)
+		}
 	}
 
 	g.tsStart, err = internalUtils.ParseUTCTime(g.conf.TimeStart)
@@ -132,7 +191,7 @@ func (g *QueryGenerator) init(conf common.GeneratorConfig) error {
 	if g.Out == nil {
 		g.Out = os.Stdout
 	}
-	g.bufOut, err = getBufferedWriter(g.conf.File, g.Out)
+	g.bufOut, g.closeOut, err = getBufferedWriter(g.conf.File, g.conf.Compression, g.Out)
 	if err != nil {
 		return err
 	}
@@ -144,6 +203,65 @@ func (g *QueryGenerator) init(conf common.GeneratorConfig) error {
 	return nil
 }
 
+// setAccessPattern sets how the query generator picks the hosts and time
+// windows of the queries, when they are not uniformly random
+func (g *QueryGenerator) setAccessPattern(useGen queryUtils.QueryGenerator) error {
+	c := g.conf
+	if !c.NoRepeat && c.WindowDistribution != config.WindowDistributionRecent &&
+		(c.HostDistribution == "" || c.HostDistribution == config.HostDistributionUniform) {
+		return nil
+	}
+	a, ok := useGen.(accessPatternUser)
+	if !ok {
+		return fmt.Errorf(errNoAccessPatternFmt, c.Format)
+	}
+
+	if c.NoRepeat {
+		a.UseNoRepeatWindows()
+		a.UseItemPicker(queryCommon.NewNoRepeatPicker())
+		return nil
+	}
+	if c.WindowDistribution == config.WindowDistributionRecent {
+		a.UseRecentWindows(c.RecencyHalfLife)
+	}
+	switch c.HostDistribution {
+	case config.HostDistributionZipf:
+		p, err := queryCommon.NewZipfPicker(c.ZipfExponent)
+		if err != nil {
+			return err
+		}
+		a.UseItemPicker(p)
+	case config.HostDistributionHotSet:
+		p, err := queryCommon.NewHotSetPicker(c.HotSetSize, c.HotSetShare)
+		if err != nil {
+			return err
+		}
+		a.UseItemPicker(p)
+	}
+	return nil
+}
+
+// applyDataConfig sets the time range and scale of the queries to the ones the
+// loaded data was generated with, read from the config sidecar of the data
+// file. The use case is set too, unless it is given.
+func applyDataConfig(c *config.QueryGeneratorConfig) error {
+	fileName := c.DataConfig
+	if !strings.HasSuffix(fileName, ConfigSidecarSuffix) {
+		fileName = ConfigSidecarFile(fileName)
+	}
+	dataConf, err := ReadConfigSidecar(fileName)
+	if err != nil {
+		return err
+	}
+	c.TimeStart = dataConf.TimeStart
+	c.TimeEnd = dataConf.TimeEnd
+	c.Scale = dataConf.Scale
+	if c.Use == "" {
+		c.Use = dataConf.Use
+	}
+	return nil
+}
+
 func (g *QueryGenerator) initFactories() error {
 	factoryMap := factories.InitQueryFactories(g.conf)
 	for db, fac := range factoryMap {
@@ -201,11 +319,15 @@ func (g *QueryGenerator) getUseCaseGenerator(c *config.QueryGeneratorConfig) (qu
 	}
 }
 
-func (g *QueryGenerator) runQueryGeneration(useGen queryUtils.QueryGenerator, filler queryUtils.QueryFiller, c *config.QueryGeneratorConfig) error {
+func (g *QueryGenerator) runQueryGeneration(useGen queryUtils.QueryGenerator, fillers []queryUtils.QueryFiller, c *config.QueryGeneratorConfig) (err error) {
 	stats := make(map[string]int64)
 	currentGroup := uint(0)
 	enc := gob.NewEncoder(g.bufOut)
-	defer g.bufOut.Flush()
+	defer func() {
+		if closeErr := flushAndClose(g.bufOut, g.closeOut); err == nil {
+			err = closeErr
+		}
+	}()
 
 	rand.Seed(g.conf.Seed)
 	//fmt.Println(g.config.Seed)
@@ -216,34 +338,51 @@ func (g *QueryGenerator) runQueryGeneration(useGen queryUtils.QueryGenerator, fi
 		}
 	}
 
+	// each of the Limit units is a query, or the queries of a dashboard
 	for i := 0; i < int(c.Limit); i++ {
-		q := useGen.GenerateEmptyQuery()
-		q = filler.Fill(q)
-
-		if currentGroup == c.InterleavedGroupID {
-			err := enc.Encode(q)
-			if err != nil {
-				return fmt.Errorf(errCouldNotEncodeQueryFmt, err)
+		if c.Dashboard != "" {
+			useGen.(dashboardUser).StartDashboard()
+		}
+		for j, filler := range fillers {
+			if j > 0 {
+				useGen.(dashboardUser).NextDashboardQuery()
 			}
-			stats[string(q.HumanLabelName())]++
-
-			if c.Debug > 0 {
-				var debugMsg string
-				if c.Debug == 1 {
-					debugMsg = string(q.HumanLabelName())
-				} else if c.Debug == 2 {
-					debugMsg = string(q.HumanDescriptionName())
-				} else if c.Debug >= 3 {
-					debugMsg = q.String()
+			q := useGen.GenerateEmptyQuery()
+			q = filler.Fill(q)
+			if c.Dashboard != "" {
+				if d, ok := q.(query.DashboardMember); ok {
+					dashboard := d.GetDashboard()
+					dashboard.DashboardName = append(dashboard.DashboardName[:0], c.Dashboard...)
+					dashboard.DashboardSeq = uint64(i) + 1
+					dashboard.DashboardSize = uint64(len(fillers))
 				}
+			}
 
-				_, err = fmt.Fprintf(g.DebugOut, debugMsg+"\n")
+			if currentGroup == c.InterleavedGroupID {
+				err := enc.Encode(q)
 				if err != nil {
-					return fmt.Errorf(errCouldNotDebugFmt, err)
+					return fmt.Errorf(errCouldNotEncodeQueryFmt, err)
+				}
+				stats[string(q.HumanLabelName())]++
+
+				if c.Debug > 0 {
+					var debugMsg string
+					if c.Debug == 1 {
+						debugMsg = string(q.HumanLabelName())
+					} else if c.Debug == 2 {
+						debugMsg = string(q.HumanDescriptionName())
+					} else if c.Debug >= 3 {
+						debugMsg = q.String()
+					}
+
+					_, err = fmt.Fprintf(g.DebugOut, debugMsg+"\n")
+					if err != nil {
+						return fmt.Errorf(errCouldNotDebugFmt, err)
+					}
 				}
 			}
+			q.Release()
 		}
-		q.Release()
 
 		currentGroup++
 		if currentGroup == c.InterleavedNumGroups {
@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -70,6 +71,64 @@ func TestQueryGeneratorConfigValidate(t *testing.T) {
 	}
 	c.QueryType = "foo"
 
+	// Test schema layout validation
+	c.SchemaLayout = constants.SchemaLayoutNarrow
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected error for narrow layout: %v", err)
+	}
+	c.Format = constants.FormatInflux
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for narrow layout on %s", constants.FormatInflux)
+	}
+	c.Format = constants.FormatTimescaleDB
+	c.SchemaLayout = ""
+
+	// Test window distribution validation
+	c.WindowDistribution = "bad distribution"
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for bad window distribution")
+	}
+	c.WindowDistribution = config.WindowDistributionRecent
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for zero recency half-life")
+	}
+	c.RecencyHalfLife = time.Hour
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected error for recent window distribution: %v", err)
+	}
+	c.DataConfig = "data.gz"
+	c.ProbeDB = "host=localhost"
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for both data config and probe")
+	}
+	c.DataConfig = ""
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected error for probe: %v", err)
+	}
+	c.Format = constants.FormatInflux
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for probe on %s", constants.FormatInflux)
+	}
+	c.Format = constants.FormatTimescaleDB
+	c.ProbeDB = ""
+
+	// Test host distribution validation
+	c.HostDistribution = "bad distribution"
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for bad host distribution")
+	}
+	c.HostDistribution = config.HostDistributionZipf
+	c.NoRepeat = true
+	if err := c.Validate(); err == nil {
+		t.Errorf("unexpected lack of error for no-repeat with a skewed distribution")
+	}
+	c.HostDistribution = config.HostDistributionUniform
+	c.WindowDistribution = config.WindowDistributionUniform
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected error for no-repeat: %v", err)
+	}
+	c.NoRepeat = false
+
 	// Test groups validation
 	c.InterleavedNumGroups = 0
 	err = c.Validate()
@@ -536,7 +595,7 @@ func TestQueryGeneratorRunQueryGeneration(t *testing.T) {
 		}
 		filler := g.useCaseMatrix[config.Use][config.QueryType](useGen)
 
-		err = g.runQueryGeneration(useGen, filler, config)
+		err = g.runQueryGeneration(useGen, []queryUtils.QueryFiller{filler}, config)
 		if err != nil {
 			t.Errorf("unexpected error: got %v", err)
 		}
@@ -577,7 +636,7 @@ func TestQueryGeneratorRunQueryGenerationErrors(t *testing.T) {
 	filler := g.useCaseMatrix[c.Use][c.QueryType](useGen)
 
 	checkErr := func(want string) {
-		err = g.runQueryGeneration(useGen, filler, c)
+		err = g.runQueryGeneration(useGen, []queryUtils.QueryFiller{filler}, c)
 		if err == nil {
 			t.Errorf("unexpected lack of error")
 		} else if got := err.Error(); !strings.HasPrefix(got, want) {
@@ -631,3 +690,211 @@ func TestQueryGeneratorGenerate(t *testing.T) {
 	}
 	checkGeneratedOutput(t, &buf)
 }
+
+func TestQueryGeneratorGenerateRecentWindows(t *testing.T) {
+	c, g := getTestConfigAndGenerator()
+	c.Limit = 100
+	c.WindowDistribution = config.WindowDistributionRecent
+	c.RecencyHalfLife = time.Minute
+	var buf bytes.Buffer
+	g.Out = &buf
+	g.DebugOut = ioutil.Discard
+	if err := g.Generate(c); err != nil {
+		t.Fatalf("unexpected error when generating: got %v", err)
+	}
+
+	// the 1h windows should all end in the last minutes of the day
+	earliest, _ := internalUtils.ParseUTCTime("2016-01-01T22:30:00Z")
+	decoder := gob.NewDecoder(&buf)
+	for i := 0; i < int(c.Limit); i++ {
+		var q query.TimescaleDB
+		if err := decoder.Decode(&q); err != nil {
+			t.Fatalf("unexpected error while decoding: got %v", err)
+		}
+		desc := string(q.HumanDescription)
+		start, err := internalUtils.ParseUTCTime(desc[strings.LastIndex(desc, " ")+1:])
+		if err != nil {
+			t.Fatalf("cannot parse the window of %s: %v", desc, err)
+		}
+		if start.Before(earliest) {
+			t.Errorf("window not recent: %s", desc)
+		}
+	}
+}
+
+func TestQueryGeneratorInitDataConfig(t *testing.T) {
+	dataFile := filepath.Join(t.TempDir(), "data.gz")
+	dataConf := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Format:    constants.FormatTimescaleDB,
+			Use:       common.UseCaseCPUOnly,
+			Scale:     42,
+			TimeStart: "2020-03-01T00:00:00Z",
+			TimeEnd:   "2020-03-04T00:00:00Z",
+		},
+		LogInterval: 10 * time.Second,
+	}
+	if err := WriteConfigSidecar(dataFile, dataConf); err != nil {
+		t.Fatalf("unexpected error writing sidecar: %v", err)
+	}
+
+	for _, fileName := range []string{dataFile, ConfigSidecarFile(dataFile)} {
+		c, g := getTestConfigAndGenerator()
+		c.Use = ""
+		c.Scale = 1
+		c.DataConfig = fileName
+		g.Out = ioutil.Discard
+		if err := g.init(c); err != nil {
+			t.Fatalf("%s: unexpected error: %v", fileName, err)
+		}
+		if c.Use != common.UseCaseCPUOnly || c.Scale != 42 {
+			t.Errorf("%s: incorrect use case or scale: %s %d", fileName, c.Use, c.Scale)
+		}
+		if got := g.tsStart.Format(time.RFC3339); got != dataConf.TimeStart {
+			t.Errorf("%s: incorrect start: got %s want %s", fileName, got, dataConf.TimeStart)
+		}
+		if got := g.tsEnd.Format(time.RFC3339); got != dataConf.TimeEnd {
+			t.Errorf("%s: incorrect end: got %s want %s", fileName, got, dataConf.TimeEnd)
+		}
+	}
+
+	c, g := getTestConfigAndGenerator()
+	c.DataConfig = filepath.Join(t.TempDir(), "missing.gz")
+	if err := g.init(c); err == nil {
+		t.Errorf("unexpected lack of error for a missing data config")
+	}
+}
+
+func TestQueryGeneratorGenerateAccessPattern(t *testing.T) {
+	cases := []struct {
+		desc   string
+		set    func(c *config.QueryGeneratorConfig)
+		check  func(hosts map[string]int, windows map[string]int) string
+		errMsg string
+	}{
+		{
+			desc: "hot set",
+			set: func(c *config.QueryGeneratorConfig) {
+				c.HostDistribution = config.HostDistributionHotSet
+				c.HotSetSize = 0.1
+				c.HotSetShare = 1
+			},
+			check: func(hosts map[string]int, _ map[string]int) string {
+				if len(hosts) != 1 || hosts["host_0"] == 0 {
+					return fmt.Sprintf("hosts not in the hot set: %v", hosts)
+				}
+				return ""
+			},
+		},
+		{
+			desc: "no repeat",
+			set: func(c *config.QueryGeneratorConfig) {
+				c.NoRepeat = true
+			},
+			check: func(hosts map[string]int, windows map[string]int) string {
+				for h, n := range hosts {
+					if n != 2 {
+						return fmt.Sprintf("host %s queried %d times", h, n)
+					}
+				}
+				for w, n := range windows {
+					if n != 1 {
+						return fmt.Sprintf("window %s queried %d times", w, n)
+					}
+				}
+				return ""
+			},
+		},
+		{
+			desc: "bad zipf exponent",
+			set: func(c *config.QueryGeneratorConfig) {
+				c.HostDistribution = config.HostDistributionZipf
+				c.ZipfExponent = 0.5
+			},
+			errMsg: "zipf exponent must be greater than 1, got 0.5",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			c, g := getTestConfigAndGenerator()
+			c.Limit = 20
+			tc.set(c)
+			var buf bytes.Buffer
+			g.Out = &buf
+			g.DebugOut = ioutil.Discard
+			err := g.Generate(c)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("incorrect error: got %v want %s", err, tc.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error when generating: got %v", err)
+			}
+
+			hosts := map[string]int{}
+			windows := map[string]int{}
+			decoder := gob.NewDecoder(&buf)
+			for i := 0; i < int(c.Limit); i++ {
+				var q query.TimescaleDB
+				if err := decoder.Decode(&q); err != nil {
+					t.Fatalf("unexpected error while decoding: got %v", err)
+				}
+				sql := string(q.SqlQuery)
+				host := sql[strings.Index(sql, "'host_")+1:]
+				hosts[host[:strings.Index(host, "'")]]++
+				desc := string(q.HumanDescription)
+				windows[desc[strings.LastIndex(desc, " ")+1:]]++
+			}
+			if msg := tc.check(hosts, windows); msg != "" {
+				t.Error(msg)
+			}
+		})
+	}
+}
+
+func TestQueryGeneratorGenerateDashboard(t *testing.T) {
+	c, g := getTestConfigAndGenerator()
+	g.useCaseMatrix[common.UseCaseCPUOnly]["single-groupby-1-8-1"] = devops.NewSingleGroupby(1, 8, 1)
+	g.Dashboards = map[string]map[string][]string{
+		common.UseCaseCPUOnly: {"overview": {"single-groupby-1-1-1", "single-groupby-1-8-1"}},
+	}
+	c.QueryType = ""
+	c.Dashboard = "unknown"
+	g.Out = ioutil.Discard
+	g.DebugOut = ioutil.Discard
+	want := fmt.Sprintf(errUnknownDashboardFmt, "unknown", common.UseCaseCPUOnly)
+	if err := g.Generate(c); err == nil || err.Error() != want {
+		t.Errorf("incorrect error for an unknown dashboard: got %v want %s", err, want)
+	}
+
+	c.Dashboard = "overview"
+	var buf bytes.Buffer
+	g.Out = &buf
+	if err := g.Generate(c); err != nil {
+		t.Fatalf("unexpected error when generating: got %v", err)
+	}
+	decoder := gob.NewDecoder(&buf)
+	for i := 0; i < int(c.Limit); i++ {
+		var members [2]query.TimescaleDB
+		for j := range members {
+			if err := decoder.Decode(&members[j]); err != nil {
+				t.Fatalf("unexpected error while decoding: got %v", err)
+			}
+			d := members[j].Dashboard
+			if string(d.DashboardName) != "overview" || d.DashboardSeq != uint64(i+1) || d.DashboardSize != 2 {
+				t.Errorf("incorrect dashboard of query %d of dashboard %d: %+v", j, i, d)
+			}
+		}
+		if a, b := string(members[0].HumanDescription), string(members[1].HumanDescription); a[strings.LastIndex(a, " "):] != b[strings.LastIndex(b, " "):] {
+			t.Errorf("time range not shared in dashboard %d: %s and %s", i, a, b)
+		}
+		host := string(members[0].SqlQuery)
+		host = host[strings.Index(host, "'host_"):]
+		host = host[:strings.Index(host[1:], "'")+2]
+		if !strings.Contains(string(members[1].SqlQuery), host) {
+			t.Errorf("host %s not shared in dashboard %d: %s", host, i, members[1].SqlQuery)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/timescale/tsbs/internal/compress"
 )
 
 const (
@@ -13,15 +15,54 @@ const (
 
 const defaultWriteSize = 4 << 20 // 4 MB
 
-func getBufferedWriter(filename string, fallback io.Writer) (*bufio.Writer, error) {
+// getBufferedWriter returns the buffered writer for the output of a generator
+// and the closer that must be called once the writer is flushed, to finish
+// the compressed stream (if any) and close the file.
+func getBufferedWriter(filename, compression string, fallback io.Writer) (*bufio.Writer, io.Closer, error) {
+	codec, err := compress.WriterCodec(compression, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &output{w: fallback}
 	// If filename is given, output should go to a file
 	if len(filename) > 0 {
 		file, err := os.Create(filename)
 		if err != nil {
-			return nil, fmt.Errorf("cannot open file for write %s: %v", filename, err)
+			return nil, nil, fmt.Errorf("cannot open file for write %s: %v", filename, err)
 		}
-		return bufio.NewWriterSize(file, defaultWriteSize), nil
+		out.w, out.file = file, file
 	}
+	if out.compressor, err = compress.NewWriter(out.w, codec); err != nil {
+		return nil, nil, fmt.Errorf("cannot compress output: %v", err)
+	}
+
+	return bufio.NewWriterSize(out.compressor, defaultWriteSize), out, nil
+}
+
+// output closes the compressor and the file behind a buffered writer
+type output struct {
+	w          io.Writer
+	file       *os.File
+	compressor io.WriteCloser
+}
 
-	return bufio.NewWriterSize(fallback, defaultWriteSize), nil
+func (o *output) Close() error {
+	if err := o.compressor.Close(); err != nil {
+		return fmt.Errorf("cannot finish compressed output: %v", err)
+	}
+	if o.file != nil {
+		return o.file.Close()
+	}
+	return nil
+}
+
+// flushAndClose flushes bufOut and closes the output behind it, if any
+func flushAndClose(bufOut *bufio.Writer, out io.Closer) error {
+	if err := bufOut.Flush(); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return out.Close()
 }
@@ -0,0 +1,261 @@
+// Package metrics implements a minimal set of Prometheus-compatible
+// collectors (counters, gauges and labeled histograms) together with an HTTP
+// handler exposing them in the Prometheus text exposition format. It lets
+// long running benchmarks be scraped and watched from a dashboard.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+	metricsPath = "/metrics"
+)
+
+// DefaultLatencyBuckets are the upper bounds (in seconds) used for latency
+// histograms when no explicit buckets are given.
+var DefaultLatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+// Registry holds a set of collectors and renders them in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounter creates and registers a monotonically increasing counter.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	r.register(c)
+	return c
+}
+
+// NewGauge creates and registers a gauge that can go up and down.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	r.register(g)
+	return g
+}
+
+// NewHistogramVec creates and registers a histogram partitioned by the
+// values of a single label. If buckets is nil, DefaultLatencyBuckets is used.
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		name:    name,
+		help:    help,
+		label:   label,
+		buckets: sorted,
+		series:  make(map[string]*histogram),
+	}
+	r.register(h)
+	return h
+}
+
+// WriteText writes all registered collectors to w in registration order.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", contentType)
+	if err := r.WriteText(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ListenAndServe exposes the registry on addr under /metrics in a
+// background goroutine. Failing to listen is fatal since the user
+// explicitly asked for the endpoint.
+func (r *Registry) ListenAndServe(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, r)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Fatalf("could not serve metrics on %s: %v", addr, err)
+		}
+	}()
+}
+
+// Counter is a monotonically increasing integer value.
+type Counter struct {
+	name  string
+	help  string
+	value uint64
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	atomic.AddUint64(&c.value, n)
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+func (c *Counter) write(w io.Writer) error {
+	if err := writeHeader(w, c.name, c.help, "counter"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %d\n", c.name, c.Value())
+	return err
+}
+
+// Gauge is an integer value that can arbitrarily go up and down.
+type Gauge struct {
+	name  string
+	help  string
+	value int64
+}
+
+// Add adds n (which may be negative) to the gauge.
+func (g *Gauge) Add(n int64) {
+	atomic.AddInt64(&g.value, n)
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v int64) {
+	atomic.StoreInt64(&g.value, v)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() int64 {
+	return atomic.LoadInt64(&g.value)
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	if err := writeHeader(w, g.name, g.help, "gauge"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s %d\n", g.name, g.Value())
+	return err
+}
+
+type histogram struct {
+	counts []uint64 // cumulative counts are computed at write time
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec is a set of histograms sharing the same buckets and
+// distinguished by the value of one label.
+type HistogramVec struct {
+	name    string
+	help    string
+	label   string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+// Observe records value v for the histogram with the given label value.
+func (h *HistogramVec) Observe(labelValue string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	if err := writeHeader(w, h.name, h.help, "histogram"); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	labelValues := make([]string, 0, len(h.series))
+	for lv := range h.series {
+		labelValues = append(labelValues, lv)
+	}
+	sort.Strings(labelValues)
+
+	for _, lv := range labelValues {
+		s := h.series[lv]
+		lbl := fmt.Sprintf("%s=%s", h.label, strconv.Quote(lv))
+		cumulative := uint64(0)
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			_, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", h.name, lbl, formatFloat(upper), cumulative)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", h.name, lbl, s.count); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_sum{%s} %s\n", h.name, lbl, formatFloat(s.sum)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s_count{%s} %d\n", h.name, lbl, s.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeader(w io.Writer, name, help, metricType string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return err
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
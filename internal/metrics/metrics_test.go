@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_total", "A counter")
+	g := r.NewGauge("test_in_flight", "A gauge")
+	h := r.NewHistogramVec("test_seconds", "A histogram", "label", []float64{1, 0.1})
+
+	c.Add(3)
+	c.Inc()
+	g.Add(2)
+	g.Add(-1)
+	h.Observe("b", 0.05)
+	h.Observe("b", 0.5)
+	h.Observe("b", 5)
+	h.Observe("a", 0.01)
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP test_total A counter
+# TYPE test_total counter
+test_total 4
+# HELP test_in_flight A gauge
+# TYPE test_in_flight gauge
+test_in_flight 1
+# HELP test_seconds A histogram
+# TYPE test_seconds histogram
+test_seconds_bucket{label="a",le="0.1"} 1
+test_seconds_bucket{label="a",le="1"} 1
+test_seconds_bucket{label="a",le="+Inf"} 1
+test_seconds_sum{label="a"} 0.01
+test_seconds_count{label="a"} 1
+test_seconds_bucket{label="b",le="0.1"} 1
+test_seconds_bucket{label="b",le="1"} 2
+test_seconds_bucket{label="b",le="+Inf"} 3
+test_seconds_sum{label="b"} 5.55
+test_seconds_count{label="b"} 3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("served_total", "Served").Inc()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("incorrect content type: got %s want %s", got, contentType)
+	}
+	if !strings.Contains(rec.Body.String(), "served_total 1\n") {
+		t.Errorf("counter missing from output:\n%s", rec.Body.String())
+	}
+}
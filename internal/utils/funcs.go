@@ -2,6 +2,8 @@ package utils
 
 import (
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -40,3 +42,18 @@ func ValidateGroups(groupID, totalGroupsNum uint) error {
 	}
 	return nil
 }
+
+// RetryAfter parses the value of a Retry-After header in seconds or as an
+// HTTP date. It returns 0 if the header is not set or invalid.
+func RetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"net/http"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestValidateGroups(t *testing.T) {
@@ -47,3 +50,19 @@ func TestValidateGroups(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryAfter(t *testing.T) {
+	if got := RetryAfter(""); got != 0 {
+		t.Errorf("incorrect duration for empty header: %v", got)
+	}
+	if got := RetryAfter(strconv.Itoa(3)); got != 3*time.Second {
+		t.Errorf("incorrect duration for seconds: %v", got)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := RetryAfter(date); got <= 0 || got > time.Minute {
+		t.Errorf("incorrect duration for date: %v", got)
+	}
+	if got := RetryAfter("soon"); got != 0 {
+		t.Errorf("incorrect duration for invalid header: %v", got)
+	}
+}
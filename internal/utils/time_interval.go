@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"time"
 )
@@ -20,6 +21,41 @@ const (
 type TimeInterval struct {
 	start time.Time
 	end   time.Time
+
+	// halfLife biases the random windows toward the end of the interval
+	// when set, see WithRecency
+	halfLife time.Duration
+	// deck deals the random windows without repeats when set, see
+	// WithoutRepeats
+	deck *windowDeck
+	// shared is the end of the windows shared until reset when set, see
+	// WithSharedEnd
+	shared *sharedEnd
+}
+
+// sharedEnd is the end of the random windows of a TimeInterval, drawn by the
+// first window after a reset
+type sharedEnd struct {
+	end int64
+	set bool
+}
+
+// windowDeck deals the window-sized slots of a TimeInterval in a shuffled
+// order, with a deck per window duration
+type windowDeck struct {
+	slots map[time.Duration][]int
+	next  map[time.Duration]int
+}
+
+// deal returns the next of the n slots of the window duration, reshuffling
+// the slots once all of them were dealt
+func (d *windowDeck) deal(window time.Duration, n int) int {
+	if len(d.slots[window]) != n || d.next[window] == n {
+		d.slots[window] = rand.Perm(n)
+		d.next[window] = 0
+	}
+	d.next[window]++
+	return d.slots[window][d.next[window]-1]
 }
 
 // NewTimeInterval creates a new TimeInterval for a given start and end. If end
@@ -28,7 +64,16 @@ func NewTimeInterval(start, end time.Time) (*TimeInterval, error) {
 	if end.Before(start) {
 		return nil, fmt.Errorf(ErrEndBeforeStart)
 	}
-	return &TimeInterval{start.UTC(), end.UTC()}, nil
+	return &TimeInterval{start: start.UTC(), end: end.UTC()}, nil
+}
+
+// WithRecency returns a copy of the TimeInterval whose random windows are
+// biased toward its end: the time between the end of a window and the end of
+// the interval follows an exponential distribution with the given half-life,
+// so half the windows end within halfLife of the end of the interval. A
+// half-life of 0 draws the windows uniformly.
+func (ti *TimeInterval) WithRecency(halfLife time.Duration) *TimeInterval {
+	return &TimeInterval{start: ti.start, end: ti.end, halfLife: halfLife}
 }
 
 // Duration returns the time.Duration of the TimeInterval.
@@ -67,8 +112,36 @@ func (ti *TimeInterval) Overlap(other *TimeInterval) bool {
 	return true
 }
 
-// RandWindow creates a TimeInterval of duration `window` at a uniformly-random
-// start time within the time period represented by this TimeInterval.
+// WithoutRepeats returns a copy of the TimeInterval whose random windows are
+// never read twice: the interval is cut in consecutive slots of the window
+// duration, which are dealt in a random order. Once all the slots of a
+// duration were dealt, they are dealt again in a new order.
+func (ti *TimeInterval) WithoutRepeats() *TimeInterval {
+	return &TimeInterval{start: ti.start, end: ti.end, deck: &windowDeck{
+		slots: map[time.Duration][]int{},
+		next:  map[time.Duration]int{},
+	}}
+}
+
+// WithSharedEnd returns a copy of the TimeInterval whose random windows all end
+// at the same time, drawn with the first window after ResetSharedEnd. A window
+// longer than the first one starts at the start of the interval at the latest.
+func (ti *TimeInterval) WithSharedEnd() *TimeInterval {
+	return &TimeInterval{start: ti.start, end: ti.end, halfLife: ti.halfLife, deck: ti.deck, shared: &sharedEnd{}}
+}
+
+// ResetSharedEnd makes the next random window draw a new shared end, if the
+// TimeInterval was created by WithSharedEnd.
+func (ti *TimeInterval) ResetSharedEnd() {
+	if ti.shared != nil {
+		ti.shared.set = false
+	}
+}
+
+// RandWindow creates a TimeInterval of duration `window` at a random start
+// time within the time period represented by this TimeInterval. The start time
+// is uniformly random unless the TimeInterval was created by WithRecency,
+// WithoutRepeats or WithSharedEnd.
 func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error) {
 	lower := ti.start.UnixNano()
 	upper := ti.end.Add(-window).UnixNano()
@@ -78,7 +151,20 @@ func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error)
 
 	}
 
-	start := lower + rand.Int63n(upper-lower)
+	var start int64
+	if ti.shared != nil && ti.shared.set {
+		start = ti.shared.end - window.Nanoseconds()
+		if start < lower {
+			start = lower
+		}
+	} else if ti.deck != nil && window > 0 {
+		n := int((ti.end.UnixNano() - lower) / window.Nanoseconds())
+		start = lower + int64(ti.deck.deal(window, n))*window.Nanoseconds()
+	} else if ti.halfLife > 0 {
+		start = upper - recentOffset(ti.halfLife, upper-lower)
+	} else {
+		start = lower + rand.Int63n(upper-lower)
+	}
 	end := start + window.Nanoseconds()
 
 	x, err := NewTimeInterval(time.Unix(0, start), time.Unix(0, end))
@@ -90,9 +176,23 @@ func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error)
 		panic("generated TimeInterval's duration does not equal window")
 	}
 
+	if ti.shared != nil && !ti.shared.set {
+		ti.shared.end, ti.shared.set = end, true
+	}
 	return x, nil
 }
 
+// recentOffset draws an offset in [0, span) from an exponential distribution
+// with the given half-life. Offsets past the span wrap around, so the windows
+// of a half-life longer than the interval are close to uniform.
+func recentOffset(halfLife time.Duration, span int64) int64 {
+	offset := rand.ExpFloat64() * float64(halfLife.Nanoseconds()) / math.Ln2
+	if o := int64(math.Mod(offset, float64(span))); o < span {
+		return o
+	}
+	return span - 1
+}
+
 // MustRandWindow is the form of RandWindow that cannot error; if it does error,
 // it causes a panic.
 func (ti *TimeInterval) MustRandWindow(window time.Duration) *TimeInterval {
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -291,6 +292,104 @@ func TestTimeIntervalRandWindow(t *testing.T) {
 	}
 }
 
+func TestTimeIntervalWithRecency(t *testing.T) {
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2016, time.January, 2, 0, 0, 0, 0, time.UTC)
+	ti, err := NewTimeInterval(start, end) // 1 day duration
+	if err != nil {
+		t.Fatalf("unexpected error creating TimeInterval: got %v", err)
+	}
+
+	recent := ti.WithRecency(time.Hour)
+	if recent.Start() != ti.Start() || recent.End() != ti.End() {
+		t.Errorf("incorrect interval: got %v-%v want %v-%v", recent.Start(), recent.End(), ti.Start(), ti.End())
+	}
+	c := randWindowCase{window: time.Minute}
+	const draws = 10000
+	lastHour := 0
+	for i := 0; i < draws; i++ {
+		x := recent.MustRandWindow(c.window)
+		c.checkTimeInterval(t, ti, x)
+		if !x.End().Before(end.Add(-time.Hour)) {
+			lastHour++
+		}
+	}
+	// half the windows should end within the half-life of the end
+	if lastHour < draws*45/100 || lastHour > draws*55/100 {
+		t.Errorf("incorrect number of windows in the last hour: got %d of %d", lastHour, draws)
+	}
+
+	// a zero half-life draws the same windows as the uniform interval
+	rand.Seed(123)
+	want := ti.MustRandWindow(c.window)
+	rand.Seed(123)
+	if got := ti.WithRecency(0).MustRandWindow(c.window); got.Start() != want.Start() {
+		t.Errorf("zero half-life not uniform: got %v want %v", got.Start(), want.Start())
+	}
+}
+
+func TestTimeIntervalWithoutRepeats(t *testing.T) {
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2016, time.January, 1, 12, 30, 0, 0, time.UTC)
+	ti, err := NewTimeInterval(start, end)
+	if err != nil {
+		t.Fatalf("unexpected error creating TimeInterval: got %v", err)
+	}
+
+	cold := ti.WithoutRepeats()
+	c := randWindowCase{window: time.Hour}
+	for round := 0; round < 2; round++ {
+		seen := map[time.Time]bool{}
+		// the 12 whole hours are dealt once per round
+		for i := 0; i < 12; i++ {
+			x := cold.MustRandWindow(c.window)
+			c.checkTimeInterval(t, ti, x)
+			if x.Start().Sub(start)%time.Hour != 0 {
+				t.Errorf("window not on a slot: %v", x.Start())
+			}
+			if seen[x.Start()] {
+				t.Errorf("round %d: window %v repeated", round, x.Start())
+			}
+			seen[x.Start()] = true
+		}
+	}
+
+	// a window of another duration has its own slots
+	x := cold.MustRandWindow(5 * time.Hour)
+	if x.Start().Sub(start)%(5*time.Hour) != 0 || x.End().After(end) {
+		t.Errorf("incorrect window: %v-%v", x.Start(), x.End())
+	}
+}
+
+func TestTimeIntervalWithSharedEnd(t *testing.T) {
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2016, time.January, 2, 0, 0, 0, 0, time.UTC)
+	ti, err := NewTimeInterval(start, end)
+	if err != nil {
+		t.Fatalf("unexpected error creating TimeInterval: got %v", err)
+	}
+
+	shared := ti.WithSharedEnd()
+	first := shared.MustRandWindow(time.Hour)
+	for _, window := range []time.Duration{time.Minute, time.Hour, 12 * time.Hour} {
+		x := shared.MustRandWindow(window)
+		randWindowCase{window: window}.checkTimeInterval(t, ti, x)
+		if x.End() != first.End() && x.Start() != start {
+			t.Errorf("window %v does not share the end %v: %v", window, first.End(), x.End())
+		}
+	}
+
+	// after a reset, a new end is drawn and shared
+	shared.ResetSharedEnd()
+	second := shared.MustRandWindow(time.Minute)
+	if x := shared.MustRandWindow(time.Minute); x.End() != second.End() {
+		t.Errorf("window does not share the new end %v: %v", second.End(), x.End())
+	}
+	if second.End() == first.End() {
+		t.Errorf("no new end drawn after the reset")
+	}
+}
+
 func TestTimeIntervalMustRandWindow(t *testing.T) {
 	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2016, time.January, 1, 1, 0, 0, 0, time.UTC)
@@ -2,7 +2,10 @@ package load
 
 import (
 	"bufio"
+	"io"
 	"os"
+
+	"github.com/timescale/tsbs/internal/compress"
 )
 
 const (
@@ -10,17 +13,24 @@ const (
 )
 
 // GetBufferedReader returns the buffered Reader that should be used by the file loader
-// if no file name is specified a buffer for STDIN is returned
+// if no file name is specified a buffer for STDIN is returned. gzip, zstd and lz4
+// compressed input is decompressed, detected by the file extension or the
+// first bytes of the input.
 func GetBufferedReader(fileName string) *bufio.Reader {
-	if len(fileName) == 0 {
-		// Read from STDIN
-		return bufio.NewReaderSize(os.Stdin, defaultReadSize)
+	var in io.Reader = os.Stdin
+	if len(fileName) > 0 {
+		// Read from specified file
+		file, err := os.Open(fileName)
+		if err != nil {
+			fatal("cannot open file for read %s: %v", fileName, err)
+			return nil
+		}
+		in = file
 	}
-	// Read from specified file
-	file, err := os.Open(fileName)
+	r, err := compress.NewAutoReader(in, fileName)
 	if err != nil {
-		fatal("cannot open file for read %s: %v", fileName, err)
+		fatal("cannot decompress input %s: %v", fileName, err)
 		return nil
 	}
-	return bufio.NewReaderSize(file, defaultReadSize)
+	return bufio.NewReaderSize(r, defaultReadSize)
 }
@@ -0,0 +1,169 @@
+package insertstrategy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	profileKindSeparator = ":"
+	profileSpanSeparator = "/"
+	profileListSeparator = ","
+
+	profileConstant = "constant"
+	profileRamp     = "ramp"
+	profileStep     = "step"
+	profileSine     = "sine"
+
+	rateProfileFormatError = "insert rate profile could not be parsed. Required: 'constant:R', 'ramp:FROM-TO/DURATION', " +
+		"'step:R1,R2,...,Rn/DURATION' or 'sine:MEAN,AMPLITUDE/PERIOD'"
+)
+
+// RateProfile describes the requested insert rate (points per second) over
+// the course of a load benchmark
+type RateProfile interface {
+	// Rate returns the requested rate after elapsed time since the start of the benchmark
+	Rate(elapsed time.Duration) float64
+}
+
+type constantProfile struct {
+	rate float64
+}
+
+func (p *constantProfile) Rate(time.Duration) float64 {
+	return p.rate
+}
+
+// rampProfile linearly changes the rate from 'from' to 'to' over 'duration'
+// and keeps the final rate afterwards
+type rampProfile struct {
+	from, to float64
+	duration time.Duration
+}
+
+func (p *rampProfile) Rate(elapsed time.Duration) float64 {
+	if elapsed >= p.duration {
+		return p.to
+	}
+	progress := float64(elapsed) / float64(p.duration)
+	return p.from + (p.to-p.from)*progress
+}
+
+// stepProfile keeps each of the rates for 'duration' and holds the last one
+type stepProfile struct {
+	rates    []float64
+	duration time.Duration
+}
+
+func (p *stepProfile) Rate(elapsed time.Duration) float64 {
+	step := int(elapsed / p.duration)
+	if step >= len(p.rates) {
+		step = len(p.rates) - 1
+	}
+	return p.rates[step]
+}
+
+// sineProfile oscillates around 'mean' with 'amplitude' and 'period', to
+// mimic diurnal traffic patterns
+type sineProfile struct {
+	mean, amplitude float64
+	period          time.Duration
+}
+
+func (p *sineProfile) Rate(elapsed time.Duration) float64 {
+	phase := 2 * math.Pi * float64(elapsed) / float64(p.period)
+	return p.mean + p.amplitude*math.Sin(phase)
+}
+
+// ParseRateProfile parses a string representation of an insert rate profile.
+// Rates are expressed in points per second and durations in Go duration format:
+// 'constant:50000' => always 50000 points/s
+// 'ramp:10000-200000/10m' => linearly from 10000 to 200000 points/s over 10 minutes, then hold 200000
+// 'step:10000,20000,40000/5m' => each rate for 5 minutes, then hold 40000
+// 'sine:100000,50000/1h' => 100000 +/- 50000 points/s with a period of one hour
+// Error returned if the profile can't be parsed or would request a rate <= 0
+func ParseRateProfile(profile string) (RateProfile, error) {
+	parts := strings.SplitN(profile, profileKindSeparator, 2)
+	if len(parts) != 2 {
+		return nil, errors.New(rateProfileFormatError)
+	}
+	kind, spec := parts[0], parts[1]
+
+	if kind == profileConstant {
+		rates, err := parseRates(spec, profileListSeparator, 1)
+		if err != nil {
+			return nil, err
+		}
+		return &constantProfile{rate: rates[0]}, nil
+	}
+
+	parts = strings.SplitN(spec, profileSpanSeparator, 2)
+	if len(parts) != 2 {
+		return nil, errors.New(rateProfileFormatError)
+	}
+	span, err := time.ParseDuration(parts[1])
+	if err != nil || span <= 0 {
+		return nil, errors.New(rateProfileFormatError)
+	}
+
+	switch kind {
+	case profileRamp:
+		rates, err := parseRates(parts[0], rangeSeparator, 2)
+		if err != nil {
+			return nil, err
+		}
+		return &rampProfile{from: rates[0], to: rates[1], duration: span}, nil
+	case profileStep:
+		rates, err := parseRates(parts[0], profileListSeparator, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &stepProfile{rates: rates, duration: span}, nil
+	case profileSine:
+		values, err := parseNumbers(parts[0], profileListSeparator, 2)
+		if err != nil {
+			return nil, err
+		}
+		if values[1] < 0 || values[0]-values[1] <= 0 {
+			return nil, fmt.Errorf("sine insert rate profile must have 0 <= amplitude < mean, got mean %v and amplitude %v", values[0], values[1])
+		}
+		return &sineProfile{mean: values[0], amplitude: values[1], period: span}, nil
+	}
+	return nil, errors.New(rateProfileFormatError)
+}
+
+// parseRates parses a list of positive rates, see parseNumbers
+func parseRates(s, sep string, expected int) ([]float64, error) {
+	rates, err := parseNumbers(s, sep, expected)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rates {
+		if r <= 0 {
+			return nil, fmt.Errorf("insert rate must be positive, got %v", r)
+		}
+	}
+	return rates, nil
+}
+
+// parseNumbers parses a sep separated list of numbers, requiring exactly
+// 'expected' of them (any number if expected is 0)
+func parseNumbers(s, sep string, expected int) ([]float64, error) {
+	parts := strings.Split(s, sep)
+	if expected > 0 && len(parts) != expected {
+		return nil, errors.New(rateProfileFormatError)
+	}
+	numbers := make([]float64, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, errors.New(rateProfileFormatError)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
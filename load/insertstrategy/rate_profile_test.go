@@ -0,0 +1,102 @@
+package insertstrategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseRateProfile(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		profile   string
+		expectErr bool
+		rates     map[time.Duration]float64
+	}{
+		{desc: "missing kind", profile: "100", expectErr: true},
+		{desc: "unknown kind", profile: "square:1,2/1m", expectErr: true},
+		{desc: "constant not a number", profile: "constant:a", expectErr: true},
+		{desc: "constant zero", profile: "constant:0", expectErr: true},
+		{desc: "ramp missing duration", profile: "ramp:1-10", expectErr: true},
+		{desc: "ramp bad duration", profile: "ramp:1-10/x", expectErr: true},
+		{desc: "ramp one rate", profile: "ramp:10/1m", expectErr: true},
+		{desc: "step negative rate", profile: "step:10,-1/1m", expectErr: true},
+		{desc: "sine amplitude too big", profile: "sine:10,10/1h", expectErr: true},
+		{
+			desc:    "constant",
+			profile: "constant:500",
+			rates:   map[time.Duration]float64{0: 500, time.Hour: 500},
+		}, {
+			desc:    "ramp",
+			profile: "ramp:100-200/10s",
+			rates:   map[time.Duration]float64{0: 100, 5 * time.Second: 150, 10 * time.Second: 200, time.Hour: 200},
+		}, {
+			desc:    "ramp down",
+			profile: "ramp:200-100/10s",
+			rates:   map[time.Duration]float64{0: 200, 5 * time.Second: 150, time.Minute: 100},
+		}, {
+			desc:    "step",
+			profile: "step:10,20,40/1m",
+			rates:   map[time.Duration]float64{0: 10, 59 * time.Second: 10, time.Minute: 20, 2 * time.Minute: 40, time.Hour: 40},
+		}, {
+			desc:    "sine",
+			profile: "sine:100,50/4h",
+			rates:   map[time.Duration]float64{0: 100, time.Hour: 150, 2 * time.Hour: 100, 3 * time.Hour: 50},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			p, err := ParseRateProfile(tc.profile)
+			if err != nil && !tc.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			} else if err == nil && tc.expectErr {
+				t.Fatalf("unexpected lack of error")
+			} else if tc.expectErr {
+				return
+			}
+			for elapsed, want := range tc.rates {
+				if got := p.Rate(elapsed); math.Abs(got-want) > 1e-9 {
+					t.Errorf("incorrect rate after %v: got %f want %f", elapsed, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateRegulatorRequestedRate(t *testing.T) {
+	if _, err := NewRateRegulator("bad", 10); err == nil {
+		t.Fatalf("unexpected lack of error")
+	}
+
+	r, err := NewRateRegulator("ramp:100-200/10s", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start, _ := time.Parse(time.RFC3339, "2019-01-01T00:00:00Z")
+	r.nowFn = func() time.Time { return start }
+	r.Start()
+	// only the first start counts
+	r.nowFn = func() time.Time { return start.Add(time.Hour) }
+	r.Start()
+
+	if got := r.RequestedRate(start.Add(5 * time.Second)); got != 150 {
+		t.Errorf("incorrect requested rate: got %f want 150", got)
+	}
+}
+
+func TestRateRegulatorWait(t *testing.T) {
+	r, err := NewRateRegulator("constant:1000", 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Start()
+	begin := time.Now()
+	// the first 100 points are the initial burst, 200 more take ~200ms
+	for i := 0; i < 3; i++ {
+		r.Wait(100)
+	}
+	if took := time.Since(begin); took < 150*time.Millisecond {
+		t.Errorf("rate not limited: 300 points at 1000 points/s took %v", took)
+	}
+}
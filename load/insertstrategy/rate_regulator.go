@@ -0,0 +1,71 @@
+package insertstrategy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateRegulator limits the insert rate of all load workers together to the
+// rate requested by a RateProfile, using one shared token bucket
+type RateRegulator struct {
+	profile RateProfile
+	limiter *rate.Limiter
+	burst   int
+	nowFn   nowProviderFn
+
+	startOnce sync.Once
+	start     time.Time
+}
+
+// NewRateRegulator returns a RateRegulator for the given profile string (see
+// ParseRateProfile). burst is the largest number of points that can be
+// inserted at once, typically the batch size.
+func NewRateRegulator(profile string, burst int) (*RateRegulator, error) {
+	p, err := ParseRateProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateRegulator{
+		profile: p,
+		limiter: rate.NewLimiter(rate.Limit(p.Rate(0)), burst),
+		burst:   burst,
+		nowFn:   time.Now,
+	}, nil
+}
+
+// Start marks the beginning of the benchmark, the profile is evaluated
+// relative to it. Only the first call has an effect.
+func (r *RateRegulator) Start() {
+	r.startOnce.Do(func() {
+		r.start = r.nowFn()
+	})
+}
+
+// RequestedRate returns the rate requested by the profile at the given time
+func (r *RateRegulator) RequestedRate(at time.Time) float64 {
+	r.Start()
+	return r.profile.Rate(at.Sub(r.start))
+}
+
+// Wait blocks until inserting numPoints points keeps the overall rate
+// within the currently requested rate
+func (r *RateRegulator) Wait(numPoints int) {
+	now := r.nowFn()
+	r.limiter.SetLimitAt(now, rate.Limit(r.RequestedRate(now)))
+	for numPoints > 0 {
+		n := numPoints
+		if n > r.burst {
+			n = r.burst
+		}
+		if err := r.limiter.WaitN(context.Background(), n); err != nil {
+			panic(err)
+		}
+		numPoints -= n
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"log"
 	"math/rand"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -44,7 +45,11 @@ type BenchmarkRunnerConfig struct {
 	NoFlowControl   bool          `yaml:"no-flow-control" mapstructure:"no-flow-control" json:"no-flow-control"`
 	ChannelCapacity uint          `yaml:"channel-capacity" mapstructure:"channel-capacity" json:"channel-capacity"`
 	InsertIntervals string        `yaml:"insert-intervals" mapstructure:"insert-intervals" json:"insert-intervals"`
+	InsertRate      string        `yaml:"insert-rate-profile" mapstructure:"insert-rate-profile" json:"insert-rate-profile"`
 	ResultsFile     string        `yaml:"results-file" mapstructure:"results-file" json:"results-file"`
+	MetricsAddress  string        `yaml:"prometheus-listen-address" mapstructure:"prometheus-listen-address" json:"prometheus-listen-address"`
+	Replay          bool          `yaml:"replay" mapstructure:"replay" json:"replay"`
+	ReplaySpeed     float64       `yaml:"replay-speed" mapstructure:"replay-speed" json:"replay-speed"`
 	// deprecated, should not be used in other places other than tsbs_load_xx commands
 	FileName string `yaml:"file" mapstructure:"file" json:"file"`
 	Seed     int64  `yaml:"seed" mapstructure:"seed" json:"seed"`
@@ -63,8 +68,12 @@ func (c BenchmarkRunnerConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.String("file", "", "File name to read data from")
 	fs.Int64("seed", 0, "PRNG seed (default: 0, which uses the current timestamp)")
 	fs.String("insert-intervals", "", "Time to wait between each insert, default '' => all workers insert ASAP. '1,2' = worker 1 waits 1s between inserts, worker 2 and others wait 2s")
+	fs.String("insert-rate-profile", "", "Target insert rate in points/sec shared by all workers, default '' => no limit. 'constant:R', 'ramp:FROM-TO/DURATION' (e.g. 'ramp:1000-100000/10m'), 'step:R1,R2,...,Rn/DURATION' or 'sine:MEAN,AMPLITUDE/PERIOD'")
+	fs.Bool("replay", false, "Whether to replay the data file in real time, processing each batch when the timestamp of its earliest point is reached, offset to the start of the run")
+	fs.Float64("replay-speed", 1, "(Used only when replay=true) Factor by which the replay is sped up, e.g. 60 replays an hour of data in a minute")
 	fs.Bool("hash-workers", false, "Whether to consistently hash insert data to the same workers (i.e., the data for a particular host always goes to the same worker)")
 	fs.String("results-file", "", "Write the test results summary json to this file")
+	fs.String("prometheus-listen-address", "", "Address (e.g. ':9100') on which to expose live load metrics in Prometheus format under /metrics, default '' => disabled")
 }
 
 type BenchmarkRunner interface {
@@ -78,8 +87,13 @@ type CommonBenchmarkRunner struct {
 	BenchmarkRunnerConfig
 	metricCnt      uint64
 	rowCnt         uint64
+	pointCnt       uint64
 	initialRand    *rand.Rand
 	sleepRegulator insertstrategy.SleepRegulator
+	rateRegulator  *insertstrategy.RateRegulator
+	metrics        *loaderMetrics
+	reportDone     chan struct{}
+	dbCreator      targets.DBCreator
 }
 
 // GetBenchmarkRunnerWithBatchSize returns the singleton CommonBenchmarkRunner for use in a benchmark program
@@ -103,6 +117,15 @@ func GetBenchmarkRunner(c BenchmarkRunnerConfig) BenchmarkRunner {
 			panic(fmt.Sprintf("could not initialize BenchmarkRunner: %v", err))
 		}
 	}
+	if c.InsertRate != "" {
+		loader.rateRegulator, err = insertstrategy.NewRateRegulator(c.InsertRate, int(loader.BatchSize))
+		if err != nil {
+			panic(fmt.Sprintf("could not initialize BenchmarkRunner: %v", err))
+		}
+	}
+	if c.MetricsAddress != "" {
+		loader.metrics = newLoaderMetrics()
+	}
 	if !c.NoFlowControl {
 		return &loader
 	}
@@ -124,15 +147,27 @@ func (l *CommonBenchmarkRunner) DatabaseName() string {
 }
 
 func (l *CommonBenchmarkRunner) preRun(b targets.Benchmark) (*sync.WaitGroup, *time.Time) {
+	if _, ok := b.(*replayBenchmark); l.Replay && !ok {
+		fatal("--replay is not supported by this loader")
+	}
+
 	// Create required DB
-	if b.GetDBCreator() != nil {
-		cleanupFn := l.useDBCreator(b.GetDBCreator())
+	l.dbCreator = b.GetDBCreator()
+	if l.dbCreator != nil {
+		cleanupFn := l.useDBCreator(l.dbCreator)
 		defer cleanupFn()
 	}
 
+	if l.rateRegulator != nil {
+		l.rateRegulator.Start()
+	}
 	if l.ReportingPeriod.Nanoseconds() > 0 {
+		l.reportDone = make(chan struct{})
 		go l.report(l.ReportingPeriod)
 	}
+	if l.metrics != nil {
+		l.metrics.registry.ListenAndServe(l.MetricsAddress)
+	}
 	wg := &sync.WaitGroup{}
 	wg.Add(int(l.Workers))
 	start := time.Now()
@@ -143,21 +178,43 @@ func (l *CommonBenchmarkRunner) postRun(wg *sync.WaitGroup, start *time.Time) {
 	// Wait for all workers to finish
 	wg.Wait()
 	end := time.Now()
+	// Stop periodic reporting so the runner can be used more than once in a process
+	if l.reportDone != nil {
+		close(l.reportDone)
+	}
 	took := end.Sub(*start)
 	l.summary(took)
+	postLoadStats := l.postLoad()
 	if l.BenchmarkRunnerConfig.ResultsFile != "" {
 		metricRate := float64(l.metricCnt) / took.Seconds()
 		rowRate := float64(l.rowCnt) / took.Seconds()
-		l.saveTestResult(took, *start, end, metricRate, rowRate)
+		l.saveTestResult(took, *start, end, metricRate, rowRate, postLoadStats)
+	}
+}
+
+// postLoad lets the DBCreator work on the loaded data, if it needs to
+func (l *CommonBenchmarkRunner) postLoad() map[string]interface{} {
+	dbcp, ok := l.dbCreator.(targets.DBCreatorPostLoad)
+	if !ok || !l.DoLoad {
+		return nil
 	}
+	stats, err := dbcp.PostLoad(l.DBName)
+	if err != nil {
+		log.Println("could not execute PostLoad:" + err.Error())
+		panic(err)
+	}
+	return stats
 }
 
-func (l *CommonBenchmarkRunner) saveTestResult(took time.Duration, start time.Time, end time.Time, metricRate, rowRate float64) {
+func (l *CommonBenchmarkRunner) saveTestResult(took time.Duration, start time.Time, end time.Time, metricRate, rowRate float64, postLoadStats map[string]interface{}) {
 	totals := make(map[string]interface{})
 	totals["metricRate"] = metricRate
 	if l.rowCnt > 0 {
 		totals["rowRate"] = rowRate
 	}
+	for k, v := range postLoadStats {
+		totals[k] = v
+	}
 
 	testResult := LoaderTestResult{
 		ResultFormatVersion: LoaderTestResultVersion,
@@ -283,9 +340,7 @@ func (l *CommonBenchmarkRunner) work(b targets.Benchmark, wg *sync.WaitGroup, c
 	// and send ACKs into duplexChannel.toScanner queue
 	for batch := range c.toWorker {
 		startedWorkAt := time.Now()
-		metricCnt, rowCnt := proc.ProcessBatch(batch, l.DoLoad)
-		atomic.AddUint64(&l.metricCnt, metricCnt)
-		atomic.AddUint64(&l.rowCnt, rowCnt)
+		l.processBatch(proc, batch, workerNum)
 		c.sendToScanner()
 		l.timeToSleep(workerNum, startedWorkAt)
 	}
@@ -299,6 +354,33 @@ func (l *CommonBenchmarkRunner) work(b targets.Benchmark, wg *sync.WaitGroup, c
 	wg.Done()
 }
 
+// processBatch hands the batch to the processor and records the resulting counts
+func (l *CommonBenchmarkRunner) processBatch(proc targets.Processor, batch targets.Batch, workerNum uint) {
+	if l.rateRegulator != nil {
+		l.rateRegulator.Wait(int(batch.Len()))
+	}
+	l.metrics.workerStarted()
+	errorsBefore := processorErrors(proc)
+	startedAt := time.Now()
+	metricCnt, rowCnt := proc.ProcessBatch(batch, l.DoLoad)
+	took := time.Since(startedAt)
+	atomic.AddUint64(&l.metricCnt, metricCnt)
+	atomic.AddUint64(&l.rowCnt, rowCnt)
+	atomic.AddUint64(&l.pointCnt, uint64(batch.Len()))
+	if l.metrics != nil {
+		errCnt := processorErrors(proc) - errorsBefore
+		l.metrics.batchProcessed(strconv.Itoa(int(workerNum)), metricCnt, rowCnt, errCnt, took)
+	}
+}
+
+// processorErrors returns the number of errors reported by proc, if it keeps track of them
+func processorErrors(proc targets.Processor) uint64 {
+	if ec, ok := proc.(targets.ProcessorErrorCounter); ok {
+		return ec.Errors()
+	}
+	return 0
+}
+
 func (l *CommonBenchmarkRunner) timeToSleep(workerNum uint, startedWorkAt time.Time) {
 	if l.sleepRegulator != nil {
 		l.sleepRegulator.Sleep(int(workerNum), startedWorkAt)
@@ -322,9 +404,23 @@ func (l *CommonBenchmarkRunner) report(period time.Duration) {
 	prevTime := start
 	prevColCount := uint64(0)
 	prevRowCount := uint64(0)
+	prevPointCount := uint64(0)
 
-	printFn("time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s\n")
-	for now := range time.NewTicker(period).C {
+	header := "time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s"
+	if l.rateRegulator != nil {
+		header += ",per. point/s,requested point/s"
+	}
+	printFn("%s\n", header)
+	done := l.reportDone
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-done:
+			return
+		case now = <-ticker.C:
+		}
 		cCount := atomic.LoadUint64(&l.metricCnt)
 		rCount := atomic.LoadUint64(&l.rowCnt)
 
@@ -332,13 +428,21 @@ func (l *CommonBenchmarkRunner) report(period time.Duration) {
 		took := now.Sub(prevTime)
 		colrate := float64(cCount-prevColCount) / float64(took.Seconds())
 		overallColRate := float64(cCount) / float64(sinceStart.Seconds())
+		line := fmt.Sprintf("%d,%0.2f,%E,%0.2f", now.Unix(), colrate, float64(cCount), overallColRate)
 		if rCount > 0 {
 			rowrate := float64(rCount-prevRowCount) / float64(took.Seconds())
 			overallRowRate := float64(rCount) / float64(sinceStart.Seconds())
-			printFn("%d,%0.2f,%E,%0.2f,%0.2f,%E,%0.2f\n", now.Unix(), colrate, float64(cCount), overallColRate, rowrate, float64(rCount), overallRowRate)
+			line += fmt.Sprintf(",%0.2f,%E,%0.2f", rowrate, float64(rCount), overallRowRate)
 		} else {
-			printFn("%d,%0.2f,%E,%0.2f,-,-,-\n", now.Unix(), colrate, float64(cCount), overallColRate)
+			line += ",-,-,-"
+		}
+		if l.rateRegulator != nil {
+			pCount := atomic.LoadUint64(&l.pointCnt)
+			pointRate := float64(pCount-prevPointCount) / float64(took.Seconds())
+			line += fmt.Sprintf(",%0.2f,%0.2f", pointRate, l.rateRegulator.RequestedRate(now))
+			prevPointCount = pCount
 		}
+		printFn("%s\n", line)
 
 		prevColCount = cCount
 		prevRowCount = rCount
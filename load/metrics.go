@@ -0,0 +1,53 @@
+package load
+
+import (
+	"time"
+
+	"github.com/timescale/tsbs/internal/metrics"
+)
+
+// loaderMetrics holds the collectors exposed on the Prometheus endpoint
+// while a load benchmark is running
+type loaderMetrics struct {
+	registry      *metrics.Registry
+	metrics       *metrics.Counter
+	rows          *metrics.Counter
+	batches       *metrics.Counter
+	errors        *metrics.Counter
+	workers       *metrics.Gauge
+	batchDuration *metrics.HistogramVec
+}
+
+func newLoaderMetrics() *loaderMetrics {
+	r := metrics.NewRegistry()
+	return &loaderMetrics{
+		registry:      r,
+		metrics:       r.NewCounter("tsbs_load_metrics_total", "Number of metric values inserted."),
+		rows:          r.NewCounter("tsbs_load_rows_total", "Number of rows inserted."),
+		batches:       r.NewCounter("tsbs_load_batches_total", "Number of batches processed."),
+		errors:        r.NewCounter("tsbs_load_errors_total", "Number of write errors reported by the workers."),
+		workers:       r.NewGauge("tsbs_load_workers_in_flight", "Number of workers currently processing a batch."),
+		batchDuration: r.NewHistogramVec("tsbs_load_batch_duration_seconds", "Time taken to process a batch.", "worker", nil),
+	}
+}
+
+// workerStarted marks a worker as busy with a batch
+func (m *loaderMetrics) workerStarted() {
+	if m == nil {
+		return
+	}
+	m.workers.Add(1)
+}
+
+// batchProcessed records the outcome of a single processed batch
+func (m *loaderMetrics) batchProcessed(workerLabel string, metricCnt, rowCnt, errCnt uint64, took time.Duration) {
+	if m == nil {
+		return
+	}
+	m.workers.Add(-1)
+	m.metrics.Add(metricCnt)
+	m.rows.Add(rowCnt)
+	m.errors.Add(errCnt)
+	m.batches.Inc()
+	m.batchDuration.Observe(workerLabel, took.Seconds())
+}
@@ -0,0 +1,141 @@
+package load
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// replayBenchmark wraps a Benchmark so that each of its batches is processed
+// when the timestamp of its earliest point is reached, as if the data file
+// was produced live. The timestamps are offset to the start of the run: the
+// first point read from the file is due at the start, and every other point
+// when as much time passed since the start as between its timestamp and the
+// one of the first point, divided by the speed. Batches that are already due
+// are processed right away, so a load that cannot keep up with the data falls
+// behind instead of skipping points.
+//
+// The points of the data sources are in a form specific to each loader, so
+// their timestamps are read by the wrapped Benchmark, which must be a
+// targets.PointTimestamper.
+type replayBenchmark struct {
+	targets.Benchmark
+	timestamper targets.PointTimestamper
+	clock       *replayClock
+}
+
+// replayClock maps the timestamps of the points to the times they are due.
+// It is set when the scanner appends the first point, before any batch is
+// sent to a worker, and is only read afterwards.
+type replayClock struct {
+	started bool
+	origin  time.Time
+	start   time.Time
+	speed   float64
+}
+
+// NewReplayBenchmark wraps b so that its data is replayed in real time, sped
+// up by speed. The timestamps of the points are read by b, so only the
+// benchmarks that are a targets.PointTimestamper can be replayed.
+func NewReplayBenchmark(b targets.Benchmark, speed float64) (targets.Benchmark, error) {
+	timestamper, ok := b.(targets.PointTimestamper)
+	if !ok {
+		return nil, fmt.Errorf("the benchmark cannot read the timestamps of its points, it does not support replay")
+	}
+	if speed <= 0 {
+		return nil, fmt.Errorf("replay speed must be positive, got %v", speed)
+	}
+	return &replayBenchmark{
+		Benchmark:   b,
+		timestamper: timestamper,
+		clock:       &replayClock{speed: speed},
+	}, nil
+}
+
+// GetBatchFactory returns a factory of batches which keep track of the
+// timestamp of their earliest point
+func (b *replayBenchmark) GetBatchFactory() targets.BatchFactory {
+	return &replayBatchFactory{inner: b.Benchmark.GetBatchFactory(), bench: b}
+}
+
+// GetProcessor returns a processor which waits until a batch is due
+func (b *replayBenchmark) GetProcessor() targets.Processor {
+	return &replayProcessor{Processor: b.Benchmark.GetProcessor(), clock: b.clock}
+}
+
+// due returns the time at which a point with timestamp ts is due
+func (c *replayClock) due(ts time.Time) time.Time {
+	offset := float64(ts.Sub(c.origin)) / c.speed
+	return c.start.Add(time.Duration(offset))
+}
+
+// observe starts the clock with the timestamp of the first point
+func (c *replayClock) observe(ts time.Time) {
+	if !c.started {
+		c.started = true
+		c.origin = ts
+		c.start = time.Now()
+	}
+}
+
+type replayBatchFactory struct {
+	inner targets.BatchFactory
+	bench *replayBenchmark
+}
+
+func (f *replayBatchFactory) New() targets.Batch {
+	return &replayBatch{Batch: f.inner.New(), bench: f.bench}
+}
+
+// replayBatch is a Batch of the wrapped Benchmark and the timestamp of its
+// earliest point
+type replayBatch struct {
+	targets.Batch
+	bench    *replayBenchmark
+	earliest time.Time
+}
+
+// Append appends the point to the wrapped Batch. Points are appended in the
+// order of the data source, so the first one appended starts the clock.
+func (b *replayBatch) Append(item data.LoadedPoint) {
+	ts, err := b.bench.timestamper.PointTimestamp(item)
+	if err != nil {
+		fatal("cannot replay point: %v", err)
+		return
+	}
+	b.bench.clock.observe(ts)
+	if b.earliest.IsZero() || ts.Before(b.earliest) {
+		b.earliest = ts
+	}
+	b.Batch.Append(item)
+}
+
+// replayProcessor processes the batches of the wrapped Processor when they
+// are due
+type replayProcessor struct {
+	targets.Processor
+	clock *replayClock
+}
+
+func (p *replayProcessor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64) {
+	batch := b.(*replayBatch)
+	if wait := time.Until(p.clock.due(batch.earliest)); wait > 0 {
+		time.Sleep(wait)
+	}
+	return p.Processor.ProcessBatch(batch.Batch, doLoad)
+}
+
+// Close closes the wrapped Processor if it is a targets.ProcessorCloser
+func (p *replayProcessor) Close(doLoad bool) {
+	if c, ok := p.Processor.(targets.ProcessorCloser); ok {
+		c.Close(doLoad)
+	}
+}
+
+// Errors returns the errors of the wrapped Processor if it is a
+// targets.ProcessorErrorCounter
+func (p *replayProcessor) Errors() uint64 {
+	return processorErrors(p.Processor)
+}
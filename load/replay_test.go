@@ -0,0 +1,184 @@
+package load
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// replayTestProcessor records when each batch is processed
+type replayTestProcessor struct {
+	testProcessor
+	processed []time.Time
+}
+
+func (p *replayTestProcessor) ProcessBatch(b targets.Batch, _ bool) (metricCount, rowCount uint64) {
+	if _, ok := b.(*testBatch); !ok {
+		panic("wrapped batch passed to the processor")
+	}
+	p.processed = append(p.processed, time.Now())
+	return uint64(b.Len()), 0
+}
+
+func (p *replayTestProcessor) Errors() uint64 {
+	return 3
+}
+
+// replayTestBenchmark is a benchmark whose points are indexes of their
+// timestamps in milliseconds
+type replayTestBenchmark struct {
+	testBenchmark
+	proc   *replayTestProcessor
+	millis []int64
+}
+
+func (b *replayTestBenchmark) GetBatchFactory() targets.BatchFactory { return &testFactory{} }
+func (b *replayTestBenchmark) GetProcessor() targets.Processor       { return b.proc }
+
+func (b *replayTestBenchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	i := int(item.Data.(byte))
+	if i >= len(b.millis) {
+		return time.Time{}, fmt.Errorf("no timestamp for point %d", i)
+	}
+	return time.Unix(0, 0).Add(time.Duration(b.millis[i]) * time.Millisecond), nil
+}
+
+func TestNewReplayBenchmarkErrors(t *testing.T) {
+	cases := []struct {
+		desc  string
+		bench targets.Benchmark
+		speed float64
+	}{
+		{desc: "no timestamper", bench: &testBenchmark{}, speed: 1},
+		{desc: "zero speed", bench: &replayTestBenchmark{}},
+		{desc: "negative speed", bench: &replayTestBenchmark{}, speed: -2},
+	}
+	for _, c := range cases {
+		if _, err := NewReplayBenchmark(c.bench, c.speed); err == nil {
+			t.Errorf("%s: expected error", c.desc)
+		}
+	}
+}
+
+func TestReplayBenchmark(t *testing.T) {
+	cases := []struct {
+		desc   string
+		millis []int64
+		speed  float64
+		// batches are the points of each batch, by index in millis
+		batches [][]int
+		// want is the expected earliest offset of each batch
+		want []time.Duration
+	}{
+		{
+			desc:    "in order",
+			millis:  []int64{1000, 1050, 1100, 1150},
+			speed:   1,
+			batches: [][]int{{0, 1}, {2, 3}},
+			want:    []time.Duration{0, 100 * time.Millisecond},
+		},
+		{
+			desc:    "out of order",
+			millis:  []int64{1000, 1200, 1100, 1300},
+			speed:   1,
+			batches: [][]int{{0}, {1, 2}, {3}},
+			want:    []time.Duration{0, 100 * time.Millisecond, 300 * time.Millisecond},
+		},
+		{
+			desc:    "sped up",
+			millis:  []int64{1000, 3000, 5000},
+			speed:   20,
+			batches: [][]int{{0}, {1}, {2}},
+			want:    []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			desc:    "earlier than the first point",
+			millis:  []int64{1000, 500},
+			speed:   1,
+			batches: [][]int{{0}, {1}},
+			want:    []time.Duration{0, 0},
+		},
+	}
+	for _, c := range cases {
+		proc := &replayTestProcessor{}
+		b, err := NewReplayBenchmark(&replayTestBenchmark{proc: proc, millis: c.millis}, c.speed)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		factory := b.GetBatchFactory()
+		var batches []targets.Batch
+		for _, points := range c.batches {
+			batch := factory.New()
+			for _, i := range points {
+				batch.Append(data.NewLoadedPoint(byte(i)))
+			}
+			batches = append(batches, batch)
+		}
+
+		p := b.GetProcessor()
+		start := time.Now()
+		for _, batch := range batches {
+			if metrics, _ := p.ProcessBatch(batch, true); metrics != uint64(batch.Len()) {
+				t.Errorf("%s: incorrect metric count: got %d want %d", c.desc, metrics, batch.Len())
+			}
+		}
+		for i, processed := range proc.processed {
+			got := processed.Sub(start)
+			if got < c.want[i]-10*time.Millisecond || got > c.want[i]+50*time.Millisecond {
+				t.Errorf("%s: batch %d processed after %v, want %v", c.desc, i, got, c.want[i])
+			}
+		}
+
+		if got := p.(targets.ProcessorErrorCounter).Errors(); got != 3 {
+			t.Errorf("%s: incorrect errors: got %d want 3", c.desc, got)
+		}
+		p.(targets.ProcessorCloser).Close(true)
+		if !proc.closed {
+			t.Errorf("%s: processor not closed", c.desc)
+		}
+	}
+}
+
+func TestReplayMalformedPoint(t *testing.T) {
+	oldFatal := fatal
+	defer func() { fatal = oldFatal }()
+	errMsg := ""
+	fatal = func(format string, args ...interface{}) {
+		errMsg = fmt.Sprintf(format, args...)
+	}
+
+	b, err := NewReplayBenchmark(&replayTestBenchmark{proc: &replayTestProcessor{}}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	batch := b.GetBatchFactory().New()
+	batch.Append(data.NewLoadedPoint(byte(0)))
+	if errMsg == "" {
+		t.Errorf("appending a malformed point did not fail")
+	}
+	if batch.Len() != 0 {
+		t.Errorf("malformed point appended to the batch")
+	}
+}
+
+func TestReplayRequiresReplayBenchmark(t *testing.T) {
+	oldFatal := fatal
+	defer func() { fatal = oldFatal }()
+	fatalCalled := false
+	fatal = func(string, ...interface{}) {
+		fatalCalled = true
+		panic("fatal")
+	}
+
+	br := &CommonBenchmarkRunner{BenchmarkRunnerConfig: BenchmarkRunnerConfig{Replay: true, Workers: 1}}
+	func() {
+		defer func() { recover() }()
+		br.preRun(&testBenchmark{})
+	}()
+	if !fatalCalled {
+		t.Errorf("replay of a benchmark not wrapped by NewReplayBenchmark did not fail")
+	}
+}
@@ -34,3 +34,32 @@ func FastFormatAppend(v interface{}, buf []byte) []byte {
 		panic(fmt.Sprintf("unknown field type for %#v", v))
 	}
 }
+
+// ParseValue parses a value formatted by FastFormatAppend back into a value
+// of the given type, named like reflect.Type.String(). An empty string is
+// parsed as nil, since FastFormatAppend writes nil values as nothing.
+func ParseValue(typ string, s string) (interface{}, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	switch typ {
+	case "int":
+		v, err := strconv.ParseInt(s, 10, 64)
+		return int(v), err
+	case "int64":
+		return strconv.ParseInt(s, 10, 64)
+	case "float64":
+		return strconv.ParseFloat(s, 64)
+	case "float32":
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case "bool":
+		return strconv.ParseBool(s)
+	case "[]uint8":
+		return []byte(s), nil
+	case "string":
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown value type '%s'", typ)
+	}
+}
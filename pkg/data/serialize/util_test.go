@@ -90,3 +90,38 @@ func TestFastFormatAppend(t *testing.T) {
 		}
 	}
 }
+
+func TestParseValue(t *testing.T) {
+	cases := []struct {
+		typ    string
+		input  interface{}
+		errors bool
+	}{
+		{typ: "float64", input: float64(29.37)},
+		{typ: "float64", input: float64(1) / 3},
+		{typ: "float64", input: float64(100)},
+		{typ: "float32", input: float32(29.37)},
+		{typ: "int", input: int(29)},
+		{typ: "int64", input: int64(5000000000)},
+		{typ: "bool", input: true},
+		{typ: "string", input: "string"},
+		{typ: "float64", input: nil},
+		{typ: "int64", input: "x", errors: true},
+		{typ: "complex128", input: "1", errors: true},
+	}
+	for _, c := range cases {
+		formatted := string(FastFormatAppend(c.input, nil))
+		got, err := ParseValue(c.typ, formatted)
+		if c.errors {
+			if err == nil {
+				t.Errorf("%s '%s': expected error", c.typ, formatted)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s '%s': unexpected error: %v", c.typ, formatted, err)
+		} else if got != c.input {
+			t.Errorf("%s '%s': incorrect value: got %#v want %#v", c.typ, formatted, got, c.input)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"fmt"
 	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/compress"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/targets/constants"
 	"strings"
@@ -113,6 +114,8 @@ type BaseConfig struct {
 	Seed  int64
 	Debug int    `yaml:"debug,omitempty" mapstructure:"debug,omitempty"`
 	File  string `yaml:"file,omitempty" mapstructure:"file,omitempty"`
+
+	Compression string `yaml:"compression,omitempty" mapstructure:"compression,omitempty"`
 }
 
 func (c *BaseConfig) AddToFlagSet(fs *pflag.FlagSet) {
@@ -126,7 +129,8 @@ func (c *BaseConfig) AddToFlagSet(fs *pflag.FlagSet) {
 
 	fs.Int64("seed", 0, "PRNG seed (default: 0, which uses the current timestamp)")
 	fs.Int("debug", 0, "Control level of debug output")
-	fs.String("file", "", "Write the output to this path")
+	fs.String("file", "", "Write the output to this path, a directory for the parquet and arrow formats")
+	fs.String("compression", "", fmt.Sprintf("Compress the output (choices: %s). Default: by the extension of --file, none for STDOUT", strings.Join(compress.Codecs, ", ")))
 }
 
 func (c *BaseConfig) Validate() error {
@@ -146,6 +150,10 @@ func (c *BaseConfig) Validate() error {
 		return fmt.Errorf(errBadUseFmt, c.Use)
 	}
 
+	if _, err := compress.ParseCodec(c.Compression); err != nil {
+		return err
+	}
+
 	return nil
 }
 
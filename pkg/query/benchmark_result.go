@@ -11,7 +11,7 @@ type LoaderTestResult struct {
 	RunnerConfig BenchmarkRunnerConfig `json:"RunnerConfig"`
 
 	// Run info
-	StartTime      int64 `json:"StartTime`
+	StartTime      int64 `json:"StartTime"`
 	EndTime        int64 `json:"EndTime"`
 	DurationMillis int64 `json:"DurationMillis"`
 
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/compress"
 	"golang.org/x/time/rate"
 )
 
@@ -38,6 +40,9 @@ type BenchmarkRunnerConfig struct {
 	PrintInterval    uint64 `mapstructure:"print-interval"`
 	PrewarmQueries   bool   `mapstructure:"prewarm-queries"`
 	ResultsFile      string `mapstructure:"results-file"`
+	MetricsAddress   string `mapstructure:"prometheus-listen-address"`
+	ExplainFile      string `mapstructure:"explain-file"`
+	ExplainQueries   uint64 `mapstructure:"explain-queries"`
 }
 
 // AddToFlagSet adds command line flags needed by the BenchmarkRunnerConfig to the flag set.
@@ -55,6 +60,9 @@ func (c BenchmarkRunnerConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.Int("debug", 0, "Whether to print debug messages.")
 	fs.String("file", "", "File name to read queries from")
 	fs.String("results-file", "", "Write the test results summary json to this file")
+	fs.String("prometheus-listen-address", "", "Address (e.g. ':9100') on which to expose live query metrics in Prometheus format under /metrics, default '' => disabled")
+	fs.String("explain-file", "", "Write the plans (EXPLAIN ANALYZE where supported) of the first queries of each label to this file, keyed by label and query ID")
+	fs.Uint64("explain-queries", 1, "Number of queries of each label to capture the plan of with --explain-file")
 }
 
 // BenchmarkRunner contains the common components for running a query benchmarking
@@ -65,6 +73,11 @@ type BenchmarkRunner struct {
 	sp      statProcessor
 	scanner *scanner
 	ch      chan Query
+	metrics *runnerMetrics
+	plans   *planRecorder
+	// createProcessor creates the additional processors of a worker running
+	// the queries of a dashboard concurrently
+	createProcessor ProcessorCreate
 }
 
 // NewBenchmarkRunner creates a new instance of BenchmarkRunner which is
@@ -81,6 +94,13 @@ func NewBenchmarkRunner(config BenchmarkRunnerConfig) *BenchmarkRunner {
 	}
 
 	runner.sp = newStatProcessor(spArgs)
+	if config.MetricsAddress != "" {
+		runner.metrics = newRunnerMetrics()
+	}
+	if config.ExplainFile != "" && config.ExplainQueries > 0 {
+		runner.plans = newPlanRecorder(config.ExplainQueries)
+		runner.scanner.plans = runner.plans
+	}
 	return runner
 }
 
@@ -116,20 +136,24 @@ type Processor interface {
 	ProcessQuery(q Query, isWarm bool) ([]*Stat, error)
 }
 
-// GetBufferedReader returns the buffered Reader that should be used by the loader
+// GetBufferedReader returns the buffered Reader that should be used by the loader.
+// Compressed query files are decompressed, see load.GetBufferedReader
 func (b *BenchmarkRunner) GetBufferedReader() *bufio.Reader {
 	if b.br == nil {
+		var in io.Reader = os.Stdin
 		if len(b.FileName) > 0 {
 			// Read from specified file
 			file, err := os.Open(b.FileName)
 			if err != nil {
 				panic(fmt.Sprintf("cannot open file for read %s: %v", b.FileName, err))
 			}
-			b.br = bufio.NewReaderSize(file, defaultReadSize)
-		} else {
-			// Read from STDIN
-			b.br = bufio.NewReaderSize(os.Stdin, defaultReadSize)
+			in = file
+		}
+		r, err := compress.NewAutoReader(in, b.FileName)
+		if err != nil {
+			panic(fmt.Sprintf("cannot decompress input %s: %v", b.FileName, err))
 		}
+		b.br = bufio.NewReaderSize(r, defaultReadSize)
 	}
 	return b.br
 }
@@ -147,17 +171,26 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, processorCreateFn ProcessorC
 		panic("burn-in is larger than limit")
 	}
 	b.ch = make(chan Query, b.Workers)
+	b.createProcessor = processorCreateFn
 
 	// Launch the stats processor:
 	go b.sp.process(b.Workers)
 
+	if b.metrics != nil {
+		b.metrics.registry.ListenAndServe(b.MetricsAddress)
+	}
+
 	rateLimiter := getRateLimiter(b.LimitRPS, b.Workers)
 
 	// Launch query processors
 	var wg sync.WaitGroup
 	for i := 0; i < int(b.Workers); i++ {
+		processor := processorCreateFn()
+		if _, ok := processor.(Explainer); b.plans != nil && !ok {
+			panic("query plans cannot be captured for this database")
+		}
 		wg.Add(1)
-		go b.processorHandler(&wg, rateLimiter, queryPool, processorCreateFn(), i)
+		go b.processorHandler(&wg, rateLimiter, queryPool, processor, i)
 	}
 
 	// Read in jobs, closing the job channel when done:
@@ -188,6 +221,14 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, processorCreateFn ProcessorC
 		f.Close()
 	}
 
+	// (Optional) save the query plans:
+	if b.plans != nil {
+		_, _ = fmt.Printf("Saving query plans to %s\n", b.ExplainFile)
+		if err := b.plans.write(b.ExplainFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// (Optional) save the results file:
 	if len(b.BenchmarkRunnerConfig.ResultsFile) > 0 {
 		b.saveTestResult(wallTook, wallStart, wallEnd)
@@ -218,11 +259,25 @@ func (b *BenchmarkRunner) saveTestResult(took time.Duration, start time.Time, en
 
 func (b *BenchmarkRunner) processorHandler(wg *sync.WaitGroup, rateLimiter *rate.Limiter, queryPool *sync.Pool, processor Processor, workerNum int) {
 	processor.Init(workerNum)
+	// processors run the queries of a dashboard concurrently, one each
+	processors := []Processor{processor}
 	for query := range b.ch {
+		if dashboard, ok := query.(*dashboardQueries); ok {
+			for len(processors) < len(dashboard.queries) {
+				p := b.createProcessor()
+				p.Init(workerNum)
+				processors = append(processors, p)
+			}
+			b.processDashboard(rateLimiter, queryPool, processors, dashboard)
+			continue
+		}
+
 		r := rateLimiter.Reserve()
 		time.Sleep(r.Delay())
 
+		b.metrics.queryStarted()
 		stats, err := processor.ProcessQuery(query, false)
+		b.metrics.queryFinished(stats, err)
 		if err != nil {
 			panic(err)
 		}
@@ -234,17 +289,93 @@ func (b *BenchmarkRunner) processorHandler(wg *sync.WaitGroup, rateLimiter *rate
 		spArgs := b.sp.getArgs()
 		if spArgs.prewarmQueries {
 			// Warm run
+			b.metrics.queryStarted()
 			stats, err = processor.ProcessQuery(query, true)
+			b.metrics.queryFinished(stats, err)
 			if err != nil {
 				panic(err)
 			}
 			b.sp.sendWarm(stats)
 		}
+
+		b.explain(processor, query)
 		queryPool.Put(query)
 	}
 	wg.Done()
 }
 
+// processDashboard runs the queries of a dashboard concurrently, one per
+// processor. Besides the stats of the queries, the latency of the dashboard,
+// the one of its slowest query, is reported under its own label.
+func (b *BenchmarkRunner) processDashboard(rateLimiter *rate.Limiter, queryPool *sync.Pool, processors []Processor, dashboard *dashboardQueries) {
+	var delay time.Duration
+	for range dashboard.queries {
+		if d := rateLimiter.Reserve().Delay(); d > delay {
+			delay = d
+		}
+	}
+	time.Sleep(delay)
+
+	run := func(isWarm bool) {
+		stats := make([][]*Stat, len(dashboard.queries))
+		var wg sync.WaitGroup
+		for i, q := range dashboard.queries {
+			wg.Add(1)
+			go func(i int, q Query) {
+				defer wg.Done()
+				b.metrics.queryStarted()
+				s, err := processors[i].ProcessQuery(q, isWarm)
+				b.metrics.queryFinished(s, err)
+				if err != nil {
+					panic(err)
+				}
+				stats[i] = s
+			}(i, q)
+		}
+		wg.Wait()
+
+		var latency float64
+		all := []*Stat{}
+		for _, s := range stats {
+			for _, stat := range s {
+				if !stat.isPartial && stat.value > latency {
+					latency = stat.value
+				}
+			}
+			all = append(all, s...)
+		}
+		// the dashboard stat is partial, not to count the queries twice
+		all = append(all, GetPartialStat().Init(append([]byte(labelDashboardPrefix), dashboard.name...), latency))
+		if isWarm {
+			b.sp.sendWarm(all)
+		} else {
+			b.sp.send(all)
+		}
+	}
+	run(false)
+	if b.sp.getArgs().prewarmQueries {
+		run(true)
+	}
+
+	for _, q := range dashboard.queries {
+		b.explain(processors[0], q)
+		queryPool.Put(q)
+	}
+}
+
+// explain captures the plan of the query if it is marked. It is explained
+// after it ran, so that the explain does not warm up the cache for the timed
+// runs.
+func (b *BenchmarkRunner) explain(processor Processor, query Query) {
+	if b.plans != nil && b.plans.isMarked(query) {
+		plan, err := processor.(Explainer).Explain(query)
+		if err != nil {
+			panic(err)
+		}
+		b.plans.add(query, plan)
+	}
+}
+
 func getRateLimiter(limitRPS uint64, workers uint) *rate.Limiter {
 	var requestRate = rate.Inf
 	var requestBurst = 0
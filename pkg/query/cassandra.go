@@ -23,10 +23,13 @@ type Cassandra struct {
 	WhereClause     []byte // e.g. "usage_user,>,90.0"
 	OrderBy         []byte // e.g. "timestamp_ns DESC"
 	Limit           int
-	TagSets         [][]string // semantically, each subgroup is OR'ed and they are all AND'ed together
+	TagSets         [][]string    // semantically, each subgroup is OR'ed and they are all AND'ed together
+	Rollup          time.Duration // e.g. time.Hour, the interval of the rollup table to read instead of the series, if any
+
+	Dashboard
 }
 
-//CassandraPool is a sync.Pool of Cassandra Query types
+// CassandraPool is a sync.Pool of Cassandra Query types
 var CassandraPool = sync.Pool{
 	New: func() interface{} {
 		return &Cassandra{
@@ -60,7 +63,7 @@ func (q *Cassandra) SetID(n uint64) {
 
 // String produces a debug-ready description of a Query.
 func (q *Cassandra) String() string {
-	return fmt.Sprintf("HumanLabel: %s, HumanDescription: %s, MeasurementName: %s, AggregationType: %s, TimeStart: %s, TimeEnd: %s, GroupByDuration: %s, TagSets: %s", q.HumanLabel, q.HumanDescription, q.MeasurementName, q.AggregationType, q.TimeStart, q.TimeEnd, q.GroupByDuration, q.TagSets)
+	return fmt.Sprintf("HumanLabel: %s, HumanDescription: %s, MeasurementName: %s, AggregationType: %s, TimeStart: %s, TimeEnd: %s, GroupByDuration: %s, TagSets: %s, Rollup: %s", q.HumanLabel, q.HumanDescription, q.MeasurementName, q.AggregationType, q.TimeStart, q.TimeEnd, q.GroupByDuration, q.TagSets, q.Rollup)
 }
 
 // HumanLabelName returns the human readable name of this Query
@@ -90,6 +93,8 @@ func (q *Cassandra) Release() {
 	q.OrderBy = q.OrderBy[:0]
 	q.Limit = 0
 	q.TagSets = q.TagSets[:0]
+	q.Rollup = 0
 
+	q.resetDashboard()
 	CassandraPool.Put(q)
 }
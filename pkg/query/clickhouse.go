@@ -14,6 +14,8 @@ type ClickHouse struct {
 	Table    []byte // e.g. "cpu"
 	SqlQuery []byte
 	id       uint64
+
+	Dashboard
 }
 
 // ClickHousePool is a sync.Pool of ClickHouse Query types
@@ -66,5 +68,6 @@ func (ch *ClickHouse) Release() {
 	ch.Table = ch.Table[:0]
 	ch.SqlQuery = ch.SqlQuery[:0]
 
+	ch.resetDashboard()
 	ClickHousePool.Put(ch)
 }
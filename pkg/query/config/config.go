@@ -2,13 +2,42 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
 const ErrEmptyQueryType = "query type cannot be empty"
 
+// Distributions of the random time windows of the queries
+const (
+	// WindowDistributionUniform draws the windows uniformly over the time range
+	WindowDistributionUniform = "uniform"
+	// WindowDistributionRecent biases the windows toward the end of the time
+	// range, like dashboards reading the last hour
+	WindowDistributionRecent = "recent"
+)
+
+// WindowDistributionChoices are the valid values of window-distribution
+var WindowDistributionChoices = []string{WindowDistributionUniform, WindowDistributionRecent}
+
+// Distributions of the hosts or trucks of the queries
+const (
+	// HostDistributionUniform picks every host equally often
+	HostDistributionUniform = "uniform"
+	// HostDistributionZipf picks host_0 most often, host_1 next and so on
+	HostDistributionZipf = "zipf"
+	// HostDistributionHotSet picks the hosts of a small hot set most often
+	HostDistributionHotSet = "hot-set"
+)
+
+// HostDistributionChoices are the valid values of host-distribution
+var HostDistributionChoices = []string{HostDistributionUniform, HostDistributionZipf, HostDistributionHotSet}
+
 // QueryGeneratorConfig is the GeneratorConfig that should be used with a
 // QueryGenerator. It includes all the fields from a BaseConfig, as well as
 // options that are specific to generating the queries to test against a
@@ -20,15 +49,48 @@ type QueryGeneratorConfig struct {
 	InterleavedGroupID   uint   `mapstructure:"interleaved-generation-group-id"`
 	InterleavedNumGroups uint   `mapstructure:"interleaved-generation-groups"`
 
+	// DataConfig is the config sidecar of the loaded data file, to read the
+	// time range and scale of the queries from
+	DataConfig string `mapstructure:"data-config"`
+	// ProbeDB is the connection string of a database to read the time range
+	// and scale of the queries from
+	ProbeDB string `mapstructure:"probe-db"`
+
+	WindowDistribution string        `mapstructure:"window-distribution"`
+	RecencyHalfLife    time.Duration `mapstructure:"recency-half-life"`
+
+	HostDistribution string  `mapstructure:"host-distribution"`
+	ZipfExponent     float64 `mapstructure:"zipf-exponent"`
+	HotSetSize       float64 `mapstructure:"hot-set-size"`
+	HotSetShare      float64 `mapstructure:"hot-set-share"`
+	// NoRepeat never queries the same host or time window twice until all of
+	// them were queried, so the queries read cold data
+	NoRepeat bool `mapstructure:"no-repeat"`
+
+	// Dashboard groups the queries in dashboards of this name: the queries of
+	// a dashboard share their hosts and time range and are run concurrently
+	Dashboard string `mapstructure:"dashboard"`
+	// DashboardQueryTypes are the query types of the dashboard, those of the
+	// predefined dashboard of the name if empty
+	DashboardQueryTypes []string `mapstructure:"dashboard-query-types"`
+
 	// TODO - I think this needs some rethinking, but a simple, elegant solution escapes me right now
 	TimescaleUseJSON       bool `mapstructure:"timescale-use-json"`
 	TimescaleUseTags       bool `mapstructure:"timescale-use-tags"`
 	TimescaleUseTimeBucket bool `mapstructure:"timescale-use-time-bucket"`
 
-	ClickhouseUseTags bool `mapstructure:"clickhouse-use-tags"`
+	TimescaleUseContinuousAggregates bool `mapstructure:"timescale-use-continuous-aggregates"`
+
+	SchemaLayout string `mapstructure:"schema-layout"`
+
+	ClickhouseUseTags         bool `mapstructure:"clickhouse-use-tags"`
+	ClickhouseUseModernSchema bool `mapstructure:"clickhouse-use-modern-schema"`
+
+	CassandraRollups string `mapstructure:"cassandra-rollups"`
 
-	MongoUseNaive bool   `mapstructure:"mongo-use-native"`
-	DbName        string `mapstructure:"db-name"`
+	MongoUseNaive      bool   `mapstructure:"mongo-use-native"`
+	MongoUseTimeSeries bool   `mapstructure:"mongo-use-time-series"`
+	DbName             string `mapstructure:"db-name"`
 }
 
 // Validate checks that the values of the QueryGeneratorConfig are reasonable.
@@ -38,9 +100,45 @@ func (c *QueryGeneratorConfig) Validate() error {
 		return err
 	}
 
-	if c.QueryType == "" {
+	if c.QueryType == "" && c.Dashboard == "" {
 		return fmt.Errorf(ErrEmptyQueryType)
 	}
+	if c.QueryType != "" && c.Dashboard != "" {
+		return fmt.Errorf("query-type and dashboard cannot be used together")
+	}
+
+	if c.SchemaLayout != "" && !utils.IsIn(c.SchemaLayout, constants.SupportedSchemaLayouts()) {
+		return fmt.Errorf("invalid schema layout '%s', valid: %s",
+			c.SchemaLayout, strings.Join(constants.SupportedSchemaLayouts(), ", "))
+	}
+	if c.SchemaLayout == constants.SchemaLayoutNarrow && !utils.IsIn(c.Format, constants.NarrowLayoutFormats()) {
+		return fmt.Errorf("the %s schema layout is not supported for format %s, only for: %s",
+			constants.SchemaLayoutNarrow, c.Format, strings.Join(constants.NarrowLayoutFormats(), ", "))
+	}
+
+	if c.DataConfig != "" && c.ProbeDB != "" {
+		return fmt.Errorf("data-config and probe-db cannot be used together")
+	}
+	if c.ProbeDB != "" && c.Format != constants.FormatTimescaleDB {
+		return fmt.Errorf("probe-db is only supported for the %s format", constants.FormatTimescaleDB)
+	}
+
+	if c.WindowDistribution != "" && !utils.IsIn(c.WindowDistribution, WindowDistributionChoices) {
+		return fmt.Errorf("invalid window distribution '%s', valid: %s",
+			c.WindowDistribution, strings.Join(WindowDistributionChoices, ", "))
+	}
+	if c.WindowDistribution == WindowDistributionRecent && c.RecencyHalfLife <= 0 {
+		return fmt.Errorf("recency half-life must be positive, got %v", c.RecencyHalfLife)
+	}
+
+	if c.HostDistribution != "" && !utils.IsIn(c.HostDistribution, HostDistributionChoices) {
+		return fmt.Errorf("invalid host distribution '%s', valid: %s",
+			c.HostDistribution, strings.Join(HostDistributionChoices, ", "))
+	}
+	if c.NoRepeat && (c.HostDistribution != "" && c.HostDistribution != HostDistributionUniform ||
+		c.WindowDistribution == WindowDistributionRecent) {
+		return fmt.Errorf("no-repeat cannot be used with a skewed host or window distribution")
+	}
 
 	err = utils.ValidateGroups(c.InterleavedGroupID, c.InterleavedNumGroups)
 	return err
@@ -56,11 +154,28 @@ func (c *QueryGeneratorConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.Uint("interleaved-generation-groups", 1,
 		"The number of round-robin serialization groups. Use this to scale up data generation to multiple processes.")
 
+	fs.String("data-config", "", "Read the time range, scale and use case of the queries from the config of the loaded data: the data file or the .config.yaml file written next to it. Overrides --timestamp-start, --timestamp-end and --scale, and --use-case unless it is set")
+	fs.String("probe-db", "", "TimescaleDB only: Read the time range and scale of the queries from the loaded database with this connection string, e.g. 'host=localhost user=postgres dbname=benchmark'. Overrides --timestamp-start, --timestamp-end and --scale")
+	fs.String("window-distribution", WindowDistributionUniform, fmt.Sprintf("Distribution of the time windows of the queries over the time range (choices: %s)", strings.Join(WindowDistributionChoices, ", ")))
+	fs.Duration("recency-half-life", time.Hour, "Half the time windows end within this duration of the end of the time range, with --window-distribution=recent")
+	fs.String("host-distribution", HostDistributionUniform, fmt.Sprintf("Distribution of the hosts or trucks of the queries (choices: %s)", strings.Join(HostDistributionChoices, ", ")))
+	fs.Float64("zipf-exponent", 1.2, "Exponent of the Zipf distribution of the hosts, greater than 1. The greater, the more the first hosts are queried")
+	fs.Float64("hot-set-size", 0.1, "Fraction of the hosts in the hot set, with --host-distribution=hot-set")
+	fs.Float64("hot-set-share", 0.9, "Fraction of the queried hosts picked from the hot set, with --host-distribution=hot-set")
+	fs.String("dashboard", "", "Generate dashboards of this name instead of the queries of --query-type: each of the --queries dashboards is a query of each of its query types, for the same hosts and time range, run concurrently. (Predefined dashboards are listed with the use case matrix.)")
+	fs.StringSlice("dashboard-query-types", nil, "Comma-separated query types of a custom --dashboard")
+	fs.Bool("no-repeat", false, "Cold reads: never query the same host or time window twice until all of them were queried")
+
+	fs.String("schema-layout", constants.SchemaLayoutWide, "Schema layout the data was loaded with, 'wide' or 'narrow' (narrow only for TimescaleDB, ClickHouse, CrateDB and QuestDB)")
 	fs.Bool("clickhouse-use-tags", true, "ClickHouse only: Use separate tags table when querying")
+	fs.Bool("clickhouse-use-modern-schema", false, "ClickHouse only: Query the tables created by the loader with --modern-schema")
+	fs.String("cassandra-rollups", "", "Cassandra only: Comma-separated intervals of the rollup tables created by the loader with --rollups, e.g. 1m,1h. Eligible queries read them")
 	fs.Bool("mongo-use-naive", true, "MongoDB only: Generate queries for the 'naive' data storage format for Mongo")
+	fs.Bool("mongo-use-time-series", false, "MongoDB only: Generate queries for the time-series collection created by the loader with --time-series")
 	fs.Bool("timescale-use-json", false, "TimescaleDB only: Use separate JSON tags table when querying")
 	fs.Bool("timescale-use-tags", true, "TimescaleDB only: Use separate tags table when querying")
 	fs.Bool("timescale-use-time-bucket", true, "TimescaleDB only: Use time bucket. Set to false to test on native PostgreSQL")
+	fs.Bool("timescale-use-continuous-aggregates", false, "TimescaleDB only: Read eligible queries from the cpu_1m and cpu_1h continuous aggregates created by the loader with --continuous-aggregates")
 
 	fs.String("db-name", "benchmark", "Specify database name. Timestream requires it in order to generate the queries")
 }
@@ -14,6 +14,8 @@ type CrateDB struct {
 	Table    []byte // e.g. "cpu"
 	SqlQuery []byte
 	id       uint64
+
+	Dashboard
 }
 
 var CrateDBPool = sync.Pool{
@@ -62,5 +64,6 @@ func (q *CrateDB) Release() {
 	q.Table = q.Table[:0]
 	q.SqlQuery = q.SqlQuery[:0]
 
+	q.resetDashboard()
 	CrateDBPool.Put(q)
 }
@@ -0,0 +1,76 @@
+package query
+
+import (
+	"fmt"
+)
+
+// labelDashboardPrefix prefixes the name of a dashboard in the label of its
+// latency stat
+const labelDashboardPrefix = "dashboard "
+
+// Dashboard is embedded in the queries to group them in dashboards. The
+// queries of a dashboard share their hosts and time range, are consecutive in
+// a query file, and are run concurrently by the query runners.
+type Dashboard struct {
+	DashboardName []byte
+	// DashboardSeq numbers the dashboards of a query file from 1. It is 0 for
+	// a query outside of a dashboard.
+	DashboardSeq uint64
+	// DashboardSize is the number of queries of the dashboard
+	DashboardSize uint64
+}
+
+// GetDashboard returns the dashboard of the query
+func (d *Dashboard) GetDashboard() *Dashboard {
+	return d
+}
+
+func (d *Dashboard) resetDashboard() {
+	d.DashboardName = d.DashboardName[:0]
+	d.DashboardSeq = 0
+	d.DashboardSize = 0
+}
+
+// DashboardMember is implemented by the queries that can be part of a
+// dashboard, which embed a Dashboard
+type DashboardMember interface {
+	GetDashboard() *Dashboard
+}
+
+// dashboardQueries are the queries of a dashboard, read by the scanner and
+// sent to a worker as one unit
+type dashboardQueries struct {
+	seq     uint64
+	name    []byte
+	queries []Query
+}
+
+// Release releases the queries of the dashboard
+func (d *dashboardQueries) Release() {
+	for _, q := range d.queries {
+		q.Release()
+	}
+}
+
+// HumanLabelName returns the name of the dashboard
+func (d *dashboardQueries) HumanLabelName() []byte {
+	return d.name
+}
+
+// HumanDescriptionName returns the name of the dashboard
+func (d *dashboardQueries) HumanDescriptionName() []byte {
+	return d.name
+}
+
+// GetID returns the ID of the first query of the dashboard
+func (d *dashboardQueries) GetID() uint64 {
+	return d.queries[0].GetID()
+}
+
+// SetID does nothing, the queries of the dashboard have their own IDs
+func (d *dashboardQueries) SetID(uint64) {}
+
+// String produces a debug-ready description of the dashboard
+func (d *dashboardQueries) String() string {
+	return fmt.Sprintf("Dashboard: %s, Queries: %d", d.name, len(d.queries))
+}
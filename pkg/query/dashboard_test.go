@@ -0,0 +1,131 @@
+package query
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestScannerDashboards(t *testing.T) {
+	// plain, dashboard 1 of 3, plain, dashboard 2 cut short, dashboard 3 of 2
+	members := []struct {
+		seq, size uint64
+	}{{0, 0}, {1, 3}, {1, 3}, {1, 3}, {0, 0}, {2, 3}, {2, 3}, {3, 2}, {3, 2}}
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	for i, m := range members {
+		q := &TimescaleDB{HumanLabel: []byte{byte('a' + i)}}
+		if m.seq > 0 {
+			q.DashboardName = []byte("overview")
+			q.DashboardSeq = m.seq
+			q.DashboardSize = m.size
+		}
+		if err := enc.Encode(q); err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+	}
+
+	limit := uint64(0)
+	c := make(chan Query, len(members))
+	newScanner(&limit).setReader(&buf).scan(&TimescaleDBPool, c)
+	close(c)
+
+	wantSizes := []int{0, 3, 0, 2, 2}
+	i := 0
+	for q := range c {
+		if i >= len(wantSizes) {
+			t.Fatalf("too many queries scanned")
+		}
+		d, isDashboard := q.(*dashboardQueries)
+		if wantSizes[i] == 0 {
+			if isDashboard {
+				t.Errorf("%d: unexpected dashboard %s", i, d)
+			} else if seq := q.(*TimescaleDB).DashboardSeq; seq != 0 {
+				t.Errorf("%d: query kept the dashboard of a previous query: %d", i, seq)
+			}
+		} else if !isDashboard || len(d.queries) != wantSizes[i] || string(d.name) != "overview" {
+			t.Errorf("%d: incorrect dashboard: %v", i, q)
+		}
+		i++
+	}
+	if i != len(wantSizes) {
+		t.Errorf("incorrect number of units scanned: got %d want %d", i, len(wantSizes))
+	}
+}
+
+// sleepProcessor takes the duration in the label of its queries to run them
+type sleepProcessor struct {
+	mu      sync.Mutex
+	running int
+	maxRun  int
+}
+
+func (p *sleepProcessor) Init(int) {}
+
+func (p *sleepProcessor) ProcessQuery(q Query, _ bool) ([]*Stat, error) {
+	p.mu.Lock()
+	p.running++
+	if p.running > p.maxRun {
+		p.maxRun = p.running
+	}
+	p.mu.Unlock()
+
+	d, _ := time.ParseDuration(string(q.HumanLabelName()))
+	time.Sleep(d)
+
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return []*Stat{GetStat().Init(q.HumanLabelName(), float64(d.Milliseconds()))}, nil
+}
+
+func TestProcessorHandlerDashboard(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string][]float64{}
+	partial := map[string]bool{}
+	b := &BenchmarkRunner{
+		sp: &mockStatProcessor{
+			args: &statProcessorArgs{},
+			onSend: func(stats []*Stat) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, s := range stats {
+					got[string(s.label)] = append(got[string(s.label)], s.value)
+					partial[string(s.label)] = s.isPartial
+				}
+			},
+		},
+	}
+	p := &sleepProcessor{}
+	b.createProcessor = func() Processor { return p }
+	b.ch = make(chan Query, 1)
+	b.ch <- &dashboardQueries{
+		name: []byte("overview"),
+		queries: []Query{
+			&testQuery{HumanLabel: []byte("50ms")},
+			&testQuery{HumanLabel: []byte("100ms")},
+			&testQuery{HumanLabel: []byte("20ms")},
+		},
+	}
+	close(b.ch)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	b.processorHandler(&wg, rate.NewLimiter(rate.Inf, 0), &testQueryPool, p, 0)
+
+	if p.maxRun != 3 {
+		t.Errorf("dashboard queries not run concurrently: %d at most", p.maxRun)
+	}
+	for _, label := range []string{"50ms", "100ms", "20ms"} {
+		if len(got[label]) != 1 || partial[label] {
+			t.Errorf("incorrect stats of %s: %v", label, got[label])
+		}
+	}
+	if v := got["dashboard overview"]; len(v) != 1 || v[0] != 100 || !partial["dashboard overview"] {
+		t.Errorf("incorrect dashboard stat: %v", v)
+	}
+}
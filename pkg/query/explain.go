@@ -0,0 +1,83 @@
+package query
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Explainer is implemented by the Processors of the databases that can show
+// the plan of a query.
+type Explainer interface {
+	// Explain returns the plan of the query, with EXPLAIN ANALYZE where the
+	// database supports it. The query is not timed.
+	Explain(q Query) (string, error)
+}
+
+// planRecorder captures the plans of the first queries of each label. The
+// queries are marked by the scanner, in the order of the query file, so the
+// same queries are explained whatever the order the workers run them in, and
+// the plans of two runs of a query file can be diffed.
+type planRecorder struct {
+	perLabel uint64
+
+	mu     sync.Mutex
+	counts map[string]uint64
+	marked map[uint64]bool
+	// plans are the lines of the plans by label and query ID
+	plans map[string]map[string][]string
+}
+
+func newPlanRecorder(perLabel uint64) *planRecorder {
+	return &planRecorder{
+		perLabel: perLabel,
+		counts:   map[string]uint64{},
+		marked:   map[uint64]bool{},
+		plans:    map[string]map[string][]string{},
+	}
+}
+
+// mark marks the query to be explained if it is one of the first queries of
+// its label.
+func (r *planRecorder) mark(q Query) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	label := string(q.HumanLabelName())
+	if r.counts[label] < r.perLabel {
+		r.counts[label]++
+		r.marked[q.GetID()] = true
+	}
+}
+
+// isMarked returns whether the query was marked to be explained
+func (r *planRecorder) isMarked(q Query) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.marked[q.GetID()]
+}
+
+// add records the plan of a query
+func (r *planRecorder) add(q Query, plan string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	label := string(q.HumanLabelName())
+	if r.plans[label] == nil {
+		r.plans[label] = map[string][]string{}
+	}
+	r.plans[label][strconv.FormatUint(q.GetID(), 10)] = strings.Split(strings.TrimRight(plan, "\n"), "\n")
+}
+
+// write writes the plans to a JSON file, as an object of the plans of each
+// label keyed by query ID, with a plan as an array of its lines. The keys are
+// sorted so the files of two runs can be diffed.
+func (r *planRecorder) write(fileName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := json.MarshalIndent(r.plans, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(file, '\n'), 0644)
+}
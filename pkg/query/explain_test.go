@@ -0,0 +1,93 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+type testExplainer struct {
+	testProcessor
+	explained []uint64
+}
+
+func (p *testExplainer) Explain(q Query) (string, error) {
+	p.explained = append(p.explained, q.GetID())
+	return fmt.Sprintf("Seq Scan on %s\n  query %d\n", q.HumanLabelName(), q.GetID()), nil
+}
+
+func TestPlanRecorderMark(t *testing.T) {
+	r := newPlanRecorder(2)
+	labels := []string{"a", "b", "a", "a", "b", "c", "b"}
+	var marked []uint64
+	for i, label := range labels {
+		q := &testQuery{HumanLabel: []byte(label), ID: uint64(i)}
+		r.mark(q)
+		if r.isMarked(q) {
+			marked = append(marked, q.ID)
+		}
+	}
+	if want := []uint64{0, 1, 2, 4, 5}; !reflect.DeepEqual(marked, want) {
+		t.Errorf("incorrect marked queries: got %v want %v", marked, want)
+	}
+}
+
+func TestProcessorHandlerExplain(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "plans.json")
+	b := NewBenchmarkRunner(BenchmarkRunnerConfig{ExplainFile: fileName, ExplainQueries: 1})
+	b.ch = make(chan Query, 4)
+
+	labels := []string{"a", "b", "a", "b"}
+	for i, label := range labels {
+		q := &testQuery{HumanLabel: []byte(label), ID: uint64(i)}
+		b.plans.mark(q)
+		b.ch <- q
+	}
+	close(b.ch)
+
+	p := &testExplainer{}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	b.processorHandler(&wg, rate.NewLimiter(rate.Inf, 0), &testQueryPool, p, 0)
+	if p.count != len(labels) {
+		t.Errorf("incorrect number of queries run: %d", p.count)
+	}
+	if want := []uint64{0, 1}; !reflect.DeepEqual(p.explained, want) {
+		t.Errorf("incorrect explained queries: got %v want %v", p.explained, want)
+	}
+
+	if err := b.plans.write(fileName); err != nil {
+		t.Fatalf("could not write plans: %v", err)
+	}
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]map[string][]string
+	if err := json.Unmarshal(contents, &got); err != nil {
+		t.Fatalf("invalid plans file: %v", err)
+	}
+	want := map[string]map[string][]string{
+		"a": {"0": {"Seq Scan on a", "  query 0"}},
+		"b": {"1": {"Seq Scan on b", "  query 1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect plans: got %v want %v", got, want)
+	}
+}
+
+func TestBenchmarkRunnerRunPanicOnNoExplainer(t *testing.T) {
+	b := NewBenchmarkRunner(BenchmarkRunnerConfig{Workers: 1, ExplainFile: "plans.json", ExplainQueries: 1})
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("did not panic for a processor that cannot explain")
+		}
+	}()
+	b.Run(&testQueryPool, func() Processor { return &testProcessor{} })
+}
@@ -19,27 +19,38 @@ import (
 
 func InitQueryFactories(config *config.QueryGeneratorConfig) map[string]interface{} {
 	factories := make(map[string]interface{})
-	factories[constants.FormatCassandra] = &cassandra.BaseGenerator{}
+	factories[constants.FormatCassandra] = &cassandra.BaseGenerator{
+		Rollups: config.CassandraRollups,
+	}
 	factories[constants.FormatClickhouse] = &clickhouse.BaseGenerator{
-		UseTags: config.ClickhouseUseTags,
+		UseTags:         config.ClickhouseUseTags,
+		UseModernSchema: config.ClickhouseUseModernSchema,
+		UseNarrowLayout: config.SchemaLayout == constants.SchemaLayoutNarrow,
+	}
+	factories[constants.FormatCrateDB] = &cratedb.BaseGenerator{
+		UseNarrowLayout: config.SchemaLayout == constants.SchemaLayoutNarrow,
 	}
-	factories[constants.FormatCrateDB] = &cratedb.BaseGenerator{}
 	factories[constants.FormatInflux] = &influx.BaseGenerator{}
 	factories[constants.FormatInfluxDB3] = &influxdb3.BaseGenerator{}
 	factories[constants.FormatTimescaleDB] = &timescaledb.BaseGenerator{
-		UseJSON:       config.TimescaleUseJSON,
-		UseTags:       config.TimescaleUseTags,
-		UseTimeBucket: config.TimescaleUseTimeBucket,
+		UseJSON:                 config.TimescaleUseJSON,
+		UseTags:                 config.TimescaleUseTags,
+		UseTimeBucket:           config.TimescaleUseTimeBucket,
+		UseContinuousAggregates: config.TimescaleUseContinuousAggregates,
+		UseNarrowLayout:         config.SchemaLayout == constants.SchemaLayoutNarrow,
 	}
 	factories[constants.FormatSiriDB] = &siridb.BaseGenerator{}
 	factories[constants.FormatMongo] = &mongo.BaseGenerator{
-		UseNaive: config.MongoUseNaive,
+		UseNaive:      config.MongoUseNaive,
+		UseTimeSeries: config.MongoUseTimeSeries,
 	}
 	factories[constants.FormatAkumuli] = &akumuli.BaseGenerator{}
 	factories[constants.FormatVictoriaMetrics] = &victoriametrics.BaseGenerator{}
 	factories[constants.FormatTimestream] = &timestream.BaseGenerator{
 		DBName: config.DbName,
 	}
-	factories[constants.FormatQuestDB] = &questdb.BaseGenerator{}
+	factories[constants.FormatQuestDB] = &questdb.BaseGenerator{
+		UseNarrowLayout: config.SchemaLayout == constants.SchemaLayoutNarrow,
+	}
 	return factories
 }
@@ -17,6 +17,8 @@ type HTTP struct {
 	StartTimestamp   int64
 	EndTimestamp     int64
 	id               uint64
+
+	Dashboard
 }
 
 // HTTPPool is a sync.Pool of HTTP Query types
@@ -76,5 +78,6 @@ func (q *HTTP) Release() {
 	q.StartTimestamp = 0
 	q.EndTimestamp = 0
 
+	q.resetDashboard()
 	HTTPPool.Put(q)
 }
@@ -13,6 +13,8 @@ type InfluxDB3 struct {
 
 	SqlQuery []byte
 	id       uint64
+
+	Dashboard
 }
 
 // InfluxDB3Pool is a sync.Pool of InfluxDB3 Query types
@@ -64,5 +66,6 @@ func (q *InfluxDB3) Release() {
 
 	q.SqlQuery = q.SqlQuery[:0]
 
+	q.resetDashboard()
 	InfluxDB3Pool.Put(q)
 }
@@ -0,0 +1,52 @@
+package query
+
+import (
+	"github.com/timescale/tsbs/internal/metrics"
+)
+
+// runnerMetrics holds the collectors exposed on the Prometheus endpoint
+// while a query benchmark is running
+type runnerMetrics struct {
+	registry *metrics.Registry
+	queries  *metrics.Counter
+	errors   *metrics.Counter
+	workers  *metrics.Gauge
+	latency  *metrics.HistogramVec
+}
+
+func newRunnerMetrics() *runnerMetrics {
+	r := metrics.NewRegistry()
+	return &runnerMetrics{
+		registry: r,
+		queries:  r.NewCounter("tsbs_query_queries_total", "Number of queries executed."),
+		errors:   r.NewCounter("tsbs_query_errors_total", "Number of queries that returned an error."),
+		workers:  r.NewGauge("tsbs_query_workers_in_flight", "Number of workers currently executing a query."),
+		latency:  r.NewHistogramVec("tsbs_query_duration_seconds", "Query latency by query label.", "label", nil),
+	}
+}
+
+// queryStarted marks a worker as busy with a query
+func (m *runnerMetrics) queryStarted() {
+	if m == nil {
+		return
+	}
+	m.workers.Add(1)
+}
+
+// queryFinished records the stats of a finished query. It must be called
+// before the stats are handed over to the stat processor, which recycles them.
+func (m *runnerMetrics) queryFinished(stats []*Stat, err error) {
+	if m == nil {
+		return
+	}
+	m.workers.Add(-1)
+	m.queries.Inc()
+	if err != nil {
+		m.errors.Inc()
+		return
+	}
+	for _, s := range stats {
+		// stat values are in milliseconds
+		m.latency.Observe(string(s.label), s.value/1e3)
+	}
+}
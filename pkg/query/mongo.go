@@ -15,6 +15,8 @@ type Mongo struct {
 	CollectionName   []byte
 	BsonDoc          []bson.M
 	id               uint64
+
+	Dashboard
 }
 
 // MongoPool is a sync.Pool of Mongo Query types
@@ -67,5 +69,6 @@ func (q *Mongo) Release() {
 	q.CollectionName = q.CollectionName[:0]
 	q.BsonDoc = nil
 
+	q.resetDashboard()
 	MongoPool.Put(q)
 }
@@ -0,0 +1,206 @@
+// Package influxdb3 runs the queries generated for InfluxDB 3. It is shared
+// by tsbs_run_queries_influxdb3 and tsbs_scenario.
+package influxdb3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/InfluxCommunity/influxdb3-go/influxdb3"
+	"github.com/apache/arrow/go/v15/arrow/flight/flightsql"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases"
+	"github.com/timescale/tsbs/pkg/query"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// Options are the connection and output settings of the processors
+type Options struct {
+	// Host is the host and port of InfluxDB, with or without the scheme
+	Host  string
+	Token string
+	// Bucket is the bucket of serverless, Database the one of dedicated
+	Bucket   string
+	Database string
+	// Bearer is used instead of Token when set
+	Bearer string
+	// Secure uses TLS for the FlightSQL connection
+	Secure bool
+	// FlightSQL runs the queries with FlightSQL rather than the client
+	FlightSQL      bool
+	PrintResponses bool
+}
+
+// NewProcessorCreate returns the query.ProcessorCreate of processors
+// querying with opts
+func NewProcessorCreate(opts *Options) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{opts: opts}
+	}
+}
+
+type processor struct {
+	client          *influxdb3.Client
+	flightSqlClient *flightsql.Client
+	opts            *Options
+}
+
+func (p *processor) Init(workerNumber int) {
+	hostPort := p.opts.Host
+
+	cfg := influxdb3.ClientConfig{
+		Host:     hostPort,
+		Token:    p.opts.Token,
+		Database: p.opts.Database,
+	}
+	if p.opts.Bearer != "" {
+		cfg.Token = p.opts.Bearer
+	}
+	client, err := influxdb3.New(cfg)
+	databases.PanicIfErr(err)
+	p.client = client
+
+	hostPort = strings.Replace(hostPort, "http://", "", 1)
+	hostPort = strings.Replace(hostPort, "https://", "", 1)
+
+	var dialOpt grpc.DialOption
+	if p.opts.Secure {
+		clientCreds := credentials.NewTLS(&tls.Config{})
+		dialOpt = grpc.WithTransportCredentials(clientCreds)
+	} else {
+		dialOpt = grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	flightSqlClient, err := flightsql.NewClient(hostPort, nil, nil, dialOpt)
+	databases.PanicIfErr(err)
+	p.flightSqlClient = flightSqlClient
+}
+
+func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+	tq := q.(*query.InfluxDB3)
+	start := time.Now()
+	qry := string(tq.SqlQuery)
+
+	if p.opts.FlightSQL {
+		ctx := p.flightSQLContext()
+		flightInfo, err := p.flightSqlClient.Execute(ctx, qry)
+		databases.PanicIfErr(err)
+
+		if p.opts.PrintResponses {
+			output := ""
+			for _, endpoint := range flightInfo.Endpoint {
+				flightReader, err := p.flightSqlClient.DoGet(ctx, endpoint.Ticket)
+				databases.PanicIfErr(err)
+				for flightReader.Next() {
+					record := flightReader.Record()
+					output += fmt.Sprintf("%v\n", record)
+				}
+				flightReader.Release()
+			}
+
+			fmt.Printf("%s\n\n%s\n-----\n\n", qry, output)
+		} else {
+			for _, endpoint := range flightInfo.Endpoint {
+				flightReader, err := p.flightSqlClient.DoGet(ctx, endpoint.Ticket)
+				databases.PanicIfErr(err)
+				// Fetching all the rows to confirm that the query is fully completed.
+				for flightReader.Next() {
+				}
+				flightReader.Release()
+			}
+		}
+	} else {
+		iterator, err := p.client.Query(context.Background(), qry)
+		databases.PanicIfErr(err)
+
+		if p.opts.PrintResponses {
+			output := ""
+			for iterator.Next() {
+				value := iterator.Value()
+				output += fmt.Sprintf("%s\n", fmt.Sprint(value))
+			}
+			fmt.Printf("%s\n\n%s\n-----\n\n", qry, output)
+		} else {
+			// Fetching all the rows to confirm that the query is fully completed.
+			for iterator.Next() {
+			}
+		}
+	}
+
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took)
+
+	return []*query.Stat{stat}, nil
+}
+
+// flightSQLContext returns the context of the FlightSQL requests, with the
+// credentials and the bucket or database in its metadata
+func (p *processor) flightSQLContext() context.Context {
+	ctx := context.Background()
+	if p.opts.Bearer != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", fmt.Sprintf("Bearer %s", p.opts.Bearer))
+	} else {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", fmt.Sprintf("Token %s", p.opts.Token))
+	}
+	if p.opts.Bucket != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "bucket-name", p.opts.Bucket)
+	}
+	if p.opts.Database != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "database", p.opts.Database)
+	}
+	return ctx
+}
+
+// Explain implements query.Explainer, it runs the query with EXPLAIN ANALYZE.
+// Each row of the output is a plan type, e.g. "Plan with Metrics", followed
+// by its plan.
+func (p *processor) Explain(q query.Query) (string, error) {
+	qry := "EXPLAIN ANALYZE " + string(q.(*query.InfluxDB3).SqlQuery)
+	text := ""
+	if p.opts.FlightSQL {
+		ctx := p.flightSQLContext()
+		flightInfo, err := p.flightSqlClient.Execute(ctx, qry)
+		if err != nil {
+			return "", err
+		}
+		for _, endpoint := range flightInfo.Endpoint {
+			flightReader, err := p.flightSqlClient.DoGet(ctx, endpoint.Ticket)
+			if err != nil {
+				return "", err
+			}
+			for flightReader.Next() {
+				record := flightReader.Record()
+				for i := 0; i < int(record.NumRows()); i++ {
+					for _, col := range record.Columns() {
+						text += col.ValueStr(i) + "\n"
+					}
+				}
+			}
+			err = flightReader.Err()
+			flightReader.Release()
+			if err != nil {
+				return "", err
+			}
+		}
+		return text, nil
+	}
+
+	iterator, err := p.client.Query(context.Background(), qry)
+	if err != nil {
+		return "", err
+	}
+	for iterator.Next() {
+		value := iterator.Value()
+		text += fmt.Sprintf("%v\n%v\n", value["plan_type"], value["plan"])
+	}
+	// the iterator has no Err, the one of its reader tells why Next stopped
+	if err := iterator.Raw().Err(); err != nil {
+		return "", err
+	}
+	return text, nil
+}
@@ -0,0 +1,200 @@
+// Package timescaledb runs the queries generated for TimescaleDB. It is shared
+// by tsbs_run_queries_timescaledb and tsbs_scenario.
+package timescaledb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+const pgxDriver = "pgx" // default driver
+const pqDriver = "postgres"
+
+// Options are the connection and output settings of the processors
+type Options struct {
+	// Postgres holds additional PostgreSQL connection parameters, e.g.
+	// 'sslmode=disable'. Parameters for host, database and user are ignored.
+	Postgres string
+	// Hosts are the PostgreSQL hosts, the workers are spread over them
+	Hosts []string
+	User  string
+	Pass  string
+	Port  string
+
+	ShowExplain     bool
+	ForceTextFormat bool
+	Debug           bool
+	PrintResponses  bool
+}
+
+// driver returns the database/sql driver of the connections
+func (o *Options) driver() string {
+	if o.ForceTextFormat {
+		return pqDriver
+	}
+	return pgxDriver
+}
+
+// ConnectString returns the connection string of a worker to dbName.
+//
+// If we're running queries against multiple nodes we need to balance the queries
+// across replicas. Each worker is assigned a sequence number -- we'll use that
+// to evenly distribute hosts to worker connections
+func (o *Options) ConnectString(dbName string, workerNumber int) string {
+	// User might be passing in host=hostname the connect string out of habit which may override the
+	// multi host configuration. Same for dbname= and user=. This sanitizes that.
+	re := regexp.MustCompile(`(host|dbname|user)=\S*\b`)
+	connectString := re.ReplaceAllString(o.Postgres, "")
+
+	// Round robin the host/worker assignment by assigning a host based on workerNumber % totalNumberOfHosts
+	host := o.Hosts[workerNumber%len(o.Hosts)]
+	connectString = fmt.Sprintf("host=%s dbname=%s user=%s %s", host, dbName, o.User, connectString)
+
+	// For optional parameters, ensure they exist then interpolate them into the connectString
+	if len(o.Port) > 0 {
+		connectString = fmt.Sprintf("%s port=%s", connectString, o.Port)
+	}
+	if len(o.Pass) > 0 {
+		connectString = fmt.Sprintf("%s password=%s", connectString, o.Pass)
+	}
+	if o.ForceTextFormat {
+		connectString = fmt.Sprintf("%s disable_prepared_binary_result=yes binary_parameters=no", connectString)
+	}
+
+	return connectString
+}
+
+// NewProcessorCreate returns the query.ProcessorCreate of processors
+// querying dbName with opts
+func NewProcessorCreate(dbName string, opts *Options) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{dbName: dbName, opts: opts}
+	}
+}
+
+// prettyPrintResponse prints a Query and its response in JSON format with two
+// keys: 'query' which has a value of the SQL used to generate the second key
+// 'results' which is an array of each row in the return set.
+func prettyPrintResponse(rows *sql.Rows, q *query.TimescaleDB) {
+	resp := make(map[string]interface{})
+	resp["query"] = string(q.SqlQuery)
+	resp["results"] = mapRows(rows)
+
+	line, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(line) + "\n")
+}
+
+func mapRows(r *sql.Rows) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	cols, _ := r.Columns()
+	for r.Next() {
+		row := make(map[string]interface{})
+		values := make([]interface{}, len(cols))
+		for i := range values {
+			values[i] = new(interface{})
+		}
+
+		err := r.Scan(values...)
+		if err != nil {
+			panic(errors.Wrap(err, "error while reading values"))
+		}
+
+		for i, column := range cols {
+			row[column] = *values[i].(*interface{})
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type processor struct {
+	db     *sql.DB
+	dbName string
+	opts   *Options
+}
+
+func (p *processor) Init(workerNumber int) {
+	db, err := sql.Open(p.opts.driver(), p.opts.ConnectString(p.dbName, workerNumber))
+	if err != nil {
+		panic(err)
+	}
+	p.db = db
+}
+
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	// No need to run again for EXPLAIN
+	if isWarm && p.opts.ShowExplain {
+		return nil, nil
+	}
+	tq := q.(*query.TimescaleDB)
+
+	start := time.Now()
+	qry := string(tq.SqlQuery)
+	if p.opts.ShowExplain {
+		qry = "EXPLAIN ANALYZE " + qry
+	}
+	rows, err := p.db.Query(qry)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.opts.Debug {
+		fmt.Println(qry)
+	}
+	if p.opts.ShowExplain {
+		text, err2 := explainText(rows)
+		if err2 != nil {
+			panic(err2)
+		}
+		fmt.Printf("%s\n\n%s\n-----\n\n", qry, text)
+	} else if p.opts.PrintResponses {
+		prettyPrintResponse(rows, tq)
+	}
+	// Fetching all the rows to confirm that the query is fully completed.
+	for rows.Next() {
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took)
+
+	return []*query.Stat{stat}, err
+}
+
+// Explain implements query.Explainer, it runs the query with EXPLAIN ANALYZE
+func (p *processor) Explain(q query.Query) (string, error) {
+	rows, err := p.db.Query("EXPLAIN ANALYZE " + string(q.(*query.TimescaleDB).SqlQuery))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	return explainText(rows)
+}
+
+// explainText returns the lines of the text output of an EXPLAIN
+func explainText(rows *sql.Rows) (string, error) {
+	text := ""
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return "", err
+		}
+		text += s + "\n"
+	}
+	return text, rows.Err()
+}
@@ -0,0 +1,30 @@
+package timescaledb
+
+import "testing"
+
+func TestConnectString(t *testing.T) {
+	opts := &Options{
+		Postgres: "host=ignored user=ignored sslmode=disable",
+		Hosts:    []string{"node1", "node2"},
+		User:     "postgres",
+		Port:     "5432",
+		Pass:     "secret",
+	}
+	cases := []struct {
+		worker int
+		want   string
+	}{
+		{worker: 0, want: "host=node1 dbname=benchmark user=postgres   sslmode=disable port=5432 password=secret"},
+		{worker: 3, want: "host=node2 dbname=benchmark user=postgres   sslmode=disable port=5432 password=secret"},
+	}
+	for _, c := range cases {
+		if got := opts.ConnectString("benchmark", c.worker); got != c.want {
+			t.Errorf("incorrect connect string for worker %d: got %q want %q", c.worker, got, c.want)
+		}
+	}
+
+	opts.ForceTextFormat = true
+	if got := opts.driver(); got != pqDriver {
+		t.Errorf("incorrect driver with text format: got %s want %s", got, pqDriver)
+	}
+}
@@ -0,0 +1,91 @@
+// Package victoriametrics runs the queries generated for VictoriaMetrics. It
+// is shared by tsbs_run_queries_victoriametrics and tsbs_scenario.
+package victoriametrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Options are the settings of the processors
+type Options struct {
+	// URLs are the VictoriaMetrics URLs (single-node or VMSelect), the
+	// workers are spread over them
+	URLs           []string
+	PrintResponses bool
+}
+
+// NewProcessorCreate returns the query.ProcessorCreate of processors
+// querying with opts
+func NewProcessorCreate(opts *Options) query.ProcessorCreate {
+	return func() query.Processor {
+		return &processor{opts: opts}
+	}
+}
+
+// query.Processor interface implementation
+type processor struct {
+	url  string
+	opts *Options
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNum int) {
+	p.url = p.opts.URLs[workerNum%len(p.opts.URLs)]
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, err := p.do(hq)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag)
+	return []*query.Stat{stat}, nil
+}
+
+func (p *processor) do(q *query.HTTP) (float64, error) {
+	// populate a request with data from the Query:
+	req, err := http.NewRequest(string(q.Method), p.url+string(q.Path), nil)
+	if err != nil {
+		return 0, fmt.Errorf("error while creating request: %s", err)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("query execution error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("error while reading response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	// Pretty print JSON responses, if applicable:
+	if p.opts.PrintResponses {
+		var pretty bytes.Buffer
+		prefix := fmt.Sprintf("ID %d: ", q.GetID())
+		if err := json.Indent(&pretty, body, prefix, "  "); err != nil {
+			return lag, err
+		}
+		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, pretty.Bytes())
+		if err != nil {
+			return lag, err
+		}
+	}
+	return lag, nil
+}
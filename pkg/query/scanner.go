@@ -12,6 +12,8 @@ import (
 type scanner struct {
 	r     io.Reader
 	limit *uint64
+	// plans marks the queries to explain, if set
+	plans *planRecorder
 }
 
 // newScanner returns a new scanner for a given Reader and its limit
@@ -25,10 +27,12 @@ func (s *scanner) setReader(r io.Reader) *scanner {
 	return s
 }
 
-// scan reads encoded Queries and places them into a channel
+// scan reads encoded Queries and places them into a channel. The queries of a
+// dashboard are placed in the channel together, as one dashboardQueries.
 func (s *scanner) scan(pool *sync.Pool, c chan Query) {
 	decoder := gob.NewDecoder(s.r)
 
+	var dashboard *dashboardQueries
 	n := uint64(0)
 	for {
 		if *s.limit > 0 && n >= *s.limit {
@@ -37,6 +41,13 @@ func (s *scanner) scan(pool *sync.Pool, c chan Query) {
 		}
 
 		q := pool.Get().(Query)
+		d, isMember := q.(DashboardMember)
+		if isMember {
+			// fields absent from the encoded query are not decoded, so a
+			// query outside of a dashboard must not keep the dashboard of a
+			// previous one
+			d.GetDashboard().resetDashboard()
+		}
 		err := decoder.Decode(q)
 		if err == io.EOF {
 			// EOF, all done
@@ -49,9 +60,34 @@ func (s *scanner) scan(pool *sync.Pool, c chan Query) {
 
 		// We have a query, send it to the runner
 		q.SetID(n)
-		c <- q
-
+		if s.plans != nil {
+			s.plans.mark(q)
+		}
 		// Queries counter
 		n++
+
+		if dashboard != nil && (!isMember || d.GetDashboard().DashboardSeq != dashboard.seq) {
+			// the previous dashboard was cut short
+			c <- dashboard
+			dashboard = nil
+		}
+		if !isMember || d.GetDashboard().DashboardSize < 2 {
+			c <- q
+			continue
+		}
+		if dashboard == nil {
+			dashboard = &dashboardQueries{
+				seq:  d.GetDashboard().DashboardSeq,
+				name: append([]byte{}, d.GetDashboard().DashboardName...),
+			}
+		}
+		dashboard.queries = append(dashboard.queries, q)
+		if uint64(len(dashboard.queries)) == d.GetDashboard().DashboardSize {
+			c <- dashboard
+			dashboard = nil
+		}
+	}
+	if dashboard != nil {
+		c <- dashboard
 	}
 }
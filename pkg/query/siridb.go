@@ -10,6 +10,8 @@ type SiriDB struct {
 	HumanDescription []byte
 	SqlQuery         []byte
 	id               uint64
+
+	Dashboard
 }
 
 var SiriDBPool = sync.Pool{
@@ -58,5 +60,6 @@ func (q *SiriDB) Release() {
 	q.id = 0
 	q.SqlQuery = q.SqlQuery[:0]
 
+	q.resetDashboard()
 	SiriDBPool.Put(q)
 }
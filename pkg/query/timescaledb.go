@@ -14,6 +14,8 @@ type TimescaleDB struct {
 	Hypertable []byte // e.g. "cpu"
 	SqlQuery   []byte
 	id         uint64
+
+	Dashboard
 }
 
 // TimescaleDBPool is a sync.Pool of TimescaleDB Query types
@@ -67,5 +69,6 @@ func (q *TimescaleDB) Release() {
 	q.Hypertable = q.Hypertable[:0]
 	q.SqlQuery = q.SqlQuery[:0]
 
+	q.resetDashboard()
 	TimescaleDBPool.Put(q)
 }
@@ -14,6 +14,8 @@ type Timestream struct {
 	Table    []byte // e.g. "cpu"
 	SqlQuery []byte
 	id       uint64
+
+	Dashboard
 }
 
 // TimestreamPool is a sync.Pool of Timestream Query types
@@ -69,5 +71,6 @@ func (q *Timestream) Release() {
 	q.Table = q.Table[:0]
 	q.SqlQuery = q.SqlQuery[:0]
 
+	q.resetDashboard()
 	TimestreamPool.Put(q)
 }
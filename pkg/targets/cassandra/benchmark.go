@@ -9,6 +9,7 @@ import (
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
 	"log"
+	"time"
 )
 
 type benchmark struct {
@@ -28,12 +29,34 @@ func NewBenchmark(dbSpecificConfig *SpecificConfig, dsConfig *source.DataSourceC
 			consistencyMapping,
 		)
 	}
+	if !replicationStrategies[dbSpecificConfig.ReplicationStrategy] {
+		return nil, fmt.Errorf(
+			"invalid replication strategy %s; allowed: %v",
+			dbSpecificConfig.ReplicationStrategy,
+			replicationStrategies,
+		)
+	}
+	if w := dbSpecificConfig.CompactionWindow; w < 0 || w%time.Minute != 0 {
+		return nil, fmt.Errorf("invalid compaction window %s; must be whole minutes", w)
+	}
+	if ttl := dbSpecificConfig.DefaultTTL; ttl < 0 || ttl%time.Second != 0 {
+		return nil, fmt.Errorf("invalid default TTL %s; must be whole seconds", ttl)
+	}
+	rollups, err := ParseRollups(dbSpecificConfig.Rollups)
+	if err != nil {
+		return nil, err
+	}
+
 	return &benchmark{
 		dbc: &dbCreator{
-			hosts:             dbSpecificConfig.Hosts,
-			consistencyLevel:  dbSpecificConfig.ConsistencyLevel,
-			replicationFactor: dbSpecificConfig.ReplicationFactor,
-			writeTimeout:      dbSpecificConfig.WriteTimeout,
+			hosts:               dbSpecificConfig.Hosts,
+			consistencyLevel:    dbSpecificConfig.ConsistencyLevel,
+			replicationStrategy: dbSpecificConfig.ReplicationStrategy,
+			replicationFactor:   dbSpecificConfig.ReplicationFactor,
+			compactionWindow:    dbSpecificConfig.CompactionWindow,
+			defaultTTL:          dbSpecificConfig.DefaultTTL,
+			rollups:             rollups,
+			writeTimeout:        dbSpecificConfig.WriteTimeout,
 		},
 		dataSourceFileName: dsConfig.File.Location,
 	}, nil
@@ -66,7 +89,8 @@ type processor struct {
 func (p *processor) Init(_ int, _, _ bool) {}
 
 // ProcessBatch reads eventsBatches which contain rows of CQL strings and
-// creates a gocql.LoggedBatch to insert
+// creates a gocql.LoggedBatch to insert, along with the partial rows of the
+// rollup tables if any
 func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
 	events := b.(*eventsBatch)
 
@@ -75,6 +99,9 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 		for _, event := range events.rows {
 			batch.Query(singleMetricToInsertStatement(event))
 		}
+		for _, stmt := range rollupInsertStatements(events.rows, p.dbc.rollups) {
+			batch.Query(stmt)
+		}
 
 		err := p.dbc.clientSession.ExecuteBatch(batch)
 		if err != nil {
@@ -18,13 +18,23 @@ var consistencyMapping = map[string]gocql.Consistency{
 	"THREE":  gocql.Three,
 }
 
+// Replication strategies of the keyspace
+var replicationStrategies = map[string]bool{
+	"SimpleStrategy":          true,
+	"NetworkTopologyStrategy": true,
+}
+
 type dbCreator struct {
-	globalSession     *gocql.Session
-	clientSession     *gocql.Session
-	consistencyLevel  string
-	hosts             string
-	replicationFactor int
-	writeTimeout      time.Duration
+	globalSession       *gocql.Session
+	clientSession       *gocql.Session
+	consistencyLevel    string
+	hosts               string
+	replicationStrategy string
+	replicationFactor   int
+	compactionWindow    time.Duration
+	defaultTTL          time.Duration
+	rollups             []time.Duration
+	writeTimeout        time.Duration
 }
 
 func (d *dbCreator) Init() {
@@ -60,7 +70,7 @@ func (d *dbCreator) RemoveOldDB(dbName string) error {
 
 func (d *dbCreator) CreateDB(dbName string) error {
 	defer d.globalSession.Close()
-	replicationConfiguration := fmt.Sprintf("{ 'class': 'SimpleStrategy', 'replication_factor': %d }", d.replicationFactor)
+	replicationConfiguration := fmt.Sprintf("{ 'class': '%s', 'replication_factor': %d }", d.replicationStrategy, d.replicationFactor)
 	if err := d.globalSession.Query(fmt.Sprintf("create keyspace %s with replication = %s;", dbName, replicationConfiguration)).Exec(); err != nil {
 		return err
 	}
@@ -71,15 +81,70 @@ func (d *dbCreator) CreateDB(dbName string) error {
 					value %s,
 					PRIMARY KEY (series_id, timestamp_ns)
 				 )
-				 WITH COMPACT STORAGE;`,
-			dbName, cassandraTypename, cassandraTypename)
+				 WITH %s;`,
+			dbName, cassandraTypename, cassandraTypename,
+			strings.Join(append([]string{"COMPACT STORAGE"}, d.tableOptions()...), " AND "))
 		if err := d.globalSession.Query(q).Exec(); err != nil {
 			return err
 		}
 	}
+	for _, rollup := range d.rollups {
+		if err := d.globalSession.Query(d.rollupTableStatement(dbName, rollup)).Exec(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// tableOptions returns the options of the tables for the compaction and TTL
+// flags, if set.
+func (d *dbCreator) tableOptions() []string {
+	var opts []string
+	if d.compactionWindow > 0 {
+		unit, size := compactionWindowUnit(d.compactionWindow)
+		opts = append(opts, fmt.Sprintf(
+			"compaction = { 'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': '%s', 'compaction_window_size': '%d' }",
+			unit, size))
+	}
+	if d.defaultTTL > 0 {
+		opts = append(opts, fmt.Sprintf("default_time_to_live = %d", int64(d.defaultTTL/time.Second)))
+	}
+	return opts
+}
+
+// rollupTableStatement returns the statement creating the rollup table of
+// the interval. The partial rows written by the batches are clustered by
+// their timeuuid within the bucket.
+func (d *dbCreator) rollupTableStatement(dbName string, rollup time.Duration) string {
+	q := fmt.Sprintf(`CREATE TABLE %s.%s (
+				series_id text,
+				bucket_ns bigint,
+				partial timeuuid,
+				min double,
+				max double,
+				sum double,
+				count bigint,
+				PRIMARY KEY (series_id, bucket_ns, partial)
+			 )`, dbName, RollupTableName(rollup))
+	if opts := d.tableOptions(); len(opts) > 0 {
+		q += " WITH " + strings.Join(opts, " AND ")
+	}
+	return q + ";"
+}
+
+// compactionWindowUnit returns the largest unit of TimeWindowCompactionStrategy
+// the window is a multiple of, and the size of the window in that unit.
+func compactionWindowUnit(window time.Duration) (string, int64) {
+	switch {
+	case window%(24*time.Hour) == 0:
+		return "DAYS", int64(window / (24 * time.Hour))
+	case window%time.Hour == 0:
+		return "HOURS", int64(window / time.Hour)
+	default:
+		return "MINUTES", int64(window / time.Minute)
+	}
+}
+
 func (d *dbCreator) PostCreateDB(dbName string) error {
 	cluster := gocql.NewCluster(strings.Split(d.hosts, ",")...)
 	cluster.Keyspace = dbName
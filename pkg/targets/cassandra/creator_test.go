@@ -0,0 +1,86 @@
+package cassandra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data/source"
+)
+
+func TestTableOptions(t *testing.T) {
+	cases := []struct {
+		desc string
+		dbc  *dbCreator
+		want []string
+	}{
+		{desc: "defaults", dbc: &dbCreator{}},
+		{
+			desc: "compaction in hours",
+			dbc:  &dbCreator{compactionWindow: 2 * time.Hour},
+			want: []string{"compaction = { 'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'HOURS', 'compaction_window_size': '2' }"},
+		},
+		{
+			desc: "compaction in days and ttl",
+			dbc:  &dbCreator{compactionWindow: 24 * time.Hour, defaultTTL: 7 * 24 * time.Hour},
+			want: []string{
+				"compaction = { 'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'DAYS', 'compaction_window_size': '1' }",
+				"default_time_to_live = 604800",
+			},
+		},
+		{
+			desc: "compaction in minutes",
+			dbc:  &dbCreator{compactionWindow: 90 * time.Minute},
+			want: []string{"compaction = { 'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'MINUTES', 'compaction_window_size': '90' }"},
+		},
+	}
+	for _, c := range cases {
+		if got := c.dbc.tableOptions(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v want %v", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestRollupTableStatement(t *testing.T) {
+	dbc := &dbCreator{}
+	got := dbc.rollupTableStatement("benchmark", time.Hour)
+	if !strings.Contains(got, "CREATE TABLE benchmark.rollup_1h (") ||
+		!strings.Contains(got, "PRIMARY KEY (series_id, bucket_ns, partial)") ||
+		!strings.HasSuffix(got, ");") {
+		t.Errorf("incorrect statement: %s", got)
+	}
+
+	dbc.defaultTTL = time.Hour
+	got = dbc.rollupTableStatement("benchmark", time.Minute)
+	if !strings.HasSuffix(got, ") WITH default_time_to_live = 3600;") {
+		t.Errorf("incorrect statement with options: %s", got)
+	}
+}
+
+func TestNewBenchmarkValidation(t *testing.T) {
+	ds := &source.DataSourceConfig{Type: source.FileDataSourceType, File: &source.FileDataSourceConfig{}}
+	valid := SpecificConfig{ConsistencyLevel: "ONE", ReplicationStrategy: "SimpleStrategy"}
+	cases := []struct {
+		desc    string
+		modify  func(*SpecificConfig)
+		wantErr bool
+	}{
+		{desc: "valid", modify: func(*SpecificConfig) {}},
+		{desc: "network topology", modify: func(c *SpecificConfig) { c.ReplicationStrategy = "NetworkTopologyStrategy" }},
+		{desc: "unknown strategy", modify: func(c *SpecificConfig) { c.ReplicationStrategy = "LocalStrategy" }, wantErr: true},
+		{desc: "compaction window", modify: func(c *SpecificConfig) { c.CompactionWindow = time.Hour }},
+		{desc: "partial minute window", modify: func(c *SpecificConfig) { c.CompactionWindow = 90 * time.Second }, wantErr: true},
+		{desc: "partial second ttl", modify: func(c *SpecificConfig) { c.DefaultTTL = 1500 * time.Millisecond }, wantErr: true},
+		{desc: "rollups", modify: func(c *SpecificConfig) { c.Rollups = "1m,1h" }},
+		{desc: "invalid rollups", modify: func(c *SpecificConfig) { c.Rollups = "7m" }, wantErr: true},
+	}
+	for _, c := range cases {
+		conf := valid
+		c.modify(&conf)
+		_, err := NewBenchmark(&conf, ds)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: got error %v, want error %v", c.desc, err, c.wantErr)
+		}
+	}
+}
@@ -6,10 +6,14 @@ import (
 )
 
 type SpecificConfig struct {
-	Hosts             string        `yaml:"hosts" mapstructure:"hosts"`
-	ReplicationFactor int           `yaml:"replication-factor" mapstructure:"replication-factor"`
-	ConsistencyLevel  string        `yaml:"consistency" mapstructure:"consistency"`
-	WriteTimeout      time.Duration `yaml:"write-timeout" mapstructureL:"write-timeout"`
+	Hosts               string        `yaml:"hosts" mapstructure:"hosts"`
+	ReplicationStrategy string        `yaml:"replication-strategy" mapstructure:"replication-strategy"`
+	ReplicationFactor   int           `yaml:"replication-factor" mapstructure:"replication-factor"`
+	ConsistencyLevel    string        `yaml:"consistency" mapstructure:"consistency"`
+	CompactionWindow    time.Duration `yaml:"compaction-window" mapstructure:"compaction-window"`
+	DefaultTTL          time.Duration `yaml:"default-ttl" mapstructure:"default-ttl"`
+	Rollups             string        `yaml:"rollups" mapstructure:"rollups"`
+	WriteTimeout        time.Duration `yaml:"write-timeout" mapstructureL:"write-timeout"`
 }
 
 func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
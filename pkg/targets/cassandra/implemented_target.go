@@ -19,8 +19,12 @@ type cassandraTarget struct {
 
 func (t *cassandraTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
 	flagSet.String(flagPrefix+"hosts", "localhost:9042", "Comma separated list of Cassandra hosts in a cluster.")
+	flagSet.String(flagPrefix+"replication-strategy", "SimpleStrategy", "Replication strategy of the keyspace: SimpleStrategy or NetworkTopologyStrategy.")
 	flagSet.Int(flagPrefix+"replication-factor", 1, "Number of nodes that must have a copy of each key.")
 	flagSet.String(flagPrefix+"consistency", "ALL", "Desired write consistency level. See Cassandra consistency documentation. Default: ALL")
+	flagSet.Duration(flagPrefix+"compaction-window", 0, "Use TimeWindowCompactionStrategy with windows of this size (whole minutes). 0 keeps the default compaction.")
+	flagSet.Duration(flagPrefix+"default-ttl", 0, "Default time to live of the rows (whole seconds). 0 means no TTL.")
+	flagSet.String(flagPrefix+"rollups", "", "Comma-separated intervals of rollup tables written at load time, e.g. 1m,1h. Empty means no rollups.")
 	flagSet.Duration(flagPrefix+"write-timeout", 10*time.Second, "Write timeout.")
 }
 
@@ -0,0 +1,134 @@
+package cassandra
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rollup tables hold the min, max, sum and count of the numeric series per
+// interval, e.g. rollup_1h for an hour. The loader writes the aggregates of
+// the metrics of each batch as a partial row of the bucket, along with the
+// metrics themselves, so the rows are merged when read:
+//
+//	SELECT max(max) FROM rollup_1h WHERE series_id = ? AND bucket_ns >= ? AND bucket_ns < ?
+//
+// The series ids are the same as in the series tables, so a rollup interval
+// must divide a day for a bucket to belong to a single series.
+const rollupTablePrefix = "rollup_"
+
+// rollupTypes are the types of the series tables that are rolled up.
+var rollupTypes = map[string]bool{
+	"series_bigint": true,
+	"series_float":  true,
+	"series_double": true,
+}
+
+// ParseRollups parses a comma-separated list of rollup intervals, e.g.
+// "1m,1h". The intervals are returned sorted and without duplicates.
+func ParseRollups(s string) ([]time.Duration, error) {
+	var rollups []time.Duration
+	seen := map[time.Duration]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rollup interval '%s': %v", part, err)
+		}
+		if d < time.Second || d%time.Second != 0 || (24*time.Hour)%d != 0 {
+			return nil, fmt.Errorf("invalid rollup interval '%s': must be whole seconds dividing a day", part)
+		}
+		if !seen[d] {
+			seen[d] = true
+			rollups = append(rollups, d)
+		}
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i] < rollups[j] })
+	return rollups, nil
+}
+
+// RollupTableName returns the name of the rollup table of the interval,
+// e.g. rollup_1h or rollup_90s.
+func RollupTableName(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%s%dd", rollupTablePrefix, d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%s%dh", rollupTablePrefix, d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%s%dm", rollupTablePrefix, d/time.Minute)
+	default:
+		return fmt.Sprintf("%s%ds", rollupTablePrefix, d/time.Second)
+	}
+}
+
+type rollupKey struct {
+	table    string
+	seriesID string
+	bucketNS int64
+}
+
+type rollupRow struct {
+	min, max, sum float64
+	count         int64
+}
+
+// rollupInsertStatements aggregates the metrics of a batch per series and
+// bucket of each rollup interval, and returns the CQL INSERT statements of
+// the partial rows. Each partial row gets a distinct timeuuid from now().
+func rollupInsertStatements(rows []string, rollups []time.Duration) []string {
+	if len(rollups) == 0 {
+		return nil
+	}
+	aggs := map[rollupKey]*rollupRow{}
+	var keys []rollupKey
+	for _, text := range rows {
+		m := parseMetric(text)
+		if !rollupTypes[m.table] {
+			continue
+		}
+		ts, err := strconv.ParseInt(m.timestampNS, 10, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(m.value, 64)
+		if err != nil {
+			continue
+		}
+		for _, d := range rollups {
+			k := rollupKey{RollupTableName(d), m.seriesID, ts - ts%int64(d)}
+			r, ok := aggs[k]
+			if !ok {
+				r = &rollupRow{min: value, max: value}
+				aggs[k] = r
+				keys = append(keys, k)
+			}
+			if value < r.min {
+				r.min = value
+			}
+			if value > r.max {
+				r.max = value
+			}
+			r.sum += value
+			r.count++
+		}
+	}
+
+	statements := make([]string, 0, len(keys))
+	for _, k := range keys {
+		r := aggs[k]
+		statements = append(statements, fmt.Sprintf(
+			"INSERT INTO %s(series_id, bucket_ns, partial, min, max, sum, count) VALUES('%s', %d, now(), %s, %s, %s, %d)",
+			k.table, k.seriesID, k.bucketNS, formatFloat(r.min), formatFloat(r.max), formatFloat(r.sum), r.count))
+	}
+	return statements
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
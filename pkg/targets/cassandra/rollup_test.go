@@ -0,0 +1,79 @@
+package cassandra
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRollups(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "1m", want: []time.Duration{time.Minute}},
+		{in: "1h, 1m,1h", want: []time.Duration{time.Minute, time.Hour}},
+		{in: "24h,90s", want: []time.Duration{90 * time.Second, 24 * time.Hour}},
+		{in: "7m", wantErr: true},
+		{in: "48h", wantErr: true},
+		{in: "500ms", wantErr: true},
+		{in: "hourly", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := ParseRollups(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected error", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %v want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRollupTableName(t *testing.T) {
+	cases := map[time.Duration]string{
+		90 * time.Second: "rollup_90s",
+		time.Minute:      "rollup_1m",
+		15 * time.Minute: "rollup_15m",
+		time.Hour:        "rollup_1h",
+		24 * time.Hour:   "rollup_1d",
+	}
+	for d, want := range cases {
+		if got := RollupTableName(d); got != want {
+			t.Errorf("%s: got %s want %s", d, got, want)
+		}
+	}
+}
+
+func TestRollupInsertStatements(t *testing.T) {
+	rows := []string{
+		"series_double,cpu,hostname=host_0,usage_user,2016-01-01,1451606400000000000,10.5",
+		"series_double,cpu,hostname=host_0,usage_user,2016-01-01,1451606410000000000,2.5",
+		"series_double,cpu,hostname=host_0,usage_user,2016-01-01,1451606460000000000,4",
+		"series_bigint,cpu,hostname=host_1,usage_user,2016-01-01,1451606400000000000,7",
+		"series_blob,cpu,hostname=host_1,note,2016-01-01,1451606400000000000,abc",
+	}
+	if got := rollupInsertStatements(rows, nil); got != nil {
+		t.Errorf("unexpected statements without rollups: %v", got)
+	}
+
+	got := rollupInsertStatements(rows, []time.Duration{time.Minute, time.Hour})
+	want := []string{
+		"INSERT INTO rollup_1m(series_id, bucket_ns, partial, min, max, sum, count) VALUES('cpu,hostname=host_0#usage_user#2016-01-01', 1451606400000000000, now(), 2.5, 10.5, 13, 2)",
+		"INSERT INTO rollup_1h(series_id, bucket_ns, partial, min, max, sum, count) VALUES('cpu,hostname=host_0#usage_user#2016-01-01', 1451606400000000000, now(), 2.5, 10.5, 17, 3)",
+		"INSERT INTO rollup_1m(series_id, bucket_ns, partial, min, max, sum, count) VALUES('cpu,hostname=host_0#usage_user#2016-01-01', 1451606460000000000, now(), 4, 4, 4, 1)",
+		"INSERT INTO rollup_1m(series_id, bucket_ns, partial, min, max, sum, count) VALUES('cpu,hostname=host_1#usage_user#2016-01-01', 1451606400000000000, now(), 7, 7, 7, 1)",
+		"INSERT INTO rollup_1h(series_id, bucket_ns, partial, min, max, sum, count) VALUES('cpu,hostname=host_1#usage_user#2016-01-01', 1451606400000000000, now(), 7, 7, 7, 1)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect statements:\ngot\n%v\nwant\n%v", got, want)
+	}
+}
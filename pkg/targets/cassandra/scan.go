@@ -33,11 +33,16 @@ func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
 	return nil
 }
 
-// Transforms a CSV string encoding a single metric into a CQL INSERT statement.
-// We currently only support a 1-line:1-metric mapping for Cassandra. Implement
-// other functions here to support other formats.
-func singleMetricToInsertStatement(text string) string {
-	insertStatement := "INSERT INTO %s(series_id, timestamp_ns, value) VALUES('%s#%s#%s', %s, %s)"
+// metric is a single metric decoded from a CSV line.
+type metric struct {
+	table       string
+	seriesID    string
+	timestampNS string
+	value       string
+}
+
+// parseMetric decodes a CSV line encoding a single metric.
+func parseMetric(text string) metric {
 	parts := strings.Split(text, ",")
 	tagsBeginIndex := 1                  // list of tags begins after the table name
 	tagsEndIndex := (len(parts) - 1) - 4 // list of tags ends right before the last 4 parts of the line
@@ -49,7 +54,20 @@ func singleMetricToInsertStatement(text string) string {
 	timestampNS := parts[tagsEndIndex+3]                            // offset: table + numTags + numTags + measurementName + dayBucket
 	value := parts[tagsEndIndex+4]                                  // offset: table + numTags + timestamp + measurementName + dayBucket + timestampNS
 
-	return fmt.Sprintf(insertStatement, table, tags, measurementName, dayBucket, timestampNS, value)
+	return metric{
+		table:       table,
+		seriesID:    tags + "#" + measurementName + "#" + dayBucket,
+		timestampNS: timestampNS,
+		value:       value,
+	}
+}
+
+// Transforms a CSV string encoding a single metric into a CQL INSERT statement.
+// We currently only support a 1-line:1-metric mapping for Cassandra. Implement
+// other functions here to support other formats.
+func singleMetricToInsertStatement(text string) string {
+	m := parseMetric(text)
+	return fmt.Sprintf("INSERT INTO %s(series_id, timestamp_ns, value) VALUES('%s', %s, %s)", m.table, m.seriesID, m.timestampNS, m.value)
 }
 
 type eventsBatch struct {
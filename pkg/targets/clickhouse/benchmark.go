@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/timescale/tsbs/load"
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/timescaledb"
 )
 
 const dbType = "clickhouse"
@@ -21,6 +24,19 @@ type ClickhouseConfig struct {
 	InTableTag bool
 	Debug      int
 	DbName     string
+
+	// ModernSchema creates partitioned MergeTree tables with DateTime64 time,
+	// per-column codecs and LowCardinality tags, and inserts columnar blocks
+	ModernSchema bool
+	// SchemaLayout is either constants.SchemaLayoutWide, with a column per
+	// field, or constants.SchemaLayoutNarrow, with a (metric_name, value) row
+	// per field value
+	SchemaLayout string
+}
+
+// narrowLayout returns whether every field value is stored in its own row
+func (conf *ClickhouseConfig) narrowLayout() bool {
+	return conf.SchemaLayout == constants.SchemaLayoutNarrow
 }
 
 // String values of tags and fields to insert - string representation
@@ -42,11 +58,16 @@ func getConnectString(conf *ClickhouseConfig, db bool) string {
 	// connectString: tcp://127.0.0.1:9000?debug=true
 	// ClickHouse ex.:
 	// tcp://host1:9000?username=user&password=qwerty&database=clicks&read_timeout=10&write_timeout=20&alt_hosts=host2:9000,host3:9000
+	connStr := fmt.Sprintf("tcp://%s:9000?username=%s&password=%s", conf.Host, conf.User, conf.Password)
 	if db {
-		return fmt.Sprintf("tcp://%s:9000?username=%s&password=%s&database=%s", conf.Host, conf.User, conf.Password, conf.DbName)
+		connStr += "&database=" + conf.DbName
 	}
-
-	return fmt.Sprintf("tcp://%s:9000?username=%s&password=%s", conf.Host, conf.User, conf.Password)
+	if conf.ModernSchema {
+		// the driver has no LowCardinality column type, so have the server
+		// convert those columns to their plain type over the native protocol
+		connStr += "&low_cardinality_allow_in_native_format=0"
+	}
+	return connStr
 }
 
 // Point is a single row of data keyed by which table it belongs
@@ -133,3 +154,8 @@ func (b *benchmark) GetProcessor() targets.Processor {
 func (b *benchmark) GetDBCreator() targets.DBCreator {
 	return &dbCreator{ds: b.GetDataSource(), config: b.conf}
 }
+
+// PointTimestamp implements targets.PointTimestamper
+func (b *benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	return timescaledb.RowTimestamp(item.Data.(*point).row.fields)
+}
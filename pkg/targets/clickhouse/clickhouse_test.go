@@ -27,6 +27,19 @@ func TestGetConnectString(t *testing.T) {
 	if connStr != want {
 		t.Errorf("incorrect connect string: got %s want %s", connStr, want)
 	}
+
+	want = fmt.Sprintf("tcp://%s:9000?username=%s&password=%s&low_cardinality_allow_in_native_format=0", wantHost, wantUser, wantPassword)
+	connStr = getConnectString(&ClickhouseConfig{
+		Host:         wantHost,
+		User:         wantUser,
+		Password:     wantPassword,
+		DbName:       wantDB,
+		ModernSchema: true,
+	},
+		false)
+	if connStr != want {
+		t.Errorf("incorrect connect string: got %s want %s", connStr, want)
+	}
 }
 
 func TestHypertableArr(t *testing.T) {
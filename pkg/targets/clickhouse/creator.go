@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	_ "github.com/ClickHouse/clickhouse-go"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/kshvakov/clickhouse"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/targets"
 )
@@ -100,6 +100,9 @@ func (d *dbCreator) CreateDB(dbName string) error {
 // createTagsTable builds CREATE TABLE SQL statement and runs it
 func createTagsTable(conf *ClickhouseConfig, db *sqlx.DB, tagNames, tagTypes []string) {
 	sql := generateTagsTableQuery(tagNames, tagTypes)
+	if conf.ModernSchema {
+		sql = generateModernTagsTableQuery(tagNames, tagTypes)
+	}
 	if conf.Debug > 0 {
 		fmt.Printf(sql)
 	}
@@ -122,6 +125,28 @@ func createMetricsTable(conf *ClickhouseConfig, db *sqlx.DB, tableName string, f
 		columnNames = append(columnNames, partitioningColumn)
 	}
 
+	if conf.narrowLayout() {
+		sql := generateNarrowMetricsTableQuery(tableName, columnNames, conf.ModernSchema)
+		if conf.Debug > 0 {
+			fmt.Printf(sql)
+		}
+		if _, err := db.Exec(sql); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if conf.ModernSchema {
+		sql := generateModernMetricsTableQuery(tableName, columnNames, fieldColumns)
+		if conf.Debug > 0 {
+			fmt.Printf(sql)
+		}
+		if _, err := db.Exec(sql); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	// Add all column names from fieldColumns into columnNames
 	columnNames = append(columnNames, fieldColumns...)
 
@@ -185,6 +210,111 @@ func generateTagsTableQuery(tagNames, tagTypes []string) string {
 		index)
 }
 
+// generateModernMetricsTableQuery builds the CREATE TABLE statement of the
+// modern schema. Time is stored with nanosecond precision and delta encoded,
+// the fields are Gorilla encoded, and the table is partitioned by day.
+// tagColumns are the tags stored in the table, i.e. the primary tag with
+// -in-table-partition-tag.
+func generateModernMetricsTableQuery(tableName string, tagColumns, fieldColumns []string) string {
+	columnDefinitions := []string{
+		"time DateTime64(9, 'UTC') CODEC(Delta, ZSTD)",
+		"tags_id UInt32 CODEC(ZSTD)",
+	}
+	for _, column := range tagColumns {
+		columnDefinitions = append(columnDefinitions, fmt.Sprintf("%s LowCardinality(String)", column))
+	}
+	for _, column := range fieldColumns {
+		if len(column) == 0 {
+			// Skip nameless columns
+			continue
+		}
+		columnDefinitions = append(columnDefinitions, fmt.Sprintf("%s Nullable(Float64) CODEC(Gorilla, ZSTD)", column))
+	}
+	columnDefinitions = append(columnDefinitions, "additional_tags String DEFAULT '' CODEC(ZSTD)")
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s(\n"+
+			"%s\n"+
+			") ENGINE = MergeTree()\n"+
+			"PARTITION BY toYYYYMMDD(time)\n"+
+			"ORDER BY (tags_id, time)",
+		tableName,
+		strings.Join(columnDefinitions, ",\n"))
+}
+
+// generateNarrowMetricsTableQuery builds the CREATE TABLE statement of the
+// narrow layout, which stores a (metric_name, value) row per field value
+// instead of a column per field. tagColumns are the tags stored in the table,
+// i.e. the primary tag with -in-table-partition-tag.
+func generateNarrowMetricsTableQuery(tableName string, tagColumns []string, modern bool) string {
+	var columnDefinitions []string
+	if modern {
+		columnDefinitions = append(columnDefinitions,
+			"time DateTime64(9, 'UTC') CODEC(Delta, ZSTD)",
+			"tags_id UInt32 CODEC(ZSTD)")
+		for _, column := range tagColumns {
+			columnDefinitions = append(columnDefinitions, fmt.Sprintf("%s LowCardinality(String)", column))
+		}
+		columnDefinitions = append(columnDefinitions,
+			"metric_name LowCardinality(String)",
+			"value Float64 CODEC(Gorilla, ZSTD)",
+			"additional_tags String DEFAULT '' CODEC(ZSTD)")
+
+		return fmt.Sprintf(
+			"CREATE TABLE %s(\n"+
+				"%s\n"+
+				") ENGINE = MergeTree()\n"+
+				"PARTITION BY toYYYYMMDD(time)\n"+
+				"ORDER BY (tags_id, metric_name, time)",
+			tableName,
+			strings.Join(columnDefinitions, ",\n"))
+	}
+
+	columnDefinitions = append(columnDefinitions,
+		"created_date Date DEFAULT today()",
+		"created_at DateTime DEFAULT now()",
+		"time String",
+		"tags_id UInt32")
+	for _, column := range tagColumns {
+		columnDefinitions = append(columnDefinitions, fmt.Sprintf("%s String", column))
+	}
+	columnDefinitions = append(columnDefinitions,
+		"metric_name String",
+		"value Float64",
+		"additional_tags String DEFAULT ''")
+
+	return fmt.Sprintf(
+		"CREATE TABLE %s(\n"+
+			"%s\n"+
+			") ENGINE = MergeTree(created_date, (tags_id, metric_name, created_at), 8192)",
+		tableName,
+		strings.Join(columnDefinitions, ",\n"))
+}
+
+// generateModernTagsTableQuery builds the CREATE TABLE statement of the tags
+// table in the modern schema, where string tags are dictionary encoded
+func generateModernTagsTableQuery(tagNames, tagTypes []string) string {
+	if len(tagNames) != len(tagTypes) {
+		panic("wrong number of tag names and tag types")
+	}
+
+	columnDefinitions := []string{"id UInt32"}
+	for i, tagName := range tagNames {
+		tagType := serializedTypeToClickHouseType(tagTypes[i])
+		if tagTypes[i] == "string" {
+			tagType = "LowCardinality(Nullable(String))"
+		}
+		columnDefinitions = append(columnDefinitions, fmt.Sprintf("%s %s", tagName, tagType))
+	}
+
+	return fmt.Sprintf(
+		"CREATE TABLE tags(\n"+
+			"%s\n"+
+			") ENGINE = MergeTree()\n"+
+			"ORDER BY id",
+		strings.Join(columnDefinitions, ",\n"))
+}
+
 func serializedTypeToClickHouseType(serializedType string) string {
 	switch serializedType {
 	case "string":
@@ -66,3 +66,100 @@ func TestGenerateTagsTableQueryPanicOnWrongType(t *testing.T) {
 
 	t.Fatalf("test should have stopped at this point")
 }
+
+func TestGenerateModernTagsTableQuery(t *testing.T) {
+	want := "CREATE TABLE tags(\n" +
+		"id UInt32,\n" +
+		"tag1 LowCardinality(Nullable(String)),\n" +
+		"tag2 Nullable(Int64)\n" +
+		") ENGINE = MergeTree()\n" +
+		"ORDER BY id"
+	got := generateModernTagsTableQuery([]string{"tag1", "tag2"}, []string{"string", "int64"})
+	if got != want {
+		t.Errorf("unexpected result.\nexpected: %s\ngot: %s", want, got)
+	}
+}
+
+func TestGenerateModernMetricsTableQuery(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		tagColumns   []string
+		fieldColumns []string
+		want         string
+	}{{
+		desc:         "tags in separate table",
+		fieldColumns: []string{"usage_user", "", "usage_system"},
+		want: "CREATE TABLE cpu(\n" +
+			"time DateTime64(9, 'UTC') CODEC(Delta, ZSTD),\n" +
+			"tags_id UInt32 CODEC(ZSTD),\n" +
+			"usage_user Nullable(Float64) CODEC(Gorilla, ZSTD),\n" +
+			"usage_system Nullable(Float64) CODEC(Gorilla, ZSTD),\n" +
+			"additional_tags String DEFAULT '' CODEC(ZSTD)\n" +
+			") ENGINE = MergeTree()\n" +
+			"PARTITION BY toYYYYMMDD(time)\n" +
+			"ORDER BY (tags_id, time)",
+	}, {
+		desc:         "in table tag",
+		tagColumns:   []string{"hostname"},
+		fieldColumns: []string{"usage_user"},
+		want: "CREATE TABLE cpu(\n" +
+			"time DateTime64(9, 'UTC') CODEC(Delta, ZSTD),\n" +
+			"tags_id UInt32 CODEC(ZSTD),\n" +
+			"hostname LowCardinality(String),\n" +
+			"usage_user Nullable(Float64) CODEC(Gorilla, ZSTD),\n" +
+			"additional_tags String DEFAULT '' CODEC(ZSTD)\n" +
+			") ENGINE = MergeTree()\n" +
+			"PARTITION BY toYYYYMMDD(time)\n" +
+			"ORDER BY (tags_id, time)",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := generateModernMetricsTableQuery("cpu", tc.tagColumns, tc.fieldColumns)
+			if got != tc.want {
+				t.Errorf("unexpected result.\nexpected: %s\ngot: %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerateNarrowMetricsTableQuery(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		tagColumns []string
+		modern     bool
+		want       string
+	}{{
+		desc: "legacy schema",
+		want: "CREATE TABLE cpu(\n" +
+			"created_date Date DEFAULT today(),\n" +
+			"created_at DateTime DEFAULT now(),\n" +
+			"time String,\n" +
+			"tags_id UInt32,\n" +
+			"metric_name String,\n" +
+			"value Float64,\n" +
+			"additional_tags String DEFAULT ''\n" +
+			") ENGINE = MergeTree(created_date, (tags_id, metric_name, created_at), 8192)",
+	}, {
+		desc:       "modern schema, in table tag",
+		tagColumns: []string{"hostname"},
+		modern:     true,
+		want: "CREATE TABLE cpu(\n" +
+			"time DateTime64(9, 'UTC') CODEC(Delta, ZSTD),\n" +
+			"tags_id UInt32 CODEC(ZSTD),\n" +
+			"hostname LowCardinality(String),\n" +
+			"metric_name LowCardinality(String),\n" +
+			"value Float64 CODEC(Gorilla, ZSTD),\n" +
+			"additional_tags String DEFAULT '' CODEC(ZSTD)\n" +
+			") ENGINE = MergeTree()\n" +
+			"PARTITION BY toYYYYMMDD(time)\n" +
+			"ORDER BY (tags_id, metric_name, time)",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := generateNarrowMetricsTableQuery("cpu", tc.tagColumns, tc.modern)
+			if got != tc.want {
+				t.Errorf("unexpected result.\nexpected: %s\ngot: %s", tc.want, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package clickhouse
 import (
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/serialize"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
@@ -30,8 +31,32 @@ func (c clickhouseTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.
 	flagSet.String(flagPrefix+"password", "", "Password to connect to ClickHouse")
 	flagSet.Bool(flagPrefix+"log-batches", false, "Whether to time individual batches.")
 	flagSet.Int(flagPrefix+"debug", 0, "Debug printing (choices: 0, 1, 2). (default 0)")
+	flagSet.String(flagPrefix+"schema-layout", constants.SchemaLayoutWide, "Layout of the metrics tables: 'wide' stores a column per field, 'narrow' a (time, tags_id, metric_name, value) row per field value")
+	flagSet.Bool(flagPrefix+"modern-schema", false, "Whether to create partitioned tables with DateTime64 time, column codecs and LowCardinality tags, inserted as columnar blocks")
 }
 
 func (c clickhouseTarget) TargetName() string {
 	return constants.FormatClickhouse
 }
+
+// timescaleInspector and timescaleParser read the data files, which are in
+// the pseudo-CSV format shared with TimescaleDB
+var (
+	timescaleInspector = timescaledb.NewTarget().(targets.FileInspector)
+	timescaleParser    = timescaledb.NewTarget().(targets.PointParser)
+)
+
+// FileDataSource implements targets.FileInspector and targets.PointParser
+func (c clickhouseTarget) FileDataSource(fileName string) targets.DataSource {
+	return timescaleInspector.FileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (c clickhouseTarget) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	return timescaleInspector.DescribePoint(item)
+}
+
+// ParsePoint implements targets.PointParser
+func (c clickhouseTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return timescaleParser.ParsePoint(item, schema, p)
+}
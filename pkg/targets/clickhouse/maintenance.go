@@ -0,0 +1,86 @@
+package clickhouse
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/jmoiron/sqlx"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// partitionDayFormat is the format of the partition ids of the modern
+// schema, partitioned by toYYYYMMDD(time)
+const partitionDayFormat = "20060102"
+
+// Maintenance implements targets.Maintainer
+func (c clickhouseTarget) Maintenance(targetDB string, v *viper.Viper) (targets.Maintenance, error) {
+	conf := &ClickhouseConfig{
+		Host:         v.GetString("host"),
+		User:         v.GetString("user"),
+		Password:     v.GetString("password"),
+		Debug:        v.GetInt("debug"),
+		DbName:       targetDB,
+		ModernSchema: v.GetBool("modern-schema"),
+		SchemaLayout: v.GetString("schema-layout"),
+	}
+	db, err := sqlx.Connect(dbType, getConnectString(conf, true))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %v", targetDB, err)
+	}
+	return &maintenance{db: db, conf: conf}, nil
+}
+
+// maintenance runs the maintenance operations of ClickHouse on the metrics
+// tables of the database
+type maintenance struct {
+	db   *sqlx.DB
+	conf *ClickhouseConfig
+}
+
+// DropBefore drops the daily partitions that end before t. Only the tables of
+// the modern schema are partitioned by time, the ones of the legacy schema are
+// partitioned by insert date and return targets.ErrMaintenanceNotSupported.
+func (m *maintenance) DropBefore(t time.Time) error {
+	if !m.conf.ModernSchema {
+		return targets.ErrMaintenanceNotSupported
+	}
+	var parts []struct {
+		Table     string `db:"table"`
+		Partition string `db:"partition_id"`
+	}
+	sql := fmt.Sprintf("SELECT DISTINCT table, partition_id FROM system.parts "+
+		"WHERE database = '%s' AND active AND table != 'tags' AND partition_id <= '%s'",
+		m.conf.DbName, lastDroppedDay(t))
+	if m.conf.Debug > 0 {
+		fmt.Println(sql)
+	}
+	if err := m.db.Select(&parts, sql); err != nil {
+		return fmt.Errorf("could not list partitions: %v", err)
+	}
+	for _, p := range parts {
+		sql := fmt.Sprintf("ALTER TABLE %s DROP PARTITION ID '%s'", p.Table, p.Partition)
+		if m.conf.Debug > 0 {
+			fmt.Println(sql)
+		}
+		if _, err := m.db.Exec(sql); err != nil {
+			return fmt.Errorf("could not drop partition %s of %s: %v", p.Partition, p.Table, err)
+		}
+	}
+	return nil
+}
+
+// lastDroppedDay returns the id of the last daily partition that ends before t
+func lastDroppedDay(t time.Time) string {
+	return t.UTC().Add(-24 * time.Hour).Format(partitionDayFormat)
+}
+
+// Downsample is not supported, ClickHouse downsamples on insert with
+// materialized views or on merges with a TTL GROUP BY
+func (m *maintenance) Downsample(_, _ time.Time) error {
+	return targets.ErrMaintenanceNotSupported
+}
+
+func (m *maintenance) Close() error {
+	return m.db.Close()
+}
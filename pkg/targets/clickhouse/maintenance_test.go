@@ -0,0 +1,22 @@
+package clickhouse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastDroppedDay(t *testing.T) {
+	cases := []struct {
+		t    time.Time
+		want string
+	}{
+		{t: time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC), want: "20160101"},
+		{t: time.Date(2016, 1, 2, 23, 59, 0, 0, time.UTC), want: "20160101"},
+		{t: time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC), want: "20151231"},
+	}
+	for _, c := range cases {
+		if got := lastDroppedDay(c.t); got != c.want {
+			t.Errorf("incorrect last dropped day for %v: got %s want %s", c.t, got, c.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ClickHouse/clickhouse-go"
 	"github.com/jmoiron/sqlx"
 	"github.com/timescale/tsbs/pkg/targets"
 )
@@ -16,12 +17,21 @@ type processor struct {
 	db   *sqlx.DB
 	csi  *syncCSI
 	conf *ClickhouseConfig
+	// conn writes columnar blocks with the modern schema
+	conn clickhouse.Clickhouse
 }
 
 // load.Processor interface implementation
 func (p *processor) Init(workerNum int, doLoad, hashWorkers bool) {
 	if doLoad {
 		p.db = sqlx.MustConnect(dbType, getConnectString(p.conf, true))
+		if p.conf.ModernSchema {
+			conn, err := clickhouse.OpenDirect(getConnectString(p.conf, true))
+			if err != nil {
+				panic(err)
+			}
+			p.conn = conn
+		}
 		if hashWorkers {
 			p.csi = newSyncCSI()
 		} else {
@@ -34,6 +44,9 @@ func (p *processor) Init(workerNum int, doLoad, hashWorkers bool) {
 func (p *processor) Close(doLoad bool) {
 	if doLoad {
 		p.db.Close()
+		if p.conn != nil {
+			p.conn.Close()
+		}
 	}
 }
 
@@ -142,24 +155,32 @@ func (p *processor) processCSI(tableName string, rows []*insertData) uint64 {
 		if err != nil {
 			panic(err)
 		}
-		timeUTC := time.Unix(0, timestampNano)
-		TimeUTCStr := timeUTC.Format("2006-01-02 15:04:05.999999 -0700")
-
-		// use nil at 2-nd position as placeholder for tagKey
 		r := make([]interface{}, 0, colLen)
-		// First columns in table are
-		// created_date
-		// created_at
-		// time
-		// tags_id - would be nil for now
-		// additional_tags
-		tagsIdPosition = 3 // what is the position of the tags_id in the row - nil value
-		r = append(r,
-			timeUTC,    // created_date
-			timeUTC,    // created_at
-			TimeUTCStr, // time
-			nil,        // tags_id
-			json)       // additional_tags
+		if p.conf.ModernSchema {
+			// time is a DateTime64 with nanosecond precision, written as is
+			tagsIdPosition = 1
+			r = append(r,
+				timestampNano, // time
+				nil,           // tags_id
+				json)          // additional_tags
+		} else {
+			timeUTC := time.Unix(0, timestampNano)
+			TimeUTCStr := timeUTC.Format("2006-01-02 15:04:05.999999 -0700")
+
+			// First columns in table are
+			// created_date
+			// created_at
+			// time
+			// tags_id - would be nil for now
+			// additional_tags
+			tagsIdPosition = 3 // what is the position of the tags_id in the row - nil value
+			r = append(r,
+				timeUTC,    // created_date
+				timeUTC,    // created_at
+				TimeUTCStr, // time
+				nil,        // tags_id
+				json)       // additional_tags
+		}
 
 		if p.conf.InTableTag {
 			r = append(r, tags[0]) // tags[0] = hostname
@@ -225,11 +246,28 @@ func (p *processor) processCSI(tableName string, rows []*insertData) uint64 {
 	// Inspite of "additional_tags" being added the last one in CREATE TABLE stmt
 	// it goes as a third one here - because we can move columns - they are named
 	// and it is easier to keep variable coumns at the end of the list
-	cols = append(cols, "created_date", "created_at", "time", "tags_id", "additional_tags")
+	if p.conf.ModernSchema {
+		cols = append(cols, "time", "tags_id", "additional_tags")
+	} else {
+		cols = append(cols, "created_date", "created_at", "time", "tags_id", "additional_tags")
+	}
 	if p.conf.InTableTag {
 		cols = append(cols, tableCols["tags"][0]) // hostname
 	}
-	cols = append(cols, tableCols[tableName]...)
+	if p.conf.narrowLayout() {
+		dataRows = targets.ToNarrowRows(dataRows, len(cols), tableCols[tableName])
+		cols = append(cols, "metric_name", "value")
+		if len(dataRows) == 0 {
+			return ret
+		}
+	} else {
+		cols = append(cols, tableCols[tableName]...)
+	}
+
+	if p.conf.ModernSchema {
+		p.insertBlock(tableName, cols, dataRows)
+		return ret
+	}
 
 	// INSERT statement template
 	sql := fmt.Sprintf(`
@@ -266,6 +304,65 @@ func (p *processor) processCSI(tableName string, rows []*insertData) uint64 {
 	return ret
 }
 
+// insertBlock inserts the rows with a single columnar block over the native
+// protocol. The block columns are in the order of cols, which starts with
+// time, tags_id and additional_tags, followed by the primary tag with
+// -in-table-partition-tag and the fields, or metric_name and value with the
+// narrow layout.
+func (p *processor) insertBlock(tableName string, cols []string, rows [][]interface{}) {
+	if _, err := p.conn.Begin(); err != nil {
+		panic(err)
+	}
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		tableName,
+		strings.Join(cols, ","),
+		strings.Repeat(",?", len(cols))[1:])
+	if _, err := p.conn.Prepare(sql); err != nil {
+		panic(err)
+	}
+	block, err := p.conn.Block()
+	if err != nil {
+		panic(err)
+	}
+	block.Reserve()
+	block.NumRows += uint64(len(rows))
+
+	lastStringColumn := 2 // additional_tags
+	if p.conf.InTableTag {
+		lastStringColumn++
+	}
+	if p.conf.narrowLayout() {
+		lastStringColumn++ // metric_name
+	}
+	for c := range cols {
+		for _, r := range rows {
+			switch {
+			case c == 0:
+				err = block.WriteInt64(c, r[c].(int64))
+			case c == 1:
+				err = block.WriteUInt32(c, uint32(r[c].(int64)))
+			case c <= lastStringColumn:
+				err = block.WriteString(c, r[c].(string))
+			case p.conf.narrowLayout():
+				err = block.WriteFloat64(c, r[c].(float64))
+			case r[c] == nil:
+				err = block.WriteFloat64Nullable(c, nil)
+			default:
+				f64 := r[c].(float64)
+				err = block.WriteFloat64Nullable(c, &f64)
+			}
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	// Commit sends the block
+	if err := p.conn.Commit(); err != nil {
+		panic(err)
+	}
+}
+
 // insertTags fills tags table with values
 func insertTags(conf *ClickhouseConfig, db *sqlx.DB, startID int, rows [][]string, returnResults bool) map[string]int64 {
 	// Map hostname to tags_id
@@ -0,0 +1,173 @@
+package columnar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/timescaledb"
+)
+
+func testSchema() *targets.DataSchema {
+	return targets.NewDataSchema(&common.GeneratedDataHeaders{
+		TagKeys:  []string{"name", "load_capacity"},
+		TagTypes: []string{"string", "float64"},
+		FieldKeys: map[string][]string{
+			"readings":    {"latitude", "status"},
+			"diagnostics": {"fuel_state"},
+		},
+	}, map[string][]string{
+		"readings":    {"float64", "int64"},
+		"diagnostics": {"float64"},
+	})
+}
+
+func testPoints() []*data.Point {
+	newPoint := func(measurement string, ts int64, tags, fields []interface{}, extraTag interface{}) *data.Point {
+		p := data.NewPoint()
+		p.SetMeasurementName([]byte(measurement))
+		t := time.Unix(0, ts)
+		p.SetTimestamp(&t)
+		for i, k := range testSchema().Headers.TagKeys {
+			p.AppendTag([]byte(k), tags[i])
+		}
+		if extraTag != nil {
+			p.AppendTag([]byte("path"), extraTag)
+		}
+		for i, k := range testSchema().Headers.FieldKeys[measurement] {
+			p.AppendField([]byte(k), fields[i])
+		}
+		return p
+	}
+	return []*data.Point{
+		newPoint("diagnostics", 1451606400000000001, []interface{}{"truck_0", 1500.0}, []interface{}{0.75}, nil),
+		newPoint("readings", 1451606400000000000, []interface{}{"truck_0", 1500.0}, []interface{}{72.5, int64(3)}, "/dev/sda"),
+		// nil tags and fields are nulls
+		newPoint("readings", 1451606410000000000, []interface{}{nil, nil}, []interface{}{nil, int64(4)}, "/dev/sdb"),
+	}
+}
+
+// serialized returns the points serialized in the pseudo-CSV format, which
+// writes all tags and fields, including nil ones
+func serialized(t *testing.T, points []*data.Point) string {
+	s := &timescaledb.Serializer{}
+	buf := new(bytes.Buffer)
+	for _, p := range points {
+		if err := s.Serialize(p, buf); err != nil {
+			t.Fatalf("unexpected error serializing: %v", err)
+		}
+	}
+	return buf.String()
+}
+
+func TestWriteAndParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-columnar")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		target      targets.ImplementedTarget
+		compression string
+		files       []string
+	}{
+		{NewParquetTarget(), "", []string{"diagnostics.parquet", "readings.parquet"}},
+		{NewParquetTarget(), "gzip", []string{"diagnostics.parquet", "readings.parquet"}},
+		{NewArrowTarget(), "", []string{"diagnostics.arrow", "readings.arrow"}},
+		{NewArrowTarget(), "lz4", []string{"diagnostics.arrow", "readings.arrow"}},
+	}
+	for _, c := range cases {
+		out := filepath.Join(dir, c.target.TargetName()+c.compression)
+		w, err := c.target.(targets.DirectoryWriter).NewPointWriter(out, testSchema(), c.compression)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", out, err)
+		}
+		for _, p := range testPoints() {
+			if err := w.Write(p); err != nil {
+				t.Fatalf("%s: unexpected error writing: %v", out, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: unexpected error closing: %v", out, err)
+		}
+		for _, f := range c.files {
+			if _, err := os.Stat(filepath.Join(out, f)); err != nil {
+				t.Errorf("%s: file %s not written: %v", out, f, err)
+			}
+		}
+
+		parser := c.target.(targets.PointParser)
+		ds := parser.FileDataSource(out)
+		var parsed []*data.Point
+		for {
+			item := ds.NextItem()
+			if item.Data == nil {
+				break
+			}
+			p := data.NewPoint()
+			if err := parser.ParsePoint(item, testSchema(), p); err != nil {
+				t.Fatalf("%s: unexpected error parsing: %v", out, err)
+			}
+			parsed = append(parsed, p)
+		}
+		// the files are read in the order of the measurement names
+		if got, want := serialized(t, parsed), serialized(t, testPoints()); got != want {
+			t.Errorf("%s: incorrect points read back: got\n%s\nwant\n%s", out, got, want)
+		}
+	}
+}
+
+func TestNewPointWriterErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-columnar")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := newPointWriter(parquetFormat, dir, testSchema(), "snappy"); err == nil {
+		t.Errorf("expected error for unknown compression")
+	}
+	w, err := newPointWriter(parquetFormat, dir, testSchema(), "lz4")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Write(testPoints()[0]); err == nil {
+		t.Errorf("expected error for lz4 compressed parquet")
+	}
+
+	w, err = newPointWriter(arrowFormat, dir, testSchema(), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p := testPoints()[0]
+	p.SetMeasurementName([]byte("unknown"))
+	if err := w.Write(p); err == nil {
+		t.Errorf("expected error for measurement not in schema")
+	}
+	p = testPoints()[0]
+	p.AppendField([]byte("speed"), 1.0)
+	if err := w.Write(p); err == nil {
+		t.Errorf("expected error for field not in schema")
+	}
+	p = testPoints()[0]
+	p.AppendTag([]byte("name"), 1)
+	if err := w.Write(p); err == nil {
+		t.Errorf("expected error for tag of the wrong type")
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("unexpected error closing: %v", err)
+	}
+}
+
+func TestSerializer(t *testing.T) {
+	if err := NewParquetTarget().Serializer().Serialize(testPoints()[0], new(bytes.Buffer)); err == nil {
+		t.Errorf("expected error serializing to a stream")
+	}
+}
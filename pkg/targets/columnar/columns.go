@@ -0,0 +1,101 @@
+package columnar
+
+import (
+	"fmt"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+)
+
+// timeColumn is the name of the first column of every file, holding the
+// timestamps of the points in nanoseconds
+const timeColumn = "time"
+
+var timeType = &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"}
+
+// arrowType returns the arrow type of the tags or fields with the given type
+// name, as in the headers of the generated data
+func arrowType(typ string) (arrow.DataType, error) {
+	switch typ {
+	case "string", "[]uint8":
+		return arrow.BinaryTypes.String, nil
+	case "float64":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "float32":
+		return arrow.PrimitiveTypes.Float32, nil
+	case "int", "int64":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "bool":
+		return arrow.FixedWidthTypes.Boolean, nil
+	default:
+		return nil, fmt.Errorf("no column type for values of type '%s'", typ)
+	}
+}
+
+// appendValue appends a tag or field value to the builder of its column. Nil
+// values are nulls.
+func appendValue(b array.Builder, v interface{}) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		switch v := v.(type) {
+		case string:
+			b.Append(v)
+			return nil
+		case []byte:
+			b.Append(string(v))
+			return nil
+		}
+	case *array.Float64Builder:
+		if v, ok := v.(float64); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Float32Builder:
+		if v, ok := v.(float32); ok {
+			b.Append(v)
+			return nil
+		}
+	case *array.Int64Builder:
+		switch v := v.(type) {
+		case int64:
+			b.Append(v)
+			return nil
+		case int:
+			b.Append(int64(v))
+			return nil
+		}
+	case *array.BooleanBuilder:
+		if v, ok := v.(bool); ok {
+			b.Append(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("value %v of type %T does not match column type %s", v, v, b.Type())
+}
+
+// columnValue returns the value of a row of a column, or nil if it is null
+func columnValue(col arrow.Array, i int) (interface{}, error) {
+	if col.IsNull(i) {
+		return nil, nil
+	}
+	switch col := col.(type) {
+	case *array.String:
+		return col.Value(i), nil
+	case *array.Float64:
+		return col.Value(i), nil
+	case *array.Float32:
+		return col.Value(i), nil
+	case *array.Int64:
+		return col.Value(i), nil
+	case *array.Boolean:
+		return col.Value(i), nil
+	case *array.Timestamp:
+		return int64(col.Value(i)), nil
+	default:
+		return nil, fmt.Errorf("unsupported column type %s", col.DataType())
+	}
+}
@@ -0,0 +1,102 @@
+package columnar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/apache/arrow/go/v15/parquet"
+	pqcompress "github.com/apache/arrow/go/v15/parquet/compress"
+	"github.com/apache/arrow/go/v15/parquet/file"
+	"github.com/apache/arrow/go/v15/parquet/pqarrow"
+	"github.com/timescale/tsbs/internal/compress"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// rowsPerRecord is the number of rows of the record batches written to the
+// files, i.e. of the parquet row groups
+const rowsPerRecord = 128 * 1024
+
+// fileFormat is a columnar file format, written as a file per measurement
+type fileFormat struct {
+	name      string
+	extension string
+	// defaultCodec compresses the files when no compression is given
+	defaultCodec compress.Codec
+	newWriter    func(f *os.File, schema *arrow.Schema, codec compress.Codec) (recordWriter, error)
+	newReader    func(f *os.File) (recordReader, error)
+}
+
+// recordWriter writes record batches to a file. Close finishes the file, but
+// does not close it.
+type recordWriter interface {
+	Write(arrow.Record) error
+	Close() error
+}
+
+// recordReader reads the record batches of a file. The records are valid
+// until the next call to Read, which returns io.EOF after the last one.
+type recordReader interface {
+	Read() (arrow.Record, error)
+}
+
+var parquetFormat = &fileFormat{
+	name:         constants.FormatParquet,
+	extension:    ".parquet",
+	defaultCodec: compress.Zstd,
+	newWriter: func(f *os.File, schema *arrow.Schema, codec compress.Codec) (recordWriter, error) {
+		var pqCodec pqcompress.Compression
+		switch codec {
+		case compress.None:
+			pqCodec = pqcompress.Codecs.Uncompressed
+		case compress.Gzip:
+			pqCodec = pqcompress.Codecs.Gzip
+		case compress.Zstd:
+			pqCodec = pqcompress.Codecs.Zstd
+		default:
+			return nil, fmt.Errorf("compression '%s' not supported for parquet files", codec)
+		}
+		props := parquet.NewWriterProperties(parquet.WithCompression(pqCodec), parquet.WithMaxRowGroupLength(rowsPerRecord))
+		// the stored arrow schema keeps the time zone of the time column
+		arrowProps := pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())
+		// the writer closes its sink, which the caller does
+		return pqarrow.NewFileWriter(schema, struct{ io.Writer }{f}, props, arrowProps)
+	},
+	newReader: func(f *os.File) (recordReader, error) {
+		pf, err := file.NewParquetReader(f)
+		if err != nil {
+			return nil, err
+		}
+		fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: rowsPerRecord}, memory.DefaultAllocator)
+		if err != nil {
+			return nil, err
+		}
+		return fr.GetRecordReader(context.Background(), nil, nil)
+	},
+}
+
+var arrowFormat = &fileFormat{
+	name:         constants.FormatArrow,
+	extension:    ".arrow",
+	defaultCodec: compress.None,
+	newWriter: func(f *os.File, schema *arrow.Schema, codec compress.Codec) (recordWriter, error) {
+		opts := []ipc.Option{ipc.WithSchema(schema)}
+		switch codec {
+		case compress.None:
+		case compress.Zstd:
+			opts = append(opts, ipc.WithZstd())
+		case compress.LZ4:
+			opts = append(opts, ipc.WithLZ4())
+		default:
+			return nil, fmt.Errorf("compression '%s' not supported for arrow files", codec)
+		}
+		return ipc.NewFileWriter(struct{ io.WriteSeeker }{f}, opts...)
+	},
+	newReader: func(f *os.File) (recordReader, error) {
+		return ipc.NewFileReader(f)
+	},
+}
@@ -0,0 +1,72 @@
+// Package columnar implements the parquet and arrow (Arrow IPC file) formats
+// of generated data, as neutral interchange for columnar engines and data
+// lakes. The points of each measurement are written to their own file, e.g.
+// <dir>/cpu.parquet, with a time column and a typed column per tag and field.
+package columnar
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// NewParquetTarget returns the target writing a parquet file per measurement
+func NewParquetTarget() targets.ImplementedTarget {
+	return &columnarTarget{format: parquetFormat}
+}
+
+// NewArrowTarget returns the target writing an Arrow IPC file per measurement
+func NewArrowTarget() targets.ImplementedTarget {
+	return &columnarTarget{format: arrowFormat}
+}
+
+type columnarTarget struct {
+	format *fileFormat
+}
+
+func (t *columnarTarget) TargetSpecificFlags(string, *pflag.FlagSet) {}
+
+func (t *columnarTarget) TargetName() string {
+	return t.format.name
+}
+
+// Serializer returns a serializer that fails, the points can only be written
+// to a directory with NewPointWriter
+func (t *columnarTarget) Serializer() serialize.PointSerializer {
+	return &serializer{format: t.format.name}
+}
+
+func (t *columnarTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	return nil, fmt.Errorf("%s files can not be loaded, convert them with tsbs_convert", t.format.name)
+}
+
+// NewPointWriter implements targets.DirectoryWriter
+func (t *columnarTarget) NewPointWriter(dir string, schema *targets.DataSchema, compression string) (targets.PointWriter, error) {
+	return newPointWriter(t.format, dir, schema, compression)
+}
+
+// FileDataSource implements targets.PointParser. fileName is the directory
+// of the measurement files, the points are read one measurement after the
+// other.
+func (t *columnarTarget) FileDataSource(fileName string) targets.DataSource {
+	return newFileDataSource(t.format, fileName)
+}
+
+// ParsePoint implements targets.PointParser
+func (t *columnarTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return parseRow(item.Data.(*row), schema, p)
+}
+
+type serializer struct {
+	format string
+}
+
+func (s *serializer) Serialize(*data.Point, io.Writer) error {
+	return fmt.Errorf("%s data is written as a file per measurement to the directory given with --file", s.format)
+}
@@ -0,0 +1,138 @@
+package columnar
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// row is a row of a measurement file, with the values of its columns. The
+// first column is the time.
+type row struct {
+	measurement string
+	columns     []string
+	values      []interface{}
+}
+
+// fileDataSource reads the rows of the measurement files of a directory, one
+// file after the other in the order of their names
+type fileDataSource struct {
+	format *fileFormat
+	files  []string
+
+	file        *os.File
+	reader      recordReader
+	measurement string
+	columns     []string
+	record      arrow.Record
+	next        int
+}
+
+func newFileDataSource(format *fileFormat, dir string) *fileDataSource {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+format.extension))
+	if err != nil {
+		log.Fatalf("cannot list %s files in %s: %v", format.name, dir, err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("no %s files in %s", format.name, dir)
+	}
+	sort.Strings(files)
+	return &fileDataSource{format: format, files: files}
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	for d.record == nil || d.next == int(d.record.NumRows()) {
+		if !d.nextRecord() {
+			return data.LoadedPoint{}
+		}
+	}
+	r := &row{
+		measurement: d.measurement,
+		columns:     d.columns,
+		values:      make([]interface{}, len(d.columns)),
+	}
+	for i, col := range d.record.Columns() {
+		v, err := columnValue(col, d.next)
+		if err != nil {
+			log.Fatalf("cannot read column '%s' of %s: %v", d.columns[i], d.measurement, err)
+		}
+		r.values[i] = v
+	}
+	d.next++
+	return data.NewLoadedPoint(r)
+}
+
+// nextRecord reads the next record of the current file, or opens the next
+// file. It returns false when all files are read.
+func (d *fileDataSource) nextRecord() bool {
+	if d.reader != nil {
+		rec, err := d.reader.Read()
+		if err == nil {
+			if d.columns == nil {
+				for _, f := range rec.Schema().Fields() {
+					d.columns = append(d.columns, f.Name)
+				}
+			}
+			d.record, d.next = rec, 0
+			return true
+		}
+		if err != io.EOF {
+			log.Fatalf("cannot read %s: %v", d.file.Name(), err)
+		}
+		d.file.Close()
+		d.reader, d.record = nil, nil
+	}
+	if len(d.files) == 0 {
+		return false
+	}
+
+	fileName := d.files[0]
+	d.files = d.files[1:]
+	f, err := os.Open(fileName)
+	if err != nil {
+		log.Fatalf("cannot open file for read %s: %v", fileName, err)
+	}
+	reader, err := d.format.newReader(f)
+	if err != nil {
+		log.Fatalf("cannot read %s file %s: %v", d.format.name, fileName, err)
+	}
+	d.file, d.reader = f, reader
+	d.measurement = strings.TrimSuffix(filepath.Base(fileName), d.format.extension)
+	d.columns = nil
+	return d.nextRecord()
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	return nil
+}
+
+// parseRow sets the measurement, tags and fields of p from a row. The columns
+// that are not fields of the measurement are tags.
+func parseRow(r *row, schema *targets.DataSchema, p *data.Point) error {
+	if len(r.columns) == 0 || r.columns[0] != timeColumn {
+		return fmt.Errorf("'%s' has no '%s' column", r.measurement, timeColumn)
+	}
+	values, err := schema.NewPointValues(r.measurement)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(r.columns); i++ {
+		if err := values.Set(r.columns[i], r.values[i]); err != nil {
+			return err
+		}
+	}
+	values.Fill(p)
+	ts := time.Unix(0, r.values[0].(int64))
+	p.SetTimestamp(&ts)
+	return nil
+}
@@ -0,0 +1,197 @@
+package columnar
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/timescale/tsbs/internal/compress"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// pointWriter writes the points of each measurement to its own file in a
+// directory, with a time column followed by a typed column per tag and
+// field
+type pointWriter struct {
+	format       *fileFormat
+	dir          string
+	schema       *targets.DataSchema
+	codec        compress.Codec
+	measurements map[string]*measurementWriter
+}
+
+func newPointWriter(format *fileFormat, dir string, schema *targets.DataSchema, compression string) (*pointWriter, error) {
+	codec, err := compress.ParseCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+	if codec == compress.Auto {
+		codec = format.defaultCodec
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create output directory %s: %v", dir, err)
+	}
+	return &pointWriter{
+		format:       format,
+		dir:          dir,
+		schema:       schema,
+		codec:        codec,
+		measurements: make(map[string]*measurementWriter),
+	}, nil
+}
+
+func (w *pointWriter) Write(p *data.Point) error {
+	name := string(p.MeasurementName())
+	m, ok := w.measurements[name]
+	if !ok {
+		var err error
+		if m, err = w.newMeasurementWriter(name, p); err != nil {
+			return err
+		}
+		w.measurements[name] = m
+	}
+	return m.write(p)
+}
+
+// Close writes the buffered rows and finishes the files of all measurements
+func (w *pointWriter) Close() error {
+	var firstErr error
+	for _, m := range w.measurements {
+		if err := m.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// newMeasurementWriter creates the file of a measurement. The columns of the
+// tags in the headers come first, followed by the measurement specific tags
+// of its first point, then the fields.
+func (w *pointWriter) newMeasurementWriter(name string, p *data.Point) (*measurementWriter, error) {
+	headers := w.schema.Headers
+	fieldKeys, ok := headers.FieldKeys[name]
+	if !ok {
+		return nil, fmt.Errorf("measurement '%s' not in schema", name)
+	}
+	m := &measurementWriter{
+		tagColumns:   make(map[string]int),
+		fieldColumns: make(map[string]int),
+	}
+	fields := []arrow.Field{{Name: timeColumn, Type: timeType}}
+	addColumn := func(columns map[string]int, key, typ string) error {
+		dt, err := arrowType(typ)
+		if err != nil {
+			return fmt.Errorf("column '%s' of '%s': %v", key, name, err)
+		}
+		columns[key] = len(fields)
+		fields = append(fields, arrow.Field{Name: key, Type: dt, Nullable: true})
+		return nil
+	}
+	for i, key := range headers.TagKeys {
+		if err := addColumn(m.tagColumns, key, headers.TagTypes[i]); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range p.TagKeys() {
+		if w.schema.IsTag(string(key)) {
+			continue
+		}
+		if err := addColumn(m.tagColumns, string(key), "string"); err != nil {
+			return nil, err
+		}
+	}
+	for i, key := range fieldKeys {
+		if err := addColumn(m.fieldColumns, key, w.schema.FieldTypes[name][i]); err != nil {
+			return nil, err
+		}
+	}
+
+	fileName := filepath.Join(w.dir, name+w.format.extension)
+	f, err := os.Create(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file for write %s: %v", fileName, err)
+	}
+	schema := arrow.NewSchema(fields, nil)
+	out, err := w.format.newWriter(f, schema, w.codec)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	m.file, m.out = f, out
+	m.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	return m, nil
+}
+
+// measurementWriter buffers the rows of a measurement into record batches
+type measurementWriter struct {
+	file    *os.File
+	out     recordWriter
+	builder *array.RecordBuilder
+	// tagColumns and fieldColumns are the column indexes of the tags and
+	// fields by key
+	tagColumns   map[string]int
+	fieldColumns map[string]int
+	rows         int
+}
+
+func (m *measurementWriter) write(p *data.Point) error {
+	m.builder.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(p.Timestamp().UnixNano()))
+	tagValues := p.TagValues()
+	for i, key := range p.TagKeys() {
+		col, ok := m.tagColumns[string(key)]
+		if !ok {
+			return fmt.Errorf("tag '%s' not in the columns of '%s'", key, p.MeasurementName())
+		}
+		if err := appendValue(m.builder.Field(col), tagValues[i]); err != nil {
+			return fmt.Errorf("tag '%s': %v", key, err)
+		}
+	}
+	fieldValues := p.FieldValues()
+	for i, key := range p.FieldKeys() {
+		col, ok := m.fieldColumns[string(key)]
+		if !ok {
+			return fmt.Errorf("field '%s' not in the columns of '%s'", key, p.MeasurementName())
+		}
+		if err := appendValue(m.builder.Field(col), fieldValues[i]); err != nil {
+			return fmt.Errorf("field '%s': %v", key, err)
+		}
+	}
+	m.rows++
+	// the tags and fields missing from the point are null
+	for _, b := range m.builder.Fields() {
+		if b.Len() < m.rows {
+			b.AppendNull()
+		}
+	}
+
+	if m.rows == rowsPerRecord {
+		return m.flush()
+	}
+	return nil
+}
+
+func (m *measurementWriter) flush() error {
+	rec := m.builder.NewRecord()
+	defer rec.Release()
+	m.rows = 0
+	return m.out.Write(rec)
+}
+
+func (m *measurementWriter) close() error {
+	defer m.builder.Release()
+	if m.rows > 0 {
+		if err := m.flush(); err != nil {
+			m.file.Close()
+			return err
+		}
+	}
+	if err := m.out.Close(); err != nil {
+		m.file.Close()
+		return err
+	}
+	return m.file.Close()
+}
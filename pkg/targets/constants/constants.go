@@ -15,6 +15,9 @@ const (
 	FormatVictoriaMetrics = "victoriametrics"
 	FormatTimestream      = "timestream"
 	FormatQuestDB         = "questdb"
+	FormatParquet         = "parquet"
+	FormatArrow           = "arrow"
+	FormatOTLP            = "otlp"
 )
 
 func SupportedFormats() []string {
@@ -32,5 +35,34 @@ func SupportedFormats() []string {
 		FormatVictoriaMetrics,
 		FormatTimestream,
 		FormatQuestDB,
+		FormatParquet,
+		FormatArrow,
+		FormatOTLP,
+	}
+}
+
+// Schema layouts of the SQL targets. Wide stores one row per measurement with
+// a column per field, narrow stores one (time, series, metric_name, value)
+// row per field value.
+const (
+	SchemaLayoutWide   = "wide"
+	SchemaLayoutNarrow = "narrow"
+)
+
+func SupportedSchemaLayouts() []string {
+	return []string{
+		SchemaLayoutWide,
+		SchemaLayoutNarrow,
+	}
+}
+
+// NarrowLayoutFormats are the formats that can load and query the narrow
+// schema layout, the others only have the wide one
+func NarrowLayoutFormats() []string {
+	return []string{
+		FormatTimescaleDB,
+		FormatClickhouse,
+		FormatCrateDB,
+		FormatQuestDB,
 	}
 }
@@ -23,6 +23,7 @@ func (t *crateTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.Flag
 	flagSet.String(flagPrefix+"pass", "", "Password for user connecting to CrateDB")
 	flagSet.Int(flagPrefix+"replicas", 0, "Number of replicas per a metric table")
 	flagSet.Int(flagPrefix+"shards", 5, "Number of shards per a metric table")
+	flagSet.String(flagPrefix+"schema-layout", constants.SchemaLayoutWide, "Layout of the metrics tables: 'wide' stores a column per field, 'narrow' a (tags, ts, metric_name, value) row per field value")
 }
 
 func (t *crateTarget) TargetName() string {
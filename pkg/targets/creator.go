@@ -34,3 +34,13 @@ type DBCreatorPost interface {
 	// PostCreateDB does further initialization after the database is created
 	PostCreateDB(dbName string) error
 }
+
+// DBCreatorPostLoad is a DBCreator that also does further work on the loaded
+// data once all workers are done (e.g., compressing it). Its stats are reported
+// separately from the load and added to the totals of the results file.
+type DBCreatorPostLoad interface {
+	DBCreator
+
+	// PostLoad runs after all data is loaded into the database with the given name
+	PostLoad(dbName string) (map[string]interface{}, error)
+}
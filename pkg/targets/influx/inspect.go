@@ -0,0 +1,98 @@
+package influx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// FileDataSource implements targets.FileInspector and targets.PointParser
+func (t *influxTarget) FileDataSource(fileName string) targets.DataSource {
+	return NewFileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (t *influxTarget) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	return DescribeLine(item.Data.(string))
+}
+
+// NewFileDataSource returns a DataSource streaming the lines of a file in the
+// InfluxDB wire protocol. The data files have no headers.
+func NewFileDataSource(fileName string) targets.DataSource {
+	return &fileDataSource{scanner: bufio.NewScanner(load.GetBufferedReader(fileName))}
+}
+
+type fileDataSource struct {
+	scanner *bufio.Scanner
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return data.LoadedPoint{}
+	} else if !ok {
+		log.Fatalf("scan error: %v", d.scanner.Err())
+	}
+	return data.NewLoadedPoint(d.scanner.Text())
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	return nil
+}
+
+// LineTimestamp returns the timestamp of a line in the InfluxDB wire protocol,
+// its last element
+func LineTimestamp(line []byte) (time.Time, error) {
+	timestamp := line[bytes.LastIndexByte(line, ' ')+1:]
+	timestampNano, err := strconv.ParseInt(string(timestamp), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", timestamp)
+	}
+	return time.Unix(0, timestampNano), nil
+}
+
+// DescribeLine describes a line in the InfluxDB wire protocol:
+// <measurement>,<tag key>=<tag value> <field name>=<field value> <timestamp>
+// Empty fields are not written, so every field has a value.
+func DescribeLine(line string) (*targets.PointDescription, error) {
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected measurement and tags, fields and timestamp, got %d parts", len(parts))
+	}
+	timestampNano, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp '%s'", parts[2])
+	}
+	series := strings.SplitN(parts[0], ",", 2)
+	if len(series) != 2 {
+		return nil, fmt.Errorf("no tags in '%s'", parts[0])
+	}
+	primaryTag := strings.SplitN(strings.SplitN(series[1], ",", 2)[0], "=", 2)
+	if len(primaryTag) != 2 {
+		return nil, fmt.Errorf("invalid tag '%s'", primaryTag[0])
+	}
+	fields := strings.Split(parts[1], ",")
+	for _, f := range fields {
+		if !strings.Contains(f, "=") {
+			return nil, fmt.Errorf("invalid field '%s'", f)
+		}
+	}
+
+	return &targets.PointDescription{
+		Measurement: series[0],
+		Series:      series[1],
+		PrimaryTag:  primaryTag[1],
+		Timestamp:   time.Unix(0, timestampNano),
+		Fields:      len(fields),
+		Values:      len(fields),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package influx
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+func TestDescribeLine(t *testing.T) {
+	got, err := DescribeLine("cpu,hostname=host_0,region=eu-west-1 usage_user=58i,usage_system=2i 1451606400000000000")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &targets.PointDescription{
+		Measurement: "cpu",
+		Series:      "hostname=host_0,region=eu-west-1",
+		PrimaryTag:  "host_0",
+		Timestamp:   time.Unix(0, 1451606400000000000),
+		Fields:      2,
+		Values:      2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect description: got %+v want %+v", got, want)
+	}
+
+	malformed := []string{
+		"cpu,hostname=host_0 usage_user=58i",
+		"cpu,hostname=host_0 usage_user=58i foo",
+		"cpu usage_user=58i 1451606400000000000",
+		"cpu,host_0 usage_user=58i 1451606400000000000",
+		"cpu,hostname=host_0 usage_user 1451606400000000000",
+	}
+	for _, line := range malformed {
+		if _, err := DescribeLine(line); err == nil {
+			t.Errorf("expected error for malformed line '%s'", line)
+		}
+	}
+}
+
+func TestLineTimestamp(t *testing.T) {
+	got, err := LineTimestamp([]byte("cpu,hostname=host_0 usage_user=58i 1451606400000000000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Unix(0, 1451606400000000000); !got.Equal(want) {
+		t.Errorf("incorrect timestamp: got %v want %v", got, want)
+	}
+	if _, err := LineTimestamp([]byte("cpu,hostname=host_0 usage_user=58i")); err == nil {
+		t.Errorf("expected error for a line without timestamp")
+	}
+}
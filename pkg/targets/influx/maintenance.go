@@ -0,0 +1,175 @@
+package influx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// downsampleInterval is the InfluxQL duration the downsampled series are
+// aggregated to
+const downsampleInterval = "1h"
+
+// downsampledDBSuffix is appended to the name of the database to name the
+// database holding its downsampled series
+const downsampledDBSuffix = "_downsampled"
+
+// Maintenance implements targets.Maintainer. The statements are sent to the
+// first of the urls.
+func (t *influxTarget) Maintenance(targetDB string, v *viper.Viper) (targets.Maintenance, error) {
+	daemonURL := strings.Split(v.GetString("urls"), ",")[0]
+	u, err := url.Parse(daemonURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %v", daemonURL, err)
+	}
+	u.Path = "query"
+	return &maintenance{
+		queryURL: u.String(),
+		db:       targetDB,
+		token:    v.GetString("token"),
+		bearer:   v.GetString("bearer"),
+		client:   &http.Client{},
+	}, nil
+}
+
+// maintenance runs the maintenance operations of InfluxDB with InfluxQL
+// statements sent to the /query endpoint
+type maintenance struct {
+	queryURL string
+	db       string
+	token    string
+	bearer   string
+	client   *http.Client
+}
+
+// queryResponse is the response of the /query endpoint
+type queryResponse struct {
+	Results []struct {
+		Error  string `json:"error"`
+		Series []struct {
+			Name    string          `json:"name"`
+			Columns []string        `json:"columns"`
+			Values  [][]interface{} `json:"values"`
+		} `json:"series"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+// DropBefore drops the shards of the database that end before t, which is
+// what the enforcement of a retention policy does with expired shard groups
+func (m *maintenance) DropBefore(t time.Time) error {
+	resp, err := m.query("SHOW SHARDS")
+	if err != nil {
+		return err
+	}
+	ids, err := shardsEndingBefore(resp, m.db, t)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := m.query(fmt.Sprintf("DROP SHARD %d", id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardsEndingBefore returns the ids of the shards of db listed by SHOW
+// SHARDS whose end time is not after t
+func shardsEndingBefore(resp *queryResponse, db string, t time.Time) ([]int64, error) {
+	var ids []int64
+	for _, result := range resp.Results {
+		for _, series := range result.Series {
+			if series.Name != db {
+				continue
+			}
+			idCol, endCol := -1, -1
+			for i, col := range series.Columns {
+				switch col {
+				case "id":
+					idCol = i
+				case "end_time":
+					endCol = i
+				}
+			}
+			if idCol < 0 || endCol < 0 {
+				return nil, fmt.Errorf("unexpected SHOW SHARDS columns: %v", series.Columns)
+			}
+			for _, row := range series.Values {
+				id, ok := row[idCol].(float64)
+				endTime, ok2 := row[endCol].(string)
+				if !ok || !ok2 {
+					return nil, fmt.Errorf("unexpected SHOW SHARDS row: %v", row)
+				}
+				end, err := time.Parse(time.RFC3339, endTime)
+				if err != nil {
+					return nil, fmt.Errorf("invalid shard end time %s: %v", endTime, err)
+				}
+				if !end.After(t) {
+					ids = append(ids, int64(id))
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Downsample aggregates the mean of every field over [start, end), per
+// series and downsampleInterval, into the database suffixed with
+// downsampledDBSuffix, as a continuous query backfill does
+func (m *maintenance) Downsample(start, end time.Time) error {
+	downsampled := m.db + downsampledDBSuffix
+	if _, err := m.query(fmt.Sprintf("CREATE DATABASE %q", downsampled)); err != nil {
+		return err
+	}
+	_, err := m.query(fmt.Sprintf(
+		"SELECT mean(*) INTO %q.\"autogen\".:MEASUREMENT FROM %q.\"autogen\"./.*/ WHERE time >= '%s' AND time < '%s' GROUP BY time(%s), *",
+		downsampled, m.db, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), downsampleInterval))
+	return err
+}
+
+func (m *maintenance) Close() error {
+	return nil
+}
+
+// query runs an InfluxQL statement, returning an error if it failed
+func (m *maintenance) query(statement string) (*queryResponse, error) {
+	form := url.Values{}
+	form.Set("db", m.db)
+	form.Set("q", statement)
+	req, err := http.NewRequest("POST", m.queryURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if m.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+m.bearer)
+	} else if m.token != "" {
+		req.Header.Set("Authorization", "Token "+m.token)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v", statement, err)
+	}
+	defer resp.Body.Close()
+
+	var r queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("%s returned code %d and an unreadable response: %v", statement, resp.StatusCode, err)
+	}
+	if r.Error != "" {
+		return nil, fmt.Errorf("%s failed: %s", statement, r.Error)
+	}
+	for _, result := range r.Results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("%s failed: %s", statement, result.Error)
+		}
+	}
+	return &r, nil
+}
@@ -0,0 +1,68 @@
+package influx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blagojts/viper"
+)
+
+const showShardsResponse = `{"results":[{"statement_id":0,"series":[
+{"name":"benchmark","columns":["id","database","retention_policy","shard_group","start_time","end_time","expiry_time","owners"],"values":[
+[1,"benchmark","autogen",1,"2015-12-28T00:00:00Z","2016-01-04T00:00:00Z","2016-01-04T00:00:00Z",""],
+[2,"benchmark","autogen",2,"2016-01-04T00:00:00Z","2016-01-11T00:00:00Z","2016-01-11T00:00:00Z",""]]},
+{"name":"other","columns":["id","database","retention_policy","shard_group","start_time","end_time","expiry_time","owners"],"values":[
+[3,"other","autogen",3,"2015-12-28T00:00:00Z","2016-01-04T00:00:00Z","2016-01-04T00:00:00Z",""]]}]}]}`
+
+func TestMaintenanceDropBefore(t *testing.T) {
+	var statements []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.FormValue("db"); got != "benchmark" {
+			t.Errorf("incorrect db: got %s want %s", got, "benchmark")
+		}
+		q := r.FormValue("q")
+		statements = append(statements, q)
+		if q == "SHOW SHARDS" {
+			w.Write([]byte(showShardsResponse))
+			return
+		}
+		w.Write([]byte(`{"results":[{"statement_id":0}]}`))
+	}))
+	defer server.Close()
+
+	v := viper.New()
+	v.Set("urls", server.URL+",http://localhost:8087")
+	m, err := NewTarget().(*influxTarget).Maintenance("benchmark", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.DropBefore(time.Date(2016, 1, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"SHOW SHARDS", "DROP SHARD 1"}
+	if !reflect.DeepEqual(statements, want) {
+		t.Errorf("incorrect statements: got %v want %v", statements, want)
+	}
+}
+
+func TestMaintenanceQueryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results":[{"statement_id":0,"error":"database not found: benchmark"}]}`))
+	}))
+	defer server.Close()
+
+	v := viper.New()
+	v.Set("urls", server.URL)
+	m, err := NewTarget().(*influxTarget).Maintenance("benchmark", v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.DropBefore(time.Now()); err == nil {
+		t.Errorf("unexpected lack of error")
+	}
+}
@@ -0,0 +1,99 @@
+package influx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// ParsePoint implements targets.PointParser
+func (t *influxTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return ParseLine(item.Data.(string), schema, p)
+}
+
+// ParseLine parses a line in the InfluxDB wire protocol, as written by
+// Serializer, into p. Tags that are not strings are written as fields, they
+// are parsed back as tags if they are tags in the schema. Nil tags and fields
+// are not written.
+//
+// With a nil schema the tags and fields are appended to p in the order of the
+// line and the field types are the ones of the wire protocol: integers have
+// the 'i' suffix, strings are quoted or are not numbers, and the rest are
+// floats or booleans.
+func ParseLine(line string, schema *targets.DataSchema, p *data.Point) error {
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 {
+		return fmt.Errorf("expected measurement and tags, fields and timestamp, got %d parts", len(parts))
+	}
+	timestampNano, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp '%s'", parts[2])
+	}
+	series := strings.Split(parts[0], ",")
+	var values *targets.PointValues
+	if schema == nil {
+		p.SetMeasurementName([]byte(series[0]))
+	} else if values, err = schema.NewPointValues(series[0]); err != nil {
+		return err
+	}
+	for _, tag := range series[1:] {
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid tag '%s'", tag)
+		}
+		if values == nil {
+			p.AppendTag([]byte(kv[0]), kv[1])
+		} else if err := values.SetTag(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	for _, field := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid field '%s'", field)
+		}
+		switch {
+		case values == nil:
+			p.AppendField([]byte(kv[0]), untypedFieldValue(kv[1]))
+		case schema.IsTag(kv[0]):
+			err = values.SetTag(kv[0], kv[1])
+		default:
+			// integers have the 'i' suffix, the schema has their type
+			err = values.SetField(kv[0], strings.TrimSuffix(kv[1], "i"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if values != nil {
+		values.Fill(p)
+	}
+	ts := time.Unix(0, timestampNano)
+	p.SetTimestamp(&ts)
+	return nil
+}
+
+// untypedFieldValue returns the value of a field with the type of the wire
+// protocol.
+func untypedFieldValue(s string) interface{} {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "i") {
+		if v, err := strconv.ParseInt(s[:len(s)-1], 10, 64); err == nil {
+			return v
+		}
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseBool(s); err == nil {
+		return v
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package influx
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+func testSchema() *targets.DataSchema {
+	return targets.NewDataSchema(&common.GeneratedDataHeaders{
+		TagKeys:   []string{"name", "fleet", "load_capacity"},
+		TagTypes:  []string{"string", "string", "float64"},
+		FieldKeys: map[string][]string{"readings": {"latitude", "status"}},
+	}, map[string][]string{"readings": {"float64", "int64"}})
+}
+
+func TestParseLine(t *testing.T) {
+	cases := []string{
+		"readings,name=truck_0,fleet=South load_capacity=1500,latitude=72.5,status=3i 1451606400000000000\n",
+		// nil tags and fields are not serialized
+		"readings,fleet=South latitude=72.5 1451606400000000000\n",
+		// measurement specific tags follow the tags in the headers
+		"readings,name=truck_0,fleet=South,path=/dev/sda load_capacity=1500,status=3i 1451606400000000000\n",
+	}
+	s := &Serializer{}
+	for _, line := range cases {
+		p := data.NewPoint()
+		if err := ParseLine(line[:len(line)-1], testSchema(), p); err != nil {
+			t.Errorf("'%s': unexpected error: %v", line, err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := s.Serialize(p, buf); err != nil {
+			t.Fatalf("unexpected error serializing: %v", err)
+		}
+		if got := buf.String(); got != line {
+			t.Errorf("incorrect round trip: got '%s' want '%s'", got, line)
+		}
+	}
+
+	malformed := []string{
+		"readings,name=truck_0 latitude=72.5",
+		"readings,name=truck_0 latitude=72.5 foo",
+		"diagnostics,name=truck_0 latitude=72.5 1451606400000000000",
+		"readings,truck_0 latitude=72.5 1451606400000000000",
+		"readings,name=truck_0 latitude 1451606400000000000",
+		"readings,name=truck_0 speed=1 1451606400000000000",
+		"readings,name=truck_0 status=3.5 1451606400000000000",
+		"readings,name=truck_0 load_capacity=x 1451606400000000000",
+	}
+	for _, line := range malformed {
+		if err := ParseLine(line, testSchema(), data.NewPoint()); err == nil {
+			t.Errorf("expected error for malformed line '%s'", line)
+		}
+	}
+}
+
+func TestParseLineWithoutSchema(t *testing.T) {
+	p := data.NewPoint()
+	err := ParseLine(`cpu,hostname=host_0,region=eu usage_user=1.5,free=42i,status="ok",up=true,model=X1 1451606400000000000`, nil, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(p.MeasurementName()); got != "cpu" {
+		t.Errorf("incorrect measurement: %s", got)
+	}
+	if got := p.TagValues(); !reflect.DeepEqual(got, []interface{}{"host_0", "eu"}) {
+		t.Errorf("incorrect tags: %v", got)
+	}
+	wantFields := []interface{}{1.5, int64(42), "ok", true, "X1"}
+	if got := p.FieldValues(); !reflect.DeepEqual(got, wantFields) {
+		t.Errorf("incorrect fields: got %v want %v", got, wantFields)
+	}
+	if got := p.Timestamp().UnixNano(); got != 1451606400000000000 {
+		t.Errorf("incorrect timestamp: %d", got)
+	}
+
+	for _, line := range []string{"cpu usage_user=1", "cpu usage_user=1 now", "cpu,hostname usage_user=1 1", "cpu usage_user 1"} {
+		if err := ParseLine(line, nil, data.NewPoint()); err == nil {
+			t.Errorf("expected error parsing '%s'", line)
+		}
+	}
+}
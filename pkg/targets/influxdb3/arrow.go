@@ -0,0 +1,247 @@
+package influxdb3
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets/influx"
+)
+
+// The columns of the Arrow records have the metadata of the columns of the
+// tables of InfluxDB 3.x: the tags are dictionary encoded strings and the
+// column type of each column is in its field metadata.
+const (
+	timeColumn = "time"
+
+	columnTypeKey         = "iox::column::type"
+	columnTypeTag         = "iox::column_type::tag"
+	columnTypeTimestamp   = "iox::column_type::timestamp"
+	columnTypeFieldPrefix = "iox::column_type::field::"
+)
+
+var (
+	timeType = &arrow.TimestampType{Unit: arrow.Nanosecond}
+	tagType  = &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}
+)
+
+// tableRecord is the record of the points of a measurement, which are
+// written to the table of the same name
+type tableRecord struct {
+	arrow.Record
+	table string
+}
+
+// linesToRecords parses the lines of a batch and returns their points as
+// Arrow records, one per measurement, in the order the measurements first
+// appear. The caller releases the records.
+func linesToRecords(mem memory.Allocator, lines []byte) ([]tableRecord, error) {
+	var points []*data.Point
+	for _, line := range bytes.Split(lines, newLine) {
+		if len(line) == 0 {
+			continue
+		}
+		p := data.NewPoint()
+		if err := influx.ParseLine(string(line), nil, p); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return pointsToRecords(mem, points)
+}
+
+// pointsToRecords returns the points as Arrow records, one per measurement,
+// in the order the measurements first appear. The caller releases the
+// records.
+func pointsToRecords(mem memory.Allocator, points []*data.Point) ([]tableRecord, error) {
+	var measurements []string
+	byMeasurement := map[string][]*data.Point{}
+	for _, p := range points {
+		name := string(p.MeasurementName())
+		if _, ok := byMeasurement[name]; !ok {
+			measurements = append(measurements, name)
+		}
+		byMeasurement[name] = append(byMeasurement[name], p)
+	}
+
+	records := make([]tableRecord, 0, len(measurements))
+	for _, name := range measurements {
+		rec, err := newRecord(mem, name, byMeasurement[name])
+		if err != nil {
+			for _, r := range records {
+				r.Release()
+			}
+			return nil, err
+		}
+		records = append(records, tableRecord{Record: rec, table: name})
+	}
+	return records, nil
+}
+
+// recordSchema returns the schema of the record of the points of a
+// measurement: its tags and then its fields, each sorted by key, and the
+// time. The points of a measurement in a batch can have different tags and
+// fields, the columns are the union of them.
+func recordSchema(measurement string, points []*data.Point) (*arrow.Schema, error) {
+	tags := map[string]bool{}
+	fields := map[string]arrow.DataType{}
+	for _, p := range points {
+		for i, k := range p.TagKeys() {
+			if p.TagValues()[i] != nil {
+				tags[string(k)] = true
+			}
+		}
+		for i, k := range p.FieldKeys() {
+			v := p.FieldValues()[i]
+			if v == nil {
+				continue
+			}
+			typ, err := fieldType(v)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s' of '%s': %v", k, measurement, err)
+			}
+			if prev, ok := fields[string(k)]; ok && !arrow.TypeEqual(prev, typ) {
+				return nil, fmt.Errorf("field '%s' of '%s' is both %s and %s", k, measurement, prev, typ)
+			}
+			fields[string(k)] = typ
+		}
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+	for k := range tags {
+		if _, ok := fields[k]; ok {
+			return nil, fmt.Errorf("'%s' of '%s' is both a tag and a field", k, measurement)
+		}
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+
+	columns := make([]arrow.Field, 0, len(tagKeys)+len(fieldKeys)+1)
+	for _, k := range tagKeys {
+		columns = append(columns, column(k, tagType, columnTypeTag, true))
+	}
+	for _, k := range fieldKeys {
+		typ := fields[k]
+		columns = append(columns, column(k, typ, columnTypeFieldPrefix+fieldTypeName(typ), true))
+	}
+	columns = append(columns, column(timeColumn, timeType, columnTypeTimestamp, false))
+	return arrow.NewSchema(columns, nil), nil
+}
+
+func column(name string, typ arrow.DataType, columnType string, nullable bool) arrow.Field {
+	return arrow.Field{
+		Name:     name,
+		Type:     typ,
+		Nullable: nullable,
+		Metadata: arrow.NewMetadata([]string{columnTypeKey}, []string{columnType}),
+	}
+}
+
+// fieldType returns the arrow type of the column of a field value
+func fieldType(v interface{}) (arrow.DataType, error) {
+	switch v.(type) {
+	case float64, float32:
+		return arrow.PrimitiveTypes.Float64, nil
+	case int64, int:
+		return arrow.PrimitiveTypes.Int64, nil
+	case string, []byte:
+		return arrow.BinaryTypes.String, nil
+	case bool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v of type %T", v, v)
+}
+
+// fieldTypeName returns the name of the InfluxDB 3.x field type of an arrow
+// type returned by fieldType
+func fieldTypeName(typ arrow.DataType) string {
+	switch typ.ID() {
+	case arrow.INT64:
+		return "integer"
+	case arrow.STRING:
+		return "string"
+	case arrow.BOOL:
+		return "boolean"
+	default:
+		return "float"
+	}
+}
+
+// newRecord returns the record of the points of a measurement
+func newRecord(mem memory.Allocator, measurement string, points []*data.Point) (arrow.Record, error) {
+	schema, err := recordSchema(measurement, points)
+	if err != nil {
+		return nil, err
+	}
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+
+	index := make(map[string]int, len(schema.Fields()))
+	for i, f := range schema.Fields() {
+		index[f.Name] = i
+	}
+	timeIndex := len(schema.Fields()) - 1
+	set := make([]bool, len(schema.Fields()))
+	for _, p := range points {
+		for i := range set {
+			set[i] = false
+		}
+		for i, k := range p.TagKeys() {
+			v := p.TagValues()[i]
+			if v == nil {
+				continue
+			}
+			j := index[string(k)]
+			if err := b.Field(j).(*array.BinaryDictionaryBuilder).AppendString(fmt.Sprint(v)); err != nil {
+				return nil, err
+			}
+			set[j] = true
+		}
+		for i, k := range p.FieldKeys() {
+			v := p.FieldValues()[i]
+			if v == nil {
+				continue
+			}
+			j := index[string(k)]
+			appendField(b.Field(j), v)
+			set[j] = true
+		}
+		b.Field(timeIndex).(*array.TimestampBuilder).Append(arrow.Timestamp(p.Timestamp().UnixNano()))
+		for i := 0; i < timeIndex; i++ {
+			if !set[i] {
+				b.Field(i).AppendNull()
+			}
+		}
+	}
+	return b.NewRecord(), nil
+}
+
+// appendField appends a field value to the builder of its column, of the
+// type returned by fieldType
+func appendField(b array.Builder, v interface{}) {
+	switch v := v.(type) {
+	case float64:
+		b.(*array.Float64Builder).Append(v)
+	case float32:
+		b.(*array.Float64Builder).Append(float64(v))
+	case int64:
+		b.(*array.Int64Builder).Append(v)
+	case int:
+		b.(*array.Int64Builder).Append(int64(v))
+	case string:
+		b.(*array.StringBuilder).Append(v)
+	case []byte:
+		b.(*array.StringBuilder).Append(string(v))
+	case bool:
+		b.(*array.BooleanBuilder).Append(v)
+	}
+}
@@ -0,0 +1,111 @@
+package influxdb3
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v15/arrow"
+	"github.com/apache/arrow/go/v15/arrow/array"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+func TestLinesToRecords(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	lines := "cpu,hostname=host_0 usage_user=1,usage_system=2 1000\n" +
+		"mem,hostname=host_0 free=42i 1000\n" +
+		"cpu,hostname=host_1,region=eu usage_user=3 2000\n"
+	records, err := linesToRecords(mem, []byte(lines))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+	if len(records) != 2 || records[0].table != "cpu" || records[1].table != "mem" {
+		t.Fatalf("incorrect records: %v", records)
+	}
+
+	cpu := records[0]
+	var names []string
+	for _, f := range cpu.Schema().Fields() {
+		names = append(names, f.Name)
+	}
+	wantNames := []string{"hostname", "region", "usage_system", "usage_user", "time"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("incorrect columns: got %v want %v", names, wantNames)
+	}
+	wantColumnTypes := []string{columnTypeTag, columnTypeTag, columnTypeFieldPrefix + "float", columnTypeFieldPrefix + "float", columnTypeTimestamp}
+	for i, f := range cpu.Schema().Fields() {
+		if got, _ := f.Metadata.GetValue(columnTypeKey); got != wantColumnTypes[i] {
+			t.Errorf("incorrect column type of %s: got %s want %s", f.Name, got, wantColumnTypes[i])
+		}
+	}
+	if cpu.NumRows() != 2 {
+		t.Fatalf("incorrect number of rows: %d", cpu.NumRows())
+	}
+	hostnames := cpu.Column(0).(*array.Dictionary)
+	if got := hostnames.Dictionary().(*array.String).Value(hostnames.GetValueIndex(1)); got != "host_1" {
+		t.Errorf("incorrect hostname: %s", got)
+	}
+	if !cpu.Column(1).IsNull(0) || cpu.Column(1).IsNull(1) {
+		t.Errorf("missing tag is not null")
+	}
+	if !cpu.Column(2).IsNull(1) || cpu.Column(2).(*array.Float64).Value(0) != 2 {
+		t.Errorf("incorrect usage_system: %v", cpu.Column(2))
+	}
+	if got := cpu.Column(3).(*array.Float64).Float64Values(); !reflect.DeepEqual(got, []float64{1, 3}) {
+		t.Errorf("incorrect usage_user: %v", got)
+	}
+	if got := cpu.Column(4).(*array.Timestamp).Value(1); got != arrow.Timestamp(2000) {
+		t.Errorf("incorrect time: %v", got)
+	}
+
+	mem2 := records[1]
+	if got := mem2.Column(1).DataType(); !arrow.TypeEqual(got, arrow.PrimitiveTypes.Int64) {
+		t.Errorf("incorrect type of integer field: %s", got)
+	}
+}
+
+func TestPointsToRecordsErrors(t *testing.T) {
+	ts := time.Unix(0, 1000)
+	newPoint := func(tags map[string]string, field string, value interface{}) *data.Point {
+		p := data.NewPoint()
+		p.SetMeasurementName([]byte("cpu"))
+		for k, v := range tags {
+			p.AppendTag([]byte(k), v)
+		}
+		p.AppendField([]byte(field), value)
+		p.SetTimestamp(&ts)
+		return p
+	}
+	cases := []struct {
+		desc   string
+		points []*data.Point
+	}{
+		{
+			desc:   "conflicting field types",
+			points: []*data.Point{newPoint(nil, "usage_user", 1.0), newPoint(nil, "usage_user", int64(1))},
+		},
+		{
+			desc:   "tag and field",
+			points: []*data.Point{newPoint(map[string]string{"hostname": "host_0"}, "hostname", "host_0")},
+		},
+		{
+			desc:   "unsupported type",
+			points: []*data.Point{newPoint(nil, "usage_user", uint8(1))},
+		},
+	}
+	for _, c := range cases {
+		mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+		if _, err := pointsToRecords(mem, c.points); err == nil {
+			t.Errorf("%s: expected error", c.desc)
+		}
+		mem.AssertSize(t, 0)
+	}
+}
@@ -0,0 +1,126 @@
+package influxdb3
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/influx"
+)
+
+const (
+	// ingestLineProtocol writes the lines of a batch to an HTTP endpoint
+	ingestLineProtocol = "line-protocol"
+	// ingestArrowFlight writes the points of a batch as Arrow record
+	// batches, one per measurement, with a Flight DoPut
+	ingestArrowFlight = "arrow-flight"
+)
+
+type SpecificConfig struct {
+	URLs              []string      `yaml:"urls" mapstructure:"urls"`
+	ReplicationFactor int           `yaml:"replication-factor" mapstructure:"replication-factor"`
+	Backoff           time.Duration `yaml:"backoff" mapstructure:"backoff"`
+	Gzip              bool          `yaml:"gzip" mapstructure:"gzip"`
+	Token             string        `yaml:"token" mapstructure:"token"`
+	Org               string        `yaml:"org" mapstructure:"org"`
+	Bearer            string        `yaml:"bearer" mapstructure:"bearer"`
+	NoSync            bool          `yaml:"no-sync" mapstructure:"no-sync"`
+	WriteEndpoint     string        `yaml:"write-endpoint" mapstructure:"write-endpoint"`
+	Ingest            string        `yaml:"ingest" mapstructure:"ingest"`
+}
+
+func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
+	var conf SpecificConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// authorization returns the value of the Authorization header of the
+// requests, empty if there are no credentials
+func (c *SpecificConfig) authorization() string {
+	if c.Bearer != "" {
+		return "Bearer " + c.Bearer
+	} else if c.Token != "" {
+		return "Token " + c.Token
+	}
+	return ""
+}
+
+// loader.Benchmark interface implementation
+type benchmark struct {
+	database   string
+	config     *SpecificConfig
+	dataSource targets.DataSource
+}
+
+// NewBenchmark returns a Benchmark loading the data source into the database
+// of InfluxDB 3.x with the given name.
+func NewBenchmark(database string, influxDB3SpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	if dataSourceConfig.Type != source.FileDataSourceType {
+		return nil, errors.New("only FILE data source type is supported for InfluxDB 3")
+	}
+	if len(influxDB3SpecificConfig.URLs) == 0 || influxDB3SpecificConfig.URLs[0] == "" {
+		return nil, errors.New("missing 'urls' flag")
+	}
+	switch influxDB3SpecificConfig.Ingest {
+	case ingestLineProtocol:
+		if e := influxDB3SpecificConfig.WriteEndpoint; e != writeEndpointV2 && e != writeEndpointV3 {
+			return nil, fmt.Errorf("invalid write endpoint '%s', valid: %s, %s", e, writeEndpointV2, writeEndpointV3)
+		}
+	case ingestArrowFlight:
+		for _, u := range influxDB3SpecificConfig.URLs {
+			if _, _, err := flightAddress(u); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid ingest '%s', valid: %s, %s", influxDB3SpecificConfig.Ingest, ingestLineProtocol, ingestArrowFlight)
+	}
+
+	br := load.GetBufferedReader(dataSourceConfig.File.Location)
+	return &benchmark{
+		database:   database,
+		config:     influxDB3SpecificConfig,
+		dataSource: &fileDataSource{scanner: bufio.NewScanner(br)},
+	}, nil
+}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return b.dataSource
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	bufPool := sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+	return &factory{bufPool: &bufPool}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{database: b.database, config: b.config}
+}
+
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return &dbCreator{config: b.config}
+}
+
+// PointTimestamp implements targets.PointTimestamper
+func (b *benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	return influx.LineTimestamp(item.Data.([]byte))
+}
@@ -1,4 +1,4 @@
-package main
+package influxdb3
 
 import (
 	"encoding/json"
@@ -11,11 +11,12 @@ import (
 )
 
 type dbCreator struct {
+	config    *SpecificConfig
 	daemonURL string
 }
 
 func (d *dbCreator) Init() {
-	d.daemonURL = daemonURLs[0] // pick first one since it always exists
+	d.daemonURL = d.config.URLs[0] // pick first one since it always exists
 }
 
 func (d *dbCreator) DBExists(dbName string) bool {
@@ -25,7 +26,7 @@ func (d *dbCreator) DBExists(dbName string) bool {
 	}
 
 	for _, db := range dbs {
-		if db == loader.DatabaseName() {
+		if db == dbName {
 			return true
 		}
 	}
@@ -41,10 +42,8 @@ func (d *dbCreator) listDatabases() ([]string, error) {
 		return nil, fmt.Errorf("listDatabases error: %s", err.Error())
 	}
 
-	if bearer != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
-	} else {
-		req.Header.Set("Authorization", "Token "+token)
+	if auth := d.config.authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
 	}
 
 	// Send req using http Client
@@ -98,10 +97,8 @@ func (d *dbCreator) RemoveOldDB(dbName string) error {
 		return fmt.Errorf("drop db error: %s", err.Error())
 	}
 
-	if bearer != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
-	} else {
-		req.Header.Set("Authorization", "Token "+token)
+	if auth := d.config.authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
 	}
 
 	// Send req using http Client
@@ -128,7 +125,7 @@ func (d *dbCreator) CreateDB(dbName string) error {
 	u.Path = "query"
 	v := u.Query()
 	v.Set("consistency", "all")
-	v.Set("q", fmt.Sprintf("CREATE DATABASE %s WITH REPLICATION %d", dbName, replicationFactor))
+	v.Set("q", fmt.Sprintf("CREATE DATABASE %s WITH REPLICATION %d", dbName, d.config.ReplicationFactor))
 	u.RawQuery = v.Encode()
 
 	req, err := http.NewRequest("GET", u.String(), nil)
@@ -136,10 +133,8 @@ func (d *dbCreator) CreateDB(dbName string) error {
 		return err
 	}
 
-	if bearer != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", bearer))
-	} else {
-		req.Header.Set("Authorization", "Token "+token)
+	if auth := d.config.authorization(); auth != "" {
+		req.Header.Set("Authorization", auth)
 	}
 
 	client := &http.Client{}
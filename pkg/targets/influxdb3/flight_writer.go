@@ -0,0 +1,146 @@
+package influxdb3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+
+	"github.com/apache/arrow/go/v15/arrow/flight"
+	"github.com/apache/arrow/go/v15/arrow/ipc"
+	"github.com/apache/arrow/go/v15/arrow/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// headerDatabase is the gRPC metadata key of the database the records of a
+// DoPut are written to
+const headerDatabase = "database"
+
+// flightWriter writes the points of a batch as Arrow records, with a Flight
+// DoPut of the record of each measurement. The descriptor of a DoPut is the
+// path of the measurement, i.e. the table, and the database and credentials
+// are sent as gRPC metadata. InfluxDB 3.x serves Flight on the port of its
+// HTTP API.
+type flightWriter struct {
+	client flight.Client
+	ctx    context.Context
+	mem    memory.Allocator
+}
+
+// flightAddress returns the gRPC address of the server at the URL of its HTTP
+// API, and whether it uses TLS.
+func flightAddress(u string) (string, bool, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid url '%s': %v", u, err)
+	}
+	var secure bool
+	port := "80"
+	switch parsed.Scheme {
+	case "http":
+	case "https":
+		secure, port = true, "443"
+	default:
+		return "", false, fmt.Errorf("invalid url '%s': scheme must be http or https", u)
+	}
+	if parsed.Port() != "" {
+		port = parsed.Port()
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), secure, nil
+}
+
+func newFlightWriter(u, database, authorization string) (*flightWriter, error) {
+	addr, secure, err := flightAddress(u)
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	client, err := flight.NewClientWithMiddleware(addr, nil, nil, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %v", addr, err)
+	}
+	md := metadata.Pairs(headerDatabase, database)
+	if authorization != "" {
+		md.Append("authorization", authorization)
+	}
+	return &flightWriter{
+		client: client,
+		ctx:    metadata.NewOutgoingContext(context.Background(), md),
+		mem:    memory.NewGoAllocator(),
+	}, nil
+}
+
+func (w *flightWriter) WriteBatch(lines []byte) error {
+	records, err := linesToRecords(w.mem, lines)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, rec := range records {
+			rec.Release()
+		}
+	}()
+	for _, rec := range records {
+		if err := w.put(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put writes a record with a DoPut and waits for the server to acknowledge it
+func (w *flightWriter) put(rec tableRecord) error {
+	stream, err := w.client.DoPut(w.ctx)
+	if err != nil {
+		return flightError(err)
+	}
+	table := rec.table
+	writer := flight.NewRecordWriter(stream, ipc.WithSchema(rec.Schema()))
+	writer.SetFlightDescriptor(&flight.FlightDescriptor{Type: flight.DescriptorPATH, Path: []string{table}})
+	err = writer.Write(rec.Record)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	// the status of a failed DoPut is returned by Recv, sending only fails
+	// with io.EOF
+	for {
+		_, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			break
+		}
+		if recvErr != nil {
+			return flightError(recvErr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not write '%s': %v", table, err)
+	}
+	return nil
+}
+
+func (w *flightWriter) Close() error {
+	return w.client.Close()
+}
+
+// flightError returns a *backoffError for the statuses of an overloaded
+// server, which has no Retry-After
+func flightError(err error) error {
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.Unavailable:
+		return &backoffError{}
+	}
+	return err
+}
@@ -0,0 +1,169 @@
+package influxdb3
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apache/arrow/go/v15/arrow/flight"
+	"github.com/timescale/tsbs/pkg/data"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testFlightServer records the DoPuts of a Flight service. It answers the
+// first DoPuts with the codes in failures.
+type testFlightServer struct {
+	flight.BaseFlightServer
+
+	mu       sync.Mutex
+	failures []codes.Code
+	tables   []string
+	rows     []int64
+	database []string
+	auth     []string
+}
+
+func (s *testFlightServer) DoPut(stream flight.FlightService_DoPutServer) error {
+	r, err := flight.NewRecordReader(stream)
+	if err != nil {
+		return err
+	}
+	defer r.Release()
+	// the descriptor is in the first message, with the schema
+	table := strings.Join(r.LatestFlightDescriptor().GetPath(), ".")
+	var rows int64
+	for r.Next() {
+		rows += r.Record().NumRows()
+	}
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		return status.Error(code, "failed")
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	s.tables = append(s.tables, table)
+	s.rows = append(s.rows, rows)
+	s.database = append(s.database, strings.Join(md.Get(headerDatabase), ","))
+	s.auth = append(s.auth, strings.Join(md.Get("authorization"), ","))
+	return nil
+}
+
+func startTestFlightServer(t *testing.T, failures ...codes.Code) (*testFlightServer, string) {
+	s := &testFlightServer{failures: failures}
+	server := flight.NewServerWithMiddleware(nil)
+	if err := server.Init("localhost:0"); err != nil {
+		t.Fatalf("could not start flight server: %v", err)
+	}
+	server.RegisterFlightService(s)
+	go server.Serve()
+	t.Cleanup(server.Shutdown)
+	return s, "http://" + server.Addr().String()
+}
+
+func TestFlightAddress(t *testing.T) {
+	cases := []struct {
+		url        string
+		wantAddr   string
+		wantSecure bool
+		wantErr    bool
+	}{
+		{url: "http://localhost:8181", wantAddr: "localhost:8181"},
+		{url: "http://localhost", wantAddr: "localhost:80"},
+		{url: "https://example.com/", wantAddr: "example.com:443", wantSecure: true},
+		{url: "grpc://localhost:8181", wantErr: true},
+	}
+	for _, c := range cases {
+		addr, secure, err := flightAddress(c.url)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", c.url)
+			}
+			continue
+		}
+		if err != nil || addr != c.wantAddr || secure != c.wantSecure {
+			t.Errorf("%s: got %s %v %v, want %s %v", c.url, addr, secure, err, c.wantAddr, c.wantSecure)
+		}
+	}
+}
+
+func TestFlightWriterWriteBatch(t *testing.T) {
+	lines := "cpu,hostname=host_0 usage_user=1 1000\n" +
+		"mem,hostname=host_0 free=42i 1000\n" +
+		"cpu,hostname=host_1 usage_user=3 2000\n"
+
+	s, u := startTestFlightServer(t)
+	w, err := newFlightWriter(u, "benchmark", "Token secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer w.Close()
+	if err := w.WriteBatch([]byte(lines)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"cpu", "mem"}; strings.Join(s.tables, ",") != strings.Join(want, ",") {
+		t.Errorf("incorrect tables: got %v want %v", s.tables, want)
+	}
+	if len(s.rows) != 2 || s.rows[0] != 2 || s.rows[1] != 1 {
+		t.Errorf("incorrect rows: %v", s.rows)
+	}
+	if s.database[0] != "benchmark" || s.auth[0] != "Token secret" {
+		t.Errorf("incorrect metadata: database %v authorization %v", s.database, s.auth)
+	}
+
+	cases := []struct {
+		code        codes.Code
+		wantBackoff bool
+	}{
+		{code: codes.ResourceExhausted, wantBackoff: true},
+		{code: codes.Unavailable, wantBackoff: true},
+		{code: codes.InvalidArgument},
+	}
+	for _, c := range cases {
+		s, u := startTestFlightServer(t, c.code)
+		w, _ := newFlightWriter(u, "benchmark", "")
+		err := w.WriteBatch([]byte(lines))
+		w.Close()
+		if err == nil {
+			t.Errorf("%s: expected error", c.code)
+			continue
+		}
+		if _, isBackoff := err.(*backoffError); isBackoff != c.wantBackoff {
+			t.Errorf("%s: backoff %v, want %v: %v", c.code, isBackoff, c.wantBackoff, err)
+		}
+		if len(s.tables) != 0 {
+			t.Errorf("%s: the batch was written after the error: %v", c.code, s.tables)
+		}
+	}
+}
+
+func TestProcessorProcessBatchArrowFlight(t *testing.T) {
+	oldPrintFn := printFn
+	defer func() { printFn = oldPrintFn }()
+	printFn = func(string, ...interface{}) (int, error) { return 0, nil }
+
+	s, u := startTestFlightServer(t, codes.Unavailable)
+	b := (&benchmark{}).GetBatchFactory().New()
+	b.Append(data.LoadedPoint{Data: []byte("cpu,hostname=host_0 usage_user=1,usage_system=2 1000")})
+	p := &processor{database: "benchmark", config: &SpecificConfig{URLs: []string{u}, Ingest: ingestArrowFlight}}
+	p.Init(0, true, false)
+	metrics, rows := p.ProcessBatch(b, true)
+	p.Close(true)
+
+	if metrics != 2 || rows != 1 {
+		t.Errorf("incorrect counts: got %d metrics %d rows", metrics, rows)
+	}
+	if p.Errors() != 1 {
+		t.Errorf("incorrect errors: %d", p.Errors())
+	}
+	if len(s.tables) != 1 || s.tables[0] != "cpu" {
+		t.Errorf("incorrect tables: %v", s.tables)
+	}
+}
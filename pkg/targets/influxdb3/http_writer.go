@@ -0,0 +1,146 @@
+package influxdb3
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	httpClientName        = "tsbs_load_influxdb3"
+	headerContentEncoding = "Content-Encoding"
+	headerGzip            = "gzip"
+	headerRetryAfter      = "Retry-After"
+
+	// writeEndpointV2 is the v2-compatible /api/v2/write endpoint
+	writeEndpointV2 = "v2"
+	// writeEndpointV3 is the native /api/v3/write_lp endpoint
+	writeEndpointV3 = "v3"
+)
+
+// backoffError is returned when the server asks for backpressure with a 429
+// or 503 status. retryAfter is the delay of its Retry-After header, 0 if it
+// has none.
+type backoffError struct {
+	retryAfter time.Duration
+}
+
+func (e *backoffError) Error() string {
+	return "backpressure is needed"
+}
+
+// HTTPWriterConfig is the configuration used to create an HTTPWriter.
+type HTTPWriterConfig struct {
+	// URL of the host, in form "http://example.com:8181"
+	Host string
+
+	// Name of the target database into which points will be written.
+	Database string
+
+	// Org is the organization of the v2-compatible endpoint.
+	Org string
+
+	// Authorization is the value of the Authorization header, e.g.
+	// "Token <token>", if not empty.
+	Authorization string
+
+	// WriteEndpoint is the write endpoint, writeEndpointV2 or writeEndpointV3.
+	WriteEndpoint string
+
+	// NoSync acknowledges writes before they are persisted. Only the native
+	// endpoint supports it.
+	NoSync bool
+
+	// Debug label for more informative errors.
+	DebugInfo string
+}
+
+// HTTPWriter is a Writer that writes to an InfluxDB 3 HTTP server.
+type HTTPWriter struct {
+	client fasthttp.Client
+
+	c   HTTPWriterConfig
+	url []byte
+}
+
+// NewHTTPWriter returns a new HTTPWriter from the supplied HTTPWriterConfig.
+func NewHTTPWriter(c HTTPWriterConfig) (*HTTPWriter, error) {
+	host := strings.TrimSuffix(c.Host, "/")
+	params := url.Values{}
+	var u string
+	switch c.WriteEndpoint {
+	case writeEndpointV2:
+		params.Set("bucket", c.Database)
+		params.Set("org", c.Org)
+		params.Set("precision", "ns")
+		u = host + "/api/v2/write?" + params.Encode()
+	case writeEndpointV3:
+		params.Set("db", c.Database)
+		params.Set("precision", "nanosecond")
+		if c.NoSync {
+			params.Set("no_sync", "true")
+		}
+		u = host + "/api/v3/write_lp?" + params.Encode()
+	default:
+		return nil, fmt.Errorf("unknown write endpoint '%s', valid: %s, %s", c.WriteEndpoint, writeEndpointV2, writeEndpointV3)
+	}
+	return &HTTPWriter{
+		client: fasthttp.Client{
+			Name: httpClientName,
+		},
+
+		c:   c,
+		url: []byte(u),
+	}, nil
+}
+
+var (
+	methodPost = []byte("POST")
+	textPlain  = []byte("text/plain; charset=utf-8")
+)
+
+func (w *HTTPWriter) initializeReq(req *fasthttp.Request, body []byte, isGzip bool) {
+	req.Header.SetContentTypeBytes(textPlain)
+	req.Header.SetMethodBytes(methodPost)
+	req.Header.SetRequestURIBytes(w.url)
+	if w.c.Authorization != "" {
+		req.Header.Add("Authorization", w.c.Authorization)
+	}
+
+	if isGzip {
+		req.Header.Add(headerContentEncoding, headerGzip)
+	}
+	req.SetBody(body)
+}
+
+// WriteLineProtocol writes the given byte slice to the HTTP server described in the Writer's HTTPWriterConfig.
+// It returns the latency in nanoseconds and any error received while sending the data over HTTP,
+// or it returns a new error if the HTTP response isn't as expected. The error is a *backoffError
+// if the server asked for backpressure.
+func (w *HTTPWriter) WriteLineProtocol(body []byte, isGzip bool) (int64, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	w.initializeReq(req, body, isGzip)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	start := time.Now()
+	err := w.client.Do(req, resp)
+	lat := time.Since(start).Nanoseconds()
+	if err != nil {
+		return lat, err
+	}
+	sc := resp.StatusCode()
+	switch {
+	case sc/100 == 2:
+		return lat, nil
+	case sc == fasthttp.StatusTooManyRequests, sc == fasthttp.StatusServiceUnavailable:
+		return lat, &backoffError{retryAfter: utils.RetryAfter(string(resp.Header.Peek(headerRetryAfter)))}
+	}
+	return lat, fmt.Errorf("[DebugInfo: %s] Invalid write response (status %d): %s", w.c.DebugInfo, sc, resp.Body())
+}
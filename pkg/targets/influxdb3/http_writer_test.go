@@ -0,0 +1,145 @@
+package influxdb3
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer records the requests of an InfluxDB 3 write endpoint. It
+// answers the first requests with the statuses in failures.
+type testServer struct {
+	mu       sync.Mutex
+	failures []int
+	// retryAfter is the Retry-After header of the failed requests
+	retryAfter string
+	paths      []string
+	queries    []string
+	bodies     []string
+	auth       []string
+}
+
+func (s *testServer) handler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		if s.retryAfter != "" {
+			w.Header().Set(headerRetryAfter, s.retryAfter)
+		}
+		http.Error(w, "failed", status)
+		return
+	}
+	var body []byte
+	if r.Header.Get(headerContentEncoding) == headerGzip {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ = ioutil.ReadAll(gr)
+	} else {
+		body, _ = ioutil.ReadAll(r.Body)
+	}
+	s.paths = append(s.paths, r.URL.Path)
+	s.queries = append(s.queries, r.URL.RawQuery)
+	s.bodies = append(s.bodies, string(body))
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func startTestServer(failures ...int) (*testServer, *httptest.Server) {
+	s := &testServer{failures: failures}
+	return s, httptest.NewServer(http.HandlerFunc(s.handler))
+}
+
+func TestNewHTTPWriter(t *testing.T) {
+	cases := []struct {
+		desc    string
+		conf    HTTPWriterConfig
+		wantURL string
+	}{
+		{
+			desc:    "v2",
+			conf:    HTTPWriterConfig{Host: "http://localhost:8181/", Database: "benchmark", Org: "tsbs", WriteEndpoint: writeEndpointV2},
+			wantURL: "http://localhost:8181/api/v2/write?bucket=benchmark&org=tsbs&precision=ns",
+		},
+		{
+			desc:    "v3",
+			conf:    HTTPWriterConfig{Host: "http://localhost:8181", Database: "benchmark", WriteEndpoint: writeEndpointV3},
+			wantURL: "http://localhost:8181/api/v3/write_lp?db=benchmark&precision=nanosecond",
+		},
+		{
+			desc:    "v3 no sync",
+			conf:    HTTPWriterConfig{Host: "http://localhost:8181", Database: "benchmark", WriteEndpoint: writeEndpointV3, NoSync: true},
+			wantURL: "http://localhost:8181/api/v3/write_lp?db=benchmark&no_sync=true&precision=nanosecond",
+		},
+	}
+	for _, c := range cases {
+		w, err := NewHTTPWriter(c.conf)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		if got := string(w.url); got != c.wantURL {
+			t.Errorf("%s: incorrect url: got %s want %s", c.desc, got, c.wantURL)
+		}
+	}
+
+	if _, err := NewHTTPWriter(HTTPWriterConfig{WriteEndpoint: "v1"}); err == nil {
+		t.Errorf("expected error for unknown write endpoint")
+	}
+}
+
+func TestHTTPWriterWriteLineProtocol(t *testing.T) {
+	line := "cpu,hostname=host_0 usage_user=1 1451606400000000000\n"
+	cases := []struct {
+		desc           string
+		failures       []int
+		retryAfter     string
+		wantRetryAfter time.Duration
+		wantBackoff    bool
+		wantErr        bool
+	}{
+		{desc: "success"},
+		{desc: "too many requests", failures: []int{http.StatusTooManyRequests}, retryAfter: "2", wantRetryAfter: 2 * time.Second, wantBackoff: true},
+		{desc: "unavailable", failures: []int{http.StatusServiceUnavailable}, wantBackoff: true},
+		{desc: "bad request", failures: []int{http.StatusBadRequest}, wantErr: true},
+		{desc: "server error", failures: []int{http.StatusInternalServerError}, wantErr: true},
+	}
+	for _, c := range cases {
+		s, server := startTestServer(c.failures...)
+		s.retryAfter = c.retryAfter
+		w, _ := NewHTTPWriter(HTTPWriterConfig{Host: server.URL, Database: "benchmark", Authorization: "Token secret", WriteEndpoint: writeEndpointV3})
+		_, err := w.WriteLineProtocol([]byte(line), false)
+		server.Close()
+
+		be, isBackoff := err.(*backoffError)
+		switch {
+		case c.wantBackoff:
+			if !isBackoff {
+				t.Errorf("%s: expected backoff error, got %v", c.desc, err)
+			} else if be.retryAfter != c.wantRetryAfter {
+				t.Errorf("%s: incorrect retry after: got %v want %v", c.desc, be.retryAfter, c.wantRetryAfter)
+			}
+		case c.wantErr:
+			if err == nil || isBackoff {
+				t.Errorf("%s: expected error, got %v", c.desc, err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", c.desc, err)
+			}
+			if len(s.bodies) != 1 || s.bodies[0] != line {
+				t.Errorf("%s: incorrect bodies: %v", c.desc, s.bodies)
+			}
+			if s.auth[0] != "Token secret" {
+				t.Errorf("%s: incorrect authorization: %s", c.desc, s.auth[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package influxdb3
+
+import (
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/influx"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &influxDB3Target{}
+}
+
+// influxDB3Target loads the data files of the influx target, which are in
+// the InfluxDB wire protocol, into InfluxDB 3.x
+type influxDB3Target struct {
+}
+
+func (t *influxDB3Target) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"urls", "http://localhost:8181", "InfluxDB 3.x URLs, comma-separated. Will be used in a round-robin fashion.")
+	flagSet.Int(flagPrefix+"replication-factor", 1, "Cluster replication factor (only applies to clustered databases).")
+	flagSet.Duration(flagPrefix+"backoff", time.Second, "Time to sleep between requests when server indicates backpressure is needed.")
+	flagSet.Bool(flagPrefix+"gzip", true, "Whether to gzip encode line protocol requests (default true).")
+	flagSet.String(flagPrefix+"token", "", "Token to access InfluxDB")
+	flagSet.String(flagPrefix+"org", "", "InfluxDB org name (only for the v2 write endpoint)")
+	flagSet.String(flagPrefix+"bearer", "", "Bearer token to access InfluxDB")
+	flagSet.Bool(flagPrefix+"no-sync", false, "Whether to acknowledge writes before they are persisted (only for the v3 write endpoint)")
+	flagSet.String(flagPrefix+"write-endpoint", writeEndpointV2, "Line protocol write endpoint: 'v2' for the v2-compatible /api/v2/write or 'v3' for the native /api/v3/write_lp")
+	flagSet.String(flagPrefix+"ingest", ingestLineProtocol, "How to write the points, valid: "+ingestLineProtocol+", "+ingestArrowFlight+" (Arrow record batches with a Flight DoPut)")
+}
+
+func (t *influxDB3Target) TargetName() string {
+	return constants.FormatInfluxDB3
+}
+
+func (t *influxDB3Target) Serializer() serialize.PointSerializer {
+	return &influx.Serializer{}
+}
+
+func (t *influxDB3Target) Benchmark(targetDB string, dataSourceConfig *source.DataSourceConfig, v *viper.Viper) (targets.Benchmark, error) {
+	influxDB3SpecificConfig, err := parseSpecificConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewBenchmark(targetDB, influxDB3SpecificConfig, dataSourceConfig)
+}
+
+// FileDataSource implements targets.FileInspector and targets.PointParser,
+// the data files are the ones of the influx target
+func (t *influxDB3Target) FileDataSource(fileName string) targets.DataSource {
+	return influx.NewFileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (t *influxDB3Target) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	return influx.DescribeLine(item.Data.(string))
+}
+
+// ParsePoint implements targets.PointParser
+func (t *influxDB3Target) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return influx.ParseLine(item.Data.(string), schema, p)
+}
@@ -0,0 +1,162 @@
+package influxdb3
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+
+	"github.com/valyala/fasthttp"
+)
+
+const backingOffChanCap = 100
+
+// allows for testing
+var printFn = fmt.Printf
+
+// batchWriter writes the lines of a batch, in the InfluxDB wire protocol, to
+// the server. The error is a *backoffError if the server asked for
+// backpressure.
+type batchWriter interface {
+	WriteBatch(lines []byte) error
+	Close() error
+}
+
+// lineProtocolWriter writes the lines to an HTTP write endpoint
+type lineProtocolWriter struct {
+	httpWriter *HTTPWriter
+	gzip       bool
+	gzipBuf    bytes.Buffer
+}
+
+func (w *lineProtocolWriter) WriteBatch(lines []byte) error {
+	if !w.gzip {
+		_, err := w.httpWriter.WriteLineProtocol(lines, false)
+		return err
+	}
+	w.gzipBuf.Reset()
+	fasthttp.WriteGzip(&w.gzipBuf, lines)
+	_, err := w.httpWriter.WriteLineProtocol(w.gzipBuf.Bytes(), true)
+	return err
+}
+
+func (w *lineProtocolWriter) Close() error {
+	return nil
+}
+
+// newBatchWriter returns the batchWriter of the ingest of the config,
+// writing to the database of the server at url
+func newBatchWriter(config *SpecificConfig, database, url, debugInfo string) (batchWriter, error) {
+	if config.Ingest == ingestArrowFlight {
+		return newFlightWriter(url, database, config.authorization())
+	}
+	w, err := NewHTTPWriter(HTTPWriterConfig{
+		DebugInfo:     debugInfo,
+		Host:          url,
+		Database:      database,
+		Org:           config.Org,
+		Authorization: config.authorization(),
+		WriteEndpoint: config.WriteEndpoint,
+		NoSync:        config.NoSync,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &lineProtocolWriter{httpWriter: w, gzip: config.Gzip}, nil
+}
+
+type processor struct {
+	database       string
+	config         *SpecificConfig
+	backingOffChan chan bool
+	backingOffDone chan struct{}
+	writer         batchWriter
+	errors         uint64
+}
+
+func (p *processor) Init(numWorker int, _, _ bool) {
+	daemonURL := p.config.URLs[numWorker%len(p.config.URLs)]
+	debugInfo := fmt.Sprintf("worker #%d, dest url: %s", numWorker, daemonURL)
+	w, err := newBatchWriter(p.config, p.database, daemonURL, debugInfo)
+	if err != nil {
+		fatal("could not create writer: %v", err)
+		return
+	}
+	p.initWithWriter(numWorker, w)
+}
+
+func (p *processor) initWithWriter(numWorker int, w batchWriter) {
+	p.backingOffChan = make(chan bool, backingOffChanCap)
+	p.backingOffDone = make(chan struct{})
+	p.writer = w
+	go p.processBackoffMessages(numWorker)
+}
+
+func (p *processor) Close(_ bool) {
+	close(p.backingOffChan)
+	<-p.backingOffDone
+	if err := p.writer.Close(); err != nil {
+		printFn("could not close writer: %v\n", err)
+	}
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+	batch := b.(*batch)
+
+	// Write the batch: try until backoff is not needed.
+	if doLoad {
+		var err error
+		for {
+			err = p.writer.WriteBatch(batch.buf.Bytes())
+			if be, ok := err.(*backoffError); ok {
+				p.errors++
+				p.backingOffChan <- true
+				// Honour the Retry-After of the server if it is longer than --backoff
+				sleep := p.config.Backoff
+				if be.retryAfter > sleep {
+					sleep = be.retryAfter
+				}
+				time.Sleep(sleep)
+			} else {
+				p.backingOffChan <- false
+				break
+			}
+		}
+		if err != nil {
+			fatal("Error writing: %s\n", err.Error())
+		}
+	}
+	metricCnt := batch.metrics
+	rowCnt := batch.rows
+
+	// Return the batch buffer to the pool.
+	batch.buf.Reset()
+	batch.bufPool.Put(batch.buf)
+	return metricCnt, uint64(rowCnt)
+}
+
+// Errors returns the number of writes that had to be retried because of backoff
+func (p *processor) Errors() uint64 {
+	return p.errors
+}
+
+func (p *processor) processBackoffMessages(workerID int) {
+	var totalBackoffSecs float64
+	var start time.Time
+	last := false
+	for this := range p.backingOffChan {
+		if this && !last {
+			start = time.Now()
+			last = true
+		} else if !this && last {
+			took := time.Now().Sub(start)
+			printFn("[worker %d] backoff took %.02fsec\n", workerID, took.Seconds())
+			totalBackoffSecs += took.Seconds()
+			last = false
+			start = time.Now()
+		}
+	}
+	printFn("[worker %d] backoffs took a total of %fsec of runtime\n", workerID, totalBackoffSecs)
+	p.backingOffDone <- struct{}{}
+}
@@ -0,0 +1,112 @@
+package influxdb3
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+func TestProcessorProcessBatch(t *testing.T) {
+	oldFatal, oldPrintFn := fatal, printFn
+	defer func() { fatal, printFn = oldFatal, oldPrintFn }()
+
+	line := "cpu,hostname=host_0 usage_user=1,usage_system=2 1451606400000000000"
+	cases := []struct {
+		desc         string
+		endpoint     string
+		doLoad       bool
+		useGzip      bool
+		failures     []int
+		wantErrors   uint64
+		wantBackoffs bool
+		wantFatal    bool
+	}{
+		{desc: "no load", endpoint: writeEndpointV3},
+		{desc: "v2", endpoint: writeEndpointV2, doLoad: true},
+		{desc: "v3", endpoint: writeEndpointV3, doLoad: true},
+		{desc: "v3 gzip", endpoint: writeEndpointV3, doLoad: true, useGzip: true},
+		{
+			desc: "backoff", endpoint: writeEndpointV3, doLoad: true,
+			failures:   []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+			wantErrors: 2, wantBackoffs: true,
+		},
+		{desc: "fatal", endpoint: writeEndpointV3, doLoad: true, failures: []int{http.StatusBadRequest}, wantFatal: true},
+	}
+	for _, c := range cases {
+		s, server := startTestServer(c.failures...)
+		var out bytes.Buffer
+		var m sync.Mutex
+		printFn = func(format string, args ...interface{}) (int, error) {
+			m.Lock()
+			defer m.Unlock()
+			return fmt.Fprintf(&out, format, args...)
+		}
+		fatalCalled := false
+		fatal = func(format string, args ...interface{}) {
+			fatalCalled = true
+		}
+		config := &SpecificConfig{
+			URLs:          []string{server.URL},
+			Backoff:       time.Millisecond,
+			Gzip:          c.useGzip,
+			Org:           "tsbs",
+			WriteEndpoint: c.endpoint,
+			Ingest:        ingestLineProtocol,
+		}
+
+		b := (&benchmark{}).GetBatchFactory().New().(*batch)
+		b.Append(data.LoadedPoint{Data: []byte(line)})
+		p := &processor{database: "benchmark", config: config}
+		p.Init(0, c.doLoad, false)
+		metrics, rows := p.ProcessBatch(b, c.doLoad)
+		p.Close(c.doLoad)
+		server.Close()
+
+		if fatalCalled != c.wantFatal {
+			t.Errorf("%s: fatal called %v, want %v", c.desc, fatalCalled, c.wantFatal)
+		}
+		if c.wantFatal {
+			continue
+		}
+		if metrics != 2 || rows != 1 {
+			t.Errorf("%s: incorrect counts: got %d metrics %d rows", c.desc, metrics, rows)
+		}
+		if got := p.Errors(); got != c.wantErrors {
+			t.Errorf("%s: incorrect errors: got %d want %d", c.desc, got, c.wantErrors)
+		}
+		wantRequests := 0
+		if c.doLoad {
+			wantRequests = 1
+		}
+		if len(s.bodies) != wantRequests {
+			t.Fatalf("%s: incorrect number of requests: got %d want %d", c.desc, len(s.bodies), wantRequests)
+		}
+		if wantRequests > 0 {
+			if s.bodies[0] != line+"\n" {
+				t.Errorf("%s: incorrect body: %s", c.desc, s.bodies[0])
+			}
+			wantPath := "/api/v3/write_lp"
+			if c.endpoint == writeEndpointV2 {
+				wantPath = "/api/v2/write"
+			}
+			if s.paths[0] != wantPath {
+				t.Errorf("%s: incorrect path: got %s want %s", c.desc, s.paths[0], wantPath)
+			}
+		}
+		m.Lock()
+		report := out.String()
+		m.Unlock()
+		if got := strings.Contains(report, "backoff took"); got != c.wantBackoffs {
+			t.Errorf("%s: backoff reported %v, want %v: %s", c.desc, got, c.wantBackoffs, report)
+		}
+		if !strings.Contains(report, "[worker 0] backoffs took a total of") {
+			t.Errorf("%s: total backoff not reported: %s", c.desc, report)
+		}
+	}
+}
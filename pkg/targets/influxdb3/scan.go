@@ -1,9 +1,11 @@
-package main
+package influxdb3
 
 import (
 	"bufio"
 	"bytes"
+	"log"
 	"strings"
+	"sync"
 
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
@@ -14,6 +16,9 @@ const errNotThreeTuplesFmt = "parse error: line does not have 3 tuples, has %d"
 
 var newLine = []byte("\n")
 
+// allows for testing
+var fatal = log.Fatalf
+
 type fileDataSource struct {
 	scanner *bufio.Scanner
 }
@@ -33,6 +38,7 @@ func (d *fileDataSource) Headers() *common.GeneratedDataHeaders { return nil }
 
 type batch struct {
 	buf     *bytes.Buffer
+	bufPool *sync.Pool
 	rows    uint
 	metrics uint64
 }
@@ -58,8 +64,10 @@ func (b *batch) Append(item data.LoadedPoint) {
 	b.buf.Write(newLine)
 }
 
-type factory struct{}
+type factory struct {
+	bufPool *sync.Pool
+}
 
 func (f *factory) New() targets.Batch {
-	return &batch{buf: bufPool.Get().(*bytes.Buffer)}
+	return &batch{buf: f.bufPool.Get().(*bytes.Buffer), bufPool: f.bufPool}
 }
@@ -1,22 +1,16 @@
-package main
+package influxdb3
 
 import (
 	"bufio"
 	"bytes"
 	"fmt"
-	"sync"
 	"testing"
 
 	"github.com/timescale/tsbs/pkg/data"
 )
 
 func TestBatch(t *testing.T) {
-	bufPool = sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
-		},
-	}
-	f := &factory{}
+	f := (&benchmark{}).GetBatchFactory()
 	b := f.New().(*batch)
 	if b.Len() != 0 {
 		t.Errorf("batch not initialized with count 0")
@@ -52,6 +46,8 @@ func TestBatch(t *testing.T) {
 	p = data.LoadedPoint{
 		Data: []byte("bad_point"),
 	}
+	oldFatal := fatal
+	defer func() { fatal = oldFatal }()
 	errMsg := ""
 	fatal = func(f string, args ...interface{}) {
 		errMsg = fmt.Sprintf(f, args...)
@@ -8,10 +8,13 @@ import (
 	"github.com/timescale/tsbs/pkg/targets/akumuli"
 	"github.com/timescale/tsbs/pkg/targets/cassandra"
 	"github.com/timescale/tsbs/pkg/targets/clickhouse"
+	"github.com/timescale/tsbs/pkg/targets/columnar"
 	"github.com/timescale/tsbs/pkg/targets/constants"
 	"github.com/timescale/tsbs/pkg/targets/crate"
 	"github.com/timescale/tsbs/pkg/targets/influx"
+	"github.com/timescale/tsbs/pkg/targets/influxdb3"
 	"github.com/timescale/tsbs/pkg/targets/mongo"
+	"github.com/timescale/tsbs/pkg/targets/otlp"
 	"github.com/timescale/tsbs/pkg/targets/prometheus"
 	"github.com/timescale/tsbs/pkg/targets/questdb"
 	"github.com/timescale/tsbs/pkg/targets/siridb"
@@ -32,8 +35,10 @@ func GetTarget(format string) targets.ImplementedTarget {
 		return clickhouse.NewTarget()
 	case constants.FormatCrateDB:
 		return crate.NewTarget()
-	case constants.FormatInflux, constants.FormatInfluxDB3:
+	case constants.FormatInflux:
 		return influx.NewTarget()
+	case constants.FormatInfluxDB3:
+		return influxdb3.NewTarget()
 	case constants.FormatMongo:
 		return mongo.NewTarget()
 	case constants.FormatPrometheus:
@@ -46,6 +51,12 @@ func GetTarget(format string) targets.ImplementedTarget {
 		return timestream.NewTarget()
 	case constants.FormatQuestDB:
 		return questdb.NewTarget()
+	case constants.FormatParquet:
+		return columnar.NewParquetTarget()
+	case constants.FormatArrow:
+		return columnar.NewArrowTarget()
+	case constants.FormatOTLP:
+		return otlp.NewTarget()
 	}
 
 	supportedFormatsStr := strings.Join(constants.SupportedFormats(), ",")
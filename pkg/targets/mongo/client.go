@@ -0,0 +1,12 @@
+package mongo
+
+import "strings"
+
+// ClientURI returns a daemon URL as a connection string of the official
+// driver, which needs it to start with the scheme
+func ClientURI(url string) string {
+	if strings.HasPrefix(url, "mongodb://") || strings.HasPrefix(url, "mongodb+srv://") {
+		return url
+	}
+	return "mongodb://" + url
+}
@@ -0,0 +1,16 @@
+package mongo
+
+import "testing"
+
+func TestClientURI(t *testing.T) {
+	cases := map[string]string{
+		"localhost:27017":               "mongodb://localhost:27017",
+		"mongodb://localhost:27017":     "mongodb://localhost:27017",
+		"mongodb+srv://cluster.example": "mongodb+srv://cluster.example",
+	}
+	for url, want := range cases {
+		if got := ClientURI(url); got != want {
+			t.Errorf("incorrect uri for %s: got %s want %s", url, got, want)
+		}
+	}
+}
@@ -21,6 +21,8 @@ func (t *mongoTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.Flag
 	flagSet.String(flagPrefix+"url", "localhost:27017", "Mongo URL.")
 	flagSet.Duration(flagPrefix+"write-timeout", 10*time.Second, "Write timeout.")
 	flagSet.Bool(flagPrefix+"document-per-event", false, "Whether to use one document per event or aggregate by hour")
+	flagSet.Bool(flagPrefix+"time-series", false, "Whether to store the events in a native time-series collection (MongoDB 5.0+) with the official driver")
+	flagSet.String(flagPrefix+"time-series-granularity", "seconds", "Granularity of the time-series collection: seconds, minutes or hours")
 }
 
 func (t *mongoTarget) TargetName() string {
@@ -0,0 +1,20 @@
+package targets
+
+// ToNarrowRows splits each wide row into one row per non-null field value, as
+// stored by the narrow schema layout (see constants.SchemaLayoutNarrow). The
+// first seriesCols values of a wide row identify the series and are repeated
+// in each narrow row, followed by the field name and value.
+func ToNarrowRows(dataRows [][]interface{}, seriesCols int, fields []string) [][]interface{} {
+	narrowRows := make([][]interface{}, 0, len(dataRows)*len(fields))
+	for _, r := range dataRows {
+		for i, v := range r[seriesCols:] {
+			if v == nil {
+				continue
+			}
+			narrow := make([]interface{}, seriesCols, seriesCols+2)
+			copy(narrow, r[:seriesCols])
+			narrowRows = append(narrowRows, append(narrow, fields[i], v))
+		}
+	}
+	return narrowRows
+}
@@ -0,0 +1,24 @@
+package targets
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToNarrowRows(t *testing.T) {
+	ts := time.Unix(0, 0)
+	dataRows := [][]interface{}{
+		{ts, int64(1), nil, 1.0, 2.0},
+		{ts, int64(2), nil, nil, 4.0},
+	}
+	want := [][]interface{}{
+		{ts, int64(1), nil, "usage_user", 1.0},
+		{ts, int64(1), nil, "usage_system", 2.0},
+		{ts, int64(2), nil, "usage_system", 4.0},
+	}
+	got := ToNarrowRows(dataRows, 3, []string{"usage_user", "usage_system"})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect narrow rows: got %v want %v", got, want)
+	}
+}
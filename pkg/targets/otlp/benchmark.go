@@ -0,0 +1,131 @@
+package otlp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+type SpecificConfig struct {
+	Protocol     string        `yaml:"protocol" mapstructure:"protocol"`
+	HTTPURL      string        `yaml:"http-url" mapstructure:"http-url"`
+	GRPCEndpoint string        `yaml:"grpc-endpoint" mapstructure:"grpc-endpoint"`
+	Gzip         bool          `yaml:"gzip" mapstructure:"gzip"`
+	Timeout      time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	Backoff      time.Duration `yaml:"backoff" mapstructure:"backoff"`
+}
+
+func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
+	var conf SpecificConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// loader.Benchmark interface implementation
+type benchmark struct {
+	config     *SpecificConfig
+	dataSource targets.DataSource
+}
+
+func NewBenchmark(otlpSpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	if dataSourceConfig.Type != source.FileDataSourceType {
+		return nil, errors.New("only FILE data source type is supported for OTLP")
+	}
+	if p := otlpSpecificConfig.Protocol; p != protocolHTTP && p != protocolGRPC {
+		return nil, fmt.Errorf(errUnknownProtocolFmt, p, protocolHTTP, protocolGRPC)
+	}
+
+	iterator, err := NewIterator(load.GetBufferedReader(dataSourceConfig.File.Location))
+	if err != nil {
+		return nil, err
+	}
+	return &benchmark{
+		config:     otlpSpecificConfig,
+		dataSource: &fileDataSource{iterator: iterator},
+	}, nil
+}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return b.dataSource
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	return &factory{}
+}
+
+func (b *benchmark) GetPointIndexer(maxPartitions uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{config: b.config}
+}
+
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return &dbCreator{}
+}
+
+// fileDataSource reads the ResourceMetrics of a serialized file
+type fileDataSource struct {
+	iterator *Iterator
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	if !d.iterator.HasNext() {
+		return data.LoadedPoint{}
+	}
+	rm, err := d.iterator.Next()
+	if err != nil {
+		log.Fatalf("could not read OTLP data: %v", err)
+	}
+	return data.NewLoadedPoint(rm)
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	return nil
+}
+
+// batch is the ResourceMetrics of the points sent in one
+// ExportMetricsServiceRequest
+type batch struct {
+	resourceMetrics []*metricspb.ResourceMetrics
+	metrics         uint64
+}
+
+func (b *batch) Len() uint {
+	return uint(len(b.resourceMetrics))
+}
+
+func (b *batch) Append(item data.LoadedPoint) {
+	rm := item.Data.(*metricspb.ResourceMetrics)
+	b.resourceMetrics = append(b.resourceMetrics, rm)
+	b.metrics += dataPoints(rm)
+}
+
+type factory struct{}
+
+func (f *factory) New() targets.Batch {
+	return &batch{}
+}
+
+// OTLP receivers don't have a database abstraction
+type dbCreator struct{}
+
+func (d *dbCreator) Init() {}
+
+func (d *dbCreator) DBExists(dbName string) bool { return true }
+
+func (d *dbCreator) CreateDB(dbName string) error { return nil }
+
+func (d *dbCreator) RemoveOldDB(dbName string) error { return nil }
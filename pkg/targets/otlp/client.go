@@ -0,0 +1,189 @@
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	protocolHTTP = "http"
+	protocolGRPC = "grpc"
+
+	contentTypeProtobuf = "application/x-protobuf"
+	// maxErrorBodySize is the number of bytes of an error response included
+	// in the returned error
+	maxErrorBodySize = 512
+
+	errUnknownProtocolFmt = "unknown OTLP protocol '%s', valid: %s, %s"
+)
+
+// errBackoff is returned when the receiver is overloaded and the request
+// should be sent again later
+var errBackoff = errors.New("receiver asked to back off")
+
+// exporter sends ExportMetricsServiceRequests to an OTLP receiver
+type exporter interface {
+	Export(req *colmetricspb.ExportMetricsServiceRequest) error
+	Close() error
+}
+
+func newExporter(conf *SpecificConfig) (exporter, error) {
+	switch conf.Protocol {
+	case protocolHTTP:
+		return newHTTPExporter(conf.HTTPURL, conf.Gzip, conf.Timeout), nil
+	case protocolGRPC:
+		return newGRPCExporter(conf.GRPCEndpoint, conf.Gzip, conf.Timeout)
+	}
+	return nil, fmt.Errorf(errUnknownProtocolFmt, conf.Protocol, protocolHTTP, protocolGRPC)
+}
+
+// rejected returns an error if the receiver rejected some of the data points
+// of a request
+func rejected(resp *colmetricspb.ExportMetricsServiceResponse) error {
+	if ps := resp.GetPartialSuccess(); ps.GetRejectedDataPoints() > 0 {
+		return fmt.Errorf("receiver rejected %d data points: %s", ps.GetRejectedDataPoints(), ps.GetErrorMessage())
+	}
+	return nil
+}
+
+// httpExporter sends requests with OTLP/HTTP in the binary protobuf encoding
+type httpExporter struct {
+	url        string
+	gzip       bool
+	httpClient *http.Client
+}
+
+func newHTTPExporter(url string, useGzip bool, timeout time.Duration) *httpExporter {
+	rt := &http.Transport{
+		MaxIdleConns:        1000,
+		MaxIdleConnsPerHost: 1000,
+		DisableCompression:  true,
+		IdleConnTimeout:     5 * time.Minute,
+	}
+	return &httpExporter{
+		url:        url,
+		gzip:       useGzip,
+		httpClient: &http.Client{Transport: rt, Timeout: timeout},
+	}
+}
+
+func (e *httpExporter) Export(req *colmetricspb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if e.gzip {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		if _, err := gw.Write(body); err != nil {
+			return err
+		}
+		if err := gw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", contentTypeProtobuf)
+	if e.gzip {
+		httpReq.Header.Set("Content-Encoding", "gzip")
+	}
+	httpResp, err := e.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, httpResp.Body)
+		httpResp.Body.Close()
+	}()
+
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return errBackoff
+	default:
+		msg, _ := ioutil.ReadAll(io.LimitReader(httpResp.Body, maxErrorBodySize))
+		return fmt.Errorf("OTLP receiver returned status: %s: %s", httpResp.Status, msg)
+	}
+
+	// the response of a full success may be empty or JSON encoded
+	if !strings.HasPrefix(httpResp.Header.Get("Content-Type"), contentTypeProtobuf) {
+		return nil
+	}
+	respBody, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	resp := &colmetricspb.ExportMetricsServiceResponse{}
+	if err := proto.Unmarshal(respBody, resp); err != nil {
+		return fmt.Errorf("could not decode response: %v", err)
+	}
+	return rejected(resp)
+}
+
+func (e *httpExporter) Close() error {
+	e.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// grpcExporter sends requests with OTLP/gRPC over an unencrypted connection
+type grpcExporter struct {
+	conn     *grpc.ClientConn
+	client   colmetricspb.MetricsServiceClient
+	callOpts []grpc.CallOption
+	timeout  time.Duration
+}
+
+func newGRPCExporter(endpoint string, useGzip bool, timeout time.Duration) (*grpcExporter, error) {
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("could not create gRPC client for %s: %v", endpoint, err)
+	}
+	e := &grpcExporter{
+		conn:    conn,
+		client:  colmetricspb.NewMetricsServiceClient(conn),
+		timeout: timeout,
+	}
+	if useGzip {
+		e.callOpts = append(e.callOpts, grpc.UseCompressor(grpcgzip.Name))
+	}
+	return e, nil
+}
+
+func (e *grpcExporter) Export(req *colmetricspb.ExportMetricsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	resp, err := e.client.Export(ctx, req, e.callOpts...)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.ResourceExhausted, codes.Unavailable, codes.Aborted, codes.DeadlineExceeded:
+			return errBackoff
+		}
+		return err
+	}
+	return rejected(resp)
+}
+
+func (e *grpcExporter) Close() error {
+	return e.conn.Close()
+}
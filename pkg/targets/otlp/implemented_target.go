@@ -0,0 +1,44 @@
+package otlp
+
+import (
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &otlpTarget{}
+}
+
+type otlpTarget struct {
+}
+
+func (t *otlpTarget) Benchmark(_ string, dataSourceConfig *source.DataSourceConfig, v *viper.Viper) (targets.Benchmark, error) {
+	otlpSpecificConfig, err := parseSpecificConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewBenchmark(otlpSpecificConfig, dataSourceConfig)
+}
+
+func (t *otlpTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{}
+}
+
+func (t *otlpTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"protocol", protocolHTTP, "OTLP transport to send the metrics with, valid: "+protocolHTTP+", "+protocolGRPC)
+	flagSet.String(flagPrefix+"http-url", "http://localhost:4318/v1/metrics", "URL of the OTLP/HTTP metrics endpoint")
+	flagSet.String(flagPrefix+"grpc-endpoint", "localhost:4317", "Address (host:port) of the OTLP/gRPC receiver")
+	flagSet.Bool(flagPrefix+"gzip", false, "Whether to gzip compress the requests")
+	flagSet.Duration(flagPrefix+"timeout", 30*time.Second, "Timeout of a request to the receiver")
+	flagSet.Duration(flagPrefix+"backoff", time.Second, "Time to sleep before retrying a request the receiver rejected with a retryable status")
+}
+
+func (t *otlpTarget) TargetName() string {
+	return constants.FormatOTLP
+}
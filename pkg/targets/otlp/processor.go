@@ -0,0 +1,60 @@
+package otlp
+
+import (
+	"log"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+type processor struct {
+	config   *SpecificConfig
+	exporter exporter
+	errors   uint64
+}
+
+func (p *processor) Init(_ int, doLoad, _ bool) {
+	if !doLoad {
+		return
+	}
+	var err error
+	p.exporter, err = newExporter(p.config)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64) {
+	batch := b.(*batch)
+	if doLoad {
+		req := &colmetricspb.ExportMetricsServiceRequest{ResourceMetrics: batch.resourceMetrics}
+		for {
+			err := p.exporter.Export(req)
+			if err == nil {
+				break
+			}
+			if err != errBackoff {
+				log.Fatalf("error while exporting metrics: %v", err)
+			}
+			p.errors++
+			time.Sleep(p.config.Backoff)
+		}
+	}
+	return batch.metrics, uint64(len(batch.resourceMetrics))
+}
+
+// Errors returns the number of requests that had to be retried because the
+// receiver asked to back off
+func (p *processor) Errors() uint64 {
+	return p.errors
+}
+
+func (p *processor) Close(doLoad bool) {
+	if p.exporter == nil {
+		return
+	}
+	if err := p.exporter.Close(); err != nil {
+		log.Printf("could not close exporter: %v", err)
+	}
+}
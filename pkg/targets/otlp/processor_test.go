@@ -0,0 +1,246 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func testResourceMetrics(t *testing.T) *metricspb.ResourceMetrics {
+	rm, err := toResourceMetrics(serialize.TestPointMultiField())
+	if err != nil {
+		t.Fatalf("could not convert point: %v", err)
+	}
+	return rm
+}
+
+func TestProcessorProcessBatch(t *testing.T) {
+	httpReceiver := startFakeHTTPReceiver(t)
+	defer httpReceiver.server.Close()
+	grpcReceiver := startFakeGRPCReceiver(t)
+	defer grpcReceiver.server.Stop()
+
+	testCases := []struct {
+		protocol string
+		gzip     bool
+		doLoad   bool
+		// backoffs is the number of requests the receiver rejects with a
+		// retryable status before accepting the batch
+		backoffs int
+		receiver *fakeReceiver
+	}{
+		{protocol: protocolHTTP, doLoad: true, receiver: &httpReceiver.fakeReceiver},
+		{protocol: protocolHTTP, doLoad: false, receiver: &httpReceiver.fakeReceiver},
+		{protocol: protocolHTTP, gzip: true, doLoad: true, receiver: &httpReceiver.fakeReceiver},
+		{protocol: protocolHTTP, doLoad: true, backoffs: 2, receiver: &httpReceiver.fakeReceiver},
+		{protocol: protocolGRPC, doLoad: true, receiver: &grpcReceiver.fakeReceiver},
+		{protocol: protocolGRPC, doLoad: false, receiver: &grpcReceiver.fakeReceiver},
+		{protocol: protocolGRPC, gzip: true, doLoad: true, receiver: &grpcReceiver.fakeReceiver},
+		{protocol: protocolGRPC, doLoad: true, backoffs: 1, receiver: &grpcReceiver.fakeReceiver},
+	}
+	for _, tc := range testCases {
+		name := fmt.Sprintf("%s gzip %v load %v backoffs %d", tc.protocol, tc.gzip, tc.doLoad, tc.backoffs)
+		t.Run(name, func(t *testing.T) {
+			b := (&factory{}).New().(*batch)
+			for i := 0; i < 3; i++ {
+				b.Append(data.NewLoadedPoint(testResourceMetrics(t)))
+			}
+
+			p := &processor{config: &SpecificConfig{
+				Protocol:     tc.protocol,
+				HTTPURL:      httpReceiver.server.URL + "/v1/metrics",
+				GRPCEndpoint: grpcReceiver.address,
+				Gzip:         tc.gzip,
+				Timeout:      time.Second,
+				Backoff:      time.Millisecond,
+			}}
+			p.Init(0, tc.doLoad, false)
+			defer p.Close(tc.doLoad)
+			tc.receiver.reset(tc.backoffs)
+
+			metrics, rows := p.ProcessBatch(b, tc.doLoad)
+			if metrics != 9 || rows != 3 {
+				t.Errorf("incorrect counts: got %d metrics %d rows, want 9 metrics 3 rows", metrics, rows)
+			}
+			wantPoints := uint64(0)
+			if tc.doLoad {
+				wantPoints = 9
+			}
+			if got := tc.receiver.received(); got != wantPoints {
+				t.Errorf("incorrect data points received: got %d want %d", got, wantPoints)
+			}
+			if got := p.Errors(); got != uint64(tc.backoffs) {
+				t.Errorf("incorrect errors: got %d want %d", got, tc.backoffs)
+			}
+		})
+	}
+}
+
+func TestHTTPExporterErrors(t *testing.T) {
+	cases := []struct {
+		desc    string
+		status  int
+		resp    *colmetricspb.ExportMetricsServiceResponse
+		wantErr bool
+	}{
+		{desc: "ok", status: http.StatusOK},
+		{desc: "ok with empty response", status: http.StatusOK, resp: &colmetricspb.ExportMetricsServiceResponse{}},
+		{
+			desc:   "partial success",
+			status: http.StatusOK,
+			resp: &colmetricspb.ExportMetricsServiceResponse{
+				PartialSuccess: &colmetricspb.ExportMetricsPartialSuccess{RejectedDataPoints: 2, ErrorMessage: "too old"},
+			},
+			wantErr: true,
+		},
+		{desc: "bad request", status: http.StatusBadRequest, wantErr: true},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.resp != nil {
+				body, _ := proto.Marshal(c.resp)
+				w.Header().Set("Content-Type", contentTypeProtobuf)
+				w.WriteHeader(c.status)
+				w.Write(body)
+				return
+			}
+			w.WriteHeader(c.status)
+		}))
+		e := newHTTPExporter(server.URL, false, time.Second)
+		err := e.Export(&colmetricspb.ExportMetricsServiceRequest{})
+		if c.wantErr && (err == nil || err == errBackoff) {
+			t.Errorf("%s: expected error, got %v", c.desc, err)
+		} else if !c.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.desc, err)
+		}
+		server.Close()
+	}
+}
+
+func TestNewExporterUnknownProtocol(t *testing.T) {
+	if _, err := newExporter(&SpecificConfig{Protocol: "udp"}); err == nil {
+		t.Errorf("expected error for unknown protocol")
+	}
+}
+
+// fakeReceiver counts the data points it receives and rejects the first
+// requests after a reset with a retryable error
+type fakeReceiver struct {
+	mu       sync.Mutex
+	points   uint64
+	backoffs int
+}
+
+func (r *fakeReceiver) reset(backoffs int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.points, r.backoffs = 0, backoffs
+}
+
+func (r *fakeReceiver) received() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.points
+}
+
+// accept returns false if the request must be retried
+func (r *fakeReceiver) accept(req *colmetricspb.ExportMetricsServiceRequest) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.backoffs > 0 {
+		r.backoffs--
+		return false
+	}
+	for _, rm := range req.GetResourceMetrics() {
+		r.points += dataPoints(rm)
+	}
+	return true
+}
+
+type fakeHTTPReceiver struct {
+	fakeReceiver
+	t      *testing.T
+	server *httptest.Server
+}
+
+func (h *fakeHTTPReceiver) handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/metrics" {
+		h.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			h.t.Errorf("could not read gzip body: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body = gr
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		h.t.Errorf("could not read body: %v", err)
+		return
+	}
+	req := &colmetricspb.ExportMetricsServiceRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		h.t.Errorf("could not decode request: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !h.accept(req) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func startFakeHTTPReceiver(t *testing.T) *fakeHTTPReceiver {
+	h := &fakeHTTPReceiver{t: t}
+	h.server = httptest.NewServer(http.HandlerFunc(h.handler))
+	return h
+}
+
+type fakeGRPCReceiver struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	fakeReceiver
+	server  *grpc.Server
+	address string
+}
+
+func (g *fakeGRPCReceiver) Export(_ context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	if !g.accept(req) {
+		return nil, status.Error(codes.Unavailable, "overloaded")
+	}
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func startFakeGRPCReceiver(t *testing.T) *fakeGRPCReceiver {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	g := &fakeGRPCReceiver{server: grpc.NewServer(), address: l.Addr().String()}
+	colmetricspb.RegisterMetricsServiceServer(g.server, g)
+	go g.server.Serve(l)
+	return g
+}
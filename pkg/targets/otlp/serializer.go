@@ -0,0 +1,205 @@
+package otlp
+
+// OTLP serializer writes each point as an OTLP ResourceMetrics message, in the
+// same length delimited format as the prometheus target:
+// <<header<version>>><<message_size><protobuf message>><<message_size><protobuf message>>...
+// The loader batches the messages into ExportMetricsServiceRequests.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/timescale/tsbs/pkg/data"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	serializerVersion uint64 = 1
+	// scopeName is the name of the instrumentation scope of all metrics
+	scopeName = "tsbs"
+)
+
+// cumulativeFields are the fields of the devops use case that are simulated as
+// monotonic counters. They are written as cumulative sums, all other fields as
+// gauges.
+var cumulativeFields = map[string]map[string]bool{
+	"diskio": {"reads": true, "writes": true, "read_bytes": true, "write_bytes": true, "read_time": true, "write_time": true, "io_time": true},
+	"kernel": {"interrupts": true, "context_switches": true, "processes_forked": true, "disk_pages_in": true, "disk_pages_out": true},
+	"net":    {"bytes_sent": true, "bytes_recv": true, "packets_sent": true, "packets_recv": true, "err_in": true, "err_out": true, "drop_in": true, "drop_out": true},
+	"nginx":  {"accepts": true, "handled": true, "requests": true},
+	"redis":  {"total_connections_received": true, "expired_keys": true, "evicted_keys": true, "keyspace_hits": true, "keyspace_misses": true},
+}
+
+// Serializer writes points as OTLP ResourceMetrics. The tags of a point are
+// the attributes of its resource, each field is a metric named
+// <measurement>.<field> with a single data point. Nil tags and fields are
+// left out.
+type Serializer struct {
+	headerWritten bool
+}
+
+// Serialize writes a point as a length delimited ResourceMetrics message
+func (s *Serializer) Serialize(p *data.Point, w io.Writer) error {
+	if !s.headerWritten {
+		var versionBuf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(versionBuf[:], serializerVersion)
+		if _, err := w.Write(versionBuf[:n]); err != nil {
+			return fmt.Errorf("error writing file header: %v", err)
+		}
+		s.headerWritten = true
+	}
+
+	rm, err := toResourceMetrics(p)
+	if err != nil {
+		return fmt.Errorf("could not serialize point: %v", err)
+	}
+	protoBytes, err := proto.Marshal(rm)
+	if err != nil {
+		return err
+	}
+	var msgSizeBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(msgSizeBuf[:], uint64(len(protoBytes)))
+	if _, err := w.Write(msgSizeBuf[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(protoBytes)
+	return err
+}
+
+func toResourceMetrics(p *data.Point) (*metricspb.ResourceMetrics, error) {
+	tagKeys := p.TagKeys()
+	tagValues := p.TagValues()
+	attributes := make([]*commonpb.KeyValue, 0, len(tagKeys))
+	for i, key := range tagKeys {
+		if tagValues[i] == nil {
+			continue
+		}
+		value, err := anyValue(tagValues[i])
+		if err != nil {
+			return nil, fmt.Errorf("tag '%s': %v", key, err)
+		}
+		attributes = append(attributes, &commonpb.KeyValue{Key: string(key), Value: value})
+	}
+
+	measurement := string(p.MeasurementName())
+	ts := uint64(p.Timestamp().UnixNano())
+	fieldKeys := p.FieldKeys()
+	fieldValues := p.FieldValues()
+	metrics := make([]*metricspb.Metric, 0, len(fieldKeys))
+	for i, key := range fieldKeys {
+		if fieldValues[i] == nil {
+			continue
+		}
+		dp := &metricspb.NumberDataPoint{TimeUnixNano: ts}
+		switch v := fieldValues[i].(type) {
+		case int:
+			dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: int64(v)}
+		case int64:
+			dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: v}
+		case float32:
+			dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: float64(v)}
+		case float64:
+			dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: v}
+		default:
+			return nil, fmt.Errorf("field '%s': unsupported value type %T", key, v)
+		}
+		metric := &metricspb.Metric{Name: measurement + "." + string(key)}
+		if cumulativeFields[measurement][string(key)] {
+			metric.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+				DataPoints:             []*metricspb.NumberDataPoint{dp},
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				IsMonotonic:            true,
+			}}
+		} else {
+			metric.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+				DataPoints: []*metricspb.NumberDataPoint{dp},
+			}}
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return &metricspb.ResourceMetrics{
+		Resource: &resourcepb.Resource{Attributes: attributes},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{
+			Scope:   &commonpb.InstrumentationScope{Name: scopeName},
+			Metrics: metrics,
+		}},
+	}, nil
+}
+
+func anyValue(v interface{}) (*commonpb.AnyValue, error) {
+	switch t := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: t}}, nil
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: string(t)}}, nil
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}, nil
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: t}}, nil
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(t)}}, nil
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: t}}, nil
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: t}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// dataPoints returns the number of data points of a ResourceMetrics
+func dataPoints(rm *metricspb.ResourceMetrics) uint64 {
+	n := uint64(0)
+	for _, sm := range rm.GetScopeMetrics() {
+		for _, m := range sm.GetMetrics() {
+			n += uint64(len(m.GetGauge().GetDataPoints()) + len(m.GetSum().GetDataPoints()))
+		}
+	}
+	return n
+}
+
+// Iterator reads the ResourceMetrics messages of a serialized file
+type Iterator struct {
+	reader *bufio.Reader
+}
+
+// NewIterator creates an iterator and reads the version of the file
+func NewIterator(reader *bufio.Reader) (*Iterator, error) {
+	version, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading file version: %v", err)
+	}
+	if version != serializerVersion {
+		return nil, fmt.Errorf("unsupported version number: %d", version)
+	}
+	return &Iterator{reader: reader}, nil
+}
+
+// HasNext returns true if there are more messages to read
+func (it *Iterator) HasNext() bool {
+	b, err := it.reader.Peek(1)
+	return err == nil && len(b) > 0
+}
+
+// Next returns the next message
+func (it *Iterator) Next() (*metricspb.ResourceMetrics, error) {
+	size, err := binary.ReadUvarint(it.reader)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading message size: %v", err)
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(it.reader, buf); err != nil {
+		return nil, fmt.Errorf("error while reading protobuf message: %v", err)
+	}
+	rm := &metricspb.ResourceMetrics{}
+	if err := proto.Unmarshal(buf, rm); err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
@@ -0,0 +1,130 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func readAll(t *testing.T, buf *bytes.Buffer) []*metricspb.ResourceMetrics {
+	it, err := NewIterator(bufio.NewReader(buf))
+	if err != nil {
+		t.Fatalf("error while creating iterator: %v", err)
+	}
+	var all []*metricspb.ResourceMetrics
+	for it.HasNext() {
+		rm, err := it.Next()
+		if err != nil {
+			t.Fatalf("error getting next: %v", err)
+		}
+		all = append(all, rm)
+	}
+	return all
+}
+
+func TestSerializer(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Serializer{}
+	for _, p := range []*data.Point{serialize.TestPointMultiField(), serialize.TestPointWithNilTag(), serialize.TestPointWithNilField()} {
+		if err := s.Serialize(p, &buf); err != nil {
+			t.Fatalf("error while serializing point: %v", err)
+		}
+	}
+	all := readAll(t, &buf)
+	if got := len(all); got != 3 {
+		t.Fatalf("incorrect number of messages: got %d want 3", got)
+	}
+
+	rm := all[0]
+	attrs := rm.GetResource().GetAttributes()
+	if got, want := len(attrs), len(serialize.TestTagKeys); got != want {
+		t.Fatalf("incorrect number of attributes: got %d want %d", got, want)
+	}
+	for i, kv := range attrs {
+		if kv.GetKey() != string(serialize.TestTagKeys[i]) || kv.GetValue().GetStringValue() != serialize.TestTagVals[i] {
+			t.Errorf("incorrect attribute %d: got %s=%s", i, kv.GetKey(), kv.GetValue().GetStringValue())
+		}
+	}
+	if got := rm.GetScopeMetrics()[0].GetScope().GetName(); got != scopeName {
+		t.Errorf("incorrect scope: got %s", got)
+	}
+	metrics := rm.GetScopeMetrics()[0].GetMetrics()
+	wantNames := []string{"cpu.big_usage_guest", "cpu.usage_guest", "cpu.usage_guest_nice"}
+	if len(metrics) != len(wantNames) {
+		t.Fatalf("incorrect number of metrics: got %d want %d", len(metrics), len(wantNames))
+	}
+	for i, m := range metrics {
+		if m.GetName() != wantNames[i] {
+			t.Errorf("incorrect metric name: got %s want %s", m.GetName(), wantNames[i])
+		}
+		dps := m.GetGauge().GetDataPoints()
+		if len(dps) != 1 {
+			t.Fatalf("metric %s: expected a gauge with 1 data point", m.GetName())
+		}
+		if got := dps[0].GetTimeUnixNano(); got != uint64(serialize.TestNow.UnixNano()) {
+			t.Errorf("metric %s: incorrect timestamp %d", m.GetName(), got)
+		}
+	}
+	if got := metrics[0].GetGauge().GetDataPoints()[0].GetAsInt(); got != serialize.TestInt64 {
+		t.Errorf("incorrect int64 value: got %d", got)
+	}
+	if got := metrics[1].GetGauge().GetDataPoints()[0].GetAsInt(); got != serialize.TestInt {
+		t.Errorf("incorrect int value: got %d", got)
+	}
+	if got := metrics[2].GetGauge().GetDataPoints()[0].GetAsDouble(); got != serialize.TestFloat {
+		t.Errorf("incorrect float value: got %f", got)
+	}
+
+	// nil tags and fields are left out
+	if got := len(all[1].GetResource().GetAttributes()); got != 0 {
+		t.Errorf("nil tag not left out: got %d attributes", got)
+	}
+	if got := dataPoints(all[2]); got != 1 {
+		t.Errorf("nil field not left out: got %d data points", got)
+	}
+}
+
+func TestSerializerCumulativeFields(t *testing.T) {
+	p := data.NewPoint()
+	p.SetMeasurementName([]byte("net"))
+	now := time.Unix(1451606400, 0)
+	p.SetTimestamp(&now)
+	p.AppendTag([]byte("hostname"), "host_0")
+	p.AppendField([]byte("bytes_sent"), int64(100))
+	p.AppendField([]byte("drop_in"), int64(0))
+	p.AppendField([]byte("load"), 1.5)
+
+	var buf bytes.Buffer
+	if err := (&Serializer{}).Serialize(p, &buf); err != nil {
+		t.Fatalf("error while serializing point: %v", err)
+	}
+	metrics := readAll(t, &buf)[0].GetScopeMetrics()[0].GetMetrics()
+	for _, m := range metrics[:2] {
+		sum := m.GetSum()
+		if sum == nil || !sum.GetIsMonotonic() || sum.GetAggregationTemporality() != metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+			t.Errorf("metric %s: expected a monotonic cumulative sum, got %v", m.GetName(), m.GetData())
+		}
+	}
+	if metrics[2].GetGauge() == nil {
+		t.Errorf("metric %s: expected a gauge, got %v", metrics[2].GetName(), metrics[2].GetData())
+	}
+}
+
+func TestSerializerErrors(t *testing.T) {
+	p := serialize.TestPointDefault()
+	p.AppendField([]byte("name"), "not a number")
+	if err := (&Serializer{}).Serialize(p, new(bytes.Buffer)); err == nil {
+		t.Errorf("expected error for string field")
+	}
+	if err := (&Serializer{}).Serialize(serialize.TestPointDefault(), &serialize.ErrWriter{}); err == nil {
+		t.Errorf("expected error for failing writer")
+	}
+	if _, err := NewIterator(bufio.NewReader(bytes.NewReader([]byte{2}))); err == nil {
+		t.Errorf("expected error for unsupported version")
+	}
+}
@@ -14,3 +14,11 @@ type ProcessorCloser interface {
 	// Close cleans up after a Processor
 	Close(doLoad bool)
 }
+
+// ProcessorErrorCounter is a Processor that keeps count of the write errors it
+// recovered from (e.g. by backing off and retrying), so they can be reported
+type ProcessorErrorCounter interface {
+	Processor
+	// Errors returns the number of errors encountered since Init
+	Errors() uint64
+}
@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -15,6 +16,9 @@ import (
 )
 
 func NewBenchmark(promSpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	if err := promSpecificConfig.Validate(); err != nil {
+		return nil, err
+	}
 	var ds targets.DataSource
 	if dataSourceConfig.Type == source.FileDataSourceType {
 		promIter, err := NewPrometheusIterator(load.GetBufferedReader(dataSourceConfig.File.Location))
@@ -37,9 +41,9 @@ func NewBenchmark(promSpecificConfig *SpecificConfig, dataSourceConfig *source.D
 	}}
 
 	return &Benchmark{
-		dataSource:      ds,
-		batchPool:       batchPool,
-		adapterWriteUrl: promSpecificConfig.AdapterWriteURL,
+		dataSource: ds,
+		batchPool:  batchPool,
+		config:     promSpecificConfig,
 	}, nil
 }
 
@@ -78,22 +82,33 @@ func (pd *FileDataSource) Headers() *common.GeneratedDataHeaders {
 	return nil
 }
 
-// PrometheusProcessor implements load.Processor interface
+// PrometheusProcessor implements load.Processor interface. The series of
+// the batches are sent by a queueManager, one request per batch unless the
+// queue is sharded.
 type Processor struct {
 	client    *Client
 	batchPool *sync.Pool
+	queueConf QueueConfig
+	queue     *queueManager
 }
 
-func (pp *Processor) Init(_ int, _, _ bool) {}
+func (pp *Processor) Init(_ int, doLoad, _ bool) {
+	if doLoad {
+		pp.queue = newQueueManager(pp.queueConf, pp.client)
+		pp.queue.Start()
+	}
+}
 
-// ProcessBatch ..
+// ProcessBatch sends the series of the batch, or queues them when the queue
+// is sharded. It blocks while the queues of their shards are full.
 func (pp *Processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
 	promBatch := b.(*Batch)
 	nrSamples := uint64(promBatch.Len())
-	if doLoad {
-		err := pp.client.Post(promBatch.series)
-		if err != nil {
-			panic(err)
+	if doLoad && !pp.queueConf.sharded() {
+		pp.queue.SendBatch(promBatch.series)
+	} else if doLoad {
+		for _, ts := range promBatch.series {
+			pp.queue.Append(ts)
 		}
 	}
 	// reset batch
@@ -102,6 +117,21 @@ func (pp *Processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 	return nrSamples, nrSamples
 }
 
+// Close sends the queued series
+func (pp *Processor) Close(_ bool) {
+	if pp.queue != nil {
+		pp.queue.Stop()
+	}
+}
+
+// Errors returns the number of requests that were retried
+func (pp *Processor) Errors() uint64 {
+	if pp.queue == nil {
+		return 0
+	}
+	return pp.queue.Retries()
+}
+
 // PrometheusBatchFactory implements Factory interface
 type BatchFactory struct {
 	batchPool *sync.Pool
@@ -113,10 +143,10 @@ func (pbf *BatchFactory) New() targets.Batch {
 
 // Benchmark implements targets.Benchmark interface
 type Benchmark struct {
-	adapterWriteUrl string
-	dataSource      targets.DataSource
-	batchPool       *sync.Pool
-	client          *Client
+	config     *SpecificConfig
+	dataSource targets.DataSource
+	batchPool  *sync.Pool
+	client     *Client
 }
 
 func (pm *Benchmark) GetDataSource() targets.DataSource {
@@ -135,16 +165,58 @@ func (pm *Benchmark) GetPointIndexer(maxPartitions uint) targets.PointIndexer {
 }
 
 func (pm *Benchmark) GetProcessor() targets.Processor {
+	return &Processor{client: pm.getClient(), batchPool: pm.batchPool, queueConf: pm.config.QueueConfig}
+}
+
+// GetDBCreator returns a DBCreator that checks the connection to the
+// receiver, if enabled. There is no database to create.
+func (pm *Benchmark) GetDBCreator() targets.DBCreator {
+	if !pm.config.CheckConnection {
+		return nil
+	}
+	return &dbCreator{client: pm.getClient()}
+}
+
+// PointTimestamp implements targets.PointTimestamper with the timestamp of
+// the first sample of the series
+func (pm *Benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	ts := item.Data.(*prompb.TimeSeries)
+	if len(ts.Samples) == 0 {
+		return time.Time{}, fmt.Errorf("series without samples")
+	}
+	return time.Unix(0, ts.Samples[0].Timestamp*int64(time.Millisecond)), nil
+}
+
+func (pm *Benchmark) getClient() *Client {
 	if pm.client == nil {
 		var err error
-		pm.client, err = NewClient(pm.adapterWriteUrl, time.Second*30)
+		pm.client, err = NewClientVersion(pm.config.WriteURL(), pm.config.RemoteWriteVersion, pm.config.RemoteTimeout)
 		if err != nil {
 			panic(err)
 		}
 	}
-	return &Processor{client: pm.client, batchPool: pm.batchPool}
+	return pm.client
 }
 
-func (pm *Benchmark) GetDBCreator() targets.DBCreator {
+// dbCreator checks that the receiver accepts remote-write requests before
+// the load starts, by sending a request without series. Unlike a query it
+// works with receivers that don't serve PromQL.
+type dbCreator struct {
+	client *Client
+}
+
+func (d *dbCreator) Init() {}
+
+func (d *dbCreator) DBExists(dbName string) bool { return false }
+
+func (d *dbCreator) CreateDB(dbName string) error { return nil }
+
+func (d *dbCreator) RemoveOldDB(dbName string) error { return nil }
+
+// PostCreateDB implements targets.DBCreatorPost
+func (d *dbCreator) PostCreateDB(dbName string) error {
+	if err := d.client.Check(); err != nil {
+		return fmt.Errorf("remote-write connection check failed: %v", err)
+	}
 	return nil
 }
@@ -11,23 +11,98 @@ import (
 )
 
 func TestPrometheusLoader(t *testing.T) {
+	// per batch (the default) and queued in shards
+	for _, maxSamplesPerSend := range []int{0, 2000} {
+		adapter := noop.Adapter{}
+		server := httptest.NewServer(http.HandlerFunc(adapter.Handler))
+		serverURL, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := testConfig(serverURL.String())
+		conf.MaxSamplesPerSend = maxSamplesPerSend
+		pb := Benchmark{
+			config:    conf,
+			batchPool: &sync.Pool{},
+		}
+		pp := pb.GetProcessor().(*Processor)
+		pp.Init(0, true, false)
+		batch := &Batch{series: []prompb.TimeSeries{{}}}
+		samples, _ := pp.ProcessBatch(batch, true)
+		pp.Close(true)
+		server.Close()
+		if samples != 1 {
+			t.Errorf("max-samples-per-send %d: wrong number of samples", maxSamplesPerSend)
+		}
+		if adapter.SampleCounter != samples {
+			t.Errorf("max-samples-per-send %d: wrong number of samples processed", maxSamplesPerSend)
+		}
+	}
+}
+
+func TestPrometheusDBCreator(t *testing.T) {
 	adapter := noop.Adapter{}
 	server := httptest.NewServer(http.HandlerFunc(adapter.Handler))
-	serverURL, err := url.Parse(server.URL)
-	if err != nil {
-		t.Fatal(err)
-	}
-	pb := Benchmark{
-		adapterWriteUrl: serverURL.String(),
-		batchPool:       &sync.Pool{},
-	}
-	pp := pb.GetProcessor().(*Processor)
-	batch := &Batch{series: []prompb.TimeSeries{{}}}
-	samples, _ := pp.ProcessBatch(batch, true)
-	if samples != 1 {
-		t.Error("wrong number of samples")
-	}
-	if adapter.SampleCounter != samples {
-		t.Error("wrong number of samples processed")
+	defer server.Close()
+
+	conf := testConfig(server.URL)
+	pb := Benchmark{config: conf, batchPool: &sync.Pool{}}
+	dbc, ok := pb.GetDBCreator().(*dbCreator)
+	if !ok {
+		t.Fatalf("expected a DBCreator checking the connection")
+	}
+	if err := dbc.PostCreateDB(""); err != nil {
+		t.Errorf("unexpected error checking connection: %v", err)
+	}
+	if adapter.SampleCounter != 0 {
+		t.Errorf("connection check wrote %d samples", adapter.SampleCounter)
+	}
+
+	// the noop adapter only supports remote-write 1.0
+	conf = testConfig(server.URL)
+	conf.RemoteWriteVersion = RemoteWriteV2
+	pb = Benchmark{config: conf, batchPool: &sync.Pool{}}
+	if err := pb.GetDBCreator().(*dbCreator).PostCreateDB(""); err == nil {
+		t.Errorf("expected error checking remote-write 2.0 connection")
+	}
+
+	conf.CheckConnection = false
+	pb = Benchmark{config: conf, batchPool: &sync.Pool{}}
+	if pb.GetDBCreator() != nil {
+		t.Errorf("expected no DBCreator without connection check")
+	}
+}
+
+func TestSpecificConfigValidate(t *testing.T) {
+	cases := []struct {
+		desc    string
+		modify  func(c *SpecificConfig)
+		wantErr bool
+	}{
+		{desc: "defaults", modify: func(c *SpecificConfig) {}},
+		{desc: "version 2.0", modify: func(c *SpecificConfig) { c.RemoteWriteVersion = RemoteWriteV2 }},
+		{desc: "unknown version", modify: func(c *SpecificConfig) { c.RemoteWriteVersion = "1.1" }, wantErr: true},
+		{desc: "no shards", modify: func(c *SpecificConfig) { c.Shards = 0 }, wantErr: true},
+		{desc: "no capacity", modify: func(c *SpecificConfig) { c.Capacity = 0 }, wantErr: true},
+		{desc: "sharded", modify: func(c *SpecificConfig) { c.Shards, c.MaxSamplesPerSend = 4, 2000 }},
+		{desc: "shards without max samples per send", modify: func(c *SpecificConfig) { c.Shards = 4 }, wantErr: true},
+		{desc: "negative max samples per send", modify: func(c *SpecificConfig) { c.MaxSamplesPerSend = -1 }, wantErr: true},
+		{desc: "max backoff below min", modify: func(c *SpecificConfig) { c.MaxBackoff = c.MinBackoff / 2 }, wantErr: true},
+	}
+	for _, c := range cases {
+		conf := testConfig("http://localhost:9201/write")
+		c.modify(conf)
+		if err := conf.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected result: %v", c.desc, err)
+		}
+	}
+
+	conf := testConfig("http://remote/write")
+	if got := conf.WriteURL(); got != "http://remote/write" {
+		t.Errorf("incorrect write URL: %s", got)
+	}
+	conf.AdapterWriteURL = "http://adapter/write"
+	if got := conf.WriteURL(); got != "http://adapter/write" {
+		t.Errorf("deprecated adapter URL not used: %s", got)
 	}
 }
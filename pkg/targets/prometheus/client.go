@@ -13,21 +13,51 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/internal/utils"
+)
+
+const (
+	contentTypeV1 = "application/x-protobuf"
+	contentTypeV2 = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+	versionV1     = "0.1.0"
+	versionV2     = "2.0.0"
+	// maxErrorBodySize is the number of bytes of an error response included
+	// in the returned error
+	maxErrorBodySize = 512
 )
 
 // Client is a wrapper around http.Client
-// Client sends data to Prometheus adapter
+// Client sends data to a Prometheus remote-write receiver
 type Client struct {
 	url        *url.URL
+	version    string
 	httpClient *http.Client
 }
 
+// recoverableError is an error after which the request can be sent again,
+// not earlier than retryAfter if it is set. rateLimited is set for the 429
+// status.
+type recoverableError struct {
+	error
+	retryAfter  time.Duration
+	rateLimited bool
+}
+
 // NewClient ..
 func NewClient(urlStr string, timeout time.Duration) (*Client, error) {
+	return NewClientVersion(urlStr, RemoteWriteV1, timeout)
+}
+
+// NewClientVersion creates a client that sends requests of a remote-write
+// protocol version
+func NewClientVersion(urlStr, version string, timeout time.Duration) (*Client, error) {
 	url, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, err
 	}
+	if version != RemoteWriteV1 && version != RemoteWriteV2 {
+		return nil, fmt.Errorf("unsupported remote-write version '%s', valid: %s, %s", version, RemoteWriteV1, RemoteWriteV2)
+	}
 
 	//options copied from Prometheus
 	var rt http.RoundTripper = &http.Transport{
@@ -42,7 +72,7 @@ func NewClient(urlStr string, timeout time.Duration) (*Client, error) {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 	httpClient := &http.Client{Transport: rt, Timeout: timeout}
-	return &Client{url: url, httpClient: httpClient}, nil
+	return &Client{url: url, version: version, httpClient: httpClient}, nil
 }
 
 var bufferPool = sync.Pool{
@@ -59,41 +89,64 @@ var snappyPool = sync.Pool{
 	},
 }
 
-// Post sends POST request to Prometheus adapter
+// Post sends POST request to the remote-write receiver. The returned error
+// is a recoverableError when the receiver failed with a 5xx or 429 status, or
+// the request could not be sent.
 func (c *Client) Post(series []prompb.TimeSeries) error {
-	wr := &prompb.WriteRequest{
-		Timeseries: series,
-	}
-
 	buffer := bufferPool.Get().(*proto.Buffer)
 	buffer.Reset()
-	err := buffer.Marshal(wr)
-	if err != nil {
+	defer bufferPool.Put(buffer)
+	if c.version == RemoteWriteV2 {
+		buffer.SetBuf(marshalWriteV2(buffer.Bytes(), series))
+	} else if err := buffer.Marshal(&prompb.WriteRequest{Timeseries: series}); err != nil {
 		return err
 	}
+
 	compressed := snappyPool.Get().([]byte)
 	compressed = compressed[:cap(compressed)]
 	compressed = snappy.Encode(compressed, buffer.Bytes())
-	bufferPool.Put(buffer)
+	defer snappyPool.Put(compressed)
 	httpReq, err := http.NewRequest("POST", c.url.String(), bytes.NewReader(compressed))
 	if err != nil {
 		return err
 	}
 	httpReq.Header.Add("Content-Encoding", "snappy")
-	httpReq.Header.Set("Content-Type", "application/x-protobuf")
-	httpReq.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if c.version == RemoteWriteV2 {
+		httpReq.Header.Set("Content-Type", contentTypeV2)
+		httpReq.Header.Set("X-Prometheus-Remote-Write-Version", versionV2)
+	} else {
+		httpReq.Header.Set("Content-Type", contentTypeV1)
+		httpReq.Header.Set("X-Prometheus-Remote-Write-Version", versionV1)
+	}
 	httpResp, err := c.httpClient.Do(httpReq)
-	snappyPool.Put(compressed)
 	if err != nil {
-		return err
+		return recoverableError{error: err}
 	}
 	defer func() {
 		io.Copy(ioutil.Discard, httpResp.Body)
 		httpResp.Body.Close()
 	}()
 
-	if httpResp.StatusCode/100 != 2 {
-		return fmt.Errorf("Prometheus adapter returned status: %s", httpResp.Status)
+	if httpResp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(httpResp.Body, maxErrorBodySize))
+	err = fmt.Errorf("remote-write receiver returned status: %s: %s", httpResp.Status, bytes.TrimSpace(msg))
+	switch {
+	case httpResp.StatusCode == http.StatusUnsupportedMediaType && c.version == RemoteWriteV2:
+		return fmt.Errorf("%v (the receiver may not support remote-write %s)", err, RemoteWriteV2)
+	case httpResp.StatusCode == http.StatusTooManyRequests, httpResp.StatusCode/100 == 5:
+		return recoverableError{
+			error:       err,
+			retryAfter:  utils.RetryAfter(httpResp.Header.Get("Retry-After")),
+			rateLimited: httpResp.StatusCode == http.StatusTooManyRequests,
+		}
 	}
-	return nil
+	return err
+}
+
+// Check sends a request without series, which a remote-write receiver
+// accepts without storing anything, to check that it is reachable
+func (c *Client) Check() error {
+	return c.Post(nil)
 }
@@ -1,10 +1,78 @@
 package prometheus
 
-import "github.com/blagojts/viper"
+import (
+	"fmt"
+	"time"
+
+	"github.com/blagojts/viper"
+)
+
+// Remote-write protocol versions
+const (
+	RemoteWriteV1 = "1.0"
+	RemoteWriteV2 = "2.0"
+)
 
 type SpecificConfig struct {
-	AdapterWriteURL string `yaml:"adapter-write-url" mapstructure:"adapter-write-url"`
-	UseCurrentTime  bool   `yaml:"use-current-time" mapstructure:"use-current-time"`
+	// AdapterWriteURL is the deprecated name of RemoteWriteURL. It is used
+	// instead of RemoteWriteURL when set.
+	AdapterWriteURL    string `yaml:"adapter-write-url" mapstructure:"adapter-write-url"`
+	RemoteWriteURL     string `yaml:"remote-write-url" mapstructure:"remote-write-url"`
+	RemoteWriteVersion string `yaml:"remote-write-version" mapstructure:"remote-write-version"`
+	UseCurrentTime     bool   `yaml:"use-current-time" mapstructure:"use-current-time"`
+	CheckConnection    bool   `yaml:"check-connection" mapstructure:"check-connection"`
+
+	QueueConfig `yaml:",inline" mapstructure:",squash"`
+}
+
+// QueueConfig configures how each worker sends its series, like the
+// queue_config of the Prometheus remote-write configuration
+type QueueConfig struct {
+	// Shards is the number of concurrent senders of each worker
+	Shards int `yaml:"shards" mapstructure:"shards"`
+	// Capacity is the number of series buffered per shard before the
+	// worker blocks
+	Capacity int `yaml:"capacity" mapstructure:"capacity"`
+	// MaxSamplesPerSend is the maximum number of samples per request of a
+	// shard. When 0 the series are not queued, each batch is sent as one
+	// request.
+	MaxSamplesPerSend int           `yaml:"max-samples-per-send" mapstructure:"max-samples-per-send"`
+	BatchSendDeadline time.Duration `yaml:"batch-send-deadline" mapstructure:"batch-send-deadline"`
+	MinBackoff        time.Duration `yaml:"min-backoff" mapstructure:"min-backoff"`
+	MaxBackoff        time.Duration `yaml:"max-backoff" mapstructure:"max-backoff"`
+	RetryOnHTTP429    bool          `yaml:"retry-on-http-429" mapstructure:"retry-on-http-429"`
+	RemoteTimeout     time.Duration `yaml:"remote-timeout" mapstructure:"remote-timeout"`
+}
+
+// sharded tells whether the series are queued in shards rather than sent
+// per batch
+func (c *QueueConfig) sharded() bool {
+	return c.MaxSamplesPerSend > 0
+}
+
+// WriteURL returns the URL the remote-write requests are sent to
+func (c *SpecificConfig) WriteURL() string {
+	if c.AdapterWriteURL != "" {
+		return c.AdapterWriteURL
+	}
+	return c.RemoteWriteURL
+}
+
+// Validate checks the remote-write version and the queue config
+func (c *SpecificConfig) Validate() error {
+	if c.RemoteWriteVersion != RemoteWriteV1 && c.RemoteWriteVersion != RemoteWriteV2 {
+		return fmt.Errorf("unsupported remote-write version '%s', valid: %s, %s", c.RemoteWriteVersion, RemoteWriteV1, RemoteWriteV2)
+	}
+	if c.Shards < 1 || c.Capacity < 1 || c.MaxSamplesPerSend < 0 {
+		return fmt.Errorf("shards and capacity must be at least 1, max-samples-per-send can't be negative")
+	}
+	if c.Shards > 1 && !c.sharded() {
+		return fmt.Errorf("shards needs max-samples-per-send, without it each batch is sent as one request")
+	}
+	if c.BatchSendDeadline <= 0 || c.MinBackoff <= 0 || c.MaxBackoff < c.MinBackoff {
+		return fmt.Errorf("batch-send-deadline and min-backoff must be positive and max-backoff at least min-backoff")
+	}
+	return nil
 }
 
 func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
@@ -1,6 +1,8 @@
 package prometheus
 
 import (
+	"time"
+
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/pkg/data/serialize"
@@ -33,6 +35,18 @@ func (t *prometheusTarget) Benchmark(_ string, dataSourceConfig *source.DataSour
 }
 
 func (t *prometheusTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
-	flagSet.String(flagPrefix+"adapter-write-url", "http://localhost:9201/write", "Prometheus adapter url to send data to")
+	flagSet.String(flagPrefix+"remote-write-url", "http://localhost:9201/write", "URL of the remote-write receiver to send data to")
+	flagSet.String(flagPrefix+"adapter-write-url", "", "Prometheus adapter url to send data to, overrides remote-write-url")
+	flagSet.MarkDeprecated(flagPrefix+"adapter-write-url", "use remote-write-url instead")
+	flagSet.String(flagPrefix+"remote-write-version", RemoteWriteV1, "Remote-write protocol version, valid: "+RemoteWriteV1+", "+RemoteWriteV2)
 	flagSet.Bool(flagPrefix+"use-current-time", false, "Whether to replace the simulated timestamp with the current timestamp")
+	flagSet.Bool(flagPrefix+"check-connection", true, "Whether to send a request without series to check that the receiver accepts remote-write requests before loading")
+	flagSet.Int(flagPrefix+"max-samples-per-send", 0, "Maximum number of samples per request. If 0, each batch of batch-size series is sent as one request, otherwise the series are queued in shards")
+	flagSet.Int(flagPrefix+"shards", 1, "Number of concurrent senders of each worker, needs max-samples-per-send. Series are sharded by their labels")
+	flagSet.Int(flagPrefix+"capacity", 10000, "Number of series queued per shard before the worker blocks, with max-samples-per-send")
+	flagSet.Duration(flagPrefix+"batch-send-deadline", 5*time.Second, "Maximum time samples wait in a shard before being sent, with max-samples-per-send")
+	flagSet.Duration(flagPrefix+"min-backoff", 30*time.Millisecond, "Initial backoff before retrying a failed request")
+	flagSet.Duration(flagPrefix+"max-backoff", 5*time.Second, "Maximum backoff before retrying a failed request")
+	flagSet.Bool(flagPrefix+"retry-on-http-429", true, "Whether to retry requests rejected with the 429 status (too many requests)")
+	flagSet.Duration(flagPrefix+"remote-timeout", 30*time.Second, "Timeout of a remote-write request")
 }
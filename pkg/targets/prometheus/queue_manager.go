@@ -0,0 +1,173 @@
+package prometheus
+
+import (
+	"hash/fnv"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/timescale/promscale/pkg/prompb"
+)
+
+// queueManager sends the series of a worker. Without MaxSamplesPerSend each
+// batch is sent as one request by SendBatch, as the loader always did.
+// Otherwise the series are queued like the remote-write queue manager of
+// Prometheus: they are sharded by their labels, so the samples of a series
+// are sent in order, and each shard sends a request when it has
+// MaxSamplesPerSend samples, or when BatchSendDeadline passed since it got
+// the first of its pending samples. Failed requests are retried with an
+// exponential backoff as long as the error is recoverable.
+type queueManager struct {
+	conf   QueueConfig
+	client *Client
+	shards []chan prompb.TimeSeries
+	wg     sync.WaitGroup
+	// retries is the number of requests that had to be sent again
+	retries uint64
+	// fatal reports an unrecoverable error, which stops the load
+	fatal func(format string, args ...interface{})
+}
+
+func newQueueManager(conf QueueConfig, client *Client) *queueManager {
+	qm := &queueManager{
+		conf:   conf,
+		client: client,
+		fatal:  log.Fatalf,
+	}
+	if !conf.sharded() {
+		return qm
+	}
+	qm.shards = make([]chan prompb.TimeSeries, conf.Shards)
+	for i := range qm.shards {
+		qm.shards[i] = make(chan prompb.TimeSeries, conf.Capacity)
+	}
+	return qm
+}
+
+// Start starts the senders of the shards
+func (qm *queueManager) Start() {
+	for _, queue := range qm.shards {
+		qm.wg.Add(1)
+		go qm.runShard(queue)
+	}
+}
+
+// Append queues a series in its shard. It blocks while the queue of the shard
+// is full.
+func (qm *queueManager) Append(ts prompb.TimeSeries) {
+	qm.shards[shardOf(ts.Labels, len(qm.shards))] <- ts
+}
+
+// SendBatch sends the series of a batch as one request, without queueing them
+func (qm *queueManager) SendBatch(series []prompb.TimeSeries) {
+	if len(series) == 0 {
+		return
+	}
+	if err := qm.send(series); err != nil {
+		qm.fatal("error while sending %d series: %v", len(series), err)
+	}
+}
+
+// Stop sends the pending series and waits for the shards to finish
+func (qm *queueManager) Stop() {
+	for _, queue := range qm.shards {
+		close(queue)
+	}
+	qm.wg.Wait()
+}
+
+// Retries returns the number of requests that had to be sent again
+func (qm *queueManager) Retries() uint64 {
+	return atomic.LoadUint64(&qm.retries)
+}
+
+func shardOf(labels []prompb.Label, shards int) int {
+	if shards == 1 {
+		return 0
+	}
+	h := fnv.New64a()
+	for _, l := range labels {
+		h.Write([]byte(l.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(l.Value))
+		h.Write([]byte{0})
+	}
+	return int(h.Sum64() % uint64(shards))
+}
+
+func (qm *queueManager) runShard(queue chan prompb.TimeSeries) {
+	defer qm.wg.Done()
+	pending := make([]prompb.TimeSeries, 0, qm.conf.MaxSamplesPerSend)
+	samples := 0
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := qm.send(pending); err != nil {
+			qm.fatal("error while sending %d series: %v", len(pending), err)
+		}
+		pending, samples = pending[:0], 0
+	}
+
+	timer := time.NewTimer(qm.conf.BatchSendDeadline)
+	defer timer.Stop()
+	for {
+		select {
+		case ts, ok := <-queue:
+			if !ok {
+				flush()
+				return
+			}
+			if len(pending) == 0 {
+				resetTimer(timer, qm.conf.BatchSendDeadline)
+			}
+			pending = append(pending, ts)
+			samples += len(ts.Samples)
+			if samples >= qm.conf.MaxSamplesPerSend {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		}
+	}
+}
+
+// send sends the series until it succeeds or fails with an unrecoverable
+// error
+func (qm *queueManager) send(series []prompb.TimeSeries) error {
+	backoff := qm.conf.MinBackoff
+	for {
+		err := qm.client.Post(series)
+		if err == nil {
+			return nil
+		}
+		recoverable, ok := err.(recoverableError)
+		if !ok {
+			return err
+		}
+		if recoverable.rateLimited && !qm.conf.RetryOnHTTP429 {
+			return err
+		}
+		atomic.AddUint64(&qm.retries, 1)
+		sleep := backoff
+		if recoverable.retryAfter > sleep {
+			sleep = recoverable.retryAfter
+		}
+		time.Sleep(sleep)
+		backoff *= 2
+		if backoff > qm.conf.MaxBackoff {
+			backoff = qm.conf.MaxBackoff
+		}
+	}
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
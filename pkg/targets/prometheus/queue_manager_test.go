@@ -0,0 +1,248 @@
+package prometheus
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/timescale/promscale/pkg/prompb"
+)
+
+// testConfig returns the config of the flag defaults, with short timings
+func testConfig(url string) *SpecificConfig {
+	return &SpecificConfig{
+		RemoteWriteURL:     url,
+		RemoteWriteVersion: RemoteWriteV1,
+		CheckConnection:    true,
+		QueueConfig: QueueConfig{
+			Shards:            1,
+			Capacity:          10000,
+			MaxSamplesPerSend: 0,
+			BatchSendDeadline: 5 * time.Second,
+			MinBackoff:        time.Millisecond,
+			MaxBackoff:        5 * time.Millisecond,
+			RetryOnHTTP429:    true,
+			RemoteTimeout:     time.Second,
+		},
+	}
+}
+
+func testSeries(n int) []prompb.TimeSeries {
+	series := make([]prompb.TimeSeries, n)
+	for i := range series {
+		series[i] = prompb.TimeSeries{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "usage_user"}, {Name: "hostname", Value: fmt.Sprintf("host_%d", i%7)}},
+			Samples: []prompb.Sample{{Value: float64(i), Timestamp: int64(i)}},
+		}
+	}
+	return series
+}
+
+// fakeReceiver decodes remote-write requests of both versions. It answers
+// the first requests with the statuses in failures.
+type fakeReceiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	failures []int
+	// requests is the number of samples of each accepted request
+	requests []int
+	series   []prompb.TimeSeries
+}
+
+func (r *fakeReceiver) handler(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.failures) > 0 {
+		status := r.failures[0]
+		r.failures = r.failures[1:]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		http.Error(w, "failed", status)
+		return
+	}
+
+	compressed, _ := ioutil.ReadAll(req.Body)
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		r.t.Errorf("could not decompress request: %v", err)
+		return
+	}
+	var series []prompb.TimeSeries
+	switch req.Header.Get("Content-Type") {
+	case contentTypeV1:
+		var wr prompb.WriteRequest
+		if err := proto.Unmarshal(body, &wr); err != nil {
+			r.t.Errorf("could not decode 1.0 request: %v", err)
+			return
+		}
+		series = wr.Timeseries
+	case contentTypeV2:
+		if series, err = unmarshalWriteV2(body); err != nil {
+			r.t.Errorf("could not decode 2.0 request: %v", err)
+			return
+		}
+	default:
+		http.Error(w, "unsupported", http.StatusUnsupportedMediaType)
+		return
+	}
+	samples := 0
+	for _, ts := range series {
+		samples += len(ts.Samples)
+	}
+	r.requests = append(r.requests, samples)
+	r.series = append(r.series, series...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func startFakeReceiver(t *testing.T, failures ...int) (*fakeReceiver, *httptest.Server) {
+	r := &fakeReceiver{t: t, failures: failures}
+	return r, httptest.NewServer(http.HandlerFunc(r.handler))
+}
+
+func TestQueueManager(t *testing.T) {
+	cases := []struct {
+		desc    string
+		version string
+		shards  int
+		series  int
+		// wantRequests is the number of samples of each request, for a
+		// single shard
+		wantRequests []int
+	}{
+		{desc: "full requests", version: RemoteWriteV1, shards: 1, series: 10, wantRequests: []int{4, 4, 2}},
+		{desc: "full requests 2.0", version: RemoteWriteV2, shards: 1, series: 10, wantRequests: []int{4, 4, 2}},
+		{desc: "sharded", version: RemoteWriteV1, shards: 3, series: 100},
+		{desc: "sharded 2.0", version: RemoteWriteV2, shards: 3, series: 100},
+	}
+	for _, c := range cases {
+		receiver, server := startFakeReceiver(t)
+		conf := testConfig(server.URL)
+		conf.RemoteWriteVersion = c.version
+		conf.Shards = c.shards
+		conf.MaxSamplesPerSend = 4
+		client, err := NewClientVersion(server.URL, c.version, time.Second)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.desc, err)
+		}
+		qm := newQueueManager(conf.QueueConfig, client)
+		qm.Start()
+		series := testSeries(c.series)
+		for _, ts := range series {
+			qm.Append(ts)
+		}
+		qm.Stop()
+		server.Close()
+
+		if len(receiver.series) != len(series) {
+			t.Fatalf("%s: incorrect number of series: got %d want %d", c.desc, len(receiver.series), len(series))
+		}
+		if c.wantRequests != nil && fmt.Sprint(receiver.requests) != fmt.Sprint(c.wantRequests) {
+			t.Errorf("%s: incorrect requests: got %v want %v", c.desc, receiver.requests, c.wantRequests)
+		}
+		// the samples of a series are sent in order
+		last := map[string]int64{}
+		for _, ts := range receiver.series {
+			host := ts.Labels[1].Value
+			if prev, ok := last[host]; ok && ts.Samples[0].Timestamp < prev {
+				t.Errorf("%s: samples of %s out of order", c.desc, host)
+			}
+			last[host] = ts.Samples[0].Timestamp
+		}
+	}
+}
+
+func TestQueueManagerSendBatch(t *testing.T) {
+	receiver, server := startFakeReceiver(t, http.StatusServiceUnavailable)
+	defer server.Close()
+	conf := testConfig(server.URL)
+	client, _ := NewClient(server.URL, time.Second)
+	qm := newQueueManager(conf.QueueConfig, client)
+	qm.Start()
+	series := testSeries(10)
+	qm.SendBatch(series[:7])
+	qm.SendBatch(series[7:])
+	qm.SendBatch(nil)
+	qm.Stop()
+
+	// each batch is one request, whatever its number of samples
+	if fmt.Sprint(receiver.requests) != fmt.Sprint([]int{7, 3}) {
+		t.Errorf("incorrect requests: got %v want [7 3]", receiver.requests)
+	}
+	if qm.Retries() != 1 {
+		t.Errorf("incorrect retries: got %d want 1", qm.Retries())
+	}
+}
+
+func TestQueueManagerBatchSendDeadline(t *testing.T) {
+	receiver, server := startFakeReceiver(t)
+	defer server.Close()
+	conf := testConfig(server.URL)
+	conf.MaxSamplesPerSend = 2000
+	conf.BatchSendDeadline = 10 * time.Millisecond
+	client, _ := NewClient(server.URL, time.Second)
+	qm := newQueueManager(conf.QueueConfig, client)
+	qm.Start()
+	defer qm.Stop()
+
+	qm.Append(testSeries(1)[0])
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		receiver.mu.Lock()
+		n := len(receiver.series)
+		receiver.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("pending sample not sent after the batch send deadline")
+}
+
+func TestQueueManagerRetries(t *testing.T) {
+	cases := []struct {
+		desc        string
+		failures    []int
+		retryOn429  bool
+		wantRetries uint64
+		wantFatal   bool
+	}{
+		{desc: "no failures", retryOn429: true},
+		{desc: "server errors", failures: []int{500, 503}, retryOn429: true, wantRetries: 2},
+		{desc: "rate limited", failures: []int{429}, retryOn429: true, wantRetries: 1},
+		{desc: "rate limited without retry", failures: []int{429}, wantFatal: true},
+		{desc: "bad request", failures: []int{400}, retryOn429: true, wantFatal: true},
+	}
+	for _, c := range cases {
+		receiver, server := startFakeReceiver(t, c.failures...)
+		conf := testConfig(server.URL)
+		conf.RetryOnHTTP429 = c.retryOn429
+		conf.MaxSamplesPerSend = 2000
+		client, _ := NewClient(server.URL, time.Second)
+		qm := newQueueManager(conf.QueueConfig, client)
+		fatal := ""
+		qm.fatal = func(format string, args ...interface{}) {
+			fatal = fmt.Sprintf(format, args...)
+		}
+		qm.Start()
+		qm.Append(testSeries(1)[0])
+		qm.Stop()
+		server.Close()
+
+		if c.wantFatal != (fatal != "") {
+			t.Errorf("%s: unexpected fatal error '%s'", c.desc, fatal)
+		}
+		if got := qm.Retries(); got != c.wantRetries {
+			t.Errorf("%s: incorrect retries: got %d want %d", c.desc, got, c.wantRetries)
+		}
+		if !c.wantFatal && len(receiver.series) != 1 {
+			t.Errorf("%s: series not received", c.desc)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package prometheus
+
+// Encoding of remote-write 2.0 requests (io.prometheus.write.v2.Request):
+//
+//	message Request {
+//	  repeated string symbols = 4;
+//	  repeated TimeSeries timeseries = 5;
+//	}
+//	message TimeSeries {
+//	  repeated uint32 labels_refs = 1;
+//	  repeated Sample samples = 2;
+//	}
+//	message Sample {
+//	  double value = 1;
+//	  int64 timestamp = 2;
+//	}
+//
+// The label names and values are references into the symbols of the
+// request, whose first symbol is always the empty string. The promscale
+// prompb package only has the 1.0 messages, so the few fields written here
+// are encoded directly.
+
+import (
+	"math"
+
+	"github.com/timescale/promscale/pkg/prompb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	requestV2SymbolsField    protowire.Number = 4
+	requestV2TimeseriesField protowire.Number = 5
+	seriesV2LabelsRefsField  protowire.Number = 1
+	seriesV2SamplesField     protowire.Number = 2
+	sampleValueField         protowire.Number = 1
+	sampleTimestampField     protowire.Number = 2
+)
+
+// symbolTable interns the strings of a remote-write 2.0 request
+type symbolTable struct {
+	refs    map[string]uint32
+	symbols []string
+}
+
+func newSymbolTable() *symbolTable {
+	return &symbolTable{refs: map[string]uint32{"": 0}, symbols: []string{""}}
+}
+
+func (t *symbolTable) ref(s string) uint32 {
+	if r, ok := t.refs[s]; ok {
+		return r
+	}
+	r := uint32(len(t.symbols))
+	t.refs[s] = r
+	t.symbols = append(t.symbols, s)
+	return r
+}
+
+// marshalWriteV2 appends the series to b as a remote-write 2.0 request
+func marshalWriteV2(b []byte, series []prompb.TimeSeries) []byte {
+	table := newSymbolTable()
+	var encoded []byte
+	var msg, refs []byte
+	for _, ts := range series {
+		msg, refs = msg[:0], refs[:0]
+		for _, l := range ts.Labels {
+			refs = protowire.AppendVarint(refs, uint64(table.ref(l.Name)))
+			refs = protowire.AppendVarint(refs, uint64(table.ref(l.Value)))
+		}
+		msg = protowire.AppendTag(msg, seriesV2LabelsRefsField, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, refs)
+		for _, s := range ts.Samples {
+			msg = protowire.AppendTag(msg, seriesV2SamplesField, protowire.BytesType)
+			msg = protowire.AppendVarint(msg, uint64(sampleSize(s)))
+			msg = appendSample(msg, s)
+		}
+		encoded = protowire.AppendTag(encoded, requestV2TimeseriesField, protowire.BytesType)
+		encoded = protowire.AppendBytes(encoded, msg)
+	}
+
+	for _, s := range table.symbols {
+		b = protowire.AppendTag(b, requestV2SymbolsField, protowire.BytesType)
+		b = protowire.AppendString(b, s)
+	}
+	return append(b, encoded...)
+}
+
+func sampleSize(s prompb.Sample) int {
+	return protowire.SizeTag(sampleValueField) + protowire.SizeFixed64() +
+		protowire.SizeTag(sampleTimestampField) + protowire.SizeVarint(uint64(s.Timestamp))
+}
+
+func appendSample(b []byte, s prompb.Sample) []byte {
+	b = protowire.AppendTag(b, sampleValueField, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(s.Value))
+	b = protowire.AppendTag(b, sampleTimestampField, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(s.Timestamp))
+}
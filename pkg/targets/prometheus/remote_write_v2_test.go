@@ -0,0 +1,150 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/timescale/promscale/pkg/prompb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestMarshalWriteV2(t *testing.T) {
+	series := []prompb.TimeSeries{
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "usage_user"}, {Name: "hostname", Value: "host_0"}},
+			Samples: []prompb.Sample{{Value: 58.13, Timestamp: 1451606400000}},
+		},
+		{
+			Labels:  []prompb.Label{{Name: "__name__", Value: "usage_system"}, {Name: "hostname", Value: "host_0"}},
+			Samples: []prompb.Sample{{Value: -2, Timestamp: 1451606410000}, {Value: 0, Timestamp: 0}},
+		},
+	}
+	b := marshalWriteV2(nil, series)
+
+	got, err := unmarshalWriteV2(b)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %v", err)
+	}
+	if !reflect.DeepEqual(got, series) {
+		t.Errorf("incorrect series: got\n%v\nwant\n%v", got, series)
+	}
+
+	// the labels are interned, after the empty string
+	var symbols []string
+	consumeFields(b, func(num protowire.Number, _ protowire.Type, v []byte) error {
+		if num == requestV2SymbolsField {
+			symbols = append(symbols, string(v))
+		}
+		return nil
+	})
+	want := []string{"", "__name__", "usage_user", "hostname", "host_0", "usage_system"}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("incorrect symbols: got %v want %v", symbols, want)
+	}
+
+	if got := marshalWriteV2(nil, nil); len(got) == 0 {
+		t.Errorf("empty request has no symbols")
+	}
+}
+
+// unmarshalWriteV2 decodes a remote-write 2.0 request into 1.0 series. It
+// only reads the fields written by marshalWriteV2.
+func unmarshalWriteV2(b []byte) ([]prompb.TimeSeries, error) {
+	var symbols []string
+	var rawSeries [][]byte
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		switch num {
+		case requestV2SymbolsField:
+			symbols = append(symbols, string(v))
+		case requestV2TimeseriesField:
+			rawSeries = append(rawSeries, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]prompb.TimeSeries, 0, len(rawSeries))
+	for _, raw := range rawSeries {
+		var ts prompb.TimeSeries
+		var refs []uint32
+		err := consumeFields(raw, func(num protowire.Number, typ protowire.Type, v []byte) error {
+			switch num {
+			case seriesV2LabelsRefsField:
+				for len(v) > 0 {
+					r, n := protowire.ConsumeVarint(v)
+					if n < 0 {
+						return protowire.ParseError(n)
+					}
+					if r >= uint64(len(symbols)) {
+						return fmt.Errorf("label reference %d out of %d symbols", r, len(symbols))
+					}
+					refs = append(refs, uint32(r))
+					v = v[n:]
+				}
+			case seriesV2SamplesField:
+				var s prompb.Sample
+				err := consumeFields(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+					switch num {
+					case sampleValueField:
+						bits, _ := protowire.ConsumeFixed64(v)
+						s.Value = math.Float64frombits(bits)
+					case sampleTimestampField:
+						ts, _ := protowire.ConsumeVarint(v)
+						s.Timestamp = int64(ts)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				ts.Samples = append(ts.Samples, s)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(refs)%2 != 0 {
+			return nil, fmt.Errorf("odd number of label references")
+		}
+		for i := 0; i < len(refs); i += 2 {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: symbols[refs[i]], Value: symbols[refs[i+1]]})
+		}
+		series = append(series, ts)
+	}
+	return series, nil
+}
+
+// consumeFields calls fn with the number, type and raw value of each field of
+// a message. The value of a varint or fixed64 field is its encoding.
+func consumeFields(b []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+			if n >= 0 {
+				v = b[:n]
+			}
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		if err := fn(num, typ, v); err != nil {
+			return err
+		}
+		b = b[n:]
+	}
+	return nil
+}
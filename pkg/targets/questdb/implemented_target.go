@@ -3,10 +3,12 @@ package questdb
 import (
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/serialize"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
 	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/influx"
 )
 
 func NewTarget() targets.ImplementedTarget {
@@ -19,6 +21,7 @@ type influxTarget struct {
 func (t *influxTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
 	flagSet.String(flagPrefix+"url", "http://localhost:9000/", "QuestDB REST end point")
 	flagSet.String(flagPrefix+"ilp-bind-to", "127.0.0.1:9009", "QuestDB influx line protocol TCP ip:port")
+	flagSet.String(flagPrefix+"schema-layout", constants.SchemaLayoutWide, "Layout of the metrics tables: 'wide' stores a column per field, 'narrow' a row per field value with metric_name and value columns")
 }
 
 func (t *influxTarget) TargetName() string {
@@ -32,3 +35,19 @@ func (t *influxTarget) Serializer() serialize.PointSerializer {
 func (t *influxTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
 	panic("not implemented")
 }
+
+// FileDataSource implements targets.FileInspector and targets.PointParser.
+// The data files are in the InfluxDB wire protocol.
+func (t *influxTarget) FileDataSource(fileName string) targets.DataSource {
+	return influx.NewFileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (t *influxTarget) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	return influx.DescribeLine(item.Data.(string))
+}
+
+// ParsePoint implements targets.PointParser
+func (t *influxTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return influx.ParseLine(item.Data.(string), schema, p)
+}
@@ -0,0 +1,189 @@
+package targets
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+// DataSchema describes the tags and fields of the points of a use case. Most
+// data formats do not keep the types of the values, so the schema is needed
+// to parse serialized points back with the types they were generated with.
+type DataSchema struct {
+	Headers *common.GeneratedDataHeaders
+	// FieldTypes are the type names of the fields of each measurement, in the
+	// order of Headers.FieldKeys
+	FieldTypes map[string][]string
+
+	tagIndex   map[string]int
+	fieldIndex map[string]map[string]int
+}
+
+// NewDataSchema returns the schema of points with the given headers and
+// field types.
+func NewDataSchema(headers *common.GeneratedDataHeaders, fieldTypes map[string][]string) *DataSchema {
+	s := &DataSchema{
+		Headers:    headers,
+		FieldTypes: fieldTypes,
+		tagIndex:   make(map[string]int, len(headers.TagKeys)),
+		fieldIndex: make(map[string]map[string]int, len(headers.FieldKeys)),
+	}
+	for i, k := range headers.TagKeys {
+		s.tagIndex[k] = i
+	}
+	for m, keys := range headers.FieldKeys {
+		s.fieldIndex[m] = make(map[string]int, len(keys))
+		for i, k := range keys {
+			s.fieldIndex[m][k] = i
+		}
+	}
+	return s
+}
+
+// IsTag returns whether key is a tag in the headers. Some formats serialize
+// the tags that are not strings as fields.
+func (s *DataSchema) IsTag(key string) bool {
+	_, ok := s.tagIndex[key]
+	return ok
+}
+
+// PointValues collects the parsed tags and fields of a point, to add them to
+// the point in the order of the schema.
+type PointValues struct {
+	schema      *DataSchema
+	measurement string
+	tags        []interface{}
+	// extraTags are the measurement specific tags, in the order they are set
+	extraTags []extraTag
+	fields    []interface{}
+}
+
+type extraTag struct {
+	key   string
+	value interface{}
+}
+
+// NewPointValues returns empty values for a point of a measurement
+func (s *DataSchema) NewPointValues(measurement string) (*PointValues, error) {
+	fields, ok := s.Headers.FieldKeys[measurement]
+	if !ok {
+		return nil, fmt.Errorf("measurement '%s' not in schema", measurement)
+	}
+	if len(s.FieldTypes[measurement]) != len(fields) {
+		return nil, fmt.Errorf("field types of '%s' not in schema", measurement)
+	}
+	return &PointValues{
+		schema:      s,
+		measurement: measurement,
+		tags:        make([]interface{}, len(s.Headers.TagKeys)),
+		fields:      make([]interface{}, len(fields)),
+	}, nil
+}
+
+// SetTag parses the serialized value of a tag. Tags that are not in the
+// headers are specific to the measurement and kept as strings. Nil tags are
+// serialized as empty values.
+func (v *PointValues) SetTag(key, value string) error {
+	i, ok := v.schema.tagIndex[key]
+	if !ok {
+		var tag interface{}
+		if len(value) > 0 {
+			tag = value
+		}
+		v.extraTags = append(v.extraTags, extraTag{key: key, value: tag})
+		return nil
+	}
+	tag, err := serialize.ParseValue(v.schema.Headers.TagTypes[i], value)
+	if err != nil {
+		return fmt.Errorf("invalid value of tag '%s': %v", key, err)
+	}
+	v.tags[i] = tag
+	return nil
+}
+
+// SetField parses the serialized value of a field of the measurement
+func (v *PointValues) SetField(key, value string) error {
+	i, ok := v.schema.fieldIndex[v.measurement][key]
+	if !ok {
+		return fmt.Errorf("field '%s' not in schema of '%s'", key, v.measurement)
+	}
+	return v.SetFieldAt(i, value)
+}
+
+// SetFieldAt parses the serialized value of the field at position i of the
+// measurement. Nil fields are serialized as empty values.
+func (v *PointValues) SetFieldAt(i int, value string) error {
+	if i >= len(v.fields) {
+		return fmt.Errorf("'%s' has %d fields, got field %d", v.measurement, len(v.fields), i+1)
+	}
+	field, err := serialize.ParseValue(v.schema.FieldTypes[v.measurement][i], value)
+	if err != nil {
+		return fmt.Errorf("invalid value of field '%s': %v", v.schema.Headers.FieldKeys[v.measurement][i], err)
+	}
+	v.fields[i] = field
+	return nil
+}
+
+// Set sets a tag or field of the point to a value that kept its type, e.g.
+// read from a typed column. Tags that are not in the headers are specific to
+// the measurement.
+func (v *PointValues) Set(key string, value interface{}) error {
+	if i, ok := v.schema.fieldIndex[v.measurement][key]; ok {
+		field, err := convertValue(v.schema.FieldTypes[v.measurement][i], value)
+		if err != nil {
+			return fmt.Errorf("invalid value of field '%s': %v", key, err)
+		}
+		v.fields[i] = field
+		return nil
+	}
+	if i, ok := v.schema.tagIndex[key]; ok {
+		tag, err := convertValue(v.schema.Headers.TagTypes[i], value)
+		if err != nil {
+			return fmt.Errorf("invalid value of tag '%s': %v", key, err)
+		}
+		v.tags[i] = tag
+		return nil
+	}
+	if _, ok := value.(string); !ok && value != nil {
+		return fmt.Errorf("invalid value of tag '%s': %v is not a string", key, value)
+	}
+	v.extraTags = append(v.extraTags, extraTag{key: key, value: value})
+	return nil
+}
+
+// convertValue converts a value to the given type name, for the types that
+// are stored as a wider type
+func convertValue(typ string, value interface{}) (interface{}, error) {
+	if value == nil || reflect.TypeOf(value).String() == typ {
+		return value, nil
+	}
+	switch v := value.(type) {
+	case int64:
+		if typ == "int" {
+			return int(v), nil
+		}
+	case string:
+		if typ == "[]uint8" {
+			return []byte(v), nil
+		}
+	}
+	return nil, fmt.Errorf("%v is not of type %s", value, typ)
+}
+
+// Fill sets the measurement, tags and fields of p. The tags in the headers
+// come first, followed by the measurement specific ones, as generated.
+func (v *PointValues) Fill(p *data.Point) {
+	p.SetMeasurementName([]byte(v.measurement))
+	for i, k := range v.schema.Headers.TagKeys {
+		p.AppendTag([]byte(k), v.tags[i])
+	}
+	for _, t := range v.extraTags {
+		p.AppendTag([]byte(t.key), t.value)
+	}
+	for i, k := range v.schema.Headers.FieldKeys[v.measurement] {
+		p.AppendField([]byte(k), v.fields[i])
+	}
+}
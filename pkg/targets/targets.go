@@ -1,6 +1,9 @@
 package targets
 
 import (
+	"errors"
+	"time"
+
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/pkg/data"
@@ -75,3 +78,95 @@ type DataSource interface {
 	NextItem() data.LoadedPoint
 	Headers() *common.GeneratedDataHeaders
 }
+
+// FileInspector is implemented by the targets whose data files can be read
+// outside of a load, e.g. by tsbs_inspect to summarize a generated file.
+type FileInspector interface {
+	// FileDataSource returns a DataSource streaming the given data file, or
+	// stdin if fileName is empty
+	FileDataSource(fileName string) DataSource
+	// DescribePoint describes a point read from a FileDataSource. It returns
+	// an error if the point is malformed.
+	DescribePoint(data.LoadedPoint) (*PointDescription, error)
+}
+
+// PointTimestamper is implemented by the benchmarks that can read the
+// timestamps of the points of their DataSource, e.g. to replay the data as
+// live traffic.
+type PointTimestamper interface {
+	// PointTimestamp returns the timestamp of a point read from the
+	// DataSource of the Benchmark. It returns an error if the point is
+	// malformed.
+	PointTimestamp(data.LoadedPoint) (time.Time, error)
+}
+
+// PointDescription is the target agnostic summary of a point read from a
+// data file.
+type PointDescription struct {
+	Measurement string
+	// Series identifies the series of the point within its measurement
+	Series string
+	// PrimaryTag is the value of the first tag, e.g. the hostname
+	PrimaryTag string
+	Timestamp  time.Time
+	// Fields is the number of fields of the point, including the empty ones
+	// if the format stores them, and Values the number of non-empty ones
+	Fields int
+	Values int
+}
+
+// PointParser is implemented by the targets whose data files can be parsed
+// back into the points they were serialized from, e.g. by tsbs_convert to
+// serialize them for another target.
+type PointParser interface {
+	// FileDataSource returns a DataSource streaming the given data file, or
+	// stdin if fileName is empty
+	FileDataSource(fileName string) DataSource
+	// ParsePoint parses a point read from a FileDataSource into p, with the
+	// tags and fields in the order of the schema. It returns an error if the
+	// point is malformed.
+	ParsePoint(item data.LoadedPoint, schema *DataSchema, p *data.Point) error
+}
+
+// DirectoryWriter is implemented by the targets whose data is not a stream of
+// serialized points but a directory with a file per measurement, e.g. the
+// columnar formats that need one schema per file. Their data can only be
+// generated with an output path.
+type DirectoryWriter interface {
+	// NewPointWriter returns a PointWriter writing the points of the schema
+	// to files in dir, compressed with the given codec name (see
+	// internal/compress), or the default codec of the format if empty
+	NewPointWriter(dir string, schema *DataSchema, compression string) (PointWriter, error)
+}
+
+// PointWriter writes points to the files of a DirectoryWriter. The files are
+// complete once Close returns.
+type PointWriter interface {
+	Write(p *data.Point) error
+	Close() error
+}
+
+// ErrMaintenanceNotSupported is returned by the Maintenance operations a
+// database cannot run.
+var ErrMaintenanceNotSupported = errors.New("maintenance operation not supported by the target")
+
+// Maintainer is implemented by the targets that can run the background work
+// of a time-series database on demand, dropping old data and downsampling, e.g.
+// by tsbs_scenario to time it and its effect on ingest and queries.
+type Maintainer interface {
+	// Maintenance returns the Maintenance of the database targetDB, connecting
+	// with the target-specific flags in v
+	Maintenance(targetDB string, v *viper.Viper) (Maintenance, error)
+}
+
+// Maintenance runs the maintenance operations of a loaded database. The
+// operations may run while the database is loaded or queried.
+type Maintenance interface {
+	// DropBefore drops the data older than t, e.g. the chunks or partitions
+	// that only hold such data
+	DropBefore(t time.Time) error
+	// Downsample aggregates the data in [start, end) into the downsampled
+	// series of the database
+	Downsample(start, end time.Time) error
+	Close() error
+}
@@ -1,15 +1,33 @@
 package timescaledb
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
 const pgxDriver = "pgx"
 const pqDriver = "postgres"
 
 func NewBenchmark(dbName string, opts *LoadingOptions, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	if opts.SchemaLayout == "" {
+		opts.SchemaLayout = constants.SchemaLayoutWide
+	}
+	if !utils.IsIn(opts.SchemaLayout, constants.SupportedSchemaLayouts()) {
+		return nil, fmt.Errorf("invalid schema layout '%s', valid: %s",
+			opts.SchemaLayout, strings.Join(constants.SupportedSchemaLayouts(), ", "))
+	}
+	if opts.narrowLayout() && opts.ContinuousAggregates {
+		return nil, fmt.Errorf("continuous aggregates require the %s schema layout", constants.SchemaLayoutWide)
+	}
+
 	var ds targets.DataSource
 	if dataSourceConfig.Type == source.FileDataSourceType {
 		ds = newFileDataSource(dataSourceConfig.File.Location)
@@ -71,3 +89,8 @@ func getDriver(forceTextFormat bool) string {
 		return pgxDriver
 	}
 }
+
+// PointTimestamp implements targets.PointTimestamper
+func (b *benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	return RowTimestamp(item.Data.(*point).row.fields)
+}
@@ -0,0 +1,100 @@
+package timescaledb
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// compressionEnabled returns whether the hypertables should be created with
+// native compression. A compression policy or compressing after the load
+// both need it, so they enable it as well.
+func (o *LoadingOptions) compressionEnabled() bool {
+	return o.UseHypertable && (o.UseCompression || o.CompressAfter > 0 || o.CompressAfterLoad)
+}
+
+// getCompressionCmds returns the commands enabling native compression on the
+// given hypertable and, if requested, adding a compression policy
+func (d *dbCreator) getCompressionCmds(tableName, partitionColumn string) []string {
+	if !d.opts.compressionEnabled() {
+		return nil
+	}
+	segmentBy := d.opts.CompressSegmentBy
+	if segmentBy == "" {
+		segmentBy = partitionColumn
+		if d.opts.narrowLayout() {
+			segmentBy += ", metric_name"
+		}
+	}
+	orderBy := d.opts.CompressOrderBy
+	if orderBy == "" {
+		orderBy = "time DESC"
+	}
+	cmds := []string{
+		fmt.Sprintf("ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = '%s', timescaledb.compress_orderby = '%s')",
+			tableName, segmentBy, orderBy),
+	}
+	if d.opts.CompressAfter > 0 {
+		cmds = append(cmds, fmt.Sprintf("SELECT add_compression_policy('%s', compress_after => INTERVAL '%d microseconds')",
+			tableName, d.opts.CompressAfter.Microseconds()))
+	}
+	return cmds
+}
+
+// compressAllChunks compresses all chunks of the loaded hypertables, adding
+// how long it took and the size of the hypertables before and after to stats
+func (d *dbCreator) compressAllChunks(db *sql.DB, stats map[string]interface{}) error {
+	var tableNames []string
+	for tableName := range d.ds.Headers().FieldKeys {
+		tableNames = append(tableNames, tableName)
+	}
+	sort.Strings(tableNames)
+
+	fmt.Println("Compressing all chunks...")
+	var totalBefore, totalAfter int64
+	start := time.Now()
+	for _, tableName := range tableNames {
+		tableStart := time.Now()
+		before, err := hypertableSize(db, tableName)
+		if err != nil {
+			return err
+		}
+		var chunks int64
+		compressQuery := fmt.Sprintf("SELECT count(compress_chunk(c, if_not_compressed => true)) FROM show_chunks('%s') c", tableName)
+		if err := db.QueryRow(compressQuery).Scan(&chunks); err != nil {
+			return fmt.Errorf("could not compress chunks of %s: %v", tableName, err)
+		}
+		after, err := hypertableSize(db, tableName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("compressed %d chunks of %s in %0.3fsec: %d bytes -> %d bytes (%0.2fx)\n",
+			chunks, tableName, time.Since(tableStart).Seconds(), before, after, compressionRatio(before, after))
+		totalBefore += before
+		totalAfter += after
+	}
+	took := time.Since(start)
+	fmt.Printf("compressed all hypertables in %0.3fsec: %d bytes -> %d bytes (%0.2fx)\n",
+		took.Seconds(), totalBefore, totalAfter, compressionRatio(totalBefore, totalAfter))
+
+	stats["compressionMillis"] = took.Milliseconds()
+	stats["sizeBeforeCompression"] = totalBefore
+	stats["sizeAfterCompression"] = totalAfter
+	return nil
+}
+
+func hypertableSize(db *sql.DB, tableName string) (int64, error) {
+	var size sql.NullInt64
+	if err := db.QueryRow("SELECT hypertable_size($1::regclass)", tableName).Scan(&size); err != nil {
+		return 0, fmt.Errorf("could not get size of %s: %v", tableName, err)
+	}
+	return size.Int64, nil
+}
+
+func compressionRatio(before, after int64) float64 {
+	if after == 0 {
+		return 0
+	}
+	return float64(before) / float64(after)
+}
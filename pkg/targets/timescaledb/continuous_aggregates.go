@@ -0,0 +1,88 @@
+package timescaledb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// continuousAggregatesTable is the hypertable the continuous aggregates are created on
+const continuousAggregatesTable = "cpu"
+
+// continuousAggregates are read by the query generator with
+// --timescale-use-continuous-aggregates, so their names and columns must
+// stay in sync with it. Each holds max_<field> and avg_<field> of every field
+// per bucket and host.
+var continuousAggregates = []struct {
+	name   string
+	bucket time.Duration
+}{
+	{name: "cpu_1m", bucket: time.Minute},
+	{name: "cpu_1h", bucket: time.Hour},
+}
+
+// continuousAggregatesEnabled returns whether the continuous aggregates should be created
+func (o *LoadingOptions) continuousAggregatesEnabled() bool {
+	return o.UseHypertable && o.ContinuousAggregates
+}
+
+// getDropContinuousAggregateCmds returns the commands dropping the continuous
+// aggregates on tableName, which must be done before dropping the table
+func (d *dbCreator) getDropContinuousAggregateCmds(tableName string) []string {
+	if !d.opts.continuousAggregatesEnabled() || tableName != continuousAggregatesTable {
+		return nil
+	}
+	var cmds []string
+	for _, ca := range continuousAggregates {
+		cmds = append(cmds, fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", ca.name))
+	}
+	return cmds
+}
+
+// getContinuousAggregateCmds returns the commands creating the continuous
+// aggregates on the given hypertable. Real-time aggregation is enabled so
+// queries also see the rows that are not materialized yet.
+func (d *dbCreator) getContinuousAggregateCmds(tableName string, columns []string) []string {
+	if !d.opts.continuousAggregatesEnabled() || tableName != continuousAggregatesTable {
+		return nil
+	}
+	groupBy := []string{"tags_id"}
+	if d.opts.InTableTag {
+		groupBy = append(groupBy, tableCols[tagsKey][0])
+	}
+	var aggs []string
+	for _, column := range columns {
+		if len(column) == 0 {
+			continue
+		}
+		aggs = append(aggs, fmt.Sprintf("max(%[1]s) AS max_%[1]s, avg(%[1]s) AS avg_%[1]s", column))
+	}
+
+	var cmds []string
+	for _, ca := range continuousAggregates {
+		cmds = append(cmds, fmt.Sprintf("CREATE MATERIALIZED VIEW %s WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS "+
+			"SELECT time_bucket('%d seconds', time) AS bucket, %s, %s FROM %s GROUP BY bucket, %s WITH NO DATA",
+			ca.name, int64(ca.bucket.Seconds()), strings.Join(groupBy, ", "), strings.Join(aggs, ", "), tableName, strings.Join(groupBy, ", ")))
+	}
+	return cmds
+}
+
+// refreshContinuousAggregates materializes the loaded data into the
+// continuous aggregates, adding how long it took to stats
+func (d *dbCreator) refreshContinuousAggregates(db *sql.DB, stats map[string]interface{}) error {
+	if _, ok := d.ds.Headers().FieldKeys[continuousAggregatesTable]; !ok {
+		return nil
+	}
+	fmt.Println("Refreshing continuous aggregates...")
+	start := time.Now()
+	for _, ca := range continuousAggregates {
+		aggStart := time.Now()
+		if _, err := db.Exec(fmt.Sprintf("CALL refresh_continuous_aggregate('%s', NULL, NULL)", ca.name)); err != nil {
+			return fmt.Errorf("could not refresh continuous aggregate %s: %v", ca.name, err)
+		}
+		fmt.Printf("refreshed %s in %0.3fsec\n", ca.name, time.Since(aggStart).Seconds())
+	}
+	stats["continuousAggregatesRefreshMillis"] = time.Since(start).Milliseconds()
+	return nil
+}
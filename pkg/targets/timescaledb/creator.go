@@ -122,6 +122,32 @@ func (d *dbCreator) PostCreateDB(dbName string) error {
 	return nil
 }
 
+// PostLoad refreshes the continuous aggregates and compresses all chunks
+// once the data is loaded, if requested
+func (d *dbCreator) PostLoad(dbName string) (map[string]interface{}, error) {
+	refresh := d.opts.continuousAggregatesEnabled() && d.opts.RefreshContinuousAggregates
+	compress := d.opts.compressionEnabled() && d.opts.CompressAfterLoad
+	if !refresh && !compress {
+		return nil, nil
+	}
+	db := MustConnect(d.driver, d.opts.GetConnectString(dbName))
+	defer db.Close()
+
+	stats := make(map[string]interface{})
+	// Aggregates are refreshed first, while the chunks are not compressed yet
+	if refresh {
+		if err := d.refreshContinuousAggregates(db, stats); err != nil {
+			return nil, err
+		}
+	}
+	if compress {
+		if err := d.compressAllChunks(db, stats); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
 // getFieldAndIndexDefinitions iterates over a list of table columns, populating lists of
 // definitions for each desired field and index. Returns separate lists of fieldDefs and indexDefs
 func (d *dbCreator) getFieldAndIndexDefinitions(tableName string, columns []string) ([]string, []string) {
@@ -136,6 +162,15 @@ func (d *dbCreator) getFieldAndIndexDefinitions(tableName string, columns []stri
 		allCols = append(allCols, partitioningField)
 	}
 
+	if d.opts.narrowLayout() {
+		// Fields are stored as rows, so there are no field columns to index
+		if d.opts.InTableTag {
+			fieldDefs = append(fieldDefs, fmt.Sprintf("%s TEXT", partitioningField))
+		}
+		fieldDefs = append(fieldDefs, "metric_name TEXT", "value DOUBLE PRECISION")
+		return fieldDefs, nil
+	}
+
 	allCols = append(allCols, columns...)
 	extraCols := 0 // set to 1 when hostname is kept in-table
 	for idx, field := range allCols {
@@ -176,10 +211,17 @@ func (d *dbCreator) createTableAndIndexes(dbBench *sql.DB, tableName string, fie
 		partitionColumn = tableCols[tagsKey][0]
 	}
 
+	for _, cmd := range d.getDropContinuousAggregateCmds(tableName) {
+		MustExec(dbBench, cmd)
+	}
 	MustExec(dbBench, fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName))
 	MustExec(dbBench, fmt.Sprintf("CREATE TABLE %s (time timestamptz, tags_id integer, %s, additional_tags JSONB DEFAULT NULL)", tableName, strings.Join(fieldDefs, ",")))
 	if d.opts.PartitionIndex {
-		MustExec(dbBench, fmt.Sprintf("CREATE INDEX ON %s(%s, \"time\" DESC)", tableName, partitionColumn))
+		if d.opts.narrowLayout() {
+			MustExec(dbBench, fmt.Sprintf("CREATE INDEX ON %s(%s, metric_name, \"time\" DESC)", tableName, partitionColumn))
+		} else {
+			MustExec(dbBench, fmt.Sprintf("CREATE INDEX ON %s(%s, \"time\" DESC)", tableName, partitionColumn))
+		}
 	}
 
 	// Only allow one or the other, it's probably never right to have both.
@@ -225,6 +267,13 @@ func (d *dbCreator) createTableAndIndexes(dbBench *sql.DB, tableName string, fie
 		MustExec(dbBench,
 			fmt.Sprintf("SELECT %s('%s'::regclass, 'time'::name, %s, chunk_time_interval => %d, create_default_indexes=>FALSE)",
 				creationCommand, tableName, partitionsOption, d.opts.ChunkTime.Nanoseconds()/1000))
+
+		for _, cmd := range d.getContinuousAggregateCmds(tableName, tableCols[tableName]) {
+			MustExec(dbBench, cmd)
+		}
+		for _, cmd := range d.getCompressionCmds(tableName, partitionColumn) {
+			MustExec(dbBench, cmd)
+		}
 	}
 }
 
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"log"
 	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
 func TestDBCreatorInit(t *testing.T) {
@@ -131,6 +134,7 @@ func TestDBCreatorGetFieldAndIndexDefinitions(t *testing.T) {
 		columns         []string
 		fieldIndexCount int
 		inTableTag      bool
+		schemaLayout    string
 		wantFieldDefs   []string
 		wantIndexDefs   []string
 	}{
@@ -179,6 +183,25 @@ func TestDBCreatorGetFieldAndIndexDefinitions(t *testing.T) {
 			wantFieldDefs:   []string{"usage_user DOUBLE PRECISION", "usage_system DOUBLE PRECISION", "usage_idle DOUBLE PRECISION", "usage_nice DOUBLE PRECISION"},
 			wantIndexDefs:   []string{"CREATE INDEX ON cpu (usage_user, time DESC)", "CREATE INDEX ON cpu (usage_system, time DESC)"},
 		},
+		{
+			desc:            "narrow layout",
+			tableName:       "cpu",
+			columns:         []string{"usage_user", "usage_system", "usage_idle", "usage_nice"},
+			fieldIndexCount: -1,
+			schemaLayout:    constants.SchemaLayoutNarrow,
+			wantFieldDefs:   []string{"metric_name TEXT", "value DOUBLE PRECISION"},
+			wantIndexDefs:   []string{},
+		},
+		{
+			desc:            "narrow layout, in table tag",
+			tableName:       "cpu",
+			columns:         []string{"usage_user", "usage_system", "usage_idle", "usage_nice"},
+			fieldIndexCount: 0,
+			inTableTag:      true,
+			schemaLayout:    constants.SchemaLayoutNarrow,
+			wantFieldDefs:   []string{"hostname TEXT", "metric_name TEXT", "value DOUBLE PRECISION"},
+			wantIndexDefs:   []string{},
+		},
 	}
 
 	for _, c := range cases {
@@ -189,8 +212,13 @@ func TestDBCreatorGetFieldAndIndexDefinitions(t *testing.T) {
 		dbc := &dbCreator{opts: &LoadingOptions{
 			InTableTag:      c.inTableTag,
 			FieldIndexCount: c.fieldIndexCount,
+			SchemaLayout:    c.schemaLayout,
 		}}
 		fieldDefs, indexDefs := dbc.getFieldAndIndexDefinitions(c.tableName, c.columns)
+		if len(fieldDefs) != len(c.wantFieldDefs) {
+			t.Errorf("%s: incorrect number of fieldDefs: got %d want %d", c.desc, len(fieldDefs), len(c.wantFieldDefs))
+			continue
+		}
 		for i, fieldDef := range fieldDefs {
 			if fieldDef != c.wantFieldDefs[i] {
 				t.Errorf("%s: incorrect fieldDef at idx %d: got %s want %s", c.desc, i, fieldDef, c.wantFieldDefs[i])
@@ -254,3 +282,121 @@ func TestGenerateTagsTableQueryPanicOnWrongType(t *testing.T) {
 
 	t.Fatalf("test should have stopped at this point")
 }
+
+func TestDBCreatorGetCompressionCmds(t *testing.T) {
+	alter := "ALTER TABLE cpu SET (timescaledb.compress, timescaledb.compress_segmentby = '%s', timescaledb.compress_orderby = '%s')"
+	cases := []struct {
+		desc string
+		opts LoadingOptions
+		want []string
+	}{
+		{
+			desc: "compression disabled",
+			opts: LoadingOptions{UseHypertable: true},
+		},
+		{
+			desc: "no hypertable",
+			opts: LoadingOptions{UseCompression: true},
+		},
+		{
+			desc: "defaults",
+			opts: LoadingOptions{UseHypertable: true, UseCompression: true},
+			want: []string{fmt.Sprintf(alter, "tags_id", "time DESC")},
+		},
+		{
+			desc: "segmentby and orderby",
+			opts: LoadingOptions{UseHypertable: true, UseCompression: true, CompressSegmentBy: "hostname", CompressOrderBy: "time ASC"},
+			want: []string{fmt.Sprintf(alter, "hostname", "time ASC")},
+		},
+		{
+			desc: "policy enables compression",
+			opts: LoadingOptions{UseHypertable: true, CompressAfter: 24 * time.Hour},
+			want: []string{
+				fmt.Sprintf(alter, "tags_id", "time DESC"),
+				"SELECT add_compression_policy('cpu', compress_after => INTERVAL '86400000000 microseconds')",
+			},
+		},
+		{
+			desc: "compress after load enables compression",
+			opts: LoadingOptions{UseHypertable: true, CompressAfterLoad: true},
+			want: []string{fmt.Sprintf(alter, "tags_id", "time DESC")},
+		},
+	}
+	for _, c := range cases {
+		dbc := &dbCreator{opts: &c.opts}
+		got := dbc.getCompressionCmds("cpu", "tags_id")
+		if len(got) != len(c.want) {
+			t.Errorf("%s: incorrect number of commands: got %d want %d", c.desc, len(got), len(c.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: incorrect command: got\n%s\nwant\n%s", c.desc, got[i], c.want[i])
+			}
+		}
+	}
+}
+
+func TestDBCreatorGetContinuousAggregateCmds(t *testing.T) {
+	tableCols[tagsKey] = []string{"hostname", "region"}
+	columns := []string{"usage_user", "", "usage_system"}
+	aggs := "max(usage_user) AS max_usage_user, avg(usage_user) AS avg_usage_user, max(usage_system) AS max_usage_system, avg(usage_system) AS avg_usage_system"
+	create := "CREATE MATERIALIZED VIEW %s WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS " +
+		"SELECT time_bucket('%d seconds', time) AS bucket, %s, " + aggs + " FROM cpu GROUP BY bucket, %s WITH NO DATA"
+	cases := []struct {
+		desc  string
+		table string
+		opts  LoadingOptions
+		want  []string
+	}{
+		{
+			desc:  "disabled",
+			table: "cpu",
+			opts:  LoadingOptions{UseHypertable: true},
+		},
+		{
+			desc:  "no hypertable",
+			table: "cpu",
+			opts:  LoadingOptions{ContinuousAggregates: true},
+		},
+		{
+			desc:  "not the cpu table",
+			table: "mem",
+			opts:  LoadingOptions{UseHypertable: true, ContinuousAggregates: true},
+		},
+		{
+			desc:  "by tags_id",
+			table: "cpu",
+			opts:  LoadingOptions{UseHypertable: true, ContinuousAggregates: true},
+			want: []string{
+				fmt.Sprintf(create, "cpu_1m", 60, "tags_id", "tags_id"),
+				fmt.Sprintf(create, "cpu_1h", 3600, "tags_id", "tags_id"),
+			},
+		},
+		{
+			desc:  "with in-table partition tag",
+			table: "cpu",
+			opts:  LoadingOptions{UseHypertable: true, ContinuousAggregates: true, InTableTag: true},
+			want: []string{
+				fmt.Sprintf(create, "cpu_1m", 60, "tags_id, hostname", "tags_id, hostname"),
+				fmt.Sprintf(create, "cpu_1h", 3600, "tags_id, hostname", "tags_id, hostname"),
+			},
+		},
+	}
+	for _, c := range cases {
+		dbc := &dbCreator{opts: &c.opts}
+		got := dbc.getContinuousAggregateCmds(c.table, columns)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: incorrect number of commands: got %d want %d", c.desc, len(got), len(c.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: incorrect command: got\n%s\nwant\n%s", c.desc, got[i], c.want[i])
+			}
+		}
+		if got := len(dbc.getDropContinuousAggregateCmds(c.table)); got != len(c.want) {
+			t.Errorf("%s: incorrect number of drop commands: got %d want %d", c.desc, got, len(c.want))
+		}
+	}
+}
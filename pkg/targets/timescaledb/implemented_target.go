@@ -51,11 +51,21 @@ func (t *timescaleTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.
 	flagSet.Bool(flagPrefix+"use-hypertable", true, "Whether to make the table a hypertable. Set this flag to false to check input write speed against regular PostgreSQL.")
 	flagSet.Bool(flagPrefix+"use-jsonb-tags", false, "Whether tags should be stored as JSONB (instead of a separate table with schema)")
 	flagSet.Bool(flagPrefix+"in-table-partition-tag", false, "Whether the partition key (e.g. hostname) should also be in the metrics hypertable")
+	flagSet.String(flagPrefix+"schema-layout", constants.SchemaLayoutWide, "Layout of the metrics hypertables: 'wide' stores a column per field, 'narrow' a (time, tags_id, metric_name, value) row per field value")
 
 	flagSet.Int(flagPrefix+"replication-factor", 0, "Setting replication factor >= 1 will create a distributed hypertable")
 	flagSet.Int(flagPrefix+"partitions", 0, "Number of partitions")
 	flagSet.Duration(flagPrefix+"chunk-time", 12*time.Hour, "Duration that each chunk should represent, e.g., 12h")
 
+	flagSet.Bool(flagPrefix+"use-compression", false, "Whether to enable native compression on the hypertables")
+	flagSet.String(flagPrefix+"compress-segmentby", "", "Columns to segment compressed data by (comma delimited). Default: the partition key (tags_id, or the partition tag with in-table-partition-tag)")
+	flagSet.String(flagPrefix+"compress-orderby", "time DESC", "Order of the rows within compressed segments")
+	flagSet.Duration(flagPrefix+"compress-after", 0, "Add a compression policy compressing chunks older than this, e.g. 24h. 0 means no policy")
+	flagSet.Bool(flagPrefix+"compress-after-load", false, "Compress all chunks once the load is done, reporting the compression time and the sizes before and after")
+
+	flagSet.Bool(flagPrefix+"continuous-aggregates", false, "Create the cpu_1m and cpu_1h continuous aggregates (with real-time aggregation) on the cpu hypertable")
+	flagSet.Bool(flagPrefix+"refresh-continuous-aggregates", true, "Refresh the continuous aggregates once the load is done. Set to false to rely on real-time aggregation only")
+
 	flagSet.Bool(flagPrefix+"time-index", true, "Whether to build an index on the time dimension")
 	flagSet.Bool(flagPrefix+"time-partition-index", false, "Whether to build an index on the time dimension, compounded with partition")
 	flagSet.Bool(flagPrefix+"partition-index", true, "Whether to build an index on the partition key")
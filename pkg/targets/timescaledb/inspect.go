@@ -0,0 +1,68 @@
+package timescaledb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// FileDataSource implements targets.FileInspector and targets.PointParser
+func (t *timescaleTarget) FileDataSource(fileName string) targets.DataSource {
+	return newFileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (t *timescaleTarget) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	p := item.Data.(*point)
+	return describeRow(p.hypertable, p.row)
+}
+
+// describeRow describes a row of the pseudo-CSV format, whose tags are
+// <tag key>=<tag value> pairs and whose fields start with the timestamp in
+// nanoseconds, followed by a possibly empty value per field.
+func describeRow(hypertable string, row *insertData) (*targets.PointDescription, error) {
+	timestamp, err := RowTimestamp(row.fields)
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(row.fields, ",")
+	primaryTag := strings.SplitN(strings.SplitN(row.tags, ",", 2)[0], "=", 2)
+	if len(primaryTag) != 2 {
+		return nil, fmt.Errorf("invalid tag '%s'", primaryTag[0])
+	}
+
+	desc := &targets.PointDescription{
+		Measurement: hypertable,
+		Series:      row.tags,
+		PrimaryTag:  primaryTag[1],
+		Timestamp:   timestamp,
+		Fields:      len(fields) - 1,
+	}
+	for _, v := range fields[1:] {
+		if v == "" {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid value '%s'", v)
+		}
+		desc.Values++
+	}
+	return desc, nil
+}
+
+// RowTimestamp returns the timestamp of a row of the pseudo-CSV format from
+// its fields, which start with the timestamp in nanoseconds
+func RowTimestamp(fields string) (time.Time, error) {
+	if i := strings.IndexByte(fields, ','); i >= 0 {
+		fields = fields[:i]
+	}
+	timestampNano, err := strconv.ParseInt(fields, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s'", fields)
+	}
+	return time.Unix(0, timestampNano), nil
+}
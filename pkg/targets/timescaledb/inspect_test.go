@@ -0,0 +1,55 @@
+package timescaledb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+func TestDescribeRow(t *testing.T) {
+	row := &insertData{
+		tags:   "hostname=host_0,region=eu-west-1",
+		fields: "1451606400000000000,58,,24",
+	}
+	got, err := describeRow("cpu", row)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &targets.PointDescription{
+		Measurement: "cpu",
+		Series:      "hostname=host_0,region=eu-west-1",
+		PrimaryTag:  "host_0",
+		Timestamp:   time.Unix(0, 1451606400000000000),
+		Fields:      3,
+		Values:      2,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("incorrect description: got %+v want %+v", got, want)
+	}
+
+	for _, fields := range []string{"foo,58", "1451606400000000000,bar"} {
+		if _, err := describeRow("cpu", &insertData{tags: row.tags, fields: fields}); err == nil {
+			t.Errorf("expected error for malformed fields '%s'", fields)
+		}
+	}
+	if _, err := describeRow("cpu", &insertData{tags: "host_0", fields: row.fields}); err == nil {
+		t.Errorf("expected error for malformed tags")
+	}
+}
+
+func TestRowTimestamp(t *testing.T) {
+	for _, fields := range []string{"1451606400000000000,58,,24", "1451606400000000000"} {
+		got, err := RowTimestamp(fields)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", fields, err)
+		}
+		if want := time.Unix(0, 1451606400000000000); !got.Equal(want) {
+			t.Errorf("incorrect timestamp for '%s': got %v want %v", fields, got, want)
+		}
+	}
+	if _, err := RowTimestamp("foo,58"); err == nil {
+		t.Errorf("expected error for malformed timestamp")
+	}
+}
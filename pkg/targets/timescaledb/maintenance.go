@@ -0,0 +1,88 @@
+package timescaledb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// Maintenance implements targets.Maintainer
+func (t *timescaleTarget) Maintenance(targetDB string, v *viper.Viper) (targets.Maintenance, error) {
+	var opts LoadingOptions
+	if err := v.Unmarshal(&opts); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(getDriver(opts.ForceTextFormat), opts.GetConnectString(targetDB))
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %v", targetDB, err)
+	}
+	return &maintenance{db: db}, nil
+}
+
+// maintenance runs the maintenance operations of TimescaleDB on all the
+// hypertables and continuous aggregates of the database
+type maintenance struct {
+	db *sql.DB
+}
+
+// DropBefore drops the chunks of every hypertable that only hold data older than t
+func (m *maintenance) DropBefore(t time.Time) error {
+	rows, err := m.db.Query("SELECT format('%I.%I', hypertable_schema, hypertable_name) FROM timescaledb_information.hypertables")
+	if err != nil {
+		return fmt.Errorf("could not list hypertables: %v", err)
+	}
+	hypertables, err := scanStrings(rows)
+	if err != nil {
+		return fmt.Errorf("could not list hypertables: %v", err)
+	}
+	for _, hypertable := range hypertables {
+		if _, err := m.db.Exec("SELECT drop_chunks($1::regclass, older_than => $2::timestamptz)", hypertable, t); err != nil {
+			return fmt.Errorf("could not drop chunks of %s: %v", hypertable, err)
+		}
+	}
+	return nil
+}
+
+// Downsample refreshes the continuous aggregates over [start, end). It
+// returns targets.ErrMaintenanceNotSupported if the database has none, i.e.
+// it was not loaded with continuous-aggregates.
+func (m *maintenance) Downsample(start, end time.Time) error {
+	rows, err := m.db.Query("SELECT format('%I.%I', view_schema, view_name) FROM timescaledb_information.continuous_aggregates")
+	if err != nil {
+		return fmt.Errorf("could not list continuous aggregates: %v", err)
+	}
+	views, err := scanStrings(rows)
+	if err != nil {
+		return fmt.Errorf("could not list continuous aggregates: %v", err)
+	}
+	if len(views) == 0 {
+		return targets.ErrMaintenanceNotSupported
+	}
+	for _, view := range views {
+		if _, err := m.db.Exec("CALL refresh_continuous_aggregate($1::regclass, $2::timestamptz, $3::timestamptz)", view, start, end); err != nil {
+			return fmt.Errorf("could not refresh continuous aggregate %s: %v", view, err)
+		}
+	}
+	return nil
+}
+
+func (m *maintenance) Close() error {
+	return m.db.Close()
+}
+
+// scanStrings reads the single text column of rows and closes them
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,55 @@
+package timescaledb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// ParsePoint implements targets.PointParser
+func (t *timescaleTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	row := item.Data.(*point)
+	return parseRow(row.hypertable, row.row, schema, p)
+}
+
+// parseRow parses a row of the pseudo-CSV format, as written by Serializer,
+// into p. Nil tags and fields are written as empty values.
+func parseRow(hypertable string, row *insertData, schema *targets.DataSchema, p *data.Point) error {
+	values, err := schema.NewPointValues(hypertable)
+	if err != nil {
+		return err
+	}
+	if len(row.tags) > 0 {
+		for _, tag := range strings.Split(row.tags, ",") {
+			kv := strings.SplitN(tag, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid tag '%s'", tag)
+			}
+			if err := values.SetTag(kv[0], kv[1]); err != nil {
+				return err
+			}
+		}
+	}
+	fields := strings.Split(row.fields, ",")
+	timestampNano, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp '%s'", fields[0])
+	}
+	if want := len(schema.Headers.FieldKeys[hypertable]); len(fields)-1 != want {
+		return fmt.Errorf("'%s' row has %d fields, expected %d", hypertable, len(fields)-1, want)
+	}
+	for i, v := range fields[1:] {
+		if err := values.SetFieldAt(i, v); err != nil {
+			return err
+		}
+	}
+
+	values.Fill(p)
+	ts := time.Unix(0, timestampNano)
+	p.SetTimestamp(&ts)
+	return nil
+}
@@ -0,0 +1,62 @@
+package timescaledb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+func TestParseRow(t *testing.T) {
+	schema := targets.NewDataSchema(&common.GeneratedDataHeaders{
+		TagKeys:   []string{"name", "fleet", "load_capacity"},
+		TagTypes:  []string{"string", "string", "float64"},
+		FieldKeys: map[string][]string{"readings": {"latitude", "status"}},
+	}, map[string][]string{"readings": {"float64", "int64"}})
+
+	cases := []string{
+		"tags,name=truck_0,fleet=South,load_capacity=1500\nreadings,1451606400000000000,72.5,3\n",
+		// nil tags and fields are serialized as empty values
+		"tags,name=,fleet=South,load_capacity=\nreadings,1451606400000000000,,3\n",
+		// measurement specific tags follow the tags in the headers
+		"tags,name=truck_0,fleet=South,load_capacity=1500,path=/dev/sda\nreadings,1451606400000000000,72.5,3\n",
+	}
+	s := &Serializer{}
+	for _, c := range cases {
+		lines := strings.Split(c, "\n")
+		hypertableAndFields := strings.SplitN(lines[1], ",", 2)
+		row := &insertData{tags: strings.TrimPrefix(lines[0], "tags,"), fields: hypertableAndFields[1]}
+		p := data.NewPoint()
+		if err := parseRow(hypertableAndFields[0], row, schema, p); err != nil {
+			t.Errorf("'%s': unexpected error: %v", c, err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := s.Serialize(p, buf); err != nil {
+			t.Fatalf("unexpected error serializing: %v", err)
+		}
+		if got := buf.String(); got != c {
+			t.Errorf("incorrect round trip: got '%s' want '%s'", got, c)
+		}
+	}
+
+	malformed := []struct {
+		hypertable string
+		row        *insertData
+	}{
+		{"diagnostics", &insertData{tags: "name=truck_0", fields: "1451606400000000000,72.5,3"}},
+		{"readings", &insertData{tags: "truck_0", fields: "1451606400000000000,72.5,3"}},
+		{"readings", &insertData{tags: "name=truck_0", fields: "x,72.5,3"}},
+		{"readings", &insertData{tags: "name=truck_0", fields: "1451606400000000000,72.5"}},
+		{"readings", &insertData{tags: "name=truck_0", fields: "1451606400000000000,72.5,3.5"}},
+		{"readings", &insertData{tags: "load_capacity=x", fields: "1451606400000000000,72.5,3"}},
+	}
+	for _, m := range malformed {
+		if err := parseRow(m.hypertable, m.row, schema, data.NewPoint()); err == nil {
+			t.Errorf("expected error for malformed row %s %+v", m.hypertable, m.row)
+		}
+	}
+}
@@ -220,7 +220,15 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 	if p.opts.InTableTag {
 		cols = append(cols, tableCols[tagsKey][0])
 	}
-	cols = append(cols, tableCols[hypertable]...)
+	if p.opts.narrowLayout() {
+		dataRows = targets.ToNarrowRows(dataRows, len(cols), tableCols[hypertable])
+		cols = append(cols, "metric_name", "value")
+	} else {
+		cols = append(cols, tableCols[hypertable]...)
+	}
+	if len(dataRows) == 0 {
+		return numMetrics
+	}
 
 	if p.opts.ForceTextFormat {
 		tx := MustBegin(p._db)
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
 // Loading option vars:
@@ -21,11 +23,22 @@ type LoadingOptions struct {
 	UseJSON       bool `yaml:"use-jsonb-tags" mapstructure:"use-jsonb-tags"`
 	InTableTag    bool `yaml:"in-table-partition-tag" mapstructure:"in-table-partition-tag"`
 
+	SchemaLayout string `yaml:"schema-layout" mapstructure:"schema-layout"`
+
 	NumberPartitions  int           `yaml:"partitions" mapstructure:"partitions"`
 	PartitionColumn   string        `yaml:"partition-column" mapstructure:"partition-column"`
 	ReplicationFactor int           `yaml:"replication-factor" mapstructure:"replication-factor"`
 	ChunkTime         time.Duration `yaml:"chunk-time" mapstructure:"chunk-time"`
 
+	UseCompression    bool          `yaml:"use-compression" mapstructure:"use-compression"`
+	CompressSegmentBy string        `yaml:"compress-segmentby" mapstructure:"compress-segmentby"`
+	CompressOrderBy   string        `yaml:"compress-orderby" mapstructure:"compress-orderby"`
+	CompressAfter     time.Duration `yaml:"compress-after" mapstructure:"compress-after"`
+	CompressAfterLoad bool          `yaml:"compress-after-load" mapstructure:"compress-after-load"`
+
+	ContinuousAggregates        bool `yaml:"continuous-aggregates" mapstructure:"continuous-aggregates"`
+	RefreshContinuousAggregates bool `yaml:"refresh-continuous-aggregates" mapstructure:"refresh-continuous-aggregates"`
+
 	TimeIndex          bool   `yaml:"time-index" mapstructure:"time-index"`
 	TimePartitionIndex bool   `yaml:"time-partition-index" mapstructure:"time-partition-index"`
 	PartitionIndex     bool   `yaml:"partition-index" mapstructure:"partition-index"`
@@ -41,6 +54,12 @@ type LoadingOptions struct {
 	UseInsert          bool     `yaml:"use-insert" mapstructure:"use-insert"`
 }
 
+// narrowLayout returns whether every field value is stored in its own
+// (time, tags_id, metric_name, value) row
+func (o *LoadingOptions) narrowLayout() bool {
+	return o.SchemaLayout == constants.SchemaLayoutNarrow
+}
+
 func (o *LoadingOptions) GetConnectString(dbName string) string {
 	// User might be passing in host=hostname the connect string out of habit which may override the
 	// multi host configuration. Same for dbname= and user=. This sanitizes that.
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"github.com/blagojts/viper"
 	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/influx"
 	"sync"
+	"time"
 )
 
 type SpecificConfig struct {
@@ -68,6 +71,11 @@ func (b *benchmark) GetDBCreator() targets.DBCreator {
 	return &dbCreator{}
 }
 
+// PointTimestamp implements targets.PointTimestamper
+func (b *benchmark) PointTimestamp(item data.LoadedPoint) (time.Time, error) {
+	return influx.LineTimestamp(item.Data.([]byte))
+}
+
 type factory struct {
 	bufPool *sync.Pool
 }
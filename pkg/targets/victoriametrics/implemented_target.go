@@ -3,6 +3,7 @@ package victoriametrics
 import (
 	"github.com/blagojts/viper"
 	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/serialize"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
@@ -41,3 +42,19 @@ func (vm vmTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet
 func (vm vmTarget) TargetName() string {
 	return constants.FormatVictoriaMetrics
 }
+
+// FileDataSource implements targets.FileInspector and targets.PointParser. The data files are in the
+// InfluxDB wire protocol.
+func (vm vmTarget) FileDataSource(fileName string) targets.DataSource {
+	return influx.NewFileDataSource(fileName)
+}
+
+// DescribePoint implements targets.FileInspector
+func (vm vmTarget) DescribePoint(item data.LoadedPoint) (*targets.PointDescription, error) {
+	return influx.DescribeLine(item.Data.(string))
+}
+
+// ParsePoint implements targets.PointParser
+func (vm vmTarget) ParsePoint(item data.LoadedPoint, schema *targets.DataSchema, p *data.Point) error {
+	return influx.ParseLine(item.Data.(string), schema, p)
+}